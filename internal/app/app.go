@@ -35,7 +35,11 @@ type App struct {
 	settingsManager   *config.SystemSettingsManager
 	groupManager      *services.GroupManager
 	logCleanupService *services.LogCleanupService
+	autoPauseService  *services.GroupAutoPauseService
+	alertService      *services.GroupAlertService
+	keyCountStats     *services.KeyCountStatsService
 	requestLogService *services.RequestLogService
+	structuredLogger  *services.StructuredRequestLogger
 	cronChecker       *keypool.CronChecker
 	keyPoolProvider   *keypool.KeyProvider
 	proxyServer       *proxy.ProxyServer
@@ -54,7 +58,11 @@ type AppParams struct {
 	SettingsManager   *config.SystemSettingsManager
 	GroupManager      *services.GroupManager
 	LogCleanupService *services.LogCleanupService
+	AutoPauseService  *services.GroupAutoPauseService
+	AlertService      *services.GroupAlertService
+	KeyCountStats     *services.KeyCountStatsService
 	RequestLogService *services.RequestLogService
+	StructuredLogger  *services.StructuredRequestLogger
 	CronChecker       *keypool.CronChecker
 	KeyPoolProvider   *keypool.KeyProvider
 	ProxyServer       *proxy.ProxyServer
@@ -71,7 +79,11 @@ func NewApp(params AppParams) *App {
 		settingsManager:   params.SettingsManager,
 		groupManager:      params.GroupManager,
 		logCleanupService: params.LogCleanupService,
+		autoPauseService:  params.AutoPauseService,
+		alertService:      params.AlertService,
+		keyCountStats:     params.KeyCountStats,
 		requestLogService: params.RequestLogService,
+		structuredLogger:  params.StructuredLogger,
 		cronChecker:       params.CronChecker,
 		keyPoolProvider:   params.KeyPoolProvider,
 		proxyServer:       params.ProxyServer,
@@ -93,6 +105,9 @@ func (a *App) Start() error {
 	if a.configManager.IsMaster() {
 		logrus.Info("Starting as Master Node.")
 
+		// 清理缓存前取出上次关闭时保存的密钥池快照
+		savedPoolState := a.keyPoolProvider.ReadSavedState()
+
 		if err := a.storage.Clear(); err != nil {
 			return fmt.Errorf("cache cleanup failed: %w", err)
 		}
@@ -106,7 +121,10 @@ func (a *App) Start() error {
 			&models.APIKey{},
 			&models.RequestLog{},
 			&models.GroupHourlyStat{},
+			&models.GroupDailyStat{},
 			&models.GroupMonthlyStat{},
+			&models.GroupKeyCountStat{},
+			&models.GroupUsageReset{},
 		); err != nil {
 			return fmt.Errorf("database auto-migration failed: %w", err)
 		}
@@ -130,9 +148,22 @@ func (a *App) Start() error {
 		}
 		logrus.Debug("API keys loaded into Redis cache by master.")
 
+		if savedPoolState != nil {
+			if result, err := a.keyPoolProvider.ImportState(savedPoolState); err != nil {
+				logrus.WithError(err).Warn("Failed to restore key pool state, starting from a cold pool")
+			} else {
+				logrus.WithField("snapshot_age", time.Since(savedPoolState.CreatedAt).Round(time.Second)).
+					Infof("Restored key pool state: %+v", *result)
+			}
+		}
+
+		a.groupManager.WarnInsecureUpstreams()
+
 		// 仅 Master 节点启动的服务
-		a.requestLogService.Start()
 		a.logCleanupService.Start()
+		a.autoPauseService.Start()
+		a.alertService.Start()
+		a.keyCountStats.Start()
 		a.cronChecker.Start()
 	} else {
 		logrus.Info("Starting as Slave Node.")
@@ -143,6 +174,8 @@ func (a *App) Start() error {
 	a.configManager.DisplayServerConfig()
 
 	a.groupManager.Initialize()
+	a.structuredLogger.Start()
+	a.requestLogService.Start(a.configManager.IsMaster())
 
 	// Create main HTTP server (full access)
 	serverConfig := a.configManager.GetEffectiveServerConfig()
@@ -246,17 +279,28 @@ func (a *App) Stop(ctx context.Context) {
 	// Wait for both HTTP servers to shutdown
 	wg.Wait()
 
+	// 保存密钥池选择状态，供下次启动时恢复
+	if serverConfig.IsMaster {
+		if err := a.keyPoolProvider.SaveState(); err != nil {
+			logrus.WithError(err).Warn("Failed to save key pool state")
+		}
+	}
+
 	// 使用原始的总超时 context 继续关闭其他后台服务
 	stoppableServices := []func(context.Context){
 		a.groupManager.Stop,
 		a.settingsManager.Stop,
+		a.structuredLogger.Stop,
+		a.requestLogService.Stop,
 	}
 
 	if serverConfig.IsMaster {
 		stoppableServices = append(stoppableServices,
 			a.cronChecker.Stop,
 			a.logCleanupService.Stop,
-			a.requestLogService.Stop,
+			a.autoPauseService.Stop,
+			a.alertService.Stop,
+			a.keyCountStats.Stop,
 		)
 	}
 
@@ -0,0 +1,114 @@
+package channel
+
+import (
+	"aimanager/internal/models"
+	"encoding/json"
+	"fmt"
+)
+
+// BodyAdapter transforms a request body on the way to the upstream and the response body on the
+// way back, e.g. to translate between the OpenAI shape and a provider's native format.
+// Adapters only see complete JSON bodies; streaming responses are passed through unchanged.
+type BodyAdapter interface {
+	// Name identifies the adapter in logs.
+	Name() string
+
+	// AdaptRequest transforms the body sent to the upstream.
+	AdaptRequest(bodyBytes []byte) ([]byte, error)
+
+	// AdaptResponse transforms a successful non-streaming upstream response body.
+	AdaptResponse(bodyBytes []byte) ([]byte, error)
+}
+
+// FieldRenameAdapter renames top-level JSON fields of request and response bodies.
+type FieldRenameAdapter struct {
+	request  map[string]string
+	response map[string]string
+}
+
+// NewFieldRenameAdapter creates an adapter from the group's field rename rules.
+func NewFieldRenameAdapter(rules *models.FieldRenameRules) *FieldRenameAdapter {
+	return &FieldRenameAdapter{
+		request:  rules.Request,
+		response: rules.Response,
+	}
+}
+
+// Name implements BodyAdapter.
+func (a *FieldRenameAdapter) Name() string {
+	return "field_rename"
+}
+
+// AdaptRequest implements BodyAdapter.
+func (a *FieldRenameAdapter) AdaptRequest(bodyBytes []byte) ([]byte, error) {
+	return renameFields(bodyBytes, a.request)
+}
+
+// AdaptResponse implements BodyAdapter.
+func (a *FieldRenameAdapter) AdaptResponse(bodyBytes []byte) ([]byte, error) {
+	return renameFields(bodyBytes, a.response)
+}
+
+// renameFields moves each present top-level field to its new name. All renames apply at once, so
+// swaps (a->b, b->a) and chains (a->b, b->c) do not depend on map order. Bodies that are not JSON
+// objects are returned unchanged.
+func renameFields(bodyBytes []byte, renames map[string]string) ([]byte, error) {
+	if len(renames) == 0 || len(bodyBytes) == 0 {
+		return bodyBytes, nil
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(bodyBytes, &data); err != nil {
+		return bodyBytes, nil
+	}
+
+	moved := make(map[string]json.RawMessage, len(renames))
+	for from, to := range renames {
+		if value, exists := data[from]; exists {
+			moved[to] = value
+		}
+	}
+	if len(moved) == 0 {
+		return bodyBytes, nil
+	}
+
+	for from := range renames {
+		delete(data, from)
+	}
+	for to, value := range moved {
+		data[to] = value
+	}
+
+	return json.Marshal(data)
+}
+
+// parseFieldRenameRules reads the field_renames group config. The raw config map is used instead of
+// ParsedConfig so that channels built from groups loaded outside the group manager behave the same.
+func parseFieldRenameRules(group *models.Group) (*models.FieldRenameRules, error) {
+	raw, ok := group.Config["field_renames"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	configBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read field_renames: %w", err)
+	}
+	var rules models.FieldRenameRules
+	if err := json.Unmarshal(configBytes, &rules); err != nil {
+		return nil, fmt.Errorf("invalid field_renames: %w", err)
+	}
+	if len(rules.Request) == 0 && len(rules.Response) == 0 {
+		return nil, nil
+	}
+	return &rules, nil
+}
+
+// newBodyAdapters builds the adapters configured for the group, in application order.
+func newBodyAdapters(rules *models.FieldRenameRules) []BodyAdapter {
+	var adapters []BodyAdapter
+	if rules != nil {
+		adapters = append(adapters, NewFieldRenameAdapter(rules))
+	}
+	return adapters
+}
@@ -1,14 +1,12 @@
 package channel
 
 import (
-	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
 	"aimanager/internal/utils"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -18,6 +16,11 @@ import (
 
 func init() {
 	Register("anthropic", newAnthropicChannel)
+	RegisterAllowedParams("anthropic",
+		"model", "messages", "system", "max_tokens", "temperature", "top_p", "top_k",
+		"stop_sequences", "stream", "metadata", "tools", "tool_choice", "thinking",
+		"service_tier", "container", "mcp_servers",
+	)
 }
 
 type AnthropicChannel struct {
@@ -74,16 +77,27 @@ func (ch *AnthropicChannel) ExtractModel(c *gin.Context, bodyBytes []byte) strin
 }
 
 // ValidateKey checks if the given API key is valid by making a messages request.
-func (ch *AnthropicChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group) (bool, error) {
+func (ch *AnthropicChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (bool, error) {
+	resp, err := ch.ProbeValidation(ctx, apiKey, group, testModel)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return validationResult(resp)
+}
+
+// ProbeValidation sends the validation request for testModel and returns the raw upstream response.
+// The caller must close the response body.
+func (ch *AnthropicChannel) ProbeValidation(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (*http.Response, error) {
 	upstreamURL := ch.getUpstreamURL()
 	if upstreamURL == nil {
-		return false, fmt.Errorf("no upstream URL configured for channel %s", ch.Name)
+		return nil, fmt.Errorf("no upstream URL configured for channel %s", ch.Name)
 	}
 
 	// Parse validation endpoint to extract path and query parameters
 	endpointURL, err := url.Parse(ch.ValidationEndpoint)
 	if err != nil {
-		return false, fmt.Errorf("failed to parse validation endpoint: %w", err)
+		return nil, fmt.Errorf("failed to parse validation endpoint: %w", err)
 	}
 
 	// Build final URL with path and query parameters
@@ -94,7 +108,7 @@ func (ch *AnthropicChannel) ValidateKey(ctx context.Context, apiKey *models.APIK
 
 	// Use a minimal, low-cost payload for validation
 	payload := gin.H{
-		"model":      ch.TestModel,
+		"model":      testModel,
 		"max_tokens": 100,
 		"messages": []gin.H{
 			{"role": "user", "content": "hi"},
@@ -102,16 +116,17 @@ func (ch *AnthropicChannel) ValidateKey(ctx context.Context, apiKey *models.APIK
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal validation payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal validation payload: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(body))
 	if err != nil {
-		return false, fmt.Errorf("failed to create validation request: %w", err)
+		return nil, fmt.Errorf("failed to create validation request: %w", err)
 	}
 	req.Header.Set("x-api-key", apiKey.KeyValue)
 	req.Header.Set("anthropic-version", "2023-06-01")
 	req.Header.Set("Content-Type", "application/json")
+	ApplyAuthHeaderMode(req, group, apiKey.KeyValue)
 
 	// Apply custom header rules if available
 	if len(group.HeaderRuleList) > 0 {
@@ -119,25 +134,9 @@ func (ch *AnthropicChannel) ValidateKey(ctx context.Context, apiKey *models.APIK
 		utils.ApplyHeaderRules(req, group.HeaderRuleList, headerCtx)
 	}
 
-	resp, err := ch.HTTPClient.Do(req)
+	resp, err := ch.ValidationClient.Do(req)
 	if err != nil {
-		return false, fmt.Errorf("failed to send validation request: %w", err)
+		return nil, fmt.Errorf("failed to send validation request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Any 2xx status code indicates the key is valid.
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return true, nil
-	}
-
-	// For non-200 responses, parse the body to provide a more specific error reason.
-	errorBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Errorf("key is invalid (status %d), but failed to read error body: %w", resp.StatusCode, err)
-	}
-
-	// Use the new parser to extract a clean error message.
-	parsedError := app_errors.ParseUpstreamError(errorBody)
-
-	return false, fmt.Errorf("[status %d] %s", resp.StatusCode, parsedError)
+	return resp, nil
 }
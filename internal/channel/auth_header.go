@@ -0,0 +1,40 @@
+package channel
+
+import (
+	"net/http"
+
+	"aimanager/internal/models"
+)
+
+// credentialHeaders are the headers channels use to carry the upstream key.
+var credentialHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key"}
+
+// ApplyAuthHeaderMode moves the key to where the group's auth_header_mode says once the channel has set
+// its own credentials. Every credential the channel or the client may have set, including Gemini's key
+// query parameter, is removed first, so the key is sent exactly once. Without a mode the channel's
+// implicit authentication is kept.
+func ApplyAuthHeaderMode(req *http.Request, group *models.Group, keyValue string) {
+	mode := group.ParsedConfig.AuthHeaderMode
+	if mode == nil || *mode == "" {
+		return
+	}
+
+	for _, header := range credentialHeaders {
+		req.Header.Del(header)
+	}
+	if query := req.URL.Query(); query.Has("key") {
+		query.Del("key")
+		req.URL.RawQuery = query.Encode()
+	}
+
+	switch *mode {
+	case models.AuthHeaderModeBearer:
+		req.Header.Set("Authorization", "Bearer "+keyValue)
+	case models.AuthHeaderModeXAPIKey:
+		req.Header.Set("X-Api-Key", keyValue)
+	case models.AuthHeaderModeCustom:
+		if name := group.ParsedConfig.AuthHeaderName; name != nil && *name != "" {
+			req.Header.Set(*name, keyValue)
+		}
+	}
+}
@@ -1,17 +1,22 @@
 package channel
 
 import (
+	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
 	"aimanager/internal/types"
 	"aimanager/internal/utils"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/datatypes"
@@ -24,15 +29,47 @@ type UpstreamInfo struct {
 	CurrentWeight int
 }
 
+// UpstreamKeyUsage is the number of requests routed to one (upstream, key) pair by joint selection.
+type UpstreamKeyUsage struct {
+	Upstream string `json:"upstream"`
+	KeyID    uint   `json:"key_id"`
+	Requests int64  `json:"requests"`
+}
+
+// keyPairUsage counts the requests joint selection routed to each upstream for one key.
+type keyPairUsage struct {
+	counts   []int64
+	lastUsed time.Time
+}
+
+const (
+	// pairUsageIdleTTL is how long a key's joint selection counters are kept without requests.
+	// Deleted or rotated-out keys would otherwise keep their counters for the channel's lifetime.
+	pairUsageIdleTTL = time.Hour
+	// pairUsagePruneInterval is the minimum time between two sweeps for idle counters.
+	pairUsagePruneInterval = 10 * time.Minute
+)
+
+// UpstreamHealthChecker reports which upstreams of a group are currently healthy. It returns all
+// given upstreams when none is healthy.
+type UpstreamHealthChecker interface {
+	GetHealthyUpstreams(groupID uint, upstreams []string) []string
+}
+
 // BaseChannel provides common functionality for channel proxies.
 type BaseChannel struct {
 	Name               string
 	Upstreams          []UpstreamInfo
 	HTTPClient         *http.Client
 	StreamClient       *http.Client
+	ValidationClient   *http.Client // shorter connect and read timeouts, used only by ValidateKey
 	TestModel          string
 	ValidationEndpoint string
 	upstreamLock       sync.Mutex
+	pairUsage          map[uint]*keyPairUsage // key ID -> requests per upstream index, for joint selection
+	pairUsagePrunedAt  time.Time
+	groupID            uint
+	upstreamHealth     UpstreamHealthChecker
 
 	// Cached fields from the group for stale check
 	channelType         string
@@ -40,10 +77,44 @@ type BaseChannel struct {
 	effectiveConfig     *types.SystemSettings
 	modelRedirectRules  datatypes.JSONMap
 	modelRedirectStrict bool
+	fieldRenames        *models.FieldRenameRules
+	connectTimeout      time.Duration
+	requestTimeout      time.Duration
+
+	adapters []BodyAdapter
+}
+
+// healthyUpstreams reports for each upstream whether weighted selection may pick it. A nil result
+// means all upstreams are eligible.
+func (b *BaseChannel) healthyUpstreams() []bool {
+	if b.upstreamHealth == nil || len(b.Upstreams) < 2 {
+		return nil
+	}
+	if b.effectiveConfig == nil || b.effectiveConfig.UpstreamFailureThreshold <= 0 {
+		return nil
+	}
+
+	urls := make([]string, len(b.Upstreams))
+	for i, up := range b.Upstreams {
+		urls[i] = up.URL.String()
+	}
+	healthy := b.upstreamHealth.GetHealthyUpstreams(b.groupID, urls)
+	if len(healthy) == len(urls) {
+		return nil
+	}
+
+	eligible := make([]bool, len(urls))
+	for i, u := range urls {
+		eligible[i] = slices.Contains(healthy, u)
+	}
+	return eligible
 }
 
-// getUpstreamURL selects an upstream URL using a smooth weighted round-robin algorithm.
+// getUpstreamURL selects an upstream URL using a smooth weighted round-robin algorithm, skipping
+// unhealthy upstreams.
 func (b *BaseChannel) getUpstreamURL() *url.URL {
+	eligible := b.healthyUpstreams()
+
 	b.upstreamLock.Lock()
 	defer b.upstreamLock.Unlock()
 
@@ -58,6 +129,9 @@ func (b *BaseChannel) getUpstreamURL() *url.URL {
 	var best *UpstreamInfo
 
 	for i := range b.Upstreams {
+		if eligible != nil && !eligible[i] {
+			continue
+		}
 		up := &b.Upstreams[i]
 		totalWeight += up.Weight
 		up.CurrentWeight += up.Weight
@@ -82,6 +156,173 @@ func (b *BaseChannel) BuildUpstreamURL(originalURL *url.URL, groupName string) (
 		return "", fmt.Errorf("no upstream URL configured for channel %s", b.Name)
 	}
 
+	return b.buildURL(base, originalURL, groupName), nil
+}
+
+// getUpstreamURLForKey selects the healthy upstream that has served the given key the least relative
+// to its weight. Combined with key rotation this spreads load over the (upstream, key) cross-product
+// instead of letting independent rotations line up into hot pairs.
+func (b *BaseChannel) getUpstreamURLForKey(keyID uint) *url.URL {
+	eligible := b.healthyUpstreams()
+
+	b.upstreamLock.Lock()
+	defer b.upstreamLock.Unlock()
+
+	if len(b.Upstreams) == 0 {
+		return nil
+	}
+	if len(b.Upstreams) == 1 {
+		return b.Upstreams[0].URL
+	}
+
+	now := time.Now()
+	if b.pairUsage == nil {
+		b.pairUsage = make(map[uint]*keyPairUsage)
+		b.pairUsagePrunedAt = now
+	} else if now.Sub(b.pairUsagePrunedAt) >= pairUsagePruneInterval {
+		b.prunePairUsage(now)
+	}
+	pair, ok := b.pairUsage[keyID]
+	if !ok {
+		pair = &keyPairUsage{counts: make([]int64, len(b.Upstreams))}
+		b.pairUsage[keyID] = pair
+	}
+	pair.lastUsed = now
+	counts := pair.counts
+
+	// Compare (count+1)/weight without division: a is better than b when (ca+1)*wb < (cb+1)*wa
+	best := -1
+	for i := range b.Upstreams {
+		if eligible != nil && !eligible[i] {
+			continue
+		}
+		if best < 0 || (counts[i]+1)*int64(b.Upstreams[best].Weight) < (counts[best]+1)*int64(b.Upstreams[i].Weight) {
+			best = i
+		}
+	}
+
+	counts[best]++
+	return b.Upstreams[best].URL
+}
+
+// prunePairUsage drops the counters of keys without requests for pairUsageIdleTTL. The caller must
+// hold upstreamLock.
+func (b *BaseChannel) prunePairUsage(now time.Time) {
+	for keyID, pair := range b.pairUsage {
+		if now.Sub(pair.lastUsed) >= pairUsageIdleTTL {
+			delete(b.pairUsage, keyID)
+		}
+	}
+	b.pairUsagePrunedAt = now
+}
+
+// BuildUpstreamURLForKey constructs the target URL, selecting the upstream jointly with the key.
+func (b *BaseChannel) BuildUpstreamURLForKey(originalURL *url.URL, groupName string, keyID uint) (string, error) {
+	base := b.getUpstreamURLForKey(keyID)
+	if base == nil {
+		return "", fmt.Errorf("no upstream URL configured for channel %s", b.Name)
+	}
+
+	return b.buildURL(base, originalURL, groupName), nil
+}
+
+// getUpstreamURLForSeed maps a seed onto the healthy upstreams in proportion to their weights. The
+// round-robin state is left untouched, so seeded requests do not shift normal traffic.
+func (b *BaseChannel) getUpstreamURLForSeed(seed uint64) *url.URL {
+	eligible := b.healthyUpstreams()
+
+	b.upstreamLock.Lock()
+	defer b.upstreamLock.Unlock()
+
+	if len(b.Upstreams) == 0 {
+		return nil
+	}
+
+	var candidates []UpstreamInfo
+	for i, up := range b.Upstreams {
+		if eligible == nil || eligible[i] {
+			candidates = append(candidates, up)
+		}
+	}
+
+	totalWeight := 0
+	for _, up := range candidates {
+		totalWeight += up.Weight
+	}
+	if totalWeight <= 0 {
+		return candidates[seed%uint64(len(candidates))].URL
+	}
+
+	point := int(seed % uint64(totalWeight))
+	for _, up := range candidates {
+		if point < up.Weight {
+			return up.URL
+		}
+		point -= up.Weight
+	}
+	return candidates[0].URL
+}
+
+// BuildUpstreamURLForSeed constructs the target URL, selecting the upstream deterministically from the seed.
+func (b *BaseChannel) BuildUpstreamURLForSeed(originalURL *url.URL, groupName string, seed uint64) (string, error) {
+	base := b.getUpstreamURLForSeed(seed)
+	if base == nil {
+		return "", fmt.Errorf("no upstream URL configured for channel %s", b.Name)
+	}
+
+	return b.buildURL(base, originalURL, groupName), nil
+}
+
+// UpstreamKeyUsage returns the joint selection counters, ordered by key then upstream.
+func (b *BaseChannel) UpstreamKeyUsage() []UpstreamKeyUsage {
+	b.upstreamLock.Lock()
+	defer b.upstreamLock.Unlock()
+
+	usage := make([]UpstreamKeyUsage, 0, len(b.pairUsage)*len(b.Upstreams))
+	for keyID, pair := range b.pairUsage {
+		for i, count := range pair.counts {
+			usage = append(usage, UpstreamKeyUsage{
+				Upstream: b.Upstreams[i].URL.String(),
+				KeyID:    keyID,
+				Requests: count,
+			})
+		}
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].KeyID != usage[j].KeyID {
+			return usage[i].KeyID < usage[j].KeyID
+		}
+		return usage[i].Upstream < usage[j].Upstream
+	})
+	return usage
+}
+
+// BuildUpstreamURLFor constructs the target URL using a specific upstream, which must be one of the channel's configured upstreams.
+func (b *BaseChannel) BuildUpstreamURLFor(originalURL *url.URL, groupName string, upstream string) (string, error) {
+	target := strings.TrimRight(strings.TrimSpace(upstream), "/")
+	for _, up := range b.Upstreams {
+		if strings.TrimRight(up.URL.String(), "/") == target {
+			return b.buildURL(up.URL, originalURL, groupName), nil
+		}
+	}
+	return "", fmt.Errorf("upstream '%s' is not configured for channel %s", upstream, b.Name)
+}
+
+// UpstreamOf returns the configured upstream the given target URL was built from, or "" if none matches.
+func (b *BaseChannel) UpstreamOf(upstreamURL string) string {
+	match := ""
+	for _, up := range b.Upstreams {
+		base := strings.TrimRight(up.URL.String(), "/")
+		if strings.HasPrefix(upstreamURL, base) && len(base) > len(match) {
+			match = base
+		}
+	}
+	return match
+}
+
+// buildURL joins the client request path (without the group prefix) onto the given upstream base.
+func (b *BaseChannel) buildURL(base *url.URL, originalURL *url.URL, groupName string) string {
 	finalURL := *base
 	requestPath := originalURL.Path
 
@@ -97,7 +338,7 @@ func (b *BaseChannel) BuildUpstreamURL(originalURL *url.URL, groupName string) (
 
 	finalURL.RawQuery = originalURL.RawQuery
 
-	return finalURL.String(), nil
+	return finalURL.String()
 }
 
 // IsConfigStale checks if the channel's configuration is stale compared to the provided group.
@@ -124,9 +365,37 @@ func (b *BaseChannel) IsConfigStale(group *models.Group) bool {
 	if b.modelRedirectStrict != group.ModelRedirectStrict {
 		return true
 	}
+	fieldRenames, err := parseFieldRenameRules(group)
+	if err != nil || !reflect.DeepEqual(b.fieldRenames, fieldRenames) {
+		return true
+	}
+	if b.connectTimeout != groupTimeoutOverride(group, "connect_timeout_seconds") ||
+		b.requestTimeout != groupTimeoutOverride(group, "request_timeout_seconds") {
+		return true
+	}
 	return false
 }
 
+// BodyAdapters returns the body adapters configured for the group.
+func (b *BaseChannel) BodyAdapters() []BodyAdapter {
+	return b.adapters
+}
+
+// validationResult interprets a validation response: any 2xx status means the key is valid, otherwise
+// the parsed upstream error is returned.
+func validationResult(resp *http.Response) (bool, error) {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return true, nil
+	}
+
+	errorBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("key is invalid (status %d), but failed to read error body: %w", resp.StatusCode, err)
+	}
+
+	return false, fmt.Errorf("[status %d] %s", resp.StatusCode, app_errors.ParseUpstreamError(errorBody))
+}
+
 // GetHTTPClient returns the client for standard requests.
 func (b *BaseChannel) GetHTTPClient() *http.Client {
 	return b.HTTPClient
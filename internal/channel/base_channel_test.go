@@ -0,0 +1,80 @@
+package channel
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestChannel(t *testing.T, weights ...int) *BaseChannel {
+	t.Helper()
+	b := &BaseChannel{Name: "test"}
+	for i, weight := range weights {
+		u, err := url.Parse(fmt.Sprintf("https://upstream-%d.example.com", i))
+		if err != nil {
+			t.Fatalf("parse upstream URL: %v", err)
+		}
+		b.Upstreams = append(b.Upstreams, UpstreamInfo{URL: u, Weight: weight})
+	}
+	return b
+}
+
+func TestJointSelectionSpreadsEvenly(t *testing.T) {
+	// With two upstreams and four keys rotated in order, independent round-robin pins keys 1 and 3
+	// to one upstream and keys 2 and 4 to the other. Joint selection must use every pair equally.
+	b := newTestChannel(t, 1, 1)
+	const keys, rounds = 4, 100
+
+	for i := range keys * len(b.Upstreams) * rounds {
+		if u := b.getUpstreamURLForKey(uint(i%keys + 1)); u == nil {
+			t.Fatal("no upstream selected")
+		}
+	}
+
+	usage := b.UpstreamKeyUsage()
+	if len(usage) != keys*len(b.Upstreams) {
+		t.Fatalf("got %d pairs, want %d", len(usage), keys*len(b.Upstreams))
+	}
+	for _, pair := range usage {
+		if pair.Requests != rounds {
+			t.Errorf("key %d on %s served %d requests, want %d", pair.KeyID, pair.Upstream, pair.Requests, rounds)
+		}
+	}
+}
+
+func TestJointSelectionFollowsWeights(t *testing.T) {
+	b := newTestChannel(t, 3, 1)
+	const keys, rounds = 3, 50
+
+	for i := range keys * 4 * rounds {
+		b.getUpstreamURLForKey(uint(i%keys + 1))
+	}
+
+	for _, pair := range b.UpstreamKeyUsage() {
+		want := int64(rounds)
+		if pair.Upstream == b.Upstreams[0].URL.String() {
+			want = 3 * rounds
+		}
+		if pair.Requests != want {
+			t.Errorf("key %d on %s served %d requests, want %d", pair.KeyID, pair.Upstream, pair.Requests, want)
+		}
+	}
+}
+
+func TestJointSelectionPrunesIdleKeys(t *testing.T) {
+	b := newTestChannel(t, 1, 1)
+	b.getUpstreamURLForKey(1)
+	b.getUpstreamURLForKey(2)
+
+	b.pairUsage[1].lastUsed = time.Now().Add(-pairUsageIdleTTL)
+	b.pairUsagePrunedAt = time.Now().Add(-pairUsagePruneInterval)
+	b.getUpstreamURLForKey(2)
+
+	if _, ok := b.pairUsage[1]; ok {
+		t.Error("idle key 1 was not pruned")
+	}
+	if _, ok := b.pairUsage[2]; !ok {
+		t.Error("active key 2 was pruned")
+	}
+}
@@ -14,6 +14,23 @@ type ChannelProxy interface {
 	// BuildUpstreamURL constructs the target URL for the upstream service.
 	BuildUpstreamURL(originalURL *url.URL, groupName string) (string, error)
 
+	// BuildUpstreamURLFor constructs the target URL using the given configured upstream, bypassing weighted selection.
+	BuildUpstreamURLFor(originalURL *url.URL, groupName string, upstream string) (string, error)
+
+	// BuildUpstreamURLForKey constructs the target URL choosing the upstream jointly with the selected key,
+	// so every (upstream, key) pair receives an even, weight-proportional share of traffic.
+	BuildUpstreamURLForKey(originalURL *url.URL, groupName string, keyID uint) (string, error)
+
+	// BuildUpstreamURLForSeed constructs the target URL choosing the upstream deterministically from a seed,
+	// in proportion to the upstream weights. Used for debugging with seeded selection.
+	BuildUpstreamURLForSeed(originalURL *url.URL, groupName string, seed uint64) (string, error)
+
+	// UpstreamOf returns the configured upstream the given target URL was built from, or "" if none matches.
+	UpstreamOf(upstreamURL string) string
+
+	// UpstreamKeyUsage returns how many requests each (upstream, key) pair received through joint selection.
+	UpstreamKeyUsage() []UpstreamKeyUsage
+
 	// IsConfigStale checks if the channel's configuration is stale compared to the provided group.
 	IsConfigStale(group *models.Group) bool
 
@@ -32,12 +49,18 @@ type ChannelProxy interface {
 	// ExtractModel extracts the model name from the request.
 	ExtractModel(c *gin.Context, bodyBytes []byte) string
 
-	// ValidateKey checks if the given API key is valid.
-	ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group) (bool, error)
+	// ValidateKey checks if the given API key is valid by sending a minimal request for testModel.
+	ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (bool, error)
+
+	// ProbeValidation sends the same request as ValidateKey and returns the raw response, which the caller must close.
+	ProbeValidation(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (*http.Response, error)
 
 	// ApplyModelRedirect applies model redirection based on the group's redirect rules.
 	ApplyModelRedirect(req *http.Request, bodyBytes []byte, group *models.Group) ([]byte, error)
 
 	// TransformModelList transforms the model list response based on redirect rules.
 	TransformModelList(req *http.Request, bodyBytes []byte, group *models.Group) (map[string]any, error)
+
+	// BodyAdapters returns the request/response body adapters configured for the channel, if any.
+	BodyAdapters() []BodyAdapter
 }
@@ -43,15 +43,17 @@ func GetChannels() []string {
 type Factory struct {
 	settingsManager *config.SystemSettingsManager
 	clientManager   *httpclient.HTTPClientManager
+	upstreamHealth  UpstreamHealthChecker
 	channelCache    map[uint]ChannelProxy
 	cacheLock       sync.Mutex
 }
 
 // NewFactory creates a new channel factory.
-func NewFactory(settingsManager *config.SystemSettingsManager, clientManager *httpclient.HTTPClientManager) *Factory {
+func NewFactory(settingsManager *config.SystemSettingsManager, clientManager *httpclient.HTTPClientManager, upstreamHealth UpstreamHealthChecker) *Factory {
 	return &Factory{
 		settingsManager: settingsManager,
 		clientManager:   clientManager,
+		upstreamHealth:  upstreamHealth,
 		channelCache:    make(map[uint]ChannelProxy),
 	}
 }
@@ -69,11 +71,7 @@ func (f *Factory) GetChannel(group *models.Group) (ChannelProxy, error) {
 
 	logrus.Debugf("Creating new channel for group %d with type '%s'", group.ID, group.ChannelType)
 
-	constructor, ok := channelRegistry[group.ChannelType]
-	if !ok {
-		return nil, fmt.Errorf("unsupported channel type: %s", group.ChannelType)
-	}
-	channel, err := constructor(f, group)
+	channel, err := f.NewChannel(group)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +79,16 @@ func (f *Factory) GetChannel(group *models.Group) (ChannelProxy, error) {
 	return channel, nil
 }
 
+// NewChannel creates a channel proxy for the group without caching it, for groups that are not
+// persisted, such as the throwaway group used to test an upstream.
+func (f *Factory) NewChannel(group *models.Group) (ChannelProxy, error) {
+	constructor, ok := channelRegistry[group.ChannelType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported channel type: %s", group.ChannelType)
+	}
+	return constructor(f, group)
+}
+
 // newBaseChannel is a helper function to create and configure a BaseChannel.
 func (f *Factory) newBaseChannel(name string, group *models.Group) (*BaseChannel, error) {
 	type upstreamDef struct {
@@ -126,6 +134,18 @@ func (f *Factory) newBaseChannel(name string, group *models.Group) (*BaseChannel
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
+	// Per-group overrides for slow upstreams. The request timeout also bounds the wait for the response
+	// headers, which is all it limits for streams since they have no total timeout.
+	connectTimeout := groupTimeoutOverride(group, "connect_timeout_seconds")
+	if connectTimeout > 0 {
+		clientConfig.ConnectTimeout = connectTimeout
+	}
+	requestTimeout := groupTimeoutOverride(group, "request_timeout_seconds")
+	if requestTimeout > 0 {
+		clientConfig.RequestTimeout = requestTimeout
+		clientConfig.ResponseHeaderTimeout = requestTimeout
+	}
+
 	// Create a dedicated configuration for streaming requests.
 	streamConfig := *clientConfig
 	streamConfig.RequestTimeout = 0
@@ -136,21 +156,55 @@ func (f *Factory) newBaseChannel(name string, group *models.Group) (*BaseChannel
 	streamConfig.MaxIdleConns = max(group.EffectiveConfig.MaxIdleConns*2, 50)
 	streamConfig.MaxIdleConnsPerHost = max(group.EffectiveConfig.MaxIdleConnsPerHost*2, 20)
 
-	// Get both clients from the manager using their respective configurations.
+	// Key validation uses its own, shorter timeouts so a dead upstream fails validation quickly
+	// instead of holding a validation worker for the full proxy timeouts.
+	validationConfig := *clientConfig
+	validationConfig.ConnectTimeout = time.Duration(group.EffectiveConfig.KeyValidationConnectTimeout) * time.Second
+	validationConfig.TLSHandshakeTimeout = validationConfig.ConnectTimeout
+	validationConfig.ResponseHeaderTimeout = time.Duration(group.EffectiveConfig.KeyValidationReadTimeout) * time.Second
+	validationConfig.RequestTimeout = time.Duration(group.EffectiveConfig.KeyValidationTimeoutSeconds) * time.Second
+
+	// Get the clients from the manager using their respective configurations.
 	httpClient := f.clientManager.GetClient(clientConfig)
 	streamClient := f.clientManager.GetClient(&streamConfig)
+	validationClient := f.clientManager.GetClient(&validationConfig)
+
+	fieldRenames, err := parseFieldRenameRules(group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build body adapters for %s channel: %w", name, err)
+	}
 
 	return &BaseChannel{
 		Name:                name,
 		Upstreams:           upstreamInfos,
 		HTTPClient:          httpClient,
 		StreamClient:        streamClient,
+		ValidationClient:    validationClient,
 		TestModel:           group.TestModel,
 		ValidationEndpoint:  utils.GetValidationEndpoint(group),
 		channelType:         group.ChannelType,
+		groupID:             group.ID,
+		upstreamHealth:      f.upstreamHealth,
 		groupUpstreams:      group.Upstreams,
 		effectiveConfig:     &group.EffectiveConfig,
 		modelRedirectRules:  group.ModelRedirectRules,
 		modelRedirectStrict: group.ModelRedirectStrict,
+		fieldRenames:        fieldRenames,
+		adapters:            newBodyAdapters(fieldRenames),
+		connectTimeout:      connectTimeout,
+		requestTimeout:      requestTimeout,
 	}, nil
 }
+
+// groupTimeoutOverride returns the group's timeout in seconds stored under key, or 0 if it is not set.
+// The raw config is read because groups loaded straight from the database have no ParsedConfig.
+func groupTimeoutOverride(group *models.Group, key string) time.Duration {
+	switch v := group.Config[key].(type) {
+	case float64:
+		return time.Duration(v) * time.Second
+	case int:
+		return time.Duration(v) * time.Second
+	default:
+		return 0
+	}
+}
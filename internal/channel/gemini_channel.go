@@ -1,14 +1,12 @@
 package channel
 
 import (
-	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
 	"aimanager/internal/utils"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -19,6 +17,13 @@ import (
 
 func init() {
 	Register("gemini", newGeminiChannel)
+	// Native generateContent parameters in both JSON spellings, plus those of the OpenAI-compatible endpoint
+	RegisterAllowedParams("gemini",
+		"contents", "systemInstruction", "system_instruction", "generationConfig", "generation_config",
+		"safetySettings", "safety_settings", "tools", "toolConfig", "tool_config",
+		"cachedContent", "cached_content", "labels",
+	)
+	RegisterAllowedParams("gemini", openAIParams...)
 }
 
 type GeminiChannel struct {
@@ -97,16 +102,27 @@ func (ch *GeminiChannel) ExtractModel(c *gin.Context, bodyBytes []byte) string {
 }
 
 // ValidateKey checks if the given API key is valid by making a generateContent request.
-func (ch *GeminiChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group) (bool, error) {
+func (ch *GeminiChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (bool, error) {
+	resp, err := ch.ProbeValidation(ctx, apiKey, group, testModel)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return validationResult(resp)
+}
+
+// ProbeValidation sends the validation request for testModel and returns the raw upstream response.
+// The caller must close the response body.
+func (ch *GeminiChannel) ProbeValidation(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (*http.Response, error) {
 	upstreamURL := ch.getUpstreamURL()
 	if upstreamURL == nil {
-		return false, fmt.Errorf("no upstream URL configured for channel %s", ch.Name)
+		return nil, fmt.Errorf("no upstream URL configured for channel %s", ch.Name)
 	}
 
 	// Safely join the path segments
-	reqURL, err := url.JoinPath(upstreamURL.String(), "v1beta", "models", ch.TestModel+":generateContent")
+	reqURL, err := url.JoinPath(upstreamURL.String(), "v1beta", "models", testModel+":generateContent")
 	if err != nil {
-		return false, fmt.Errorf("failed to create gemini validation path: %w", err)
+		return nil, fmt.Errorf("failed to create gemini validation path: %w", err)
 	}
 	reqURL += "?key=" + apiKey.KeyValue
 
@@ -122,14 +138,15 @@ func (ch *GeminiChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey,
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal validation payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal validation payload: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(body))
 	if err != nil {
-		return false, fmt.Errorf("failed to create validation request: %w", err)
+		return nil, fmt.Errorf("failed to create validation request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	ApplyAuthHeaderMode(req, group, apiKey.KeyValue)
 
 	// Apply custom header rules if available
 	if len(group.HeaderRuleList) > 0 {
@@ -137,27 +154,11 @@ func (ch *GeminiChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey,
 		utils.ApplyHeaderRules(req, group.HeaderRuleList, headerCtx)
 	}
 
-	resp, err := ch.HTTPClient.Do(req)
+	resp, err := ch.ValidationClient.Do(req)
 	if err != nil {
-		return false, fmt.Errorf("failed to send validation request: %w", err)
+		return nil, fmt.Errorf("failed to send validation request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Any 2xx status code indicates the key is valid.
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return true, nil
-	}
-
-	// For non-200 responses, parse the body to provide a more specific error reason.
-	errorBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Errorf("key is invalid (status %d), but failed to read error body: %w", resp.StatusCode, err)
-	}
-
-	// Use the new parser to extract a clean error message.
-	parsedError := app_errors.ParseUpstreamError(errorBody)
-
-	return false, fmt.Errorf("[status %d] %s", resp.StatusCode, parsedError)
+	return resp, nil
 }
 
 // ApplyModelRedirect overrides the default implementation for Gemini channel.
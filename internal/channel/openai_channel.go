@@ -1,14 +1,12 @@
 package channel
 
 import (
-	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
 	"aimanager/internal/utils"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -18,6 +16,7 @@ import (
 
 func init() {
 	Register("openai", newOpenAIChannel)
+	RegisterAllowedParams("openai", openAIParams...)
 }
 
 type OpenAIChannel struct {
@@ -73,16 +72,27 @@ func (ch *OpenAIChannel) ExtractModel(c *gin.Context, bodyBytes []byte) string {
 }
 
 // ValidateKey checks if the given API key is valid by making a chat completion request.
-func (ch *OpenAIChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group) (bool, error) {
+func (ch *OpenAIChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (bool, error) {
+	resp, err := ch.ProbeValidation(ctx, apiKey, group, testModel)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return validationResult(resp)
+}
+
+// ProbeValidation sends the validation request for testModel and returns the raw upstream response.
+// The caller must close the response body.
+func (ch *OpenAIChannel) ProbeValidation(ctx context.Context, apiKey *models.APIKey, group *models.Group, testModel string) (*http.Response, error) {
 	upstreamURL := ch.getUpstreamURL()
 	if upstreamURL == nil {
-		return false, fmt.Errorf("no upstream URL configured for channel %s", ch.Name)
+		return nil, fmt.Errorf("no upstream URL configured for channel %s", ch.Name)
 	}
 
 	// Parse validation endpoint to extract path and query parameters
 	endpointURL, err := url.Parse(ch.ValidationEndpoint)
 	if err != nil {
-		return false, fmt.Errorf("failed to parse validation endpoint: %w", err)
+		return nil, fmt.Errorf("failed to parse validation endpoint: %w", err)
 	}
 
 	// Build final URL with path and query parameters
@@ -93,22 +103,23 @@ func (ch *OpenAIChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey,
 
 	// Use a minimal, low-cost payload for validation
 	payload := gin.H{
-		"model": ch.TestModel,
+		"model": testModel,
 		"messages": []gin.H{
 			{"role": "user", "content": "hi"},
 		},
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal validation payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal validation payload: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(body))
 	if err != nil {
-		return false, fmt.Errorf("failed to create validation request: %w", err)
+		return nil, fmt.Errorf("failed to create validation request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey.KeyValue)
 	req.Header.Set("Content-Type", "application/json")
+	ApplyAuthHeaderMode(req, group, apiKey.KeyValue)
 
 	// Apply custom header rules if available
 	if len(group.HeaderRuleList) > 0 {
@@ -116,25 +127,9 @@ func (ch *OpenAIChannel) ValidateKey(ctx context.Context, apiKey *models.APIKey,
 		utils.ApplyHeaderRules(req, group.HeaderRuleList, headerCtx)
 	}
 
-	resp, err := ch.HTTPClient.Do(req)
+	resp, err := ch.ValidationClient.Do(req)
 	if err != nil {
-		return false, fmt.Errorf("failed to send validation request: %w", err)
+		return nil, fmt.Errorf("failed to send validation request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Any 2xx status code indicates the key is valid.
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return true, nil
-	}
-
-	// For non-200 responses, parse the body to provide a more specific error reason.
-	errorBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Errorf("key is invalid (status %d), but failed to read error body: %w", resp.StatusCode, err)
-	}
-
-	// Use the new parser to extract a clean error message.
-	parsedError := app_errors.ParseUpstreamError(errorBody)
-
-	return false, fmt.Errorf("[status %d] %s", resp.StatusCode, parsedError)
+	return resp, nil
 }
@@ -0,0 +1,74 @@
+package channel
+
+import (
+	"slices"
+	"strings"
+)
+
+// allowedParams holds, per channel type, the top-level request body parameters its upstream API
+// accepts. Channel types without an entry are not checked.
+var allowedParams = make(map[string]map[string]struct{})
+
+// RegisterAllowedParams records the top-level request parameters accepted by a channel type.
+func RegisterAllowedParams(channelType string, params ...string) {
+	set, ok := allowedParams[channelType]
+	if !ok {
+		set = make(map[string]struct{}, len(params))
+		allowedParams[channelType] = set
+	}
+	for _, param := range params {
+		set[param] = struct{}{}
+	}
+}
+
+// AllowedParams returns the sorted top-level request parameters accepted by the channel type, or nil
+// if the channel type does not define them.
+func AllowedParams(channelType string) []string {
+	set, ok := allowedParams[channelType]
+	if !ok {
+		return nil
+	}
+	params := make([]string, 0, len(set))
+	for param := range set {
+		params = append(params, param)
+	}
+	slices.Sort(params)
+	return params
+}
+
+// UnknownParams returns the sorted names in params the channel type does not accept. Channel types
+// without an allowed parameter set accept every name.
+func UnknownParams(channelType string, params map[string]any) []string {
+	set, ok := allowedParams[channelType]
+	if !ok {
+		return nil
+	}
+	var unknown []string
+	for param := range params {
+		if _, allowed := set[strings.TrimSpace(param)]; !allowed {
+			unknown = append(unknown, param)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// openAIParams are the request parameters of the OpenAI chat completions, completions, responses and
+// embeddings APIs, plus sampling and routing parameters common OpenAI-compatible upstreams (vLLM,
+// OpenRouter, Mistral, Qwen, DeepSeek) accept. The Gemini channel accepts them too, for its
+// OpenAI-compatible endpoint.
+var openAIParams = []string{
+	"model", "messages", "prompt", "input", "instructions", "suffix",
+	"temperature", "top_p", "n", "best_of", "echo", "seed", "stop",
+	"max_tokens", "max_completion_tokens", "max_output_tokens",
+	"presence_penalty", "frequency_penalty", "logit_bias", "logprobs", "top_logprobs",
+	"stream", "stream_options", "user", "metadata", "store", "service_tier",
+	"tools", "tool_choice", "parallel_tool_calls", "functions", "function_call",
+	"response_format", "text", "reasoning", "reasoning_effort", "verbosity",
+	"modalities", "audio", "prediction", "web_search_options",
+	"previous_response_id", "include", "truncation", "background",
+	"prompt_cache_key", "safety_identifier", "dimensions", "encoding_format",
+	"top_k", "min_p", "top_a", "repetition_penalty", "length_penalty", "random_seed", "safe_prompt",
+	"thinking", "enable_thinking", "thinking_budget", "chat_template_kwargs", "extra_body",
+	"provider", "models", "route", "transforms", "plugins", "usage",
+}
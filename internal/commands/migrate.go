@@ -673,6 +673,11 @@ func (cmd *MigrateKeysCommand) switchColumns() error {
 			return fmt.Errorf("failed to update api_keys from temp_migration: %w", err)
 		}
 
+		// Suffix fingerprints were derived from the old key, key search recomputes them when cleared
+		if err := tx.Model(&models.APIKey{}).Where("key_suffix_hash <> ''").Update("key_suffix_hash", "").Error; err != nil {
+			return fmt.Errorf("failed to clear key suffix fingerprints: %w", err)
+		}
+
 		logrus.Info("Successfully updated original table with migrated data")
 		return nil
 	})
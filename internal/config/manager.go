@@ -84,6 +84,8 @@ func (m *Manager) ReloadConfig() error {
 			Key:             os.Getenv("AUTH_KEY"),
 			MaxFailedAttempts: utils.ParseInteger(os.Getenv("LOGIN_MAX_FAILED_ATTEMPTS"), 10),
 			LockoutDuration:    utils.ParseInteger(os.Getenv("LOGIN_LOCKOUT_DURATION"), 300),
+			GlobalMaxFailedAttempts: utils.ParseInteger(os.Getenv("LOGIN_GLOBAL_MAX_FAILED_ATTEMPTS"), 100),
+			GlobalFailureWindow: utils.ParseInteger(os.Getenv("LOGIN_GLOBAL_FAILURE_WINDOW"), 900),
 		},
 		CORS: types.CORSConfig{
 			Enabled:          utils.ParseBoolean(os.Getenv("ENABLE_CORS"), false),
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -22,6 +23,14 @@ import (
 
 const SettingsUpdateChannel = "system_settings:updated"
 
+// sensitiveSettingKeys lists settings whose values must never be exposed by diagnostic endpoints.
+var sensitiveSettingKeys = map[string]bool{
+	"proxy_keys": true,
+	"proxy_url":  true, // may carry proxy credentials as userinfo
+}
+
+const redactedSettingValue = "******"
+
 // SystemSettingsManager 管理系统配置
 type SystemSettingsManager struct {
 	syncer *syncer.CacheSyncer[types.SystemSettings]
@@ -207,6 +216,47 @@ func (sm *SystemSettingsManager) UpdateSettings(settingsMap map[string]any) erro
 	return sm.syncer.Invalidate()
 }
 
+// CompareWithDB 对比内存中的配置快照与数据库中持久化的值，用于排查配置未同步的问题。
+// 敏感配置项的值会被脱敏，但仍然参与差异比较。
+func (sm *SystemSettingsManager) CompareWithDB() ([]models.SettingDriftInfo, error) {
+	var dbSettings []models.SystemSetting
+	if err := db.DB.Find(&dbSettings).Error; err != nil {
+		return nil, fmt.Errorf("failed to load system settings from db: %w", err)
+	}
+
+	dbValues := make(map[string]string, len(dbSettings))
+	for _, setting := range dbSettings {
+		dbValues[setting.SettingKey] = setting.SettingValue
+	}
+
+	currentSettings := sm.GetSettings()
+	metadata := utils.GenerateSettingsMetadata(&currentSettings)
+
+	result := make([]models.SettingDriftInfo, 0, len(metadata))
+	for _, meta := range metadata {
+		memValue := fmt.Sprintf("%v", meta.Value)
+		dbValue, inDB := dbValues[meta.Key]
+
+		info := models.SettingDriftInfo{
+			Key:           meta.Key,
+			InMemoryValue: memValue,
+			DBValue:       dbValue,
+			InDB:          inDB,
+			Drifted:       !inDB || memValue != dbValue,
+		}
+		if sensitiveSettingKeys[meta.Key] {
+			info.InMemoryValue = redactedSettingValue
+			if inDB {
+				info.DBValue = redactedSettingValue
+			}
+			info.Redacted = true
+		}
+		result = append(result, info)
+	}
+
+	return result, nil
+}
+
 // GetEffectiveConfig 获取有效配置 (系统配置 + 分组覆盖)
 func (sm *SystemSettingsManager) GetEffectiveConfig(groupConfigJSON datatypes.JSONMap) types.SystemSettings {
 	effectiveConfig := sm.GetSettings()
@@ -289,6 +339,13 @@ func (sm *SystemSettingsManager) ValidateSettings(settingsMap map[string]any) er
 						return fmt.Errorf("value for %s (%d) is below minimum value (%d)", key, intVal, minVal)
 					}
 				}
+				if strings.HasPrefix(trimmedRule, "max=") {
+					maxValStr := strings.TrimPrefix(trimmedRule, "max=")
+					maxVal, _ := strconv.Atoi(maxValStr)
+					if intVal > maxVal {
+						return fmt.Errorf("value for %s (%d) is above maximum value (%d)", key, intVal, maxVal)
+					}
+				}
 			}
 		case reflect.Bool:
 			if _, ok := value.(bool); !ok {
@@ -306,6 +363,12 @@ func (sm *SystemSettingsManager) ValidateSettings(settingsMap map[string]any) er
 						return fmt.Errorf("value for %s is required", key)
 					}
 				}
+				if strings.HasPrefix(trimmedRule, "oneof=") {
+					allowed := strings.Fields(strings.TrimPrefix(trimmedRule, "oneof="))
+					if !slices.Contains(allowed, strVal) {
+						return fmt.Errorf("invalid value for %s: must be one of %s", key, strings.Join(allowed, ", "))
+					}
+				}
 			}
 		default:
 			return fmt.Errorf("unsupported type for setting key validation: %s", key)
@@ -363,6 +426,13 @@ func (sm *SystemSettingsManager) ValidateGroupConfigOverrides(configMap map[stri
 						return fmt.Errorf("value for %s (%d) is below minimum value (%d)", key, intVal, minVal)
 					}
 				}
+				if strings.HasPrefix(trimmedRule, "max=") {
+					maxValStr := strings.TrimPrefix(trimmedRule, "max=")
+					maxVal, _ := strconv.Atoi(maxValStr)
+					if intVal > maxVal {
+						return fmt.Errorf("value for %s (%d) is above maximum value (%d)", key, intVal, maxVal)
+					}
+				}
 			}
 		case reflect.String:
 			strVal, ok := value.(string)
@@ -376,6 +446,12 @@ func (sm *SystemSettingsManager) ValidateGroupConfigOverrides(configMap map[stri
 						return fmt.Errorf("value for %s is required", key)
 					}
 				}
+				if strings.HasPrefix(trimmedRule, "oneof=") {
+					allowed := strings.Fields(strings.TrimPrefix(trimmedRule, "oneof="))
+					if !slices.Contains(allowed, strVal) {
+						return fmt.Errorf("invalid value for %s: must be one of %s", key, strings.Join(allowed, ", "))
+					}
+				}
 			}
 		case reflect.Bool:
 			_, ok := value.(bool)
@@ -398,6 +474,19 @@ func (sm *SystemSettingsManager) DisplaySystemConfig(settings types.SystemSettin
 	logrus.Infof("    App URL: %s", settings.AppUrl)
 	logrus.Infof("    Request Log Retention: %d days", settings.RequestLogRetentionDays)
 	logrus.Infof("    Request Log Write Interval: %d minutes", settings.RequestLogWriteIntervalMinutes)
+	logrus.Infof("    Request Log Buffer: %d (backpressure policy: %s)", settings.RequestLogBufferSize, settings.RequestLogBackpressurePolicy)
+	logrus.Infof("    Mask Keys In Log Export: %t", settings.MaskKeysInLogExport)
+	logrus.Infof("    Structured Request Log: %t", settings.EnableStructuredRequestLog)
+	logrus.Infof("    Enforce Unique Proxy Keys: %t", settings.EnforceUniqueProxyKeys)
+	logrus.Infof("    Stats Exclude Current Hour: %t", settings.StatsExcludeCurrentHour)
+	logrus.Infof("    Stats Time-Series Max Window: %d hours", settings.StatsTimeseriesMaxHours)
+	if settings.StatsHourlyRetentionDays > 0 {
+		logrus.Infof("    Hourly Stats Retention: %d days (older stats rolled up daily, at least 31 days and the time-series window are kept)", settings.StatsHourlyRetentionDays)
+	} else {
+		logrus.Info("    Hourly Stats Retention: unlimited")
+	}
+	logrus.Infof("    Group Cache Refresh: every %d seconds (±%d jitter)", settings.GroupCacheRefreshIntervalSeconds, settings.GroupCacheRefreshJitterSeconds)
+	logrus.Infof("    Group Cache Invalidation Window: %d ms", settings.GroupCacheInvalidateWindowMs)
 
 	logrus.Info("  --- Request Behavior ---")
 	logrus.Infof("    Request Timeout: %d seconds", settings.RequestTimeout)
@@ -406,11 +495,27 @@ func (sm *SystemSettingsManager) DisplaySystemConfig(settings types.SystemSettin
 	logrus.Infof("    Idle Connection Timeout: %d seconds", settings.IdleConnTimeout)
 	logrus.Infof("    Max Idle Connections: %d", settings.MaxIdleConns)
 	logrus.Infof("    Max Idle Connections Per Host: %d", settings.MaxIdleConnsPerHost)
+	logrus.Infof("    Stream Buffer Size: %d KB", settings.StreamBufferSizeKB)
+	logrus.Infof("    Normalize Proxy Path: %t", settings.NormalizeProxyPath)
+	logrus.Infof("    Trusted Proxy Depth: %d", settings.TrustedProxyDepth)
+	logrus.Infof("    Require HTTPS Upstreams: %t", settings.RequireHTTPSUpstreams)
+	logrus.Infof("    Global Rate Limit: %d req/s (burst: %d)", settings.GlobalRateLimitRPS, settings.GlobalRateLimitBurst)
+	logrus.Infof("    Global Max Concurrent Requests: %d (overflow: %s, wait timeout: %dms)", settings.GlobalMaxConcurrent, settings.ConcurrencyOverflow, settings.ConcurrencyWaitTimeoutMs)
+	logrus.Infof("    Request Hedging: delay %dms (budget: %d%%)", settings.HedgeDelayMs, settings.HedgeBudgetPercent)
+	logrus.Infof("    Max Response Size: %d KB (oversized: %s)", settings.MaxResponseSizeKB, settings.OversizedResponseMode)
+	logrus.Infof("    Upstream Failover: after %d consecutive failures (cooldown: %d seconds)", settings.UpstreamFailureThreshold, settings.UpstreamCooldownSeconds)
 
 	logrus.Info("  --- Key & Group Behavior ---")
 	logrus.Infof("    Max Retries: %d", settings.MaxRetries)
 	logrus.Infof("    Blacklist Threshold: %d", settings.BlacklistThreshold)
 	logrus.Infof("    Key Validation Interval: %d minutes", settings.KeyValidationIntervalMinutes)
+	logrus.Infof("    Key Validation Timeouts: connect %ds, read %ds, total %ds", settings.KeyValidationConnectTimeout, settings.KeyValidationReadTimeout, settings.KeyValidationTimeoutSeconds)
+	logrus.Infof("    Key Selection Mode: %s", settings.KeySelectionMode)
+	logrus.Infof("    Key Warm-up Window: %d minutes", settings.KeyWarmupMinutes)
+	logrus.Infof("    Rate Limit Warning Threshold: %d%%", settings.RateLimitWarningPercent)
+	logrus.Infof("    Joint Upstream/Key Selection: %t", settings.JointUpstreamKeySelection)
+	logrus.Infof("    Key Validation Hysteresis: invalidate after %d failures, recover after %d successes", settings.KeyInvalidateAfterFailures, settings.KeyRecoverAfterSuccesses)
+	logrus.Infof("    Group Auto-Pause: failure rate %d%% over %d hours (min %d requests, cooldown: %d minutes)", settings.AutoPauseFailureRate, settings.AutoPauseWindowHours, settings.AutoPauseMinRequests, settings.AutoPauseCooldownMinutes)
 	logrus.Info("====================================")
 	logrus.Info("")
 }
@@ -44,6 +44,20 @@ func BuildContainer() (*dig.Container, error) {
 	if err := container.Provide(httpclient.NewHTTPClientManager); err != nil {
 		return nil, err
 	}
+	if err := container.Provide(services.NewUpstreamHealth); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewRequestRateCounter); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewRateLimitBucket); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(func(upstreamHealth *services.UpstreamHealth) channel.UpstreamHealthChecker {
+		return upstreamHealth
+	}); err != nil {
+		return nil, err
+	}
 	if err := container.Provide(channel.NewFactory); err != nil {
 		return nil, err
 	}
@@ -70,12 +84,39 @@ func BuildContainer() (*dig.Container, error) {
 	if err := container.Provide(services.NewLogService); err != nil {
 		return nil, err
 	}
+	if err := container.Provide(services.NewGroupAutoPauseService); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewGroupAlertService); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewKeyCountStatsService); err != nil {
+		return nil, err
+	}
 	if err := container.Provide(services.NewLogCleanupService); err != nil {
 		return nil, err
 	}
+	if err := container.Provide(services.NewRequestLogFeed); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewStructuredRequestLogger); err != nil {
+		return nil, err
+	}
 	if err := container.Provide(services.NewRequestLogService); err != nil {
 		return nil, err
 	}
+	if err := container.Provide(services.NewConnectivityService); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewGlobalRateLimiter); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewConcurrencyLimiter); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewGroupCircuitBreaker); err != nil {
+		return nil, err
+	}
 	if err := container.Provide(services.NewSubGroupManager); err != nil {
 		return nil, err
 	}
@@ -85,9 +126,18 @@ func BuildContainer() (*dig.Container, error) {
 	if err := container.Provide(services.NewGroupService); err != nil {
 		return nil, err
 	}
+	if err := container.Provide(services.NewSubGroupAvailability); err != nil {
+		return nil, err
+	}
 	if err := container.Provide(services.NewAggregateGroupService); err != nil {
 		return nil, err
 	}
+	if err := container.Provide(services.NewGroupArchiveService); err != nil {
+		return nil, err
+	}
+	if err := container.Provide(services.NewUpstreamProbeService); err != nil {
+		return nil, err
+	}
 	if err := container.Provide(keypool.NewProvider); err != nil {
 		return nil, err
 	}
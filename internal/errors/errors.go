@@ -41,6 +41,16 @@ var (
 	ErrNoKeysAvailable    = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: "NO_KEYS_AVAILABLE", Message: "No API keys available to process the request"}
 	ErrGroupExpired       = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: "GROUP_EXPIRED", Message: "当前负载较高，请稍后尝试.EXP。"}
 	ErrRateLimitExceeded  = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: "RATE_LIMIT_EXCEEDED", Message: "当前负载较高，请稍后尝试.RATE_LIMIT。"}
+	ErrGlobalRateLimit    = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: "GLOBAL_RATE_LIMIT_EXCEEDED", Message: "Service is overloaded, please retry later"}
+	ErrConcurrencyLimit   = &APIError{HTTPStatus: http.StatusTooManyRequests, Code: "CONCURRENCY_LIMIT_EXCEEDED", Message: "Too many concurrent requests, please retry later"}
+	ErrReplayDetected     = &APIError{HTTPStatus: http.StatusConflict, Code: "REPLAY_DETECTED", Message: "Request nonce has already been used"}
+	ErrGroupPaused        = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: "GROUP_PAUSED", Message: "Group is temporarily paused after sustained upstream failures"}
+	ErrCircuitOpen        = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: "CIRCUIT_OPEN", Message: "Group circuit breaker is open after a high upstream failure rate, please retry later"}
+	ErrEditConflict       = &APIError{HTTPStatus: http.StatusConflict, Code: "EDIT_CONFLICT", Message: "The resource was changed by another request, reload and try again"}
+	ErrResponseTooLarge   = &APIError{HTTPStatus: http.StatusBadGateway, Code: "RESPONSE_TOO_LARGE", Message: "Upstream response exceeds the configured size limit"}
+	ErrRequestTooLarge    = &APIError{HTTPStatus: http.StatusRequestEntityTooLarge, Code: "REQUEST_TOO_LARGE", Message: "Request body exceeds the configured size limit"}
+	ErrModelNotAllowed    = &APIError{HTTPStatus: http.StatusForbidden, Code: "MODEL_NOT_ALLOWED", Message: "The requested model is not allowed for this group"}
+	ErrClientIPNotAllowed = &APIError{HTTPStatus: http.StatusForbidden, Code: "CLIENT_IP_NOT_ALLOWED", Message: "This proxy key cannot be used from your IP address"}
 )
 
 // NewAPIError creates a new APIError with a custom message.
@@ -95,10 +105,10 @@ func ParseDBError(err error) *APIError {
 
 // RateLimitError represents a rate limit or expiry error with details
 type RateLimitError struct {
-	Reason  string    // "expired", "hourly_limit", "monthly_limit"
+	Reason  string    // "expired", "hourly_limit", "monthly_limit", "token_bucket_limit"
 	Limit   int64     // 限制值
 	Used    int64     // 已使用量
-	ResetAt time.Time // 重置时间
+	ResetAt time.Time // 重置时间，令牌桶模式下为下一个令牌可用的时间
 }
 
 // Error implements the error interface
@@ -414,6 +414,11 @@ func (s *Server) EncryptionStatus(c *gin.Context) {
 	})
 }
 
+// Connectivity measures round-trip latency to the database and the cache store
+func (s *Server) Connectivity(c *gin.Context) {
+	response.Success(c, s.ConnectivityService.Check(c.Request.Context()))
+}
+
 // checkEncryptionMismatch detects encryption configuration mismatches
 func (s *Server) checkEncryptionMismatch(c *gin.Context) (bool, string, string, string) {
 	encryptionKey := s.config.GetEncryptionKey()
@@ -2,7 +2,10 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
 	"os"
 	"strconv"
@@ -64,15 +67,9 @@ type GroupCreateRequest struct {
 	ProxyKeys           string              `json:"proxy_keys"`
 }
 
-// CreateGroup handles the creation of a new group.
-func (s *Server) CreateGroup(c *gin.Context) {
-	var req GroupCreateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
-		return
-	}
-
-	params := services.GroupCreateParams{
+// toParams converts the request into service parameters.
+func (req *GroupCreateRequest) toParams() services.GroupCreateParams {
+	return services.GroupCreateParams{
 		Name:                req.Name,
 		DisplayName:         req.DisplayName,
 		Description:         req.Description,
@@ -89,8 +86,17 @@ func (s *Server) CreateGroup(c *gin.Context) {
 		HeaderRules:         req.HeaderRules,
 		ProxyKeys:           req.ProxyKeys,
 	}
+}
+
+// CreateGroup handles the creation of a new group.
+func (s *Server) CreateGroup(c *gin.Context) {
+	var req GroupCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
 
-	group, err := s.GroupService.CreateGroup(c.Request.Context(), params)
+	group, err := s.GroupService.CreateGroup(c.Request.Context(), req.toParams())
 	if s.handleGroupError(c, err) {
 		return
 	}
@@ -98,6 +104,148 @@ func (s *Server) CreateGroup(c *gin.Context) {
 	response.Success(c, s.newGroupResponse(group))
 }
 
+// ValidateGroup handles a dry run of group creation. It accepts the CreateGroup payload, fails the same
+// way CreateGroup would, and on success returns the group as it would be stored without writing it.
+func (s *Server) ValidateGroup(c *gin.Context) {
+	var req GroupCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	group, err := s.GroupService.ValidateGroup(c.Request.Context(), req.toParams())
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, s.newGroupResponse(group))
+}
+
+// UpstreamTestRequest defines the payload for testing an upstream outside of any group.
+type UpstreamTestRequest struct {
+	URL         string `json:"url"`
+	ChannelType string `json:"channel_type"`
+	TestModel   string `json:"test_model"`
+	APIKey      string `json:"api_key" binding:"required"`
+}
+
+// TestUpstream sends a single validation request to an upstream that is not part of a group yet.
+func (s *Server) TestUpstream(c *gin.Context) {
+	var req UpstreamTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	result, err := s.UpstreamProbeService.Probe(c.Request.Context(), services.UpstreamProbeParams{
+		URL:         req.URL,
+		ChannelType: req.ChannelType,
+		TestModel:   req.TestModel,
+		APIKey:      req.APIKey,
+	})
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GroupArchiveImportRequest defines the JSON payload for importing a group archive.
+type GroupArchiveImportRequest struct {
+	Archive             services.GroupArchive `json:"archive"`
+	SourceEncryptionKey string                `json:"source_encryption_key"`
+}
+
+// ImportGroupArchive handles creating groups and queuing their key imports from a migration archive,
+// uploaded as a JSON file or sent inline.
+func (s *Server) ImportGroupArchive(c *gin.Context) {
+	var req GroupArchiveImportRequest
+
+	if strings.Contains(c.ContentType(), "multipart/form-data") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.file_required")
+			return
+		}
+
+		fileContent, err := file.Open()
+		if err != nil {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.failed_to_open_file")
+			return
+		}
+		defer fileContent.Close()
+
+		if err := json.NewDecoder(fileContent).Decode(&req.Archive); err != nil {
+			response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+			return
+		}
+		req.SourceEncryptionKey = c.PostForm("source_encryption_key")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	result, err := s.GroupArchiveService.ImportArchive(c.Request.Context(), &req.Archive, req.SourceEncryptionKey)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	// Per-group failures are reported in the result, translated like top-level errors
+	for i := range result.Groups {
+		var i18nErr *services.I18nError
+		if errors.As(result.Groups[i].Err, &i18nErr) {
+			result.Groups[i].Error = i18n.Message(c, i18nErr.MessageID, i18nErr.Template)
+		}
+	}
+
+	response.Success(c, result)
+}
+
+// ExportGroups handles serializing all groups into one archive document. The keys query parameter
+// selects the keys included in plain text: none (default), valid_only or all.
+func (s *Server) ExportGroups(c *gin.Context) {
+	archive, err := s.GroupArchiveService.ExportGroups(c.Request.Context(), c.Query("keys"))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, archive)
+}
+
+// ImportGroups handles recreating all groups of an exported archive in a single transaction.
+// If a group fails, nothing is imported and the error names the group.
+func (s *Server) ImportGroups(c *gin.Context) {
+	var archive services.GroupArchive
+	if err := c.ShouldBindJSON(&archive); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	result, err := s.GroupArchiveService.ImportGroups(c.Request.Context(), &archive)
+	var importErr *services.GroupImportError
+	if errors.As(err, &importErr) {
+		apiErr := app_errors.ErrValidation
+		message := importErr.Err.Error()
+		var i18nErr *services.I18nError
+		if errors.As(importErr.Err, &i18nErr) {
+			apiErr = i18nErr.APIError
+			message = i18n.Message(c, i18nErr.MessageID, i18nErr.Template)
+		} else if errors.As(importErr.Err, &apiErr) {
+			message = apiErr.Message
+		}
+		response.ErrorI18nFromAPIError(c, apiErr, "validation.group_import_failed", map[string]any{
+			"group": importErr.GroupName,
+			"error": message,
+		})
+		return
+	}
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, result)
+}
+
 // ListGroups handles listing all groups.
 func (s *Server) ListGroups(c *gin.Context) {
 	groups, err := s.GroupService.ListGroups(c.Request.Context())
@@ -105,12 +253,13 @@ func (s *Server) ListGroups(c *gin.Context) {
 		return
 	}
 
+	upstreamHealth := s.GroupService.GetUpstreamHealthForGroups(groups)
 	groupResponses := make([]GroupResponse, 0, len(groups))
 	for i := range groups {
-		groupResp := s.newGroupResponse(&groups[i])
+		groupResp := s.buildGroupResponse(&groups[i], upstreamHealth[groups[i].ID])
 
 		// 获取分组的统计信息（24小时、7天和30天）
-		stats, err := s.GroupService.GetGroupListStats(c.Request.Context(), groups[i].ID)
+		stats, err := s.GroupService.GetGroupListStats(c.Request.Context(), groups[i].ID, s.excludeCurrentHour(c))
 		if err == nil && stats != nil {
 			groupResp.Stats24Hour = &stats.Stats24Hour
 			groupResp.Stats7Day = &stats.Stats7Day
@@ -215,16 +364,27 @@ type GroupResponse struct {
 	HeaderRules         []models.HeaderRule `json:"header_rules"`
 	ProxyKeys           string              `json:"proxy_keys"`
 	LastValidatedAt     *time.Time          `json:"last_validated_at"`
+	AutoPausedAt        *time.Time          `json:"auto_paused_at"`
+	AutoPauseResumedAt  *time.Time          `json:"auto_pause_resumed_at"`
 	CreatedAt           time.Time           `json:"created_at"`
 	UpdatedAt           time.Time           `json:"updated_at"`
+	DeletedAt           *time.Time          `json:"deleted_at,omitempty"`
 	// 统计信息
 	Stats24Hour         *services.RequestStats `json:"stats_24_hour,omitempty"`
 	Stats7Day           *services.RequestStats `json:"stats_7_day,omitempty"`
 	Stats30Day          *services.RequestStats `json:"stats_30_day,omitempty"`
+	// 上游健康状态（只读）
+	UpstreamHealth []services.UpstreamHealthStatus `json:"upstream_health,omitempty"`
 }
 
 // newGroupResponse creates a new GroupResponse from a models.Group.
 func (s *Server) newGroupResponse(group *models.Group) *GroupResponse {
+	return s.buildGroupResponse(group, s.GroupService.GetUpstreamHealth(group))
+}
+
+// buildGroupResponse creates a new GroupResponse from a models.Group with already loaded upstream
+// health, so list endpoints can read the health of all groups at once.
+func (s *Server) buildGroupResponse(group *models.Group, upstreamHealth []services.UpstreamHealthStatus) *GroupResponse {
 	appURL := s.SettingsManager.GetAppUrl()
 	endpoint := ""
 	if appURL != "" {
@@ -244,6 +404,11 @@ func (s *Server) newGroupResponse(group *models.Group) *GroupResponse {
 		}
 	}
 
+	var deletedAt *time.Time
+	if group.DeletedAt.Valid {
+		deletedAt = &group.DeletedAt.Time
+	}
+
 	return &GroupResponse{
 		ID:                  group.ID,
 		Name:                group.Name,
@@ -263,8 +428,12 @@ func (s *Server) newGroupResponse(group *models.Group) *GroupResponse {
 		HeaderRules:         headerRules,
 		ProxyKeys:           group.ProxyKeys,
 		LastValidatedAt:     group.LastValidatedAt,
+		AutoPausedAt:        group.AutoPausedAt,
+		AutoPauseResumedAt:  group.AutoPauseResumedAt,
+		UpstreamHealth:      upstreamHealth,
 		CreatedAt:           group.CreatedAt,
 		UpdatedAt:           group.UpdatedAt,
+		DeletedAt:           deletedAt,
 	}
 }
 
@@ -282,6 +451,51 @@ func (s *Server) DeleteGroup(c *gin.Context) {
 	response.SuccessI18n(c, "success.group_deleted", nil)
 }
 
+// ListDeletedGroups returns the soft-deleted groups that can still be restored.
+func (s *Server) ListDeletedGroups(c *gin.Context) {
+	groups, err := s.GroupService.ListDeletedGroups(c.Request.Context())
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	groupResponses := make([]GroupResponse, 0, len(groups))
+	for i := range groups {
+		groupResponses = append(groupResponses, *s.buildGroupResponse(&groups[i], nil))
+	}
+
+	response.Success(c, groupResponses)
+}
+
+// RestoreGroup handles restoring a soft-deleted group.
+func (s *Server) RestoreGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	group, err := s.GroupService.RestoreGroup(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, s.newGroupResponse(group))
+}
+
+// PurgeGroup handles permanently deleting a group, whether soft-deleted or not.
+func (s *Server) PurgeGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	if s.handleGroupError(c, s.GroupService.PurgeGroup(c.Request.Context(), uint(id))) {
+		return
+	}
+	response.SuccessI18n(c, "success.group_purged", nil)
+}
+
 // ConfigOption represents a single configurable option for a group.
 type ConfigOption struct {
 	Key          string `json:"key"`
@@ -319,6 +533,17 @@ func (s *Server) GetGroupConfigOptions(c *gin.Context) {
 	response.Success(c, translated)
 }
 
+// excludeCurrentHour resolves whether windowed stats should skip the in-progress hour: the
+// exclude_current_hour query parameter wins, otherwise the stats_exclude_current_hour setting applies.
+func (s *Server) excludeCurrentHour(c *gin.Context) bool {
+	if value := c.Query("exclude_current_hour"); value != "" {
+		if exclude, err := strconv.ParseBool(value); err == nil {
+			return exclude
+		}
+	}
+	return s.SettingsManager.GetSettings().StatsExcludeCurrentHour
+}
+
 // calculateRequestStats is a helper to compute request statistics.
 func (s *Server) GetGroupStats(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -327,7 +552,7 @@ func (s *Server) GetGroupStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := s.GroupService.GetGroupStats(c.Request.Context(), uint(id))
+	stats, err := s.GroupService.GetGroupStats(c.Request.Context(), uint(id), s.excludeCurrentHour(c))
 	if s.handleGroupError(c, err) {
 		return
 	}
@@ -335,9 +560,39 @@ func (s *Server) GetGroupStats(c *gin.Context) {
 	response.Success(c, stats)
 }
 
+// GetGroupStatsTimeseries returns a group's hourly request counts, for charting.
+func (s *Server) GetGroupStatsTimeseries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	maxHours := s.SettingsManager.GetSettings().StatsTimeseriesMaxHours
+	hours := min(services.DefaultStatsTimeseriesHours, maxHours)
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		parsed, err := strconv.Atoi(hoursStr)
+		if err != nil || parsed <= 0 || parsed > maxHours {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_stats_timeseries_hours", map[string]any{"max": maxHours})
+			return
+		}
+		hours = parsed
+	}
+
+	points, err := s.GroupService.GetGroupStatsTimeseries(c.Request.Context(), uint(id), hours, s.excludeCurrentHour(c))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, points)
+}
+
 // GroupCopyRequest defines the payload for copying a group.
 type GroupCopyRequest struct {
-	CopyKeys string `json:"copy_keys"` // "none"|"valid_only"|"all"
+	CopyKeys    string          `json:"copy_keys"`    // "none"|"valid_only"|"all"
+	OnConflict  string          `json:"on_conflict"`  // "fail"|"rename"|"overwrite", defaults to "rename"
+	ChannelType string          `json:"channel_type"` // optional, defaults to the source group's channel type
+	Upstreams   json.RawMessage `json:"upstreams"`    // optional, defaults to the source group's upstreams
 }
 
 // GroupCopyResponse defines the response for group copy operation.
@@ -360,7 +615,10 @@ func (s *Server) CopyGroup(c *gin.Context) {
 		return
 	}
 
-	newGroup, err := s.GroupService.CopyGroup(c.Request.Context(), uint(id), req.CopyKeys)
+	newGroup, err := s.GroupService.CopyGroup(c.Request.Context(), uint(id), req.CopyKeys, req.OnConflict, services.GroupCopyOverrides{
+		ChannelType: req.ChannelType,
+		Upstreams:   req.Upstreams,
+	})
 	if s.handleGroupError(c, err) {
 		return
 	}
@@ -373,6 +631,42 @@ func (s *Server) CopyGroup(c *gin.Context) {
 	response.Success(c, copyResponse)
 }
 
+// SwapGroupNamesRequest defines the payload for swapping two groups' names.
+type SwapGroupNamesRequest struct {
+	GroupAID uint `json:"group_a_id"`
+	GroupBID uint `json:"group_b_id"`
+}
+
+// SwapGroupNamesResponse contains both groups after the swap.
+type SwapGroupNamesResponse struct {
+	GroupA *GroupResponse `json:"group_a"`
+	GroupB *GroupResponse `json:"group_b"`
+}
+
+// SwapGroupNames handles atomically swapping the names of two groups.
+func (s *Server) SwapGroupNames(c *gin.Context) {
+	var req SwapGroupNamesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	if req.GroupAID == 0 || req.GroupBID == 0 {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	groupA, groupB, err := s.GroupService.SwapGroupNames(c.Request.Context(), req.GroupAID, req.GroupBID)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, &SwapGroupNamesResponse{
+		GroupA: s.newGroupResponse(groupA),
+		GroupB: s.newGroupResponse(groupB),
+	})
+}
+
 // List godoc
 func (s *Server) List(c *gin.Context) {
 	var groups []models.Group
@@ -393,6 +687,13 @@ type UpdateSubGroupWeightRequest struct {
 	Weight int `json:"weight"`
 }
 
+// UpdateSubGroupWeightsRequest defines the payload for bulk-updating sub group weights.
+// Either Weights (sub group ID -> weight) or Scale (multiplier for all weights) must be set.
+type UpdateSubGroupWeightsRequest struct {
+	Weights map[uint]int `json:"weights"`
+	Scale   *float64     `json:"scale"`
+}
+
 // GetSubGroups handles getting sub groups of an aggregate group
 func (s *Server) GetSubGroups(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -409,6 +710,51 @@ func (s *Server) GetSubGroups(c *gin.Context) {
 	response.Success(c, subGroups)
 }
 
+// GetEffectiveUpstreams handles listing the flattened upstreams of an aggregate group with effective weights
+func (s *Server) GetEffectiveUpstreams(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	upstreams, err := s.AggregateGroupService.GetEffectiveUpstreams(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, upstreams)
+}
+
+// GetRoutingWeights handles returning the live selection probability of each sub-group of an aggregate group
+func (s *Server) GetRoutingWeights(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	dbGroup, ok := s.findGroupByID(c, uint(id))
+	if !ok {
+		return
+	}
+	if dbGroup.GroupType != "aggregate" {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "group.not_aggregate")
+		return
+	}
+
+	// The cached group carries the sub-group list used for selection
+	group, err := s.GroupManager.GetGroupByName(dbGroup.Name)
+	if err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	weights := s.SubGroupAvailability.GetRoutingWeights(c.Request.Context(), group)
+
+	response.Success(c, weights)
+}
+
 // AddSubGroups handles adding sub groups to an aggregate group
 func (s *Server) AddSubGroups(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -457,6 +803,33 @@ func (s *Server) UpdateSubGroupWeight(c *gin.Context) {
 	response.SuccessI18n(c, "success.sub_group_weight_updated", nil)
 }
 
+// UpdateSubGroupWeights handles setting or proportionally scaling all sub group weights at once
+func (s *Server) UpdateSubGroupWeights(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	var req UpdateSubGroupWeightsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	var subGroups []models.SubGroupInfo
+	if req.Scale != nil {
+		subGroups, err = s.AggregateGroupService.ScaleSubGroupWeights(c.Request.Context(), uint(id), *req.Scale)
+	} else {
+		subGroups, err = s.AggregateGroupService.SetSubGroupWeights(c.Request.Context(), uint(id), req.Weights)
+	}
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.SuccessI18n(c, "success.sub_group_weights_updated", subGroups)
+}
+
 // DeleteSubGroup handles deleting a sub group from an aggregate group
 func (s *Server) DeleteSubGroup(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -502,17 +875,29 @@ type GroupUsageData struct {
 	MonthlyUsage int64  `json:"monthly_usage"`
 	MonthlyLimit int64  `json:"monthly_limit"`
 	LastUpdated  string `json:"last_updated"`
+	// Requests per second in the last complete second and averaged over the last minute, summed over
+	// the sub-groups for aggregate groups
+	CurrentRPS int64   `json:"current_rps"`
+	AvgRPS1m   float64 `json:"avg_rps_1m"`
+	// Requests being forwarded on this instance
+	InFlightRequests int `json:"in_flight_requests"`
+
+	MonthlyProjection *services.MonthlyUsageProjection `json:"monthly_projection,omitempty"`
 }
 
 // GroupMonitorResponse represents the response for group monitor API
 type GroupMonitorResponse struct {
-	Groups []GroupMonitorItem `json:"groups"`
+	Groups            []GroupMonitorItem                   `json:"groups"`
+	RequestLog        services.RequestLogBackpressureStats `json:"request_log"`
+	GlobalRate        services.GlobalRateLimitStats        `json:"global_rate"`
+	GlobalConcurrency services.GlobalConcurrencyStats      `json:"global_concurrency"`
 }
 
 // GroupMonitorItem represents a single group item in the monitor response
 type GroupMonitorItem struct {
 	*GroupResponse
-	UsageData *GroupUsageData `json:"usage_data,omitempty"`
+	UsageData      *GroupUsageData                `json:"usage_data,omitempty"`
+	CircuitBreaker *services.CircuitBreakerStatus `json:"circuit_breaker,omitempty"`
 }
 
 // GetGroupMonitor handles the request to get all groups with their usage data
@@ -530,33 +915,79 @@ func (s *Server) GetGroupMonitor(c *gin.Context) {
 
 	// Prepare result items
 	items := make([]GroupMonitorItem, 0, len(groups))
+	excludeCurrentHour := s.excludeCurrentHour(c)
+
+	rates, err := s.RequestRateCounter.Rates()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read group request rates")
+	}
 
+	upstreamHealth := s.GroupService.GetUpstreamHealthForGroups(groups)
 	for i := range groups {
 		group := &groups[i]
-		groupResp := s.newGroupResponse(group)
+		groupResp := s.buildGroupResponse(group, upstreamHealth[group.ID])
 
 		// Get usage data
 		usageData := s.getGroupUsageData(group.ID, currentHour, currentMonth)
+		rate := s.getGroupRequestRate(c.Request.Context(), group, rates)
+		usageData.CurrentRPS = rate.CurrentRPS
+		usageData.AvgRPS1m = rate.AvgRPS1m
+		usageData.InFlightRequests = s.ConcurrencyLimiter.InFlight(group.ID)
 
 		// 获取分组的统计信息（24小时、7天和30天）
-		stats, err := s.GroupService.GetGroupListStats(c.Request.Context(), group.ID)
+		stats, err := s.GroupService.GetGroupListStats(c.Request.Context(), group.ID, excludeCurrentHour)
 		if err == nil && stats != nil {
 			groupResp.Stats24Hour = &stats.Stats24Hour
 			groupResp.Stats7Day = &stats.Stats7Day
 			groupResp.Stats30Day = &stats.Stats30Day
 		}
 
+		// The circuit breaker settings are read from the parsed config of the cached group
+		var circuitBreaker *services.CircuitBreakerStatus
+		if cachedGroup, err := s.GroupManager.GetGroupByID(group.ID); err == nil {
+			circuitBreaker = s.CircuitBreaker.GetStatus(cachedGroup)
+		}
+
 		items = append(items, GroupMonitorItem{
-			GroupResponse: groupResp,
-			UsageData:    usageData,
+			GroupResponse:  groupResp,
+			UsageData:      usageData,
+			CircuitBreaker: circuitBreaker,
 		})
 	}
 
 	response.Success(c, GroupMonitorResponse{
-		Groups: items,
+		Groups:            items,
+		RequestLog:        s.RequestLogService.GetBackpressureStats(),
+		GlobalRate:        s.GlobalRateLimiter.Stats(),
+		GlobalConcurrency: s.ConcurrencyLimiter.Stats(),
 	})
 }
 
+// GetRateLimitStatus returns the current rate limit status of every group in one call.
+func (s *Server) GetRateLimitStatus(c *gin.Context) {
+	statuses, err := s.GroupService.GetAllRateLimitStatus(c.Request.Context())
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, statuses)
+}
+
+// getGroupRequestRate returns the group's request rate from the given rates. Requests are counted on
+// the sub-group that served them, so an aggregate group's rate is the sum of its sub-groups'.
+func (s *Server) getGroupRequestRate(ctx context.Context, group *models.Group, rates map[uint]services.GroupRequestRate) services.GroupRequestRate {
+	if group.GroupType != "aggregate" {
+		return rates[group.ID]
+	}
+
+	subGroupIDs, err := s.AggregateGroupService.GetSubGroupIDs(ctx, group.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to get sub-groups for request rate")
+		return services.GroupRequestRate{}
+	}
+	return services.SumRequestRates(rates, subGroupIDs)
+}
+
 // getGroupUsageData retrieves the usage data for a specific group
 func (s *Server) getGroupUsageData(groupID uint, currentHour, currentMonth time.Time) *GroupUsageData {
 	// Get limits from group config
@@ -615,13 +1046,285 @@ func (s *Server) getGroupUsageData(groupID uint, currentHour, currentMonth time.
 	}
 
 	return &GroupUsageData{
-		GroupID:      groupID,
-		HourlyUsage:  hourlyUsage,
-		HourlyLimit:  hourlyLimit,
-		MonthlyUsage: monthlyUsage,
-		MonthlyLimit: monthlyLimit,
-		LastUpdated:  time.Now().Format(time.RFC3339),
+		GroupID:           groupID,
+		HourlyUsage:       hourlyUsage,
+		HourlyLimit:       hourlyLimit,
+		MonthlyUsage:      monthlyUsage,
+		MonthlyLimit:      monthlyLimit,
+		LastUpdated:       time.Now().Format(time.RFC3339),
+		MonthlyProjection: services.ProjectMonthlyUsage(monthlyUsage, monthlyLimit, time.Now()),
+	}
+}
+
+// ResetGroupUsageRequest defines the payload for resetting a group's usage counters.
+type ResetGroupUsageRequest struct {
+	Scope   string `json:"scope"` // "hourly"|"monthly"|"both"
+	Confirm bool   `json:"confirm"`
+}
+
+// ResetGroupUsage handles manually resetting a group's current hourly and/or monthly counters.
+func (s *Server) ResetGroupUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	var req ResetGroupUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	// Resetting counters lifts rate limits immediately, so require explicit confirmation
+	if !req.Confirm {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.usage_reset_confirm_required")
+		return
+	}
+
+	result, err := s.GroupService.ResetGroupUsage(c.Request.Context(), uint(id), req.Scope, s.loginClientIP(c))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ListGroupUsageResets returns the audit records of manual usage resets of a group.
+func (s *Server) ListGroupUsageResets(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	resets, err := s.GroupService.ListGroupUsageResets(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, resets)
+}
+
+// ResumeGroup handles manually resuming a group that was paused after sustained failures.
+func (s *Server) ResumeGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
 	}
+
+	state, err := s.GroupAutoPauseService.ResumeGroup(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, state)
+}
+
+// GetGroupUsageProjection handles the request to project a group's monthly usage to month-end.
+func (s *Server) GetGroupUsageProjection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	projection, err := s.GroupService.GetMonthlyUsageProjection(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, projection)
+}
+
+// GetGroupKeyCountTrend returns the hourly key count snapshots of a group.
+func (s *Server) GetGroupKeyCountTrend(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	hours := services.DefaultKeyCountTrendHours
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		parsed, err := strconv.Atoi(hoursStr)
+		if err != nil || parsed <= 0 || parsed > services.MaxKeyCountTrendHours {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_key_count_trend_hours", map[string]any{"max": services.MaxKeyCountTrendHours})
+			return
+		}
+		hours = parsed
+	}
+
+	points, err := s.GroupService.GetKeyCountTrend(c.Request.Context(), uint(id), hours)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, points)
+}
+
+// GetGroupAvailability returns the share of recent hours in which a group was up, with its down intervals.
+func (s *Server) GetGroupAvailability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	hours := services.DefaultAvailabilityHours
+	failureThreshold := services.DefaultAvailabilityFailureThreshold
+	minRequests := int64(services.DefaultAvailabilityMinRequests)
+	valid := true
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		hours, err = strconv.Atoi(hoursStr)
+		valid = err == nil && hours > 0 && hours <= services.MaxAvailabilityHours
+	}
+	if thresholdStr := c.Query("failure_threshold"); valid && thresholdStr != "" {
+		failureThreshold, err = strconv.Atoi(thresholdStr)
+		valid = err == nil && failureThreshold >= 1 && failureThreshold <= 100
+	}
+	if minRequestsStr := c.Query("min_requests"); valid && minRequestsStr != "" {
+		minRequests, err = strconv.ParseInt(minRequestsStr, 10, 64)
+		valid = err == nil && minRequests >= 1
+	}
+	if !valid {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_availability_params", map[string]any{"max": services.MaxAvailabilityHours})
+		return
+	}
+
+	availability, err := s.GroupService.GetGroupAvailability(c.Request.Context(), uint(id), hours, failureThreshold, minRequests)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, availability)
+}
+
+// GetGroupBillingReport handles exporting a group's billing report for a date range as JSON or CSV.
+func (s *Server) GetGroupBillingReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_billing_report_format")
+		return
+	}
+
+	report, err := s.GroupService.GetBillingReport(c.Request.Context(), uint(id), c.Query("start"), c.Query("end"))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	if format == "json" {
+		response.Success(c, report)
+		return
+	}
+
+	filename := fmt.Sprintf("billing_%s_%s_%s.csv", report.GroupName, report.StartDate, report.EndDate)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	if err := report.WriteCSV(c.Writer); err != nil {
+		logrus.WithError(err).Error("Failed to write billing report CSV")
+	}
+}
+
+// ModelRedirectRuleRequest defines the payload for editing a single model redirect rule.
+// ExpectedTarget is the target the client last saw, "" if it saw no rule; omit it to skip the check.
+type ModelRedirectRuleRequest struct {
+	Source         string  `json:"source" binding:"required"`
+	Target         string  `json:"target"`
+	ExpectedTarget *string `json:"expected_target"`
+}
+
+// AddModelRedirect creates or replaces one model redirect rule of a group.
+func (s *Server) AddModelRedirect(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	var req ModelRedirectRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	rules, err := s.GroupService.AddModelRedirect(c.Request.Context(), uint(id), req.Source, req.Target, req.ExpectedTarget)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, gin.H{"model_redirect_rules": rules})
+}
+
+// RemoveModelRedirect deletes one model redirect rule of a group.
+func (s *Server) RemoveModelRedirect(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	var req ModelRedirectRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	rules, err := s.GroupService.RemoveModelRedirect(c.Request.Context(), uint(id), req.Source, req.ExpectedTarget)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, gin.H{"model_redirect_rules": rules})
+}
+
+// GetGroupConfigDiff returns only the settings a group overrides compared to defaults and channel presets.
+func (s *Server) GetGroupConfigDiff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	diff, err := s.GroupService.GetGroupConfigDiff(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, diff)
+}
+
+// GetGroupEffectiveConfig returns every group setting with its resolved value and where it comes from.
+func (s *Server) GetGroupEffectiveConfig(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	effective, err := s.GroupService.GetGroupEffectiveConfig(c.Request.Context(), uint(id))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, effective)
+}
+
+// GetProxyKeyCollisions reports proxy keys that are declared by more than one group.
+func (s *Server) GetProxyKeyCollisions(c *gin.Context) {
+	collisions, err := s.GroupService.FindProxyKeyCollisions(c.Request.Context())
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, collisions)
 }
 
 // GroupSortOrder represents the sort order for groups
@@ -3,16 +3,24 @@ package handler
 
 import (
 	"crypto/subtle"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"aimanager/internal/channel"
 	"aimanager/internal/config"
 	"aimanager/internal/encryption"
+	app_errors "aimanager/internal/errors"
 	"aimanager/internal/i18n"
+	"aimanager/internal/response"
 	"aimanager/internal/services"
 	"aimanager/internal/types"
+	"aimanager/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"go.uber.org/dig"
 	"gorm.io/gorm"
 )
@@ -25,12 +33,25 @@ type Server struct {
 	GroupManager               *services.GroupManager
 	GroupService               *services.GroupService
 	AggregateGroupService      *services.AggregateGroupService
+	SubGroupManager            *services.SubGroupManager
+	SubGroupAvailability       *services.SubGroupAvailability
+	ChannelFactory             *channel.Factory
 	KeyManualValidationService *services.KeyManualValidationService
 	TaskService                *services.TaskService
 	KeyService                 *services.KeyService
 	KeyImportService           *services.KeyImportService
+	GroupArchiveService        *services.GroupArchiveService
+	GroupAutoPauseService      *services.GroupAutoPauseService
 	KeyDeleteService           *services.KeyDeleteService
 	LogService                 *services.LogService
+	RequestLogFeed             *services.RequestLogFeed
+	RequestLogService          *services.RequestLogService
+	ConnectivityService        *services.ConnectivityService
+	UpstreamProbeService       *services.UpstreamProbeService
+	GlobalRateLimiter          *services.GlobalRateLimiter
+	RequestRateCounter         *services.RequestRateCounter
+	ConcurrencyLimiter         *services.ConcurrencyLimiter
+	CircuitBreaker             *services.GroupCircuitBreaker
 	CommonHandler              *CommonHandler
 	EncryptionSvc              encryption.Service
 	LoginLimiter               *services.LoginLimiter
@@ -45,12 +66,25 @@ type NewServerParams struct {
 	GroupManager               *services.GroupManager
 	GroupService               *services.GroupService
 	AggregateGroupService      *services.AggregateGroupService
+	SubGroupManager            *services.SubGroupManager
+	SubGroupAvailability       *services.SubGroupAvailability
+	ChannelFactory             *channel.Factory
 	KeyManualValidationService *services.KeyManualValidationService
 	TaskService                *services.TaskService
 	KeyService                 *services.KeyService
 	KeyImportService           *services.KeyImportService
+	GroupArchiveService        *services.GroupArchiveService
+	GroupAutoPauseService      *services.GroupAutoPauseService
 	KeyDeleteService           *services.KeyDeleteService
 	LogService                 *services.LogService
+	RequestLogFeed             *services.RequestLogFeed
+	RequestLogService          *services.RequestLogService
+	ConnectivityService        *services.ConnectivityService
+	UpstreamProbeService       *services.UpstreamProbeService
+	GlobalRateLimiter          *services.GlobalRateLimiter
+	RequestRateCounter         *services.RequestRateCounter
+	ConcurrencyLimiter         *services.ConcurrencyLimiter
+	CircuitBreaker             *services.GroupCircuitBreaker
 	CommonHandler              *CommonHandler
 	EncryptionSvc              encryption.Service
 	LoginLimiter               *services.LoginLimiter
@@ -65,12 +99,25 @@ func NewServer(params NewServerParams) *Server {
 		GroupManager:               params.GroupManager,
 		GroupService:               params.GroupService,
 		AggregateGroupService:      params.AggregateGroupService,
+		SubGroupManager:            params.SubGroupManager,
+		SubGroupAvailability:       params.SubGroupAvailability,
+		ChannelFactory:             params.ChannelFactory,
 		KeyManualValidationService: params.KeyManualValidationService,
 		TaskService:                params.TaskService,
 		KeyService:                 params.KeyService,
 		KeyImportService:           params.KeyImportService,
+		GroupArchiveService:        params.GroupArchiveService,
+		GroupAutoPauseService:      params.GroupAutoPauseService,
 		KeyDeleteService:           params.KeyDeleteService,
 		LogService:                 params.LogService,
+		RequestLogFeed:             params.RequestLogFeed,
+		RequestLogService:          params.RequestLogService,
+		ConnectivityService:        params.ConnectivityService,
+		UpstreamProbeService:       params.UpstreamProbeService,
+		GlobalRateLimiter:          params.GlobalRateLimiter,
+		RequestRateCounter:         params.RequestRateCounter,
+		ConcurrencyLimiter:         params.ConcurrencyLimiter,
+		CircuitBreaker:             params.CircuitBreaker,
 		CommonHandler:              params.CommonHandler,
 		EncryptionSvc:              params.EncryptionSvc,
 		LoginLimiter:               params.LoginLimiter,
@@ -99,9 +146,11 @@ func (s *Server) Login(c *gin.Context) {
 		return
 	}
 
+	clientIP := s.loginClientIP(c)
+
 	// Check if login is locked
 	if s.LoginLimiter != nil {
-		allowed, remaining := s.LoginLimiter.CheckLogin()
+		allowed, remaining := s.LoginLimiter.CheckLogin(clientIP)
 		if !allowed {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"success": false,
@@ -120,7 +169,7 @@ func (s *Server) Login(c *gin.Context) {
 	if isValid {
 		// Record successful login
 		if s.LoginLimiter != nil {
-			s.LoginLimiter.RecordSuccess()
+			s.LoginLimiter.RecordSuccess(clientIP)
 		}
 		c.JSON(http.StatusOK, LoginResponse{
 			Success: true,
@@ -129,7 +178,7 @@ func (s *Server) Login(c *gin.Context) {
 	} else {
 		// Record failed login attempt
 		if s.LoginLimiter != nil {
-			locked, duration := s.LoginLimiter.RecordFailure()
+			locked, duration := s.LoginLimiter.RecordFailure(clientIP)
 			if locked {
 				c.JSON(http.StatusTooManyRequests, gin.H{
 					"success": false,
@@ -147,6 +196,57 @@ func (s *Server) Login(c *gin.Context) {
 	}
 }
 
+// loginClientIP returns the IP login attempts are counted against, honoring trusted_proxy_depth so a
+// client cannot pick its own IP through X-Forwarded-For.
+func (s *Server) loginClientIP(c *gin.Context) string {
+	if ip := utils.ClientIP(c.Request, s.SettingsManager.GetSettings().TrustedProxyDepth); ip != nil {
+		return ip.String()
+	}
+	return c.Request.RemoteAddr
+}
+
+// GetLoginLockouts returns the failed login attempts and lockouts per source IP.
+func (s *Server) GetLoginLockouts(c *gin.Context) {
+	response.Success(c, s.LoginLimiter.GetStatus())
+}
+
+// ResetLockoutRequest defines the payload for resetting the login limiter. Without an IP all failed
+// attempts and lockouts are cleared.
+type ResetLockoutRequest struct {
+	IP string `json:"ip,omitempty"`
+}
+
+// ResetLoginLockout clears the failed login attempts and lockout of one IP, or of all IPs, together with
+// the global cap, and returns the state before the reset for auditing.
+func (s *Server) ResetLoginLockout(c *gin.Context) {
+	var req ResetLockoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+	ip := strings.TrimSpace(req.IP)
+
+	fields := logrus.Fields{"admin_ip": s.loginClientIP(c)}
+	if ip == "" {
+		previous := s.LoginLimiter.Reset()
+		fields["global_failed_attempts"] = previous.GlobalFailedAttempts
+		fields["cleared_ips"] = len(previous.IPs)
+		logrus.WithFields(fields).Warn("Login limiter reset via admin API")
+		response.SuccessI18n(c, "auth.login_lockout_cleared", previous)
+		return
+	}
+
+	previous, ok := s.LoginLimiter.ResetIP(ip)
+	if !ok {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrResourceNotFound, "auth.login_lockout_not_found")
+		return
+	}
+	fields["ip"] = ip
+	fields["failed_attempts"] = previous.FailedAttempts
+	logrus.WithFields(fields).Warn("Login lockout reset via admin API")
+	response.SuccessI18n(c, "auth.login_lockout_cleared", previous)
+}
+
 // Health handles health check requests
 func (s *Server) Health(c *gin.Context) {
 	uptime := "unknown"
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"aimanager/internal/channel"
 	app_errors "aimanager/internal/errors"
+	"aimanager/internal/keypool"
 	"aimanager/internal/models"
 	"aimanager/internal/response"
+	"aimanager/internal/services"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -67,6 +71,31 @@ type KeyTextRequest struct {
 	KeysText string `json:"keys_text" binding:"required"`
 }
 
+// KeyModelMatrixRequest defines the payload for probing sampled keys with candidate models.
+type KeyModelMatrixRequest struct {
+	GroupID    uint     `json:"group_id" binding:"required"`
+	Models     []string `json:"models"`
+	SampleSize int      `json:"sample_size"`
+}
+
+// KeyImportRequest defines the payload for asynchronous key imports.
+// Format is one of "text" (default), "csv" or "jsonl"; structured formats carry per-key tags, notes and status.
+type KeyImportRequest struct {
+	GroupID                    uint   `json:"group_id" binding:"required"`
+	KeysText                   string `json:"keys_text" binding:"required"`
+	Format                     string `json:"format,omitempty"`
+	Tier                       int    `json:"tier,omitempty"`
+	SkipDuplicatesAcrossGroups bool   `json:"skip_duplicates_across_groups,omitempty"`
+}
+
+// importFormatsByExt maps accepted upload file extensions to their import format.
+var importFormatsByExt = map[string]string{
+	".txt":    services.KeyImportFormatText,
+	".csv":    services.KeyImportFormatCSV,
+	".jsonl":  services.KeyImportFormatJSONL,
+	".ndjson": services.KeyImportFormatJSONL,
+}
+
 // GroupIDRequest defines a generic payload for operations requiring only a group ID.
 type GroupIDRequest struct {
 	GroupID uint `json:"group_id" binding:"required"`
@@ -113,6 +142,9 @@ func (s *Server) AddMultipleKeys(c *gin.Context) {
 func (s *Server) AddMultipleKeysAsync(c *gin.Context) {
 	var groupID uint
 	var keysText string
+	var format string
+	var tier int
+	var skipDuplicates bool
 
 	// Check content type to determine if it's a file upload or JSON request
 	contentType := c.ContentType()
@@ -141,10 +173,21 @@ func (s *Server) AddMultipleKeysAsync(c *gin.Context) {
 
 		// Validate file extension
 		ext := strings.ToLower(filepath.Ext(file.Filename))
-		if ext != ".txt" {
-			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.only_txt_supported")
+		extFormat, ok := importFormatsByExt[ext]
+		if !ok {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.unsupported_import_file_type")
 			return
 		}
+		format = c.PostForm("format")
+		if format == "" {
+			format = extFormat
+		}
+		if tierStr := c.PostForm("tier"); tierStr != "" {
+			if tier, err = strconv.Atoi(tierStr); err != nil {
+				tier = -1
+			}
+		}
+		skipDuplicates, _ = strconv.ParseBool(c.PostForm("skip_duplicates_across_groups"))
 
 		// Read file content
 		fileContent, err := file.Open()
@@ -163,13 +206,27 @@ func (s *Server) AddMultipleKeysAsync(c *gin.Context) {
 		keysText = string(buf)
 	} else {
 		// Handle JSON request (original behavior)
-		var req KeyTextRequest
+		var req KeyImportRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
 			return
 		}
 		groupID = req.GroupID
 		keysText = req.KeysText
+		format = req.Format
+		tier = req.Tier
+		skipDuplicates = req.SkipDuplicatesAcrossGroups
+	}
+
+	if tier < 0 || tier > services.MaxKeyTier {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_key_tier", map[string]any{"max": services.MaxKeyTier})
+		return
+	}
+
+	format, err := services.NormalizeKeyImportFormat(format)
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_import_format")
+		return
 	}
 
 	group, ok := s.findGroupByID(c, groupID)
@@ -181,8 +238,74 @@ func (s *Server) AddMultipleKeysAsync(c *gin.Context) {
 		return
 	}
 
-	taskStatus, err := s.KeyImportService.StartImportTask(group, keysText)
+	var taskStatus *services.TaskStatus
+	if format == services.KeyImportFormatText {
+		taskStatus, err = s.KeyImportService.StartImportTask(group, keysText, tier, skipDuplicates)
+	} else {
+		taskStatus, err = s.KeyImportService.StartStructuredImportTask(group, keysText, format, tier, skipDuplicates)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrNoValidKeys) || errors.Is(err, services.ErrInvalidKeyImport) {
+			response.Error(c, app_errors.NewAPIError(app_errors.ErrValidation, err.Error()))
+			return
+		}
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrTaskInProgress, err.Error()))
+		return
+	}
+
+	response.Success(c, taskStatus)
+}
+
+// KeyImportURLRequest defines the payload for importing keys from a remote URL. Either bearer_token or
+// username and password can be given to authenticate the fetch.
+type KeyImportURLRequest struct {
+	URL                        string `json:"url" binding:"required"`
+	Format                     string `json:"format,omitempty"`
+	Tier                       int    `json:"tier,omitempty"`
+	BearerToken                string `json:"bearer_token,omitempty"`
+	Username                   string `json:"username,omitempty"`
+	Password                   string `json:"password,omitempty"`
+	SkipDuplicatesAcrossGroups bool   `json:"skip_duplicates_across_groups,omitempty"`
+}
+
+// ImportKeysFromURL handles starting a task that fetches a key list from a URL and imports it into the group.
+func (s *Server) ImportKeysFromURL(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	var req KeyImportURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	if req.Tier < 0 || req.Tier > services.MaxKeyTier {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_key_tier", map[string]any{"max": services.MaxKeyTier})
+		return
+	}
+
+	group, ok := s.findGroupByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	taskStatus, err := s.KeyImportService.StartURLImportTask(group, services.KeyImportURLParams{
+		URL:                        req.URL,
+		Format:                     req.Format,
+		Tier:                       req.Tier,
+		BearerToken:                req.BearerToken,
+		Username:                   req.Username,
+		Password:                   req.Password,
+		SkipDuplicatesAcrossGroups: req.SkipDuplicatesAcrossGroups,
+	})
 	if err != nil {
+		if _, ok := err.(*services.I18nError); ok {
+			s.handleGroupError(c, err)
+			return
+		}
 		response.Error(c, app_errors.NewAPIError(app_errors.ErrTaskInProgress, err.Error()))
 		return
 	}
@@ -202,7 +325,7 @@ func (s *Server) ListKeysInGroup(c *gin.Context) {
 	}
 
 	statusFilter := c.Query("status")
-	if statusFilter != "" && statusFilter != models.KeyStatusActive && statusFilter != models.KeyStatusInvalid {
+	if statusFilter != "" && statusFilter != models.KeyStatusActive && statusFilter != models.KeyStatusInvalid && statusFilter != models.KeyStatusDisabled {
 		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_status_filter")
 		return
 	}
@@ -368,6 +491,37 @@ func (s *Server) TestMultipleKeys(c *gin.Context) {
 	})
 }
 
+// TestKeyModels probes a sample of a group's keys with candidate models and returns the per-key result matrix.
+func (s *Server) TestKeyModels(c *gin.Context) {
+	var req KeyModelMatrixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	groupDB, ok := s.findGroupByID(c, req.GroupID)
+	if !ok {
+		return
+	}
+
+	group, err := s.GroupManager.GetGroupByName(groupDB.Name)
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrResourceNotFound, "validation.group_not_found")
+		return
+	}
+
+	start := time.Now()
+	matrix, err := s.KeyService.TestModelMatrix(group, req.Models, req.SampleSize)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, gin.H{
+		"matrix":         matrix,
+		"total_duration": time.Since(start).Milliseconds(),
+	})
+}
+
 // ValidateGroupKeys initiates a manual validation task for all keys in a group.
 func (s *Server) ValidateGroupKeys(c *gin.Context) {
 	var req ValidateGroupKeysRequest
@@ -402,6 +556,50 @@ func (s *Server) ValidateGroupKeys(c *gin.Context) {
 	response.Success(c, taskStatus)
 }
 
+// RevalidateInvalidKeys initiates a task that validates only the group's invalid keys and restores
+// those that pass.
+func (s *Server) RevalidateInvalidKeys(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	groupDB, ok := s.findGroupByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	group, err := s.GroupManager.GetGroupByName(groupDB.Name)
+	if err != nil {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrResourceNotFound, "validation.group_not_found")
+		return
+	}
+
+	taskStatus, err := s.KeyManualValidationService.StartInvalidRevalidationTask(group)
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrTaskInProgress, err.Error()))
+		return
+	}
+	if taskStatus == nil {
+		response.SuccessI18n(c, "success.no_invalid_keys_to_revalidate", nil)
+		return
+	}
+
+	response.Success(c, taskStatus)
+}
+
+// ValidateAllKeys initiates a validation task covering every key in every standard group.
+func (s *Server) ValidateAllKeys(c *gin.Context) {
+	taskStatus, err := s.KeyManualValidationService.StartGlobalValidationTask()
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrTaskInProgress, err.Error()))
+		return
+	}
+
+	response.Success(c, taskStatus)
+}
+
 // RestoreAllInvalidKeys sets the status of all 'inactive' keys in a group to 'active'.
 func (s *Server) RestoreAllInvalidKeys(c *gin.Context) {
 	var req GroupIDRequest
@@ -465,6 +663,28 @@ func (s *Server) ClearAllKeys(c *gin.Context) {
 	response.SuccessI18n(c, "success.all_keys_cleared", nil, map[string]any{"count": rowsAffected})
 }
 
+// GetOrphanedKeys handles listing keys whose group no longer exists.
+func (s *Server) GetOrphanedKeys(c *gin.Context) {
+	report, err := s.KeyService.FindOrphanedKeys()
+	if err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// ClearOrphanedKeys handles deleting keys whose group no longer exists from the database and the key pool.
+func (s *Server) ClearOrphanedKeys(c *gin.Context) {
+	rowsAffected, err := s.KeyService.RemoveOrphanedKeys()
+	if err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	response.SuccessI18n(c, "success.orphaned_keys_cleared", nil, map[string]any{"count": rowsAffected})
+}
+
 // ExportKeys handles exporting keys to a text file.
 func (s *Server) ExportKeys(c *gin.Context) {
 	groupID, ok := validateGroupIDFromQuery(c)
@@ -478,7 +698,7 @@ func (s *Server) ExportKeys(c *gin.Context) {
 	}
 
 	switch statusFilter {
-	case "all", models.KeyStatusActive, models.KeyStatusInvalid:
+	case "all", models.KeyStatusActive, models.KeyStatusInvalid, models.KeyStatusDisabled:
 	default:
 		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_status_filter")
 		return
@@ -498,6 +718,41 @@ func (s *Server) ExportKeys(c *gin.Context) {
 	}
 }
 
+// ExportGroupKeysCSV handles exporting a group's decrypted keys, with their status, tier and last use,
+// as a CSV file. Pass active_only=true to export only active keys.
+func (s *Server) ExportGroupKeysCSV(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrBadRequest, "validation.invalid_group_id")
+		return
+	}
+
+	activeOnly := false
+	if value := c.Query("active_only"); value != "" {
+		activeOnly, err = strconv.ParseBool(value)
+		if err != nil {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_active_only")
+			return
+		}
+	}
+
+	group, ok := s.findGroupByID(c, uint(id))
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("keys-%s.csv", group.Name)
+	if activeOnly {
+		filename = fmt.Sprintf("keys-%s-active.csv", group.Name)
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+
+	if err := s.KeyService.StreamKeysToCSV(group.ID, activeOnly, c.Writer); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Error("Failed to stream keys CSV")
+	}
+}
+
 // UpdateKeyNotesRequest defines the payload for updating a key's notes.
 type UpdateKeyNotesRequest struct {
 	Notes string `json:"notes"`
@@ -544,3 +799,239 @@ func (s *Server) UpdateKeyNotes(c *gin.Context) {
 
 	response.Success(c, nil)
 }
+
+// UpdateKeyStatusRequest defines the payload for manually changing a key's status.
+type UpdateKeyStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// UpdateKeyStatus handles manually marking a specific API key as active or invalid.
+func (s *Server) UpdateKeyStatus(c *gin.Context) {
+	keyIDStr := c.Param("id")
+	keyID, err := strconv.Atoi(keyIDStr)
+	if err != nil || keyID <= 0 {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, "invalid key ID format"))
+		return
+	}
+
+	var req UpdateKeyStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	if req.Status != models.KeyStatusActive && req.Status != models.KeyStatusInvalid {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_status_value")
+		return
+	}
+
+	key, err := s.KeyService.SetKeyStatus(c.Request.Context(), uint(keyID), req.Status)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, app_errors.ErrResourceNotFound)
+		} else {
+			response.Error(c, app_errors.ParseDBError(err))
+		}
+		return
+	}
+
+	response.SuccessI18n(c, "success.key_status_updated", gin.H{
+		"id":       key.ID,
+		"group_id": key.GroupID,
+		"status":   key.Status,
+	})
+}
+
+// DisableKey handles manually disabling a specific API key.
+func (s *Server) DisableKey(c *gin.Context) {
+	s.setKeyDisabled(c, true)
+}
+
+// EnableKey handles re-enabling a manually disabled API key.
+func (s *Server) EnableKey(c *gin.Context) {
+	s.setKeyDisabled(c, false)
+}
+
+func (s *Server) setKeyDisabled(c *gin.Context, disabled bool) {
+	keyIDStr := c.Param("id")
+	keyID, err := strconv.Atoi(keyIDStr)
+	if err != nil || keyID <= 0 {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, "invalid key ID format"))
+		return
+	}
+
+	var key *models.APIKey
+	successKey := "success.key_disabled"
+	if disabled {
+		key, err = s.KeyService.DisableKey(c.Request.Context(), uint(keyID))
+	} else {
+		key, err = s.KeyService.EnableKey(c.Request.Context(), uint(keyID))
+		successKey = "success.key_enabled"
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, app_errors.ErrResourceNotFound)
+		} else if errors.Is(err, keypool.ErrKeyNotDisabled) {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.key_not_disabled")
+		} else {
+			response.Error(c, app_errors.ParseDBError(err))
+		}
+		return
+	}
+
+	response.SuccessI18n(c, successKey, gin.H{
+		"id":       key.ID,
+		"group_id": key.GroupID,
+		"status":   key.Status,
+	})
+}
+
+// UpdateKeyTierRequest defines the payload for moving a key to another tier.
+type UpdateKeyTierRequest struct {
+	Tier *int `json:"tier" binding:"required"`
+}
+
+// UpdateKeyTier handles moving a specific API key to another tier.
+func (s *Server) UpdateKeyTier(c *gin.Context) {
+	keyIDStr := c.Param("id")
+	keyID, err := strconv.Atoi(keyIDStr)
+	if err != nil || keyID <= 0 {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, "invalid key ID format"))
+		return
+	}
+
+	var req UpdateKeyTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	if *req.Tier < 0 || *req.Tier > services.MaxKeyTier {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_key_tier", map[string]any{"max": services.MaxKeyTier})
+		return
+	}
+
+	key, err := s.KeyService.SetKeyTier(c.Request.Context(), uint(keyID), *req.Tier)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, app_errors.ErrResourceNotFound)
+		} else {
+			response.Error(c, app_errors.ParseDBError(err))
+		}
+		return
+	}
+
+	response.SuccessI18n(c, "success.key_tier_updated", gin.H{
+		"id":       key.ID,
+		"group_id": key.GroupID,
+		"tier":     key.Tier,
+	})
+}
+
+// GetKeyFailureScores returns the key selection diagnostics of a group: the decayed recent-failure
+// scores used by least-failures selection, the keys still warming up after reactivation and the
+// (upstream, key) distribution of joint selection.
+func (s *Server) GetKeyFailureScores(c *gin.Context) {
+	groupID, ok := validateGroupIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	group, ok := s.findGroupByID(c, groupID)
+	if !ok {
+		return
+	}
+
+	effectiveConfig := s.SettingsManager.GetEffectiveConfig(group.Config)
+
+	warmupWindow := time.Duration(effectiveConfig.KeyWarmupMinutes) * time.Minute
+
+	// Joint selection counters live on the channel built from the cached group
+	upstreamKeyUsage := []channel.UpstreamKeyUsage{}
+	if cachedGroup, err := s.GroupManager.GetGroupByName(group.Name); err == nil && cachedGroup.GroupType != "aggregate" {
+		if channelHandler, err := s.ChannelFactory.GetChannel(cachedGroup); err == nil {
+			upstreamKeyUsage = channelHandler.UpstreamKeyUsage()
+		}
+	}
+
+	response.Success(c, gin.H{
+		"group_id":           group.ID,
+		"selection_mode":     effectiveConfig.KeySelectionMode,
+		"scores":             s.KeyService.KeyProvider.GetFailureScores(group.ID),
+		"warmup_minutes":     effectiveConfig.KeyWarmupMinutes,
+		"warming_up":         s.KeyService.KeyProvider.GetWarmupStates(group.ID, warmupWindow),
+		"joint_selection":    effectiveConfig.JointUpstreamKeySelection,
+		"upstream_key_usage": upstreamKeyUsage,
+	})
+}
+
+// GetKeyUtilization returns how many of a group's keys served traffic over a window of hours,
+// with the peak number of keys busy within one minute and an hourly breakdown.
+func (s *Server) GetKeyUtilization(c *gin.Context) {
+	groupID, ok := validateGroupIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := s.findGroupByID(c, groupID); !ok {
+		return
+	}
+
+	hours := services.DefaultKeyUtilizationHours
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		parsed, err := strconv.Atoi(hoursStr)
+		if err != nil || parsed <= 0 || parsed > services.MaxKeyUtilizationHours {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_utilization_hours", map[string]any{"max": services.MaxKeyUtilizationHours})
+			return
+		}
+		hours = parsed
+	}
+
+	utilization, err := s.KeyService.GetKeyUtilization(groupID, hours)
+	if err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	response.Success(c, utilization)
+}
+
+// SearchKeys handles finding keys across all groups by a prefix and/or suffix fragment.
+// Matching keys are only returned masked.
+func (s *Server) SearchKeys(c *gin.Context) {
+	result, err := s.KeyService.SearchKeysByFragment(c.Query("prefix"), c.Query("suffix"))
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ExportKeyPoolState returns a snapshot of the key pool selection state: the rotation order of
+// every group's active keys, failure scores and warm-up windows.
+func (s *Server) ExportKeyPoolState(c *gin.Context) {
+	snapshot, err := s.KeyService.KeyProvider.ExportState()
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInternalServer, err.Error()))
+		return
+	}
+
+	response.Success(c, snapshot)
+}
+
+// ImportKeyPoolState restores a snapshot produced by ExportKeyPoolState on top of the current pool.
+func (s *Server) ImportKeyPoolState(c *gin.Context) {
+	var snapshot keypool.KeyPoolSnapshot
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInvalidJSON, err.Error()))
+		return
+	}
+
+	result, err := s.KeyService.KeyProvider.ImportState(&snapshot)
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrValidation, err.Error()))
+		return
+	}
+
+	response.Success(c, result)
+}
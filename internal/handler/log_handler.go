@@ -5,8 +5,13 @@ import (
 	"aimanager/internal/i18n"
 	"aimanager/internal/models"
 	"aimanager/internal/response"
+	"aimanager/internal/services"
+	"aimanager/internal/utils"
 	"fmt"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,8 +24,18 @@ type LogResponse struct {
 }
 
 // GetLogs handles fetching request logs with filtering and pagination.
+// Passing a cursor query parameter, empty for the first page, switches from offset to cursor
+// pagination, which stays fast on deep pages.
 func (s *Server) GetLogs(c *gin.Context) {
-	query := s.LogService.GetLogsQuery(c)
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		s.getLogsByCursor(c, cursor)
+		return
+	}
+
+	query, err := s.LogService.GetLogsQuery(c, "")
+	if s.handleGroupError(c, err) {
+		return
+	}
 
 	var logs []models.RequestLog
 	query = query.Order("timestamp desc")
@@ -30,7 +45,41 @@ func (s *Server) GetLogs(c *gin.Context) {
 		return
 	}
 
-	// 解密所有日志中的密钥用于前端显示
+	s.decryptLogKeys(logs)
+	pagination.Items = logs
+	response.Success(c, pagination)
+}
+
+// getLogsByCursor returns the page of logs following the cursor, ordered by timestamp and ID.
+func (s *Server) getLogsByCursor(c *gin.Context, cursor string) {
+	query, err := s.LogService.GetLogsQuery(c, cursor)
+	if s.handleGroupError(c, err) {
+		return
+	}
+
+	pageSize := response.GetPageSize(c)
+	var logs []models.RequestLog
+	if err := query.Order("timestamp desc, id desc").Limit(pageSize + 1).Find(&logs).Error; err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	nextCursor := ""
+	if len(logs) > pageSize {
+		logs = logs[:pageSize]
+		nextCursor = services.EncodeLogCursor(&logs[pageSize-1])
+	}
+
+	s.decryptLogKeys(logs)
+	response.Success(c, &response.CursorPaginatedResponse{
+		Items:      logs,
+		PageSize:   pageSize,
+		NextCursor: nextCursor,
+	})
+}
+
+// decryptLogKeys decrypts the keys of the logs in place for display in the frontend.
+func (s *Server) decryptLogKeys(logs []models.RequestLog) {
 	for i := range logs {
 		if logs[i].KeyValue != "" {
 			decryptedValue, err := s.EncryptionSvc.Decrypt(logs[i].KeyValue)
@@ -42,13 +91,14 @@ func (s *Server) GetLogs(c *gin.Context) {
 			}
 		}
 	}
-
-	pagination.Items = logs
-	response.Success(c, pagination)
 }
 
 // ExportLogs handles exporting filtered log keys to a CSV file.
 func (s *Server) ExportLogs(c *gin.Context) {
+	if s.handleGroupError(c, services.ValidateLogFilters(c)) {
+		return
+	}
+
 	filename := fmt.Sprintf("log_keys_export_%s.csv", time.Now().Format("20060102150405"))
 	c.Header("Content-Disposition", "attachment; filename="+filename)
 	c.Header("Content-Type", "text/csv; charset=utf-8")
@@ -62,8 +112,105 @@ func (s *Server) ExportLogs(c *gin.Context) {
 	}
 }
 
+// GetModelUsage handles listing the distinct models requested per group, with request counts.
+func (s *Server) GetModelUsage(c *gin.Context) {
+	if s.handleGroupError(c, services.ValidateLogFilters(c)) {
+		return
+	}
+
+	usage, err := s.LogService.GetModelUsageByGroup(c)
+	if err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	response.Success(c, usage)
+}
+
+// GetLatencyPercentiles handles reporting p50/p95/p99 request durations per group, optionally broken
+// down by upstream and/or model through a comma-separated group_by parameter.
+func (s *Server) GetLatencyPercentiles(c *gin.Context) {
+	if s.handleGroupError(c, services.ValidateLogFilters(c)) {
+		return
+	}
+
+	var groupBy []string
+	for _, dimension := range strings.Split(c.Query("group_by"), ",") {
+		dimension = strings.TrimSpace(dimension)
+		if dimension == "" {
+			continue
+		}
+		if !services.IsValidLatencyGroupBy(dimension) {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_latency_group_by", map[string]any{"value": dimension})
+			return
+		}
+		groupBy = append(groupBy, dimension)
+	}
+
+	percentiles, err := s.LogService.GetLatencyPercentiles(c, groupBy)
+	if err != nil {
+		response.Error(c, app_errors.ParseDBError(err))
+		return
+	}
+
+	response.Success(c, percentiles)
+}
+
+// GetRequestDiagnosis handles explaining the outcome of a single request, looked up either by
+// request_id or by time and proxy_key.
+func (s *Server) GetRequestDiagnosis(c *gin.Context) {
+	if requestID := strings.TrimSpace(c.Query("request_id")); requestID != "" {
+		diagnosis, err := s.LogService.DiagnoseRequestByID(requestID)
+		if s.handleGroupError(c, err) {
+			return
+		}
+		response.Success(c, diagnosis)
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, c.Query("time"))
+	proxyKey := strings.TrimSpace(c.Query("proxy_key"))
+	if err != nil || proxyKey == "" {
+		response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.request_diagnosis_params")
+		return
+	}
+
+	diagnosis, err := s.LogService.DiagnoseRequestAt(at, proxyKey)
+	if s.handleGroupError(c, err) {
+		return
+	}
+	response.Success(c, diagnosis)
+}
+
+// ExportLogsNDJSON handles streaming filtered logs as newline-delimited JSON for log ingestion tools.
+func (s *Server) ExportLogsNDJSON(c *gin.Context) {
+	if s.handleGroupError(c, services.ValidateLogFilters(c)) {
+		return
+	}
+
+	// Large exports can outlive the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logrus.WithError(err).Debug("Failed to clear write deadline for NDJSON export")
+	}
+
+	filename := fmt.Sprintf("logs_export_%s.ndjson", time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	maskKeys := s.SettingsManager.GetSettings().MaskKeysInLogExport
+	if err := s.LogService.StreamLogsToNDJSON(c, c.Writer, maskKeys); err != nil {
+		// Headers are already sent, so the error can only be logged
+		logrus.WithError(err).Error("Failed to stream logs as NDJSON")
+	}
+}
+
 // ClearLogs handles deleting logs based on filters (physical deletion).
 func (s *Server) ClearLogs(c *gin.Context) {
+	if s.handleGroupError(c, services.ValidateLogFilters(c)) {
+		return
+	}
+
 	// 获取筛选后的日志数量
 	count, err := s.LogService.CountFilteredLogs(c)
 	if err != nil {
@@ -90,3 +237,162 @@ func (s *Server) ClearLogs(c *gin.Context) {
 		"count": deletedCount,
 	})
 }
+
+const (
+	logTailIdleTimeout       = 5 * time.Minute
+	logTailHeartbeatInterval = 15 * time.Second
+)
+
+// LiveLogEvent is a request log entry pushed over the live tail stream. Keys are masked and
+// request bodies are omitted.
+type LiveLogEvent struct {
+	ID              string    `json:"id"`
+	Timestamp       time.Time `json:"timestamp"`
+	GroupID         uint      `json:"group_id"`
+	GroupName       string    `json:"group_name"`
+	ParentGroupName string    `json:"parent_group_name,omitempty"`
+	KeyValue        string    `json:"key_value"`
+	RequestID       string    `json:"request_id,omitempty"`
+	Model           string    `json:"model"`
+	ModelVariant    string    `json:"model_variant,omitempty"`
+	IsSuccess       bool      `json:"is_success"`
+	StatusCode      int       `json:"status_code"`
+	RequestPath     string    `json:"request_path"`
+	RequestType     string    `json:"request_type"`
+	Duration        int64     `json:"duration_ms"`
+	IsStream        bool      `json:"is_stream"`
+	ErrorMessage    string    `json:"error_message,omitempty"`
+}
+
+// logTailFilter holds the optional filters for the live tail stream.
+type logTailFilter struct {
+	isSuccess  *bool
+	statusCode int
+	model      string
+}
+
+func (f *logTailFilter) match(entry *models.RequestLog) bool {
+	if f.isSuccess != nil && entry.IsSuccess != *f.isSuccess {
+		return false
+	}
+	if f.statusCode != 0 && entry.StatusCode != f.statusCode {
+		return false
+	}
+	if f.model != "" && !strings.Contains(strings.ToLower(entry.Model), f.model) {
+		return false
+	}
+	return true
+}
+
+// TailLogs streams new request logs of a group as server-sent events.
+// The stream closes automatically when no matching event arrives within the idle timeout.
+func (s *Server) TailLogs(c *gin.Context) {
+	groupID, ok := validateGroupIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := s.findGroupByID(c, groupID); !ok {
+		return
+	}
+
+	filter := logTailFilter{model: strings.ToLower(strings.TrimSpace(c.Query("model")))}
+	if isSuccessStr := c.Query("is_success"); isSuccessStr != "" {
+		isSuccess, err := strconv.ParseBool(isSuccessStr)
+		if err != nil {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_status_filter")
+			return
+		}
+		filter.isSuccess = &isSuccess
+	}
+	if statusCodeStr := c.Query("status_code"); statusCodeStr != "" {
+		statusCode, err := strconv.Atoi(statusCodeStr)
+		if err != nil {
+			response.ErrorI18nFromAPIError(c, app_errors.ErrValidation, "validation.invalid_status_filter")
+			return
+		}
+		filter.statusCode = statusCode
+	}
+
+	events, unsubscribe := s.RequestLogFeed.Subscribe(groupID)
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout, so clear the deadline for this connection.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logrus.WithError(err).Debug("Failed to clear write deadline for log tail stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	idleTimer := time.NewTimer(logTailIdleTimeout)
+	defer idleTimer.Stop()
+	heartbeat := time.NewTicker(logTailHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-idleTimer.C:
+			c.SSEvent("timeout", gin.H{"idle_seconds": int(logTailIdleTimeout.Seconds())})
+			c.Writer.Flush()
+			return
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case entry := <-events:
+			if !filter.match(&entry) {
+				continue
+			}
+			c.SSEvent("log", s.newLiveLogEvent(&entry))
+			c.Writer.Flush()
+
+			if !idleTimer.Stop() {
+				select {
+				case <-idleTimer.C:
+				default:
+				}
+			}
+			idleTimer.Reset(logTailIdleTimeout)
+		}
+	}
+}
+
+// newLiveLogEvent converts a request entry into a live event with a masked key.
+func (s *Server) newLiveLogEvent(entry *models.RequestLog) LiveLogEvent {
+	maskedKey := ""
+	if entry.KeyValue != "" {
+		decryptedValue, err := s.EncryptionSvc.Decrypt(entry.KeyValue)
+		if err != nil {
+			maskedKey = "failed-to-decrypt"
+		} else {
+			maskedKey = utils.MaskAPIKey(decryptedValue)
+		}
+	}
+
+	return LiveLogEvent{
+		ID:              entry.ID,
+		Timestamp:       entry.Timestamp,
+		GroupID:         entry.GroupID,
+		GroupName:       entry.GroupName,
+		ParentGroupName: entry.ParentGroupName,
+		KeyValue:        maskedKey,
+		RequestID:       entry.RequestID,
+		Model:           entry.Model,
+		ModelVariant:    entry.ModelVariant,
+		IsSuccess:       entry.IsSuccess,
+		StatusCode:      entry.StatusCode,
+		RequestPath:     entry.RequestPath,
+		RequestType:     entry.RequestType,
+		Duration:        entry.Duration,
+		IsStream:        entry.IsStream,
+		ErrorMessage:    entry.ErrorMessage,
+	}
+}
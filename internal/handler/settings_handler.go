@@ -87,3 +87,32 @@ func (s *Server) UpdateSettings(c *gin.Context) {
 
 	response.SuccessI18n(c, "settings.update_success", nil)
 }
+
+// SettingsDiagnosticsResponse reports drift between this node's in-memory settings and the database.
+type SettingsDiagnosticsResponse struct {
+	HasDrift bool                      `json:"has_drift"`
+	Settings []models.SettingDriftInfo `json:"settings"`
+}
+
+// GetSettingsDiagnostics handles the GET /api/settings/diagnostics request.
+// It compares the in-memory settings snapshot of this node with the values persisted in the database.
+func (s *Server) GetSettingsDiagnostics(c *gin.Context) {
+	settings, err := s.SettingsManager.CompareWithDB()
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrDatabase, err.Error()))
+		return
+	}
+
+	hasDrift := false
+	for _, setting := range settings {
+		if setting.Drifted {
+			hasDrift = true
+			break
+		}
+	}
+
+	response.Success(c, SettingsDiagnosticsResponse{
+		HasDrift: hasDrift,
+		Settings: settings,
+	})
+}
@@ -16,3 +16,12 @@ func (s *Server) GetTaskStatus(c *gin.Context) {
 	}
 	response.Success(c, taskStatus)
 }
+
+// CancelTask requests cancellation of the running long-running task.
+func (s *Server) CancelTask(c *gin.Context) {
+	if err := s.TaskService.RequestCancel(); err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, err.Error()))
+		return
+	}
+	response.SuccessI18n(c, "task.cancel_requested", nil)
+}
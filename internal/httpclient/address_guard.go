@@ -0,0 +1,32 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when a client created with BlockPrivateAddresses dials an address
+// that is not public.
+var ErrBlockedAddress = errors.New("connections to private, loopback or link-local addresses are not allowed")
+
+// IsPublicIP reports whether ip is a global unicast address outside the private ranges, so neither
+// loopback, private, link-local, unspecified nor multicast.
+func IsPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// blockPrivateAddresses is a net.Dialer Control function refusing connections to addresses that are
+// not public. It sees the address after DNS resolution, so host names resolving to internal addresses,
+// including after a redirect or a DNS rebind, are refused too.
+func blockPrivateAddresses(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
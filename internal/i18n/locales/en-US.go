@@ -110,11 +110,13 @@ var MessagesEnUS = map[string]string{
 	"database.group_stats_failed":    "Failed to get partial statistics",
 
 	// Success messages
-	"success.group_deleted":        "Group and related keys deleted successfully",
+	"success.group_deleted":        "Group deleted, it can be restored until it is purged",
 	"success.keys_restored":        "{{.count}} keys restored",
 	"success.invalid_keys_cleared": "{{.count}} invalid keys cleared",
 	"success.all_keys_cleared":     "{{.count}} keys cleared",
 
+	"success.orphaned_keys_cleared": "{{.count}} orphaned keys cleared",
+
 	// Password security related
 	"security.password_too_short":         "{{.keyType}} is too short ({{.length}} characters), recommend at least 16 characters",
 	"security.password_short":             "{{.keyType}} is short ({{.length}} characters), recommend 32+ characters",
@@ -166,6 +168,11 @@ var MessagesEnUS = map[string]string{
 	"config.key_validation_timeout":          "Key Validation Timeout (seconds)",
 	"config.key_validation_timeout_desc":     "API request timeout (seconds) when validating a single key in the background.",
 
+	"config.key_validation_connect_timeout":      "Key Validation Connect Timeout (seconds)",
+	"config.key_validation_connect_timeout_desc": "Timeout (in seconds) for establishing the connection, including the TLS handshake, when validating a key. Separate from the proxy connect timeout so dead upstreams fail validation quickly.",
+	"config.key_validation_read_timeout":         "Key Validation Read Timeout (seconds)",
+	"config.key_validation_read_timeout_desc":    "Timeout (in seconds) to wait for the upstream's response headers after a validation request is sent. Separate from the proxy request timeout.",
+
 	// Category labels
 	"config.category.basic":   "Basic",
 	"config.category.request": "Request Settings",
@@ -204,4 +211,249 @@ var MessagesEnUS = map[string]string{
 	"group.not_aggregate":              "Group is not an aggregate group",
 	"group.sub_group_already_exists":   "Sub group {{.sub_group_id}} already exists",
 	"group.sub_group_not_found":        "Sub group not found",
+
+	// Group name swap
+	"validation.swap_same_group": "Cannot swap a group with itself",
+
+	// Group cache refresh
+	"config.group_cache_refresh_interval":      "Group Cache Refresh Interval (seconds)",
+	"config.group_cache_refresh_interval_desc": "Interval (in seconds) at which each node reloads the group cache from the database as a safety net against missed invalidations, 0 to disable.",
+	"config.group_cache_refresh_jitter":        "Group Cache Refresh Jitter (seconds)",
+	"config.group_cache_refresh_jitter_desc":   "Random offset (in seconds, ±) added to each refresh so nodes do not reload simultaneously.",
+
+	"config.group_cache_invalidate_window":      "Group Cache Invalidation Window (ms)",
+	"config.group_cache_invalidate_window_desc": "Window (in milliseconds) within which successive group changes are merged into a single cache reload on every node, 0 to reload immediately on each change.",
+
+	// Streaming
+	"config.stream_buffer_size_kb":      "Stream Buffer Size (KB)",
+	"config.stream_buffer_size_kb_desc": "Size (in KB) of the buffer used when forwarding streaming responses. Larger values reduce syscalls for high-throughput models; smaller values lower time-to-first-byte. At most 1024 KB.",
+
+	// Manual key status
+	"success.key_status_updated": "Key status updated",
+
+	// Group name conflict
+	"validation.invalid_name_conflict_strategy": "Invalid on_conflict value. Must be 'fail', 'rename', or 'overwrite'",
+	"validation.overwrite_group_type_mismatch":  "Cannot overwrite a group of a different group type",
+
+	// Proxy path normalization
+	"config.normalize_proxy_path":      "Normalize Proxy Path",
+	"config.normalize_proxy_path_desc": "Collapse duplicate slashes, drop trailing slashes, resolve ./ and ../ segments and lower-case API version segments (e.g. /V1/) in proxied paths. Leave disabled for providers that require exact paths.",
+
+	// Task cancellation
+	"task.cancel_requested": "Task cancellation requested",
+
+	// Group usage reset
+	"validation.invalid_usage_reset_scope":    "Invalid scope. Must be 'hourly', 'monthly', or 'both'",
+	"validation.usage_reset_confirm_required": "Resetting usage counters takes effect on rate limits immediately; set confirm to true to proceed",
+
+	// Key selection
+	"config.key_selection_mode":      "Key Selection Mode",
+	"config.key_selection_mode_desc": "How keys are picked from the active pool: round_robin, or least_failures to prefer keys with the fewest recent failures.",
+
+	// Request log backpressure
+	"config.log_buffer_size":              "Log Buffer Size",
+	"config.log_buffer_size_desc":         "Maximum number of request logs queued in memory before the backpressure policy applies. Takes effect after restart.",
+	"config.log_backpressure_policy":      "Log Backpressure Policy",
+	"config.log_backpressure_policy_desc": "What to do when the log buffer is full: drop (discard and count), block (wait up to the block timeout, then drop), or disable (temporarily skip detailed logs while keeping stat counters).",
+	"config.log_block_timeout":            "Log Block Timeout (ms)",
+	"config.log_block_timeout_desc":       "Maximum time (in milliseconds) a request waits for buffer space under the block policy.",
+
+	// HTTPS-only upstreams
+	"config.require_https_upstreams":      "Require HTTPS Upstreams",
+	"config.require_https_upstreams_desc": "When enabled, groups can only be created or updated with https:// upstream URLs. Existing non-HTTPS upstreams are reported at startup.",
+	"validation.https_upstream_required":  "Upstream {{.url}} must use HTTPS",
+
+	// Bulk sub-group weights
+	"validation.sub_group_weights_empty":    "No sub-group weights provided",
+	"validation.sub_group_weights_all_zero": "At least one sub-group must have a weight greater than 0",
+	"validation.invalid_weight_scale":       "Weight scale factor must be a positive number",
+	"success.sub_group_weights_updated":     "Sub group weights updated successfully",
+
+	// Key warm-up
+	"config.key_warmup_minutes":      "Key Warm-up Window (minutes)",
+	"config.key_warmup_minutes_desc": "Reactivated keys start with a reduced selection probability that ramps up to full over this window. A failure during warm-up restarts the ramp. 0 disables warm-up.",
+
+	// Log export masking
+	"config.mask_keys_in_log_export":      "Mask Keys in Log Export",
+	"config.mask_keys_in_log_export_desc": "When enabled, keys in NDJSON log exports are masked (first and last 4 characters only) instead of decrypted.",
+
+	// Structured key import
+	"validation.unsupported_import_file_type": "Only .txt, .csv, .jsonl and .ndjson files are supported",
+	"validation.invalid_import_format":        "Invalid import format, must be text, csv or jsonl",
+
+	// Global rate limit
+	"config.global_rate_limit_rps":        "Global Rate Limit (req/s)",
+	"config.global_rate_limit_rps_desc":   "Maximum requests per second accepted by this instance across all groups. Requests above the limit receive 503. 0 disables the limit.",
+	"config.global_rate_limit_burst":      "Global Rate Limit Burst",
+	"config.global_rate_limit_burst_desc": "Maximum number of requests allowed in a burst above the global rate. 0 uses the global rate value.",
+
+	// Rate limit warning
+	"config.rate_limit_warning_percent":      "Rate Limit Warning Threshold (%)",
+	"config.rate_limit_warning_percent_desc": "When hourly or monthly usage reaches this percentage of the group limit, responses carry an X-RateLimit-Warning header. Traffic is not blocked. 0 disables the warning.",
+
+	// Proxy key uniqueness
+	"config.enforce_unique_proxy_keys":      "Enforce Unique Group Proxy Keys",
+	"config.enforce_unique_proxy_keys_desc": "When enabled, a group cannot be saved with a proxy key that another group already uses.",
+	"validation.proxy_key_conflict":         "Proxy key {{.key}} is already used by group {{.group}}",
+
+	// Stats partial hour
+	"config.stats_exclude_current_hour":      "Exclude Current Hour From Stats",
+	"config.stats_exclude_current_hour_desc": "When enabled, 24h/7d/30d group statistics end at the start of the current hour so rolling numbers do not jump as the in-progress hour fills. Real-time usage in the monitor still includes the current hour. Can be overridden per request with exclude_current_hour.",
+
+	// Request hedging
+	"config.hedge_delay_ms":            "Hedge Delay (ms)",
+	"config.hedge_delay_ms_desc":       "For non-streaming requests, send a second attempt with another key if the first has not responded within this delay; the first successful response wins. 0 disables hedging.",
+	"config.hedge_budget_percent":      "Hedge Budget (%)",
+	"config.hedge_budget_percent_desc": "Maximum share of a group's requests per minute that may be hedged, to avoid doubling upstream load.",
+
+	// Response size limit
+	"config.max_response_size_kb":         "Max Response Size (KB)",
+	"config.max_response_size_kb_desc":    "Largest non-streaming upstream response buffered for inspection, such as model list rewriting, body adapters and error parsing. 0 means unlimited. Streaming and plain passthrough responses are not limited by it; the group setting max_response_body_bytes caps every response as received, streams included, and whichever limit is reached first applies.",
+	"config.oversized_response_mode":      "Oversized Response Handling",
+	"config.oversized_response_mode_desc": "What to do when a buffered response exceeds the max response size: reject returns a 502 error, truncate returns the body cut at the limit with an X-Response-Truncated header; compressed responses are always rejected. Either way the request is logged as failed.",
+
+	// Key utilization
+	"validation.invalid_utilization_hours": "hours must be an integer between 1 and {{.max}}",
+
+	// Group archive import
+	"validation.archive_no_groups":           "The archive does not contain any groups",
+	"validation.archive_source_key_required": "The archive keys are encrypted, source_encryption_key is required",
+	"validation.archive_source_key_invalid":  "Invalid source encryption key: {{.error}}",
+
+	// Joint upstream/key selection
+	"config.joint_upstream_key_selection":      "Joint Upstream/Key Selection",
+	"config.joint_upstream_key_selection_desc": "Choose the upstream together with the selected key so traffic spreads evenly over every (upstream, key) pair instead of rotating both independently.",
+
+	// Key validation hysteresis
+	"config.key_invalidate_after_failures":      "Invalidate After Failed Validations",
+	"config.key_invalidate_after_failures_desc": "Number of consecutive failed validations before an active key is marked invalid. 0 uses the blacklist threshold like proxy failures.",
+	"config.key_recover_after_successes":        "Recover After Successful Validations",
+	"config.key_recover_after_successes_desc":   "Number of consecutive successful validations before an invalid key is reactivated, to avoid flapping when a provider is briefly unstable.",
+	"config.auto_pause_failure_rate":            "Auto-Pause Failure Rate (%)",
+	"config.auto_pause_failure_rate_desc":       "Pause the group when its failure rate over the auto-pause window reaches this percentage. 0 disables automatic pausing.",
+	"config.auto_pause_window_hours":            "Auto-Pause Window (hours)",
+	"config.auto_pause_window_hours_desc":       "Number of recent hours, including the current one, over which the failure rate is measured.",
+	"config.auto_pause_min_requests":            "Auto-Pause Minimum Requests",
+	"config.auto_pause_min_requests_desc":       "Minimum number of requests in the window before the failure rate is considered, so a few failures cannot pause a quiet group.",
+	"config.auto_pause_cooldown_minutes":        "Auto-Pause Cooldown (minutes)",
+	"config.auto_pause_cooldown_minutes_desc":   "Minutes after which an auto-paused group is resumed automatically. 0 keeps it paused until it is resumed manually.",
+
+	// Billing report
+	"validation.invalid_billing_report_date":   "Invalid date {{.value}}, expected YYYY-MM-DD",
+	"validation.invalid_billing_report_range":  "Start date must not be after end date, and the range may span at most {{.max}} days",
+	"validation.invalid_billing_report_format": "Format must be json or csv",
+
+	// Key search
+	"validation.key_search_fragment_too_short": "Prefix and suffix must be at least {{.min}} characters long in total",
+
+	// Key count trend
+	"validation.invalid_key_count_trend_hours": "hours must be an integer between 1 and {{.max}}",
+
+	// Key model matrix
+	"validation.model_matrix_aggregate_group":     "Aggregate groups have no keys of their own, test one of their sub-groups instead",
+	"validation.model_matrix_invalid_sample_size": "sample_size must be at most {{.max}}",
+	"validation.model_matrix_too_many_models":     "At most {{.max}} models can be tested at once",
+	"validation.model_matrix_no_active_keys":      "The group has no active keys to test",
+
+	// Single model redirect rule edits
+	"validation.invalid_model_redirect":   "Invalid model redirect rules: {{.error}}",
+	"validation.model_redirect_not_found": "No redirect rule exists for model {{.model}}",
+	"validation.model_redirect_conflict":  "The redirect rule for model {{.model}} was changed by someone else (current target: \"{{.current}}\"), reload and try again",
+	"validation.model_redirect_busy":      "The group is being modified concurrently, please try again",
+
+	// Latency percentiles
+	"validation.invalid_latency_group_by": "Invalid group_by value \"{{.value}}\", supported: upstream, model",
+
+	// Request diagnosis
+	"log.request_not_found":               "No matching request log found. Requests rejected before reaching an upstream (rate limits, paused groups, replay checks) and requests of groups with request logging disabled are not logged.",
+	"validation.request_diagnosis_params": "Provide either request_id, or time (RFC3339) together with proxy_key",
+
+	// Group availability
+	"validation.invalid_availability_params": "hours must be between 1 and {{.max}}, failure_threshold between 1 and 100, and min_requests at least 1",
+
+	// Upstream failover
+	"config.upstream_failure_threshold":      "Upstream Failure Threshold",
+	"config.upstream_failure_threshold_desc": "Consecutive 5xx responses or connection errors after which an upstream is taken out of rotation for the cooldown. 0 disables upstream failover. If every upstream is unhealthy, all of them are used.",
+	"config.upstream_cooldown_seconds":       "Upstream Cooldown (seconds)",
+	"config.upstream_cooldown_seconds_desc":  "How long an unhealthy upstream stays out of rotation. Afterwards it receives traffic again: one success marks it healthy, another failure takes it out for a new cooldown.",
+
+	// Group bulk export/import
+	"validation.invalid_export_keys_value":   "Invalid keys value. Must be 'none', 'valid_only', or 'all'",
+	"validation.group_import_keys_encrypted": "The keys of this archive are encrypted, use the archive import with source_encryption_key instead",
+	"validation.group_import_failed":         "Import rolled back, group {{.group}} failed: {{.error}}",
+
+	// Aggregate model routing
+	"validation.model_routing_aggregate_only":       "model_routing is only supported for aggregate groups",
+	"validation.model_routing_sub_group_not_member": "model_routing for model {{.model}} points to group {{.sub_group_id}}, which is not a sub-group of this aggregate group",
+
+	// Aggregate session affinity
+	"validation.session_affinity_aggregate_only": "session_affinity_header and session_affinity_ttl_seconds are only supported for aggregate groups",
+
+	// Group soft delete
+	"success.group_purged":         "Group and related keys permanently deleted",
+	"validation.group_not_deleted": "The group is not deleted",
+	"error.restore_group_cache":    "Failed to restore group: unable to load its keys into the cache",
+
+	// Param overrides validation
+	"validation.unknown_param_overrides": "Unknown parameters in param_overrides: {{.params}}. Valid parameters for the {{.channel}} channel: {{.valid}}. Turn off strict_param_overrides to send them anyway",
+
+	// Group stats time-series
+	"config.stats_timeseries_max_hours":         "Stats Time-Series Max Window",
+	"config.stats_timeseries_max_hours_desc":    "The longest window, in hours, that can be requested from the hourly group stats time-series. Bounds the rows read per request; the default is 90 days and the maximum 8760 hours (one year).",
+	"validation.invalid_stats_timeseries_hours": "hours must be an integer between 1 and {{.max}}",
+
+	// Key tiers
+	"success.key_tier_updated":    "Key tier updated",
+	"validation.invalid_key_tier": "tier must be an integer between 0 and {{.max}}",
+
+	// Key disable/enable
+	"success.key_disabled": "Key disabled",
+	"success.key_enabled":  "Key enabled",
+
+	"validation.key_not_disabled": "Only disabled keys can be enabled. Use validation or restore for invalid keys",
+
+	// Group copy
+	"validation.aggregate_no_upstreams": "Aggregate groups do not support upstreams",
+	"validation.aggregate_channel_type": "The channel type of an aggregate group follows its sub-groups and cannot be overridden",
+
+	// Hourly stats retention
+	"config.stats_hourly_retention_days":      "Hourly Stats Retention (days)",
+	"config.stats_hourly_retention_days_desc": "Days of hourly group stats to keep. Older hours are rolled up into daily stats, which the billing report still reads, and then deleted. 0 keeps hourly stats forever. At least 91 days, and at least the time-series max window, are always kept, since stats windows, time-series and availability read hourly stats.",
+
+	// Invalid key revalidation
+	"success.no_invalid_keys_to_revalidate": "The group has no invalid keys, nothing to revalidate",
+
+	// Trusted proxy depth
+	"config.trusted_proxy_depth":      "Trusted Proxy Depth",
+	"config.trusted_proxy_depth_desc": "Number of reverse proxies in front of this service that append to X-Forwarded-For. The client IP checked against proxy key IP allow-lists is the entry this many positions from the right; 0 ignores the header and uses the connection address.",
+
+	// Key CSV export
+	"validation.invalid_active_only": "active_only must be true or false",
+
+	// Log cursor pagination
+	"validation.invalid_cursor":          "Invalid pagination cursor",
+	"validation.invalid_log_time_filter": "{{.param}} must be an RFC 3339 time, e.g. 2006-01-02T15:04:05Z",
+
+	// Concurrency limiter
+	"config.global_max_concurrent_requests":      "Global Max Concurrent Requests",
+	"config.global_max_concurrent_requests_desc": "Maximum number of requests forwarded at the same time across all groups on each instance. 0 means unlimited. Groups can also set max_concurrent_requests.",
+	"config.concurrency_overflow":                "Concurrency Overflow",
+	"config.concurrency_overflow_desc":           "What to do when a concurrency limit is reached: reject returns 429 at once, queue waits for a free slot up to the wait timeout and then returns 429.",
+	"config.concurrency_wait_timeout_ms":         "Concurrency Wait Timeout (ms)",
+	"config.concurrency_wait_timeout_ms_desc":    "How long a request waits for a free slot when concurrency overflow is queue.",
+
+	// Key import from URL
+	"validation.invalid_import_url":       "Import URL must be an http or https URL",
+	"validation.import_url_auth_conflict": "Use either a bearer token or a username and password, not both",
+	"validation.import_url_not_public":    "Import URL must not point to a private, loopback or link-local address",
+
+	// Structured request log
+	"config.enable_structured_request_log":      "Structured Request Log",
+	"config.enable_structured_request_log_desc": "Write one JSON line per proxied request to stdout for log pipelines, independent of the stored request logs. Lines are dropped rather than slowing requests when output falls behind.",
+	"config.structured_log_redact_fields":       "Structured Log Redacted Fields",
+	"config.structured_log_redact_fields_desc":  "Comma-separated field and request header names whose values are masked in structured request logs, case-insensitive.",
+
+	// Login lockout
+	"auth.login_lockout_not_found": "No failed login attempts recorded for this IP",
+	"auth.login_lockout_cleared":   "Login lockout cleared",
 }
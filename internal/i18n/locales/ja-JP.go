@@ -110,11 +110,13 @@ var MessagesJaJP = map[string]string{
 	"database.group_stats_failed":    "部分統計の取得に失敗しました",
 
 	// Success messages
-	"success.group_deleted":        "グループと関連キーが正常に削除されました",
+	"success.group_deleted":        "グループを削除しました。完全削除するまでは復元できます",
 	"success.keys_restored":        "{{.count}}個のキーが復元されました",
 	"success.invalid_keys_cleared": "{{.count}}個の無効なキーがクリアされました",
 	"success.all_keys_cleared":     "{{.count}}個のキーがクリアされました",
 
+	"success.orphaned_keys_cleared": "{{.count}}個の孤立したキーがクリアされました",
+
 	// Password security related
 	"security.password_too_short":         "{{.keyType}}が短すぎます（{{.length}}文字）。少なくとも16文字を推奨します",
 	"security.password_short":             "{{.keyType}}が短いです（{{.length}}文字）。32文字以上を推奨します",
@@ -166,6 +168,11 @@ var MessagesJaJP = map[string]string{
 	"config.key_validation_timeout":          "キー検証タイムアウト（秒）",
 	"config.key_validation_timeout_desc":     "バックグラウンドで単一キーを検証する際のAPIリクエストタイムアウト（秒）。",
 
+	"config.key_validation_connect_timeout":      "キー検証接続タイムアウト（秒）",
+	"config.key_validation_connect_timeout_desc": "キー検証時に接続（TLSハンドシェイクを含む）を確立するまでのタイムアウト（秒）。プロキシの接続タイムアウトとは独立しており、応答しない上流では検証がすぐに失敗します。",
+	"config.key_validation_read_timeout":         "キー検証読み取りタイムアウト（秒）",
+	"config.key_validation_read_timeout_desc":    "検証リクエスト送信後、上流のレスポンスヘッダーを待つタイムアウト（秒）。プロキシのリクエストタイムアウトとは独立しています。",
+
 	// Category labels
 	"config.category.basic":   "基本設定",
 	"config.category.request": "リクエスト設定",
@@ -204,4 +211,249 @@ var MessagesJaJP = map[string]string{
 	"group.not_aggregate":              "グループはアグリゲートグループではありません",
 	"group.sub_group_already_exists":   "サブグループ{{.sub_group_id}}は既に存在します",
 	"group.sub_group_not_found":        "サブグループが見つかりません",
+
+	// Group name swap
+	"validation.swap_same_group": "同じグループ同士で名前を入れ替えることはできません",
+
+	// Group cache refresh
+	"config.group_cache_refresh_interval":      "グループキャッシュ更新間隔（秒）",
+	"config.group_cache_refresh_interval_desc": "各ノードがデータベースからグループキャッシュを再読み込みする間隔（秒）。無効化通知の取りこぼし対策です。0で無効。",
+	"config.group_cache_refresh_jitter":        "グループキャッシュ更新ジッター（秒）",
+	"config.group_cache_refresh_jitter_desc":   "各更新に加えるランダムなオフセット（秒、±）。ノードが同時に再読み込みしないようにします。",
+
+	"config.group_cache_invalidate_window":      "グループキャッシュ無効化ウィンドウ（ミリ秒）",
+	"config.group_cache_invalidate_window_desc": "このウィンドウ（ミリ秒）内に連続したグループ変更を、各ノードで1回のキャッシュ再読み込みにまとめます。0で変更ごとに即時再読み込み。",
+
+	// Streaming
+	"config.stream_buffer_size_kb":      "ストリームバッファサイズ（KB）",
+	"config.stream_buffer_size_kb_desc": "ストリーミングレスポンス転送時に使用するバッファサイズ（KB）。大きい値は高スループットモデルのシステムコールを減らし、小さい値は最初のバイトまでの遅延を下げます。最大 1024 KB。",
+
+	// Manual key status
+	"success.key_status_updated": "キーのステータスを更新しました",
+
+	// Group name conflict
+	"validation.invalid_name_conflict_strategy": "無効な on_conflict 値です。'fail'、'rename'、'overwrite' のいずれかを指定してください",
+	"validation.overwrite_group_type_mismatch":  "種類の異なるグループは上書きできません",
+
+	// Proxy path normalization
+	"config.normalize_proxy_path":      "プロキシパスの正規化",
+	"config.normalize_proxy_path_desc": "プロキシパスの重複スラッシュをまとめ、末尾のスラッシュを削除し、「./」と「../」を解決し、API バージョン部分（例: /V1/）を小文字にします。厳密なパスが必要なプロバイダーでは無効のままにしてください。",
+
+	// Task cancellation
+	"task.cancel_requested": "タスクのキャンセルを要求しました",
+
+	// Group usage reset
+	"validation.invalid_usage_reset_scope":    "無効な scope です。'hourly'、'monthly'、'both' のいずれかを指定してください",
+	"validation.usage_reset_confirm_required": "使用量カウンターのリセットはレート制限に即時反映されます。続行するには confirm を true にしてください",
+
+	// Key selection
+	"config.key_selection_mode":      "キー選択モード",
+	"config.key_selection_mode_desc": "アクティブなキープールからキーを選ぶ方法：round_robin（ラウンドロビン）、または最近の失敗が最も少ないキーを優先する least_failures。",
+
+	// Request log backpressure
+	"config.log_buffer_size":              "ログバッファサイズ",
+	"config.log_buffer_size_desc":         "バックプレッシャーポリシーが適用される前にメモリにキューイングできるリクエストログの最大数。再起動後に有効になります。",
+	"config.log_backpressure_policy":      "ログバックプレッシャーポリシー",
+	"config.log_backpressure_policy_desc": "ログバッファが満杯の場合の動作：drop（破棄してカウント）、block（ブロックタイムアウトまで待機後に破棄）、disable（統計カウンターは保持しつつ詳細ログを一時的にスキップ）。",
+	"config.log_block_timeout":            "ログブロックタイムアウト（ミリ秒）",
+	"config.log_block_timeout_desc":       "block ポリシーでリクエストがバッファの空きを待つ最大時間（ミリ秒）。",
+
+	// HTTPS-only upstreams
+	"config.require_https_upstreams":      "HTTPS アップストリームを必須にする",
+	"config.require_https_upstreams_desc": "有効にすると、グループの作成・更新時に https:// のアップストリーム URL のみ使用できます。既存の非 HTTPS アップストリームは起動時に報告されます。",
+	"validation.https_upstream_required":  "アップストリーム {{.url}} は HTTPS を使用する必要があります",
+
+	// Bulk sub-group weights
+	"validation.sub_group_weights_empty":    "サブグループの重みが指定されていません",
+	"validation.sub_group_weights_all_zero": "少なくとも 1 つのサブグループの重みを 0 より大きくする必要があります",
+	"validation.invalid_weight_scale":       "重みのスケール係数は正の数である必要があります",
+	"success.sub_group_weights_updated":     "サブグループの重みが正常に更新されました",
+
+	// Key warm-up
+	"config.key_warmup_minutes":      "キーウォームアップ期間（分）",
+	"config.key_warmup_minutes_desc": "再有効化されたキーは低い選択確率から始まり、この期間で通常まで引き上げられます。ウォームアップ中に失敗すると最初からやり直します。0 で無効。",
+
+	// Log export masking
+	"config.mask_keys_in_log_export":      "ログエクスポートでキーをマスク",
+	"config.mask_keys_in_log_export_desc": "有効にすると、NDJSON ログエクスポート内のキーは復号ではなくマスク（先頭と末尾 4 文字のみ）されます。",
+
+	// Structured key import
+	"validation.unsupported_import_file_type": ".txt、.csv、.jsonl、.ndjson ファイルのみサポートされています",
+	"validation.invalid_import_format":        "無効なインポート形式です。text、csv、jsonl のいずれかを指定してください",
+
+	// Global rate limit
+	"config.global_rate_limit_rps":        "グローバルレート制限（リクエスト/秒）",
+	"config.global_rate_limit_rps_desc":   "このインスタンスが全グループ合計で受け付ける 1 秒あたりの最大リクエスト数。超過時は 503 を返します。0 で無効。",
+	"config.global_rate_limit_burst":      "グローバルレート制限バースト",
+	"config.global_rate_limit_burst_desc": "グローバルレートを超えて許可されるバーストリクエスト数の上限。0 の場合はグローバルレートと同じ値を使用します。",
+
+	// Rate limit warning
+	"config.rate_limit_warning_percent":      "レート制限警告しきい値（%）",
+	"config.rate_limit_warning_percent_desc": "グループの時間・月間使用量が上限のこの割合に達すると、レスポンスに X-RateLimit-Warning ヘッダーを付与します。トラフィックはブロックされません。0 で無効。",
+
+	// Proxy key uniqueness
+	"config.enforce_unique_proxy_keys":      "グループプロキシキーの一意性を強制",
+	"config.enforce_unique_proxy_keys_desc": "有効にすると、他のグループで使用中のプロキシキーを持つグループは保存できません。",
+	"validation.proxy_key_conflict":         "プロキシキー {{.key}} はグループ {{.group}} で既に使用されています",
+
+	// Stats partial hour
+	"config.stats_exclude_current_hour":      "統計から現在の時間帯を除外",
+	"config.stats_exclude_current_hour_desc": "有効にすると、グループの 24 時間/7 日/30 日統計は現在の時間帯の開始時点までとなり、進行中の時間帯による数値の変動を防ぎます。モニターのリアルタイム使用量には現在の時間帯が含まれます。リクエストパラメータ exclude_current_hour で個別に上書きできます。",
+
+	// Request hedging
+	"config.hedge_delay_ms":            "ヘッジ遅延（ミリ秒）",
+	"config.hedge_delay_ms_desc":       "非ストリーミングリクエストがこの遅延内に応答しない場合、別のキーで 2 回目のリクエストを送信し、最初に成功した応答を使用します。0 で無効になります。",
+	"config.hedge_budget_percent":      "ヘッジ予算（%）",
+	"config.hedge_budget_percent_desc": "上流の負荷が倍増しないよう、1 分あたりにヘッジを許可するグループリクエストの最大割合。",
+
+	// Response size limit
+	"config.max_response_size_kb":         "最大レスポンスサイズ (KB)",
+	"config.max_response_size_kb_desc":    "モデル一覧の書き換え、ボディアダプター、エラー解析など、検査のためにバッファリングされる非ストリーミングの上流レスポンスの最大サイズ。0 は無制限。ストリーミングおよびそのまま転送されるレスポンスはこの設定では制限されません。グループ設定の max_response_body_bytes はストリーミングを含むすべてのレスポンスを受信サイズで制限し、先に達した制限が適用されます。",
+	"config.oversized_response_mode":      "サイズ超過レスポンスの処理",
+	"config.oversized_response_mode_desc": "バッファリングしたレスポンスが最大サイズを超えた場合の処理：reject は 502 エラーを返し、truncate は上限で切り詰めた本文を X-Response-Truncated ヘッダー付きで返します（圧縮されたレスポンスは常に拒否されます）。いずれの場合もリクエストは失敗として記録されます。",
+
+	// Key utilization
+	"validation.invalid_utilization_hours": "hours は 1 から {{.max}} までの整数である必要があります",
+
+	// Group archive import
+	"validation.archive_no_groups":           "アーカイブにグループが含まれていません",
+	"validation.archive_source_key_required": "アーカイブのキーは暗号化されています。source_encryption_key が必要です",
+	"validation.archive_source_key_invalid":  "ソース暗号化キーが無効です：{{.error}}",
+
+	// Joint upstream/key selection
+	"config.joint_upstream_key_selection":      "上流とキーの同時選択",
+	"config.joint_upstream_key_selection_desc": "選択されたキーに合わせて上流を選び、両者を独立にローテーションする代わりに、すべての（上流、キー）の組み合わせにトラフィックを均等に分散します。",
+
+	// Key validation hysteresis
+	"config.key_invalidate_after_failures":      "検証連続失敗による無効化回数",
+	"config.key_invalidate_after_failures_desc": "アクティブなキーを無効にするまでに必要な連続検証失敗回数。0 の場合はプロキシ失敗と同様にブラックリストしきい値を使用します。",
+	"config.key_recover_after_successes":        "検証連続成功による復帰回数",
+	"config.key_recover_after_successes_desc":   "無効なキーを再有効化するまでに必要な連続検証成功回数。プロバイダーが一時的に不安定な場合の状態の揺れを防ぎます。",
+	"config.auto_pause_failure_rate":            "自動一時停止の失敗率 (%)",
+	"config.auto_pause_failure_rate_desc":       "自動一時停止ウィンドウ内のグループの失敗率がこの割合に達するとグループを一時停止します。0 で自動一時停止を無効にします。",
+	"config.auto_pause_window_hours":            "自動一時停止ウィンドウ（時間）",
+	"config.auto_pause_window_hours_desc":       "失敗率を計測する直近の時間数（現在の時間を含む）。",
+	"config.auto_pause_min_requests":            "自動一時停止の最小リクエスト数",
+	"config.auto_pause_min_requests_desc":       "失敗率を判断する前にウィンドウ内で必要な最小リクエスト数。少数の失敗で低トラフィックのグループが一時停止されるのを防ぎます。",
+	"config.auto_pause_cooldown_minutes":        "自動一時停止のクールダウン（分）",
+	"config.auto_pause_cooldown_minutes_desc":   "自動一時停止されたグループが自動的に再開されるまでの分数。0 の場合は手動で再開するまで一時停止したままです。",
+
+	// Billing report
+	"validation.invalid_billing_report_date":   "日付 {{.value}} が無効です。YYYY-MM-DD 形式で指定してください",
+	"validation.invalid_billing_report_range":  "開始日は終了日より後にできず、範囲は最大 {{.max}} 日です",
+	"validation.invalid_billing_report_format": "形式は json または csv である必要があります",
+
+	// Key search
+	"validation.key_search_fragment_too_short": "プレフィックスとサフィックスは合計 {{.min}} 文字以上必要です",
+
+	// Key count trend
+	"validation.invalid_key_count_trend_hours": "hours は 1 から {{.max}} までの整数である必要があります",
+
+	// Key model matrix
+	"validation.model_matrix_aggregate_group":     "集約グループには独自のキーがありません。サブグループをテストしてください",
+	"validation.model_matrix_invalid_sample_size": "sample_size は {{.max}} 以下である必要があります",
+	"validation.model_matrix_too_many_models":     "一度にテストできるモデルは最大 {{.max}} 個です",
+	"validation.model_matrix_no_active_keys":      "このグループにはテストできる有効なキーがありません",
+
+	// Single model redirect rule edits
+	"validation.invalid_model_redirect":   "モデルリダイレクトルールが無効です: {{.error}}",
+	"validation.model_redirect_not_found": "モデル {{.model}} のリダイレクトルールは存在しません",
+	"validation.model_redirect_conflict":  "モデル {{.model}} のリダイレクトルールは他のユーザーによって変更されました（現在のターゲット: \"{{.current}}\"）。再読み込みしてやり直してください",
+	"validation.model_redirect_busy":      "グループが同時に変更されています。もう一度お試しください",
+
+	// Latency percentiles
+	"validation.invalid_latency_group_by": "無効な group_by の値 \"{{.value}}\"、使用可能: upstream、model",
+
+	// Request diagnosis
+	"log.request_not_found":               "一致するリクエストログが見つかりません。上流に到達する前に拒否されたリクエスト（レート制限、グループの一時停止、リプレイチェック）と、リクエストログが無効なグループのリクエストは記録されません。",
+	"validation.request_diagnosis_params": "request_id、または time（RFC3339 形式）と proxy_key の両方を指定してください",
+
+	// Group availability
+	"validation.invalid_availability_params": "hours は 1 から {{.max}}、failure_threshold は 1 から 100 の範囲で、min_requests は 1 以上である必要があります",
+
+	// Upstream failover
+	"config.upstream_failure_threshold":      "上流失敗しきい値",
+	"config.upstream_failure_threshold_desc": "上流が連続してこの回数 5xx レスポンスまたは接続エラーを返すと、クールダウンの間ローテーションから外します。0 で上流フェイルオーバーを無効にします。すべての上流が異常な場合は、すべての上流を使用します。",
+	"config.upstream_cooldown_seconds":       "上流クールダウン（秒）",
+	"config.upstream_cooldown_seconds_desc":  "異常な上流がローテーションから外れる時間。その後は再びトラフィックを受け、1 回成功すると正常に戻り、再度失敗すると新たなクールダウンに入ります。",
+
+	// Group bulk export/import
+	"validation.invalid_export_keys_value":   "keys の値が無効です。'none'、'valid_only'、'all' のいずれかを指定してください",
+	"validation.group_import_keys_encrypted": "このアーカイブのキーは暗号化されています。source_encryption_key を指定してアーカイブインポートを使用してください",
+	"validation.group_import_failed":         "インポートはロールバックされました。グループ {{.group}} が失敗しました: {{.error}}",
+
+	// Aggregate model routing
+	"validation.model_routing_aggregate_only":       "model_routing は集約グループでのみ使用できます",
+	"validation.model_routing_sub_group_not_member": "model_routing のモデル {{.model}} が指すグループ {{.sub_group_id}} はこの集約グループのサブグループではありません",
+
+	// Aggregate session affinity
+	"validation.session_affinity_aggregate_only": "session_affinity_header と session_affinity_ttl_seconds は集約グループでのみ使用できます",
+
+	// Group soft delete
+	"success.group_purged":         "グループと関連キーを完全に削除しました",
+	"validation.group_not_deleted": "このグループは削除されていません",
+	"error.restore_group_cache":    "グループの復元に失敗: キーをキャッシュに読み込めません",
+
+	// Param overrides validation
+	"validation.unknown_param_overrides": "param_overrides に不明なパラメータがあります: {{.params}}。{{.channel}} チャネルで有効なパラメータ: {{.valid}}。そのまま送信するには strict_param_overrides をオフにしてください",
+
+	// Group stats time-series
+	"config.stats_timeseries_max_hours":         "統計時系列の最大期間",
+	"config.stats_timeseries_max_hours_desc":    "グループの時間別統計時系列で取得できる最長期間（時間）。1 回のリクエストで読み込むデータ量を制限します。既定値は 90 日、最大は 8760 時間（1 年）です。",
+	"validation.invalid_stats_timeseries_hours": "hours は 1 から {{.max}} までの整数である必要があります",
+
+	// Key tiers
+	"success.key_tier_updated":    "キーのティアを更新しました",
+	"validation.invalid_key_tier": "tier は 0 から {{.max}} までの整数である必要があります",
+
+	// Key disable/enable
+	"success.key_disabled": "キーを無効化しました",
+	"success.key_enabled":  "キーを有効化しました",
+
+	"validation.key_not_disabled": "有効化できるのは無効化されたキーのみです。無効なキーは検証または復元を使用してください",
+
+	// Group copy
+	"validation.aggregate_no_upstreams": "集約グループはアップストリームをサポートしていません",
+	"validation.aggregate_channel_type": "集約グループのチャンネルタイプはサブグループに従うため、上書きできません",
+
+	// Hourly stats retention
+	"config.stats_hourly_retention_days":      "時間別統計の保持日数",
+	"config.stats_hourly_retention_days_desc": "グループの時間別統計を保持する日数。これより古い時間別統計は日別統計（請求レポートは引き続き参照します）に集計されたうえで削除されます。0 は無期限に保持します。統計期間、時系列、可用性は時間別統計を参照するため、少なくとも 91 日間と時系列の最大期間は常に保持されます。",
+
+	// Invalid key revalidation
+	"success.no_invalid_keys_to_revalidate": "このグループには無効なキーがないため、再検証するものはありません",
+
+	// Trusted proxy depth
+	"config.trusted_proxy_depth":      "信頼するプロキシの段数",
+	"config.trusted_proxy_depth_desc": "このサービスの前段にあり X-Forwarded-For に追記するリバースプロキシの数。プロキシキーの IP 許可リストでは、このヘッダーの右から N 番目のアドレスをクライアント IP として使用します。0 の場合はヘッダーを無視し、接続元アドレスを使用します。",
+
+	// Key CSV export
+	"validation.invalid_active_only": "active_only は true または false である必要があります",
+
+	// Log cursor pagination
+	"validation.invalid_cursor":          "無効なページネーションカーソルです",
+	"validation.invalid_log_time_filter": "{{.param}} は RFC 3339 形式の時刻で指定してください（例: 2006-01-02T15:04:05Z）",
+
+	// Concurrency limiter
+	"config.global_max_concurrent_requests":      "グローバル最大同時リクエスト数",
+	"config.global_max_concurrent_requests_desc": "各インスタンスで全グループ合計の同時転送リクエスト数の上限。0 は無制限です。グループごとに max_concurrent_requests も設定できます。",
+	"config.concurrency_overflow":                "同時実行数超過時の処理",
+	"config.concurrency_overflow_desc":           "同時実行数の上限に達した場合の処理：reject は即座に 429 を返し、queue は待機タイムアウトまで空きを待ってから 429 を返します。",
+	"config.concurrency_wait_timeout_ms":         "同時実行待機タイムアウト（ミリ秒）",
+	"config.concurrency_wait_timeout_ms_desc":    "同時実行数超過時の処理が queue の場合に、リクエストが空きを待つ最大時間。",
+
+	// Key import from URL
+	"validation.invalid_import_url":       "インポート URL は http または https の URL である必要があります",
+	"validation.import_url_auth_conflict": "Bearer トークンとユーザー名・パスワードのどちらか一方のみ指定してください",
+	"validation.import_url_not_public":    "インポート URL にプライベート、ループバック、リンクローカルのアドレスは指定できません",
+
+	// Structured request log
+	"config.enable_structured_request_log":      "構造化リクエストログ",
+	"config.enable_structured_request_log_desc": "ログパイプライン向けに、プロキシされた各リクエストについて JSON を1行標準出力へ書き出します。保存されるリクエストログとは独立しています。出力が追いつかない場合はリクエストを遅らせず行を破棄します。",
+	"config.structured_log_redact_fields":       "構造化ログのマスク対象",
+	"config.structured_log_redact_fields_desc":  "構造化リクエストログで値をマスクするフィールド名とリクエストヘッダー名（カンマ区切り、大文字小文字を区別しない）。",
+
+	// Login lockout
+	"auth.login_lockout_not_found": "この IP のログイン失敗記録はありません",
+	"auth.login_lockout_cleared":   "ログインロックを解除しました",
 }
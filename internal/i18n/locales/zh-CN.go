@@ -110,11 +110,13 @@ var MessagesZhCN = map[string]string{
 	"database.group_stats_failed":    "获取部分统计信息失败",
 
 	// Success messages
-	"success.group_deleted":        "分组及相关密钥删除成功",
+	"success.group_deleted":        "分组已删除，彻底删除前可恢复",
 	"success.keys_restored":        "{{.count}}个密钥已恢复",
 	"success.invalid_keys_cleared": "{{.count}}个无效密钥已清除",
 	"success.all_keys_cleared":     "{{.count}}个密钥已清除",
 
+	"success.orphaned_keys_cleared": "{{.count}}个孤立密钥已清除",
+
 	// Password security related
 	"security.password_too_short":         "{{.keyType}}长度不足（{{.length}}字符），建议至少16字符",
 	"security.password_short":             "{{.keyType}}长度偏短（{{.length}}字符），建议32字符以上",
@@ -166,6 +168,11 @@ var MessagesZhCN = map[string]string{
 	"config.key_validation_timeout":          "密钥验证超时（秒）",
 	"config.key_validation_timeout_desc":     "后台定时验证单个 Key 时的 API 请求超时时间（秒）。",
 
+	"config.key_validation_connect_timeout":      "密钥验证连接超时（秒）",
+	"config.key_validation_connect_timeout_desc": "验证密钥时建立连接（包括 TLS 握手）的超时时间（秒）。与代理连接超时相互独立，使失效的上游能快速验证失败。",
+	"config.key_validation_read_timeout":         "密钥验证读取超时（秒）",
+	"config.key_validation_read_timeout_desc":    "发送验证请求后等待上游响应头的超时时间（秒）。与代理请求超时相互独立。",
+
 	// Category labels
 	"config.category.basic":   "基础参数",
 	"config.category.request": "请求设置",
@@ -204,4 +211,249 @@ var MessagesZhCN = map[string]string{
 	"group.not_aggregate":              "该分组不是聚合分组",
 	"group.sub_group_already_exists":   "子分组{{.sub_group_id}}已存在",
 	"group.sub_group_not_found":        "子分组不存在",
+
+	// Group name swap
+	"validation.swap_same_group": "不能与自身交换分组名称",
+
+	// Group cache refresh
+	"config.group_cache_refresh_interval":      "分组缓存刷新间隔（秒）",
+	"config.group_cache_refresh_interval_desc": "每个节点从数据库重新加载分组缓存的间隔（秒），用于防止遗漏缓存失效通知，0 表示禁用。",
+	"config.group_cache_refresh_jitter":        "分组缓存刷新抖动（秒）",
+	"config.group_cache_refresh_jitter_desc":   "每次刷新附加的随机偏移（秒，±），避免多个节点同时重新加载。",
+
+	"config.group_cache_invalidate_window":      "分组缓存失效合并窗口（毫秒）",
+	"config.group_cache_invalidate_window_desc": "在该窗口（毫秒）内连续发生的分组变更会合并为每个节点的一次缓存重新加载，0 表示每次变更立即重新加载。",
+
+	// Streaming
+	"config.stream_buffer_size_kb":      "流式缓冲区大小（KB）",
+	"config.stream_buffer_size_kb_desc": "转发流式响应时使用的缓冲区大小（KB）。较大的值可减少高吞吐模型的系统调用，较小的值可降低首字节延迟。最大 1024 KB。",
+
+	// Manual key status
+	"success.key_status_updated": "密钥状态已更新",
+
+	// Group name conflict
+	"validation.invalid_name_conflict_strategy": "无效的 on_conflict 值，必须是 'fail'、'rename' 或 'overwrite'",
+	"validation.overwrite_group_type_mismatch":  "无法覆盖类型不同的分组",
+
+	// Proxy path normalization
+	"config.normalize_proxy_path":      "规范化代理路径",
+	"config.normalize_proxy_path_desc": "合并代理路径中的重复斜杠、去除末尾斜杠，解析“./”和“../”路径段，并将 API 版本段（如 /V1/）转换为小写。对要求严格路径的服务商请保持关闭。",
+
+	// Task cancellation
+	"task.cancel_requested": "已请求取消任务",
+
+	// Group usage reset
+	"validation.invalid_usage_reset_scope":    "无效的 scope，必须是 'hourly'、'monthly' 或 'both'",
+	"validation.usage_reset_confirm_required": "重置用量计数会立即影响限流，请将 confirm 设为 true 以继续",
+
+	// Key selection
+	"config.key_selection_mode":      "密钥选择模式",
+	"config.key_selection_mode_desc": "从可用密钥池中选择密钥的方式：round_robin 轮询，或 least_failures 优先选择近期失败次数最少的密钥。",
+
+	// Request log backpressure
+	"config.log_buffer_size":              "日志缓冲区大小",
+	"config.log_buffer_size_desc":         "在应用背压策略之前，内存中可排队的请求日志最大数量。重启后生效。",
+	"config.log_backpressure_policy":      "日志背压策略",
+	"config.log_backpressure_policy_desc": "日志缓冲区已满时的处理方式：drop（丢弃并计数）、block（最多等待阻塞超时时间后丢弃）或 disable（暂时跳过详细日志，但保留统计计数）。",
+	"config.log_block_timeout":            "日志阻塞超时（毫秒）",
+	"config.log_block_timeout_desc":       "block 策略下请求等待缓冲区空间的最长时间（毫秒）。",
+
+	// HTTPS-only upstreams
+	"config.require_https_upstreams":      "强制 HTTPS 上游",
+	"config.require_https_upstreams_desc": "启用后，只能使用 https:// 上游地址创建或更新分组。启动时会报告已存在的非 HTTPS 上游。",
+	"validation.https_upstream_required":  "上游 {{.url}} 必须使用 HTTPS",
+
+	// Bulk sub-group weights
+	"validation.sub_group_weights_empty":    "未提供子分组权重",
+	"validation.sub_group_weights_all_zero": "至少需要一个子分组的权重大于 0",
+	"validation.invalid_weight_scale":       "权重缩放系数必须为正数",
+	"success.sub_group_weights_updated":     "子分组权重更新成功",
+
+	// Key warm-up
+	"config.key_warmup_minutes":      "密钥预热时长（分钟）",
+	"config.key_warmup_minutes_desc": "重新激活的密钥以较低的选中概率开始，在此时长内逐步提升至正常。预热期间失败会重新开始预热。0 表示禁用。",
+
+	// Log export masking
+	"config.mask_keys_in_log_export":      "日志导出时脱敏密钥",
+	"config.mask_keys_in_log_export_desc": "启用后，NDJSON 日志导出中的密钥将脱敏显示（仅保留首尾 4 个字符），而不是解密后的明文。",
+
+	// Structured key import
+	"validation.unsupported_import_file_type": "仅支持 .txt、.csv、.jsonl 和 .ndjson 文件",
+	"validation.invalid_import_format":        "无效的导入格式，必须为 text、csv 或 jsonl",
+
+	// Global rate limit
+	"config.global_rate_limit_rps":        "全局速率限制（请求/秒）",
+	"config.global_rate_limit_rps_desc":   "本实例所有分组合计每秒接受的最大请求数，超出时返回 503。0 表示不限制。",
+	"config.global_rate_limit_burst":      "全局速率限制突发量",
+	"config.global_rate_limit_burst_desc": "允许超出全局速率的突发请求数上限。0 表示与全局速率相同。",
+
+	// Rate limit warning
+	"config.rate_limit_warning_percent":      "限流预警阈值（%）",
+	"config.rate_limit_warning_percent_desc": "分组每小时或每月用量达到限额的该百分比时，在响应中添加 X-RateLimit-Warning 头，不会拦截请求。0 表示关闭。",
+
+	// Proxy key uniqueness
+	"config.enforce_unique_proxy_keys":      "强制分组代理密钥唯一",
+	"config.enforce_unique_proxy_keys_desc": "开启后，保存分组时不允许使用其他分组已使用的代理密钥。",
+	"validation.proxy_key_conflict":         "代理密钥 {{.key}} 已被分组 {{.group}} 使用",
+
+	// Stats partial hour
+	"config.stats_exclude_current_hour":      "统计排除当前小时",
+	"config.stats_exclude_current_hour_desc": "开启后，分组的 24 小时/7 天/30 天统计截止到当前小时开始，避免未完成的小时导致滚动数据跳动。监控中的实时用量仍包含当前小时。可通过请求参数 exclude_current_hour 单独覆盖。",
+
+	// Request hedging
+	"config.hedge_delay_ms":            "对冲请求延迟（毫秒）",
+	"config.hedge_delay_ms_desc":       "非流式请求在此延迟内未响应时，使用另一个密钥发送第二个请求，采用最先成功的响应。0 表示禁用。",
+	"config.hedge_budget_percent":      "对冲请求预算（%）",
+	"config.hedge_budget_percent_desc": "每分钟内分组请求中允许发起对冲的最大比例，避免上游负载翻倍。",
+
+	// Response size limit
+	"config.max_response_size_kb":         "最大响应大小 (KB)",
+	"config.max_response_size_kb_desc":    "为检查而缓冲的非流式上游响应的最大大小，例如模型列表改写、响应体适配器和错误解析。0 表示不限制。流式响应和直接透传的响应不受此项限制；分组配置 max_response_body_bytes 按接收大小限制所有响应（含流式），两者以先达到的为准。",
+	"config.oversized_response_mode":      "超大响应处理方式",
+	"config.oversized_response_mode_desc": "缓冲的响应超过最大响应大小时的处理方式：reject 返回 502 错误，truncate 返回截断到上限的响应体并附带 X-Response-Truncated 响应头（压缩的响应始终被拒绝）。两种方式都会将请求记录为失败。",
+
+	// Key utilization
+	"validation.invalid_utilization_hours": "hours 必须是 1 到 {{.max}} 之间的整数",
+
+	// Group archive import
+	"validation.archive_no_groups":           "归档中没有任何分组",
+	"validation.archive_source_key_required": "归档中的密钥已加密，需要提供 source_encryption_key",
+	"validation.archive_source_key_invalid":  "源加密密钥无效：{{.error}}",
+
+	// Joint upstream/key selection
+	"config.joint_upstream_key_selection":      "上游与密钥联合选择",
+	"config.joint_upstream_key_selection_desc": "根据已选中的密钥选择上游，使流量在每个（上游，密钥）组合间均匀分布，而不是两者各自独立轮询。",
+
+	// Key validation hysteresis
+	"config.key_invalidate_after_failures":      "验证连续失败禁用次数",
+	"config.key_invalidate_after_failures_desc": "活跃密钥连续验证失败达到此次数后才标记为无效。0 表示与代理请求失败一样使用黑名单阈值。",
+	"config.key_recover_after_successes":        "验证连续成功恢复次数",
+	"config.key_recover_after_successes_desc":   "无效密钥连续验证成功达到此次数后才重新启用，避免服务商短暂不稳定时状态反复切换。",
+	"config.auto_pause_failure_rate":            "自动暂停失败率 (%)",
+	"config.auto_pause_failure_rate_desc":       "分组在自动暂停窗口内的失败率达到此百分比时暂停该分组。0 表示不自动暂停。",
+	"config.auto_pause_window_hours":            "自动暂停统计窗口（小时）",
+	"config.auto_pause_window_hours_desc":       "计算失败率所用的最近小时数，包含当前小时。",
+	"config.auto_pause_min_requests":            "自动暂停最小请求数",
+	"config.auto_pause_min_requests_desc":       "窗口内请求数达到此值后才判断失败率，避免少量失败就暂停低流量分组。",
+	"config.auto_pause_cooldown_minutes":        "自动暂停冷却时间（分钟）",
+	"config.auto_pause_cooldown_minutes_desc":   "自动暂停的分组经过此分钟数后自动恢复。0 表示需手动恢复。",
+
+	// Billing report
+	"validation.invalid_billing_report_date":   "日期 {{.value}} 无效，格式应为 YYYY-MM-DD",
+	"validation.invalid_billing_report_range":  "开始日期不能晚于结束日期，且范围最多 {{.max}} 天",
+	"validation.invalid_billing_report_format": "格式必须为 json 或 csv",
+
+	// Key search
+	"validation.key_search_fragment_too_short": "前缀和后缀合计至少需要 {{.min}} 个字符",
+
+	// Key count trend
+	"validation.invalid_key_count_trend_hours": "hours 必须是 1 到 {{.max}} 之间的整数",
+
+	// Key model matrix
+	"validation.model_matrix_aggregate_group":     "聚合分组没有自己的密钥，请改为测试其子分组",
+	"validation.model_matrix_invalid_sample_size": "sample_size 不能超过 {{.max}}",
+	"validation.model_matrix_too_many_models":     "一次最多只能测试 {{.max}} 个模型",
+	"validation.model_matrix_no_active_keys":      "该分组没有可测试的有效密钥",
+
+	// Single model redirect rule edits
+	"validation.invalid_model_redirect":   "模型重定向规则无效：{{.error}}",
+	"validation.model_redirect_not_found": "模型 {{.model}} 没有重定向规则",
+	"validation.model_redirect_conflict":  "模型 {{.model}} 的重定向规则已被他人修改（当前目标：\"{{.current}}\"），请刷新后重试",
+	"validation.model_redirect_busy":      "该分组正在被并发修改，请重试",
+
+	// Latency percentiles
+	"validation.invalid_latency_group_by": "无效的 group_by 值 \"{{.value}}\"，支持: upstream、model",
+
+	// Request diagnosis
+	"log.request_not_found":               "未找到匹配的请求日志。在到达上游之前被拒绝的请求（限流、分组暂停、防重放检查）以及禁用了请求日志的分组的请求不会被记录。",
+	"validation.request_diagnosis_params": "请提供 request_id，或同时提供 time（RFC3339 格式）和 proxy_key",
+
+	// Group availability
+	"validation.invalid_availability_params": "hours 必须在 1 到 {{.max}} 之间，failure_threshold 必须在 1 到 100 之间，min_requests 至少为 1",
+
+	// Upstream failover
+	"config.upstream_failure_threshold":      "上游失败阈值",
+	"config.upstream_failure_threshold_desc": "上游连续返回 5xx 或连接错误达到该次数后，在冷却时间内将其移出轮询。0 表示禁用上游故障转移。如果所有上游都不健康，则使用全部上游。",
+	"config.upstream_cooldown_seconds":       "上游冷却时间（秒）",
+	"config.upstream_cooldown_seconds_desc":  "不健康的上游被移出轮询的时长。之后它会重新接收流量：一次成功即恢复为健康，再次失败则重新进入冷却。",
+
+	// Group bulk export/import
+	"validation.invalid_export_keys_value":   "keys 参数无效，必须为 'none'、'valid_only' 或 'all'",
+	"validation.group_import_keys_encrypted": "该归档中的密钥已加密，请改用归档导入并提供 source_encryption_key",
+	"validation.group_import_failed":         "导入已回滚，分组 {{.group}} 失败：{{.error}}",
+
+	// Aggregate model routing
+	"validation.model_routing_aggregate_only":       "model_routing 仅支持聚合分组",
+	"validation.model_routing_sub_group_not_member": "model_routing 中模型 {{.model}} 指向的分组 {{.sub_group_id}} 不是该聚合分组的子分组",
+
+	// Aggregate session affinity
+	"validation.session_affinity_aggregate_only": "session_affinity_header 和 session_affinity_ttl_seconds 仅支持聚合分组",
+
+	// Group soft delete
+	"success.group_purged":         "分组及相关密钥已彻底删除",
+	"validation.group_not_deleted": "该分组未被删除",
+	"error.restore_group_cache":    "恢复分组失败: 无法将密钥载入缓存",
+
+	// Param overrides validation
+	"validation.unknown_param_overrides": "param_overrides 中包含未知参数: {{.params}}。{{.channel}} 渠道支持的参数: {{.valid}}。如需传递这些参数，请关闭 strict_param_overrides",
+
+	// Group stats time-series
+	"config.stats_timeseries_max_hours":         "统计时间序列最大窗口",
+	"config.stats_timeseries_max_hours_desc":    "分组每小时统计时间序列可查询的最长时间窗口（小时），用于限制单次查询读取的数据量，默认 90 天，最大 8760 小时（一年）。",
+	"validation.invalid_stats_timeseries_hours": "hours 必须是 1 到 {{.max}} 之间的整数",
+
+	// Key tiers
+	"success.key_tier_updated":    "密钥层级已更新",
+	"validation.invalid_key_tier": "tier 必须是 0 到 {{.max}} 之间的整数",
+
+	// Key disable/enable
+	"success.key_disabled": "密钥已禁用",
+	"success.key_enabled":  "密钥已启用",
+
+	"validation.key_not_disabled": "只能启用已禁用的密钥，无效密钥请使用验证或恢复操作",
+
+	// Group copy
+	"validation.aggregate_no_upstreams": "聚合分组不支持配置上游",
+	"validation.aggregate_channel_type": "聚合分组的渠道类型由其子分组决定，不能覆盖",
+
+	// Hourly stats retention
+	"config.stats_hourly_retention_days":      "小时统计保留天数",
+	"config.stats_hourly_retention_days_desc": "保留分组小时统计的天数。更早的小时统计会汇总为每日统计（账单报表仍会读取）后删除。0 表示永久保留。统计窗口、时间序列和可用率读取小时统计，因此至少保留 91 天以及时间序列的最大窗口。",
+
+	// Invalid key revalidation
+	"success.no_invalid_keys_to_revalidate": "该分组没有无效密钥，无需重新验证",
+
+	// Trusted proxy depth
+	"config.trusted_proxy_depth":      "可信代理层数",
+	"config.trusted_proxy_depth_desc": "本服务前方会追加 X-Forwarded-For 的反向代理层数。代理密钥 IP 白名单使用该请求头从右数第 N 个地址作为客户端 IP；0 表示忽略该请求头，使用连接地址。",
+
+	// Key CSV export
+	"validation.invalid_active_only": "active_only 必须为 true 或 false",
+
+	// Log cursor pagination
+	"validation.invalid_cursor":          "无效的分页游标",
+	"validation.invalid_log_time_filter": "{{.param}} 必须是 RFC 3339 格式的时间，例如 2006-01-02T15:04:05Z",
+
+	// Concurrency limiter
+	"config.global_max_concurrent_requests":      "全局最大并发请求数",
+	"config.global_max_concurrent_requests_desc": "每个实例上所有分组同时转发的最大请求数，0 表示不限制。分组还可以单独设置 max_concurrent_requests。",
+	"config.concurrency_overflow":                "并发超限处理方式",
+	"config.concurrency_overflow_desc":           "达到并发上限时的处理方式：reject 立即返回 429，queue 在等待超时时间内排队等待空闲名额，超时后返回 429。",
+	"config.concurrency_wait_timeout_ms":         "并发排队超时（毫秒）",
+	"config.concurrency_wait_timeout_ms_desc":    "并发超限处理方式为 queue 时，请求等待空闲名额的最长时间。",
+
+	// Key import from URL
+	"validation.invalid_import_url":       "导入地址必须是 http 或 https URL",
+	"validation.import_url_auth_conflict": "只能使用 Bearer 令牌或用户名和密码中的一种",
+	"validation.import_url_not_public":    "导入地址不能指向私有、回环或链路本地地址",
+
+	// Structured request log
+	"config.enable_structured_request_log":      "结构化请求日志",
+	"config.enable_structured_request_log_desc": "为每个代理请求向标准输出写入一行 JSON，供日志管道采集，与数据库中的请求日志相互独立。输出跟不上时会丢弃日志行，而不会拖慢请求。",
+	"config.structured_log_redact_fields":       "结构化日志脱敏字段",
+	"config.structured_log_redact_fields_desc":  "以逗号分隔的字段名和请求头名称，其值在结构化请求日志中会被遮盖，不区分大小写。",
+
+	// Login lockout
+	"auth.login_lockout_not_found": "该 IP 没有登录失败记录",
+	"auth.login_lockout_cleared":   "已解除登录锁定",
 }
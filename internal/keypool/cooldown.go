@@ -0,0 +1,99 @@
+package keypool
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"aimanager/internal/models"
+	"aimanager/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cooldownMaxAttempts bounds how many candidates a selection may check for cooldown, each check being a
+// store read. Once it is reached the candidate that failed longest ago is used.
+const cooldownMaxAttempts = 5
+
+// keyCooldown returns the group's key_cooldown_seconds, 0 meaning disabled.
+func keyCooldown(group *models.Group) time.Duration {
+	if group.ParsedConfig.KeyCooldownSeconds == nil {
+		return 0
+	}
+	return time.Duration(*group.ParsedConfig.KeyCooldownSeconds) * time.Second
+}
+
+// keyCooldownKey holds the last failure time of a key in the store. It expires with the cooldown, so
+// all nodes skip the key for the same window.
+func keyCooldownKey(keyID uint) string {
+	return fmt.Sprintf("key_cooldown:%d", keyID)
+}
+
+// startCooldown records the key's failure time for the group's cooldown window.
+func (p *KeyProvider) startCooldown(group *models.Group, keyID uint) {
+	cooldown := keyCooldown(group)
+	if cooldown <= 0 {
+		return
+	}
+	value := []byte(strconv.FormatInt(time.Now().UnixMilli(), 10))
+	if err := p.store.Set(keyCooldownKey(keyID), value, cooldown); err != nil {
+		logrus.WithFields(logrus.Fields{"keyID": keyID, "error": err}).Warn("Failed to store key cooldown")
+	}
+}
+
+// cooldownFailedAt returns when the key last failed if it is still cooling down.
+func (p *KeyProvider) cooldownFailedAt(keyID uint) (time.Time, bool) {
+	value, err := p.store.Get(keyCooldownKey(keyID))
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			logrus.WithFields(logrus.Fields{"keyID": keyID, "error": err}).Warn("Failed to read key cooldown")
+		}
+		return time.Time{}, false
+	}
+	failedAt, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(failedAt), true
+}
+
+// selectCooledDownKey selects keys until one is not cooling down, trying each active key at most
+// once and at most cooldownMaxAttempts keys. If all of them are cooling down, the one that failed
+// longest ago is used, so a group is never left without a key because of cooldown alone.
+func (p *KeyProvider) selectCooledDownKey(group *models.Group) (*models.APIKey, error) {
+	var fallback *models.APIKey
+	var fallbackFailedAt time.Time
+	seen := make(map[uint]bool)
+
+	for range cooldownMaxAttempts {
+		apiKey, err := p.selectWarmKey(group)
+		if err != nil {
+			if fallback != nil {
+				break
+			}
+			return nil, err
+		}
+		if seen[apiKey.ID] {
+			// Every active key has been tried
+			break
+		}
+		seen[apiKey.ID] = true
+
+		failedAt, cooling := p.cooldownFailedAt(apiKey.ID)
+		if !cooling {
+			return apiKey, nil
+		}
+		if fallback == nil || failedAt.Before(fallbackFailedAt) {
+			fallback = apiKey
+			fallbackFailedAt = failedAt
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group":    group.Name,
+		"keyID":    fallback.ID,
+		"failedAt": fallbackFailedAt,
+	}).Debug("All candidate keys are cooling down, using the least recently failed one")
+	return fallback, nil
+}
@@ -13,6 +13,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// CronCheckInterval is how often the master node runs its periodic key checks.
+const CronCheckInterval = 5 * time.Minute
+
 // NewCronChecker is responsible for periodically validating invalid keys.
 type CronChecker struct {
 	DB              *gorm.DB
@@ -70,7 +73,7 @@ func (s *CronChecker) runLoop() {
 
 	s.submitValidationJobs()
 
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(CronCheckInterval)
 	defer ticker.Stop()
 
 	for {
@@ -0,0 +1,114 @@
+package keypool
+
+import (
+	"fmt"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UpdateValidationStatus records the outcome of a key validation with hysteresis. The key row
+// tracks consecutive validation failures (while active) and successes (while invalid):
+//   - an active key is disabled after key_invalidate_after_failures consecutive failed
+//     validations, or by blacklist_threshold as for proxy failures when that setting is 0;
+//   - an invalid key is reactivated after key_recover_after_successes consecutive successes.
+//
+// Any opposite outcome resets the other counter.
+func (p *KeyProvider) UpdateValidationStatus(apiKey *models.APIKey, group *models.Group, isSuccess bool, errorMessage string) {
+	if !isSuccess && app_errors.IsUnCounted(errorMessage) {
+		p.UpdateStatus(apiKey, group, isSuccess, errorMessage)
+		return
+	}
+
+	cfg := group.EffectiveConfig
+	go func() {
+		keyHashKey := fmt.Sprintf("key:%d", apiKey.ID)
+		activeKeysListKey := fmt.Sprintf("group:%d:active_keys", group.ID)
+
+		key, err := p.recordValidationOutcome(apiKey.ID, isSuccess)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "error": err}).Error("Failed to record key validation outcome")
+			return
+		}
+
+		if key.Status == models.KeyStatusDisabled {
+			return
+		}
+
+		if isSuccess {
+			if key.Status == models.KeyStatusInvalid && key.ConsecutiveSuccesses < int64(cfg.KeyRecoverAfterSuccesses) {
+				logrus.WithFields(logrus.Fields{
+					"keyID":     apiKey.ID,
+					"successes": key.ConsecutiveSuccesses,
+					"required":  cfg.KeyRecoverAfterSuccesses,
+				}).Debug("Invalid key passed validation, waiting for more consecutive successes before reactivating")
+				return
+			}
+			p.warmups.recordSuccess(apiKey.ID)
+			if err := p.handleSuccess(group.ID, apiKey.ID, keyHashKey, activeKeysListKey); err != nil {
+				logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "error": err}).Error("Failed to handle key success")
+			}
+			return
+		}
+
+		shouldBlacklistAt := blacklistPolicy(cfg.BlacklistThreshold)
+		if threshold := int64(cfg.KeyInvalidateAfterFailures); threshold > 0 {
+			shouldBlacklistAt = func(int64) bool { return key.ConsecutiveFailures >= threshold }
+		}
+
+		p.failureScores.recordFailure(group.ID, apiKey.ID)
+		p.warmups.recordFailure(apiKey.ID)
+		if err := p.handleFailure(apiKey, keyHashKey, activeKeysListKey, shouldBlacklistAt); err != nil {
+			logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "error": err}).Error("Failed to handle key failure")
+		}
+	}()
+}
+
+// recordValidationOutcome updates the consecutive validation counters of a key and returns the
+// updated row. Successes only accumulate while the key is invalid, failures while it is active,
+// so a state change always starts counting from zero.
+func (p *KeyProvider) recordValidationOutcome(keyID uint, isSuccess bool) (*models.APIKey, error) {
+	var key models.APIKey
+	err := p.executeTransactionWithRetry(func(tx *gorm.DB) error {
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&key, keyID).Error; err != nil {
+			return fmt.Errorf("failed to lock key %d for update: %w", keyID, err)
+		}
+
+		isActive := key.Status == models.KeyStatusActive
+		switch {
+		case isSuccess && isActive:
+			key.ConsecutiveSuccesses, key.ConsecutiveFailures = 0, 0
+		case isSuccess:
+			key.ConsecutiveSuccesses, key.ConsecutiveFailures = key.ConsecutiveSuccesses+1, 0
+		case isActive:
+			key.ConsecutiveSuccesses, key.ConsecutiveFailures = 0, key.ConsecutiveFailures+1
+		default:
+			key.ConsecutiveSuccesses, key.ConsecutiveFailures = 0, 0
+		}
+
+		return tx.Model(&key).Updates(map[string]any{
+			"consecutive_successes": key.ConsecutiveSuccesses,
+			"consecutive_failures":  key.ConsecutiveFailures,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// resetValidationCounters returns a copy of the DB updates that also clears the consecutive
+// validation counters, so hysteresis starts over whenever a key changes status.
+func resetValidationCounters(updates map[string]any) map[string]any {
+	result := make(map[string]any, len(updates)+2)
+	for field, value := range updates {
+		result[field] = value
+	}
+	result["consecutive_failures"] = 0
+	result["consecutive_successes"] = 0
+	return result
+}
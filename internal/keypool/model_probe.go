@@ -0,0 +1,87 @@
+package keypool
+
+import (
+	"fmt"
+	"sync"
+
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+)
+
+// ModelProbeResult is the outcome of probing one key with one model.
+type ModelProbeResult struct {
+	Model     string `json:"model"`
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// KeyModelMatrixRow lists which of the probed models a key can access.
+type KeyModelMatrixRow struct {
+	KeyID     uint               `json:"key_id"`
+	MaskedKey string             `json:"masked_key"`
+	Results   []ModelProbeResult `json:"results"`
+}
+
+// DefaultProbeModels returns the models validation uses for the group: its test model followed by
+// its fallback test models.
+func DefaultProbeModels(group *models.Group) []string {
+	return validationTestModels(group)
+}
+
+// ProbeModels sends one validation request per key and model and returns the result matrix, with
+// rows in key order and results in model order. Key statuses are never changed, since a key lacking
+// access to one model says nothing about whether the key itself works.
+// Keys must hold decrypted values.
+func (s *KeyValidator) ProbeModels(group *models.Group, keys []models.APIKey, testModels []string) ([]KeyModelMatrixRow, error) {
+	if group.EffectiveConfig.AppUrl == "" {
+		group.EffectiveConfig = s.SettingsManager.GetEffectiveConfig(group.Config)
+	}
+
+	ch, err := s.channelFactory.GetChannel(group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel for group %s: %w", group.Name, err)
+	}
+
+	rows := make([]KeyModelMatrixRow, len(keys))
+	for i := range keys {
+		rows[i] = KeyModelMatrixRow{
+			KeyID:     keys[i].ID,
+			MaskedKey: utils.MaskAPIKey(keys[i].KeyValue),
+			Results:   make([]ModelProbeResult, len(testModels)),
+		}
+	}
+
+	type probeJob struct {
+		keyIndex   int
+		modelIndex int
+	}
+	jobs := make(chan probeJob)
+
+	var wg sync.WaitGroup
+	for range max(group.EffectiveConfig.KeyValidationConcurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				model := testModels[job.modelIndex]
+				available, probeErr := s.validateWithModel(ch, &keys[job.keyIndex], group, model)
+				result := ModelProbeResult{Model: model, Available: available}
+				if !available && probeErr != nil {
+					result.Error = probeErr.Error()
+				}
+				// Each job writes its own cell, so no locking is needed
+				rows[job.keyIndex].Results[job.modelIndex] = result
+			}
+		}()
+	}
+
+	for keyIndex := range keys {
+		for modelIndex := range testModels {
+			jobs <- probeJob{keyIndex: keyIndex, modelIndex: modelIndex}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return rows, nil
+}
@@ -6,6 +6,7 @@ import (
 	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
 	"aimanager/internal/store"
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -17,11 +18,21 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrKeyNotDisabled is returned by EnableKey for keys that were not manually disabled.
+var ErrKeyNotDisabled = errors.New("key is not disabled")
+
+// errKeyStatusUnchanged rolls back a guarded status change for a key already in the target status.
+var errKeyStatusUnchanged = errors.New("key status unchanged")
+
 type KeyProvider struct {
 	db              *gorm.DB
 	store           store.Store
 	settingsManager *config.SystemSettingsManager
 	encryptionSvc   encryption.Service
+	failureScores   *failureScoreTracker
+	warmups         *warmupTracker
+	tierCursors     tierCursors
+	tierCache       keyTierCache
 }
 
 // NewProvider 创建一个新的 KeyProvider 实例。
@@ -31,6 +42,8 @@ func NewProvider(db *gorm.DB, store store.Store, settingsManager *config.SystemS
 		store:           store,
 		settingsManager: settingsManager,
 		encryptionSvc:   encryptionSvc,
+		failureScores:   newFailureScoreTracker(),
+		warmups:         newWarmupTracker(),
 	}
 }
 
@@ -52,6 +65,11 @@ func (p *KeyProvider) SelectKey(groupID uint) (*models.APIKey, error) {
 		return nil, fmt.Errorf("failed to parse key ID '%s': %w", keyIDStr, err)
 	}
 
+	return p.loadKey(groupID, uint(keyID))
+}
+
+// loadKey 从 Store 中读取 Key 详情并解密。
+func (p *KeyProvider) loadKey(groupID uint, keyID uint) (*models.APIKey, error) {
 	// 2. Get key details from HASH
 	keyHashKey := fmt.Sprintf("key:%d", keyID)
 	keyDetails, err := p.store.HGetAll(keyHashKey)
@@ -76,7 +94,7 @@ func (p *KeyProvider) SelectKey(groupID uint) (*models.APIKey, error) {
 	}
 
 	apiKey := &models.APIKey{
-		ID:           uint(keyID),
+		ID:           keyID,
 		KeyValue:     decryptedKeyValue,
 		Status:       keyDetails["status"],
 		FailureCount: failureCount,
@@ -89,12 +107,18 @@ func (p *KeyProvider) SelectKey(groupID uint) (*models.APIKey, error) {
 
 // UpdateStatus 异步地提交一个 Key 状态更新任务。
 func (p *KeyProvider) UpdateStatus(apiKey *models.APIKey, group *models.Group, isSuccess bool, errorMessage string) {
+	if !isSuccess && !app_errors.IsUnCounted(errorMessage) {
+		// Set before returning, so the key is not selected again while the failure is being processed
+		p.startCooldown(group, apiKey.ID)
+	}
+
 	go func() {
 		keyHashKey := fmt.Sprintf("key:%d", apiKey.ID)
 		activeKeysListKey := fmt.Sprintf("group:%d:active_keys", group.ID)
 
 		if isSuccess {
-			if err := p.handleSuccess(apiKey.ID, keyHashKey, activeKeysListKey); err != nil {
+			p.warmups.recordSuccess(apiKey.ID)
+			if err := p.handleSuccess(group.ID, apiKey.ID, keyHashKey, activeKeysListKey); err != nil {
 				logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "error": err}).Error("Failed to handle key success")
 			}
 		} else {
@@ -104,7 +128,9 @@ func (p *KeyProvider) UpdateStatus(apiKey *models.APIKey, group *models.Group, i
 					"error": errorMessage,
 				}).Debug("Uncounted error, skipping failure handling")
 			} else {
-				if err := p.handleFailure(apiKey, group, keyHashKey, activeKeysListKey); err != nil {
+				p.failureScores.recordFailure(group.ID, apiKey.ID)
+				p.warmups.recordFailure(apiKey.ID)
+				if err := p.handleFailure(apiKey, keyHashKey, activeKeysListKey, blacklistPolicy(group.EffectiveConfig.BlacklistThreshold)); err != nil {
 					logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "error": err}).Error("Failed to handle key failure")
 				}
 			}
@@ -139,7 +165,7 @@ func (p *KeyProvider) executeTransactionWithRetry(operation func(tx *gorm.DB) er
 	return err
 }
 
-func (p *KeyProvider) handleSuccess(keyID uint, keyHashKey, activeKeysListKey string) error {
+func (p *KeyProvider) handleSuccess(groupID, keyID uint, keyHashKey, activeKeysListKey string) error {
 	keyDetails, err := p.store.HGetAll(keyHashKey)
 	if err != nil {
 		return fmt.Errorf("failed to get key details from store: %w", err)
@@ -148,7 +174,8 @@ func (p *KeyProvider) handleSuccess(keyID uint, keyHashKey, activeKeysListKey st
 	failureCount, _ := strconv.ParseInt(keyDetails["failure_count"], 10, 64)
 	isActive := keyDetails["status"] == models.KeyStatusActive
 
-	if failureCount == 0 && isActive {
+	// Manually disabled keys only come back through EnableKey
+	if (failureCount == 0 && isActive) || keyDetails["status"] == models.KeyStatusDisabled {
 		return nil
 	}
 
@@ -157,13 +184,18 @@ func (p *KeyProvider) handleSuccess(keyID uint, keyHashKey, activeKeysListKey st
 		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&key, keyID).Error; err != nil {
 			return fmt.Errorf("failed to lock key %d for update: %w", keyID, err)
 		}
+		if key.Status == models.KeyStatusDisabled {
+			return nil
+		}
 
 		updates := map[string]any{"failure_count": 0}
+		dbUpdates := map[string]any{"failure_count": 0}
 		if !isActive {
 			updates["status"] = models.KeyStatusActive
+			dbUpdates = resetValidationCounters(updates)
 		}
 
-		if err := tx.Model(&key).Updates(updates).Error; err != nil {
+		if err := tx.Model(&key).Updates(dbUpdates).Error; err != nil {
 			return fmt.Errorf("failed to update key in DB: %w", err)
 		}
 
@@ -179,39 +211,49 @@ func (p *KeyProvider) handleSuccess(keyID uint, keyHashKey, activeKeysListKey st
 			if err := p.store.LPush(activeKeysListKey, keyID); err != nil {
 				return fmt.Errorf("failed to LPush key back to active list: %w", err)
 			}
+			p.warmups.start(groupID, keyID)
 		}
 
 		return nil
 	})
 }
 
-func (p *KeyProvider) handleFailure(apiKey *models.APIKey, group *models.Group, keyHashKey, activeKeysListKey string) error {
+// blacklistPolicy disables a key once its failure count reaches the threshold; 0 never disables.
+func blacklistPolicy(threshold int) func(newFailureCount int64) bool {
+	return func(newFailureCount int64) bool {
+		return threshold > 0 && newFailureCount >= int64(threshold)
+	}
+}
+
+// handleFailure increments the key's failure count and disables it once shouldBlacklistAt returns true.
+func (p *KeyProvider) handleFailure(apiKey *models.APIKey, keyHashKey, activeKeysListKey string, shouldBlacklistAt func(newFailureCount int64) bool) error {
 	keyDetails, err := p.store.HGetAll(keyHashKey)
 	if err != nil {
 		return fmt.Errorf("failed to get key details from store: %w", err)
 	}
 
-	if keyDetails["status"] == models.KeyStatusInvalid {
+	if status := keyDetails["status"]; status == models.KeyStatusInvalid || status == models.KeyStatusDisabled {
 		return nil
 	}
 
 	failureCount, _ := strconv.ParseInt(keyDetails["failure_count"], 10, 64)
 
-	// 获取该分组的有效配置
-	blacklistThreshold := group.EffectiveConfig.BlacklistThreshold
-
 	return p.executeTransactionWithRetry(func(tx *gorm.DB) error {
 		var key models.APIKey
 		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&key, apiKey.ID).Error; err != nil {
 			return fmt.Errorf("failed to lock key %d for update: %w", apiKey.ID, err)
 		}
+		if key.Status == models.KeyStatusDisabled {
+			return nil
+		}
 
 		newFailureCount := failureCount + 1
 
 		updates := map[string]any{"failure_count": newFailureCount}
-		shouldBlacklist := blacklistThreshold > 0 && newFailureCount >= int64(blacklistThreshold)
+		shouldBlacklist := shouldBlacklistAt(newFailureCount)
 		if shouldBlacklist {
 			updates["status"] = models.KeyStatusInvalid
+			updates = resetValidationCounters(updates)
 		}
 
 		if err := tx.Model(&key).Updates(updates).Error; err != nil {
@@ -223,7 +265,7 @@ func (p *KeyProvider) handleFailure(apiKey *models.APIKey, group *models.Group,
 		}
 
 		if shouldBlacklist {
-			logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "threshold": blacklistThreshold}).Warn("Key has reached blacklist threshold, disabling.")
+			logrus.WithFields(logrus.Fields{"keyID": apiKey.ID, "failureCount": newFailureCount}).Warn("Key has reached blacklist threshold, disabling.")
 			if err := p.store.LRem(activeKeysListKey, 0, apiKey.ID); err != nil {
 				return fmt.Errorf("failed to LRem key from active list: %w", err)
 			}
@@ -242,10 +284,20 @@ func (p *KeyProvider) LoadKeysFromDB() error {
 
 	// 1. 分批从数据库加载并使用 Pipeline 写入 Redis
 	allActiveKeyIDs := make(map[uint][]any)
+	allKeyTiers := make(map[uint]map[string]any)
 	batchSize := 10000
 	var batchKeys []*models.APIKey
 
-	err := p.db.Model(&models.APIKey{}).FindInBatches(&batchKeys, batchSize, func(tx *gorm.DB, batch int) error {
+	// 跳过分组已不存在的孤立密钥，避免其进入任何分组的密钥池
+	var orphanedCount int64
+	if err := p.orphanedKeysQuery(p.db).Count(&orphanedCount).Error; err != nil {
+		return fmt.Errorf("failed to count orphaned keys: %w", err)
+	}
+	if orphanedCount > 0 {
+		logrus.WithField("count", orphanedCount).Warn("Found keys referencing non-existent groups, they are not loaded into the pool")
+	}
+
+	err := p.db.Model(&models.APIKey{}).Where("group_id IN (?)", p.db.Model(&models.Group{}).Select("id")).FindInBatches(&batchKeys, batchSize, func(tx *gorm.DB, batch int) error {
 		logrus.Debugf("Processing batch %d with %d keys...", batch, len(batchKeys))
 
 		var pipeline store.Pipeliner
@@ -268,6 +320,12 @@ func (p *KeyProvider) LoadKeysFromDB() error {
 			if key.Status == models.KeyStatusActive {
 				allActiveKeyIDs[key.GroupID] = append(allActiveKeyIDs[key.GroupID], key.ID)
 			}
+			if key.Tier != 0 {
+				if allKeyTiers[key.GroupID] == nil {
+					allKeyTiers[key.GroupID] = make(map[string]any)
+				}
+				allKeyTiers[key.GroupID][strconv.FormatUint(uint64(key.ID), 10)] = key.Tier
+			}
 		}
 
 		if pipeline != nil {
@@ -294,6 +352,15 @@ func (p *KeyProvider) LoadKeysFromDB() error {
 		}
 	}
 
+	// 3. 更新分组的密钥层级
+	for groupID, tiers := range allKeyTiers {
+		p.store.Delete(keyTiersKey(groupID))
+		if err := p.store.HSet(keyTiersKey(groupID), tiers); err != nil {
+			logrus.WithFields(logrus.Fields{"groupID": groupID, "error": err}).Error("Failed to HSet key tiers for group")
+		}
+		p.tierCache.invalidate(groupID)
+	}
+
 	return nil
 }
 
@@ -380,10 +447,10 @@ func (p *KeyProvider) RestoreKeys(groupID uint) (int64, error) {
 			return nil
 		}
 
-		updates := map[string]any{
+		updates := resetValidationCounters(map[string]any{
 			"status":        models.KeyStatusActive,
 			"failure_count": 0,
-		}
+		})
 		result := tx.Model(&models.APIKey{}).Where("group_id = ? AND status = ?", groupID, models.KeyStatusInvalid).Updates(updates)
 		if result.Error != nil {
 			return result.Error
@@ -397,6 +464,7 @@ func (p *KeyProvider) RestoreKeys(groupID uint) (int64, error) {
 				logrus.WithFields(logrus.Fields{"keyID": key.ID, "error": err}).Error("Failed to restore key in store after DB update, rolling back transaction")
 				return err
 			}
+			p.warmups.start(groupID, key.ID)
 		}
 		return nil
 	})
@@ -406,27 +474,37 @@ func (p *KeyProvider) RestoreKeys(groupID uint) (int64, error) {
 
 // RestoreMultipleKeys 恢复指定的 Key。
 func (p *KeyProvider) RestoreMultipleKeys(groupID uint, keyValues []string) (int64, error) {
-	if len(keyValues) == 0 {
+	var keyHashes []string
+	for _, keyValue := range keyValues {
+		keyHash := p.encryptionSvc.Hash(keyValue)
+		if keyHash != "" {
+			keyHashes = append(keyHashes, keyHash)
+		}
+	}
+
+	if len(keyHashes) == 0 {
 		return 0, nil
 	}
 
+	return p.restoreInvalidKeys(groupID, "key_hash IN ?", keyHashes)
+}
+
+// RestoreKeysByID 按 ID 恢复组内的无效 Key，其他状态的 Key 保持不变。
+func (p *KeyProvider) RestoreKeysByID(groupID uint, keyIDs []uint) (int64, error) {
+	if len(keyIDs) == 0 {
+		return 0, nil
+	}
+
+	return p.restoreInvalidKeys(groupID, "id IN ?", keyIDs)
+}
+
+// restoreInvalidKeys 将组内满足 condition 的无效 Key 恢复为活跃状态。
+func (p *KeyProvider) restoreInvalidKeys(groupID uint, condition string, value any) (int64, error) {
 	var keysToRestore []models.APIKey
 	var restoredCount int64
 
 	err := p.db.Transaction(func(tx *gorm.DB) error {
-		var keyHashes []string
-		for _, keyValue := range keyValues {
-			keyHash := p.encryptionSvc.Hash(keyValue)
-			if keyHash != "" {
-				keyHashes = append(keyHashes, keyHash)
-			}
-		}
-
-		if len(keyHashes) == 0 {
-			return nil
-		}
-
-		if err := tx.Where("group_id = ? AND key_hash IN ? AND status = ?", groupID, keyHashes, models.KeyStatusInvalid).Find(&keysToRestore).Error; err != nil {
+		if err := tx.Where("group_id = ? AND status = ?", groupID, models.KeyStatusInvalid).Where(condition, value).Find(&keysToRestore).Error; err != nil {
 			return err
 		}
 
@@ -436,10 +514,10 @@ func (p *KeyProvider) RestoreMultipleKeys(groupID uint, keyValues []string) (int
 
 		keyIDsToRestore := pluckIDs(keysToRestore)
 
-		updates := map[string]any{
+		updates := resetValidationCounters(map[string]any{
 			"status":        models.KeyStatusActive,
 			"failure_count": 0,
-		}
+		})
 		result := tx.Model(&models.APIKey{}).Where("id IN ?", keyIDsToRestore).Updates(updates)
 		if result.Error != nil {
 			return result.Error
@@ -453,6 +531,7 @@ func (p *KeyProvider) RestoreMultipleKeys(groupID uint, keyValues []string) (int
 				logrus.WithFields(logrus.Fields{"keyID": key.ID, "error": err}).Error("Failed to restore key in store after DB update")
 				return err
 			}
+			p.warmups.start(groupID, key.ID)
 		}
 
 		return nil
@@ -461,6 +540,89 @@ func (p *KeyProvider) RestoreMultipleKeys(groupID uint, keyValues []string) (int
 	return restoredCount, err
 }
 
+// SetKeyStatus 手动设置单个 Key 的状态，同时更新数据库和 Store 中的活跃列表。
+// 与自动验证不同，该操作不依赖失败计数，激活时会重置 failure_count。
+// 设置为 disabled 的 Key 不会被请求成功或定时验证重新激活，只能再次手动启用。
+func (p *KeyProvider) SetKeyStatus(ctx context.Context, keyID uint, status string) (*models.APIKey, error) {
+	return p.setKeyStatus(ctx, keyID, status, "")
+}
+
+// EnableKey 将手动禁用的 Key 重新设为 active。只允许 disabled -> active：
+// 已经是 active 的 Key 保持不变，invalid 的 Key 返回 ErrKeyNotDisabled，需通过验证或恢复操作处理。
+func (p *KeyProvider) EnableKey(ctx context.Context, keyID uint) (*models.APIKey, error) {
+	return p.setKeyStatus(ctx, keyID, models.KeyStatusActive, models.KeyStatusDisabled)
+}
+
+// setKeyStatus changes the key's status. With a non-empty fromStatus only keys in that status are
+// changed: keys already in the target status are returned as they are, others fail with ErrKeyNotDisabled.
+func (p *KeyProvider) setKeyStatus(ctx context.Context, keyID uint, status string, fromStatus string) (*models.APIKey, error) {
+	if status != models.KeyStatusActive && status != models.KeyStatusInvalid && status != models.KeyStatusDisabled {
+		return nil, fmt.Errorf("invalid key status: %s", status)
+	}
+
+	var key models.APIKey
+	var previousStatus string
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&key, keyID).Error; err != nil {
+			return err
+		}
+		previousStatus = key.Status
+		if fromStatus != "" && previousStatus != fromStatus {
+			if previousStatus == status {
+				return errKeyStatusUnchanged
+			}
+			return ErrKeyNotDisabled
+		}
+
+		updates := map[string]any{"status": status}
+		if status == models.KeyStatusActive {
+			updates["failure_count"] = 0
+		}
+		if status != previousStatus {
+			updates = resetValidationCounters(updates)
+		}
+		if err := tx.Model(&key).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update key status in DB: %w", err)
+		}
+		key.Status = status
+		if status == models.KeyStatusActive {
+			key.FailureCount = 0
+		}
+
+		if err := p.addKeyToStore(&key); err != nil {
+			return err
+		}
+		if status != models.KeyStatusActive {
+			activeKeysListKey := fmt.Sprintf("group:%d:active_keys", key.GroupID)
+			if err := p.store.LRem(activeKeysListKey, 0, key.ID); err != nil {
+				return fmt.Errorf("failed to LRem key %d from active list: %w", key.ID, err)
+			}
+			p.warmups.remove(key.ID)
+		} else if previousStatus != models.KeyStatusActive {
+			p.warmups.start(key.GroupID, key.ID)
+		}
+
+		return nil
+	})
+	if errors.Is(err, errKeyStatusUnchanged) {
+		return &key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"keyID":      key.ID,
+		"groupID":    key.GroupID,
+		"fromStatus": previousStatus,
+		"toStatus":   status,
+		"source":     "manual",
+	}).Info("Key status changed manually")
+
+	return &key, nil
+}
+
 // RemoveInvalidKeys 移除组内所有无效的 Key。
 func (p *KeyProvider) RemoveInvalidKeys(groupID uint) (int64, error) {
 	return p.removeKeysByStatus(groupID, models.KeyStatusInvalid)
@@ -513,6 +675,44 @@ func (p *KeyProvider) removeKeysByStatus(groupID uint, status ...string) (int64,
 	return removedCount, err
 }
 
+// orphanedKeysQuery 返回 group_id 没有对应分组的密钥查询
+func (p *KeyProvider) orphanedKeysQuery(tx *gorm.DB) *gorm.DB {
+	// 软删除分组的密钥保留在数据库中以便恢复，不视为孤立密钥
+	return tx.Model(&models.APIKey{}).Where("group_id NOT IN (?)", tx.Unscoped().Model(&models.Group{}).Select("id"))
+}
+
+// RemoveOrphanedKeys 从数据库和存储中删除 group_id 没有对应分组的密钥
+func (p *KeyProvider) RemoveOrphanedKeys() (int64, error) {
+	var keysToRemove []models.APIKey
+	var removedCount int64
+
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		if err := p.orphanedKeysQuery(tx).Select("id", "group_id").Find(&keysToRemove).Error; err != nil {
+			return err
+		}
+
+		if len(keysToRemove) == 0 {
+			return nil
+		}
+
+		result := tx.Where("id IN ?", pluckIDs(keysToRemove)).Delete(&models.APIKey{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removedCount = result.RowsAffected
+
+		for _, key := range keysToRemove {
+			if err := p.removeKeyFromStore(key.ID, key.GroupID); err != nil {
+				logrus.WithFields(logrus.Fields{"keyID": key.ID, "error": err}).Error("Failed to remove orphaned key from store after DB deletion, rolling back transaction")
+				return err
+			}
+		}
+		return nil
+	})
+
+	return removedCount, err
+}
+
 // RemoveKeysFromStore 直接从内存存储中移除指定的键，不涉及数据库操作
 // 这个方法适用于数据库已经删除但需要清理内存存储的场景
 func (p *KeyProvider) RemoveKeysFromStore(groupID uint, keyIDs []uint) error {
@@ -531,6 +731,14 @@ func (p *KeyProvider) RemoveKeysFromStore(groupID uint, keyIDs []uint) error {
 		return err
 	}
 
+	if err := p.store.Delete(keyTiersKey(groupID)); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"groupID": groupID,
+			"error":   err,
+		}).Error("Failed to delete key tiers")
+	}
+	p.tierCache.invalidate(groupID)
+
 	// 第二步：批量删除所有相关的key hash
 	for _, keyID := range keyIDs {
 		keyHashKey := fmt.Sprintf("key:%d", keyID)
@@ -550,6 +758,15 @@ func (p *KeyProvider) RemoveKeysFromStore(groupID uint, keyIDs []uint) error {
 	return nil
 }
 
+// LoadGroupKeysToStore 将分组在数据库中的全部密钥重新载入存储，用于恢复软删除的分组
+func (p *KeyProvider) LoadGroupKeysToStore(tx *gorm.DB, groupID uint) error {
+	var keys []models.APIKey
+	if err := tx.Where("group_id = ?", groupID).Find(&keys).Error; err != nil {
+		return fmt.Errorf("failed to load keys of group %d: %w", groupID, err)
+	}
+	return p.addKeysToCacheBatch(groupID, keys)
+}
+
 // addKeyToStore is a helper to add a single key to the cache.
 func (p *KeyProvider) addKeyToStore(key *models.APIKey) error {
 	// 1. Store key details in HASH
@@ -559,6 +776,10 @@ func (p *KeyProvider) addKeyToStore(key *models.APIKey) error {
 		return fmt.Errorf("failed to HSet key details for key %d: %w", key.ID, err)
 	}
 
+	if err := p.storeKeyTiers(key.GroupID, []models.APIKey{*key}); err != nil {
+		return err
+	}
+
 	// 2. If active, add to the active LIST
 	if key.Status == models.KeyStatusActive {
 		activeKeysListKey := fmt.Sprintf("group:%d:active_keys", key.GroupID)
@@ -599,11 +820,20 @@ func (p *KeyProvider) addKeysToCacheBatch(groupID uint, keys []models.APIKey) er
 		}
 	}
 
-	// 2. 收集所有密钥 ID
+	if err := p.storeKeyTiers(groupID, keys); err != nil {
+		return err
+	}
+
+	// 2. 收集活跃密钥 ID（导入时可指定初始状态为 invalid）
 	activeKeysListKey := fmt.Sprintf("group:%d:active_keys", groupID)
-	activeKeyIDs := make([]any, len(keys))
+	activeKeyIDs := make([]any, 0, len(keys))
 	for i := range keys {
-		activeKeyIDs[i] = keys[i].ID
+		if keys[i].Status == models.KeyStatusActive {
+			activeKeyIDs = append(activeKeyIDs, keys[i].ID)
+		}
+	}
+	if len(activeKeyIDs) == 0 {
+		return nil
 	}
 
 	// 3. 批量 LPush 活跃密钥
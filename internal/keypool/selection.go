@@ -0,0 +1,242 @@
+package keypool
+
+import (
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Key selection modes.
+const (
+	KeySelectionRoundRobin    = "round_robin"
+	KeySelectionLeastFailures = "least_failures"
+)
+
+const (
+	// failureScoreHalfLife controls how fast recent failures are forgotten.
+	failureScoreHalfLife = 10 * time.Minute
+	// leastFailuresSampleSize is how many rotated candidates are compared per selection.
+	leastFailuresSampleSize = 3
+	// minFailureScore drops entries that have decayed to noise.
+	minFailureScore = 0.01
+)
+
+// KeyFailureScore is the decayed recent-failure score of a key, exposed for diagnostics.
+type KeyFailureScore struct {
+	KeyID     uint      `json:"key_id"`
+	Score     float64   `json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type failureScore struct {
+	groupID   uint
+	value     float64
+	updatedAt time.Time
+}
+
+// failureScoreTracker keeps an exponentially decaying failure count per key in memory.
+// Scores are local to this instance and reset on restart.
+type failureScoreTracker struct {
+	mu     sync.Mutex
+	scores map[uint]*failureScore
+}
+
+func newFailureScoreTracker() *failureScoreTracker {
+	return &failureScoreTracker{scores: make(map[uint]*failureScore)}
+}
+
+func decayScore(value float64, elapsed time.Duration) float64 {
+	return value * math.Pow(0.5, elapsed.Seconds()/failureScoreHalfLife.Seconds())
+}
+
+func (t *failureScoreTracker) recordFailure(groupID, keyID uint) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.scores[keyID]
+	if !ok {
+		t.scores[keyID] = &failureScore{groupID: groupID, value: 1, updatedAt: now}
+		return
+	}
+	entry.value = decayScore(entry.value, now.Sub(entry.updatedAt)) + 1
+	entry.groupID = groupID
+	entry.updatedAt = now
+}
+
+func (t *failureScoreTracker) score(keyID uint) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.scores[keyID]
+	if !ok {
+		return 0
+	}
+	value := decayScore(entry.value, time.Since(entry.updatedAt))
+	if value < minFailureScore {
+		delete(t.scores, keyID)
+		return 0
+	}
+	return value
+}
+
+func (t *failureScoreTracker) groupScores(groupID uint) []KeyFailureScore {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var result []KeyFailureScore
+	for keyID, entry := range t.scores {
+		if entry.groupID != groupID {
+			continue
+		}
+		value := decayScore(entry.value, now.Sub(entry.updatedAt))
+		if value < minFailureScore {
+			delete(t.scores, keyID)
+			continue
+		}
+		result = append(result, KeyFailureScore{
+			KeyID:     keyID,
+			Score:     math.Round(value*1000) / 1000,
+			UpdatedAt: entry.updatedAt,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	return result
+}
+
+// SelectKeyForGroup selects a key using the group's effective selection mode. Keys that failed within
+// the group's key_cooldown_seconds are skipped, see selectCooledDownKey. Groups whose keys are split
+// into tiers use the lowest tier first, see selectTieredKey.
+func (p *KeyProvider) SelectKeyForGroup(group *models.Group) (*models.APIKey, error) {
+	tiers, err := p.groupKeyTiers(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiers) > 0 {
+		return p.selectTieredKey(group, tiers)
+	}
+
+	if keyCooldown(group) > 0 {
+		return p.selectCooledDownKey(group)
+	}
+	return p.selectWarmKey(group)
+}
+
+// selectWarmKey selects a key using the group's effective selection mode. Keys that are
+// still warming up after reactivation are skipped with a probability that shrinks over the
+// warm-up window; if every candidate is skipped the last one is used anyway.
+func (p *KeyProvider) selectWarmKey(group *models.Group) (*models.APIKey, error) {
+	cfg := group.EffectiveConfig
+	window := time.Duration(cfg.KeyWarmupMinutes) * time.Minute
+	if window <= 0 || p.warmups.empty() {
+		return p.selectKeyWithMode(group.ID, cfg.KeySelectionMode)
+	}
+
+	var last *models.APIKey
+	for range warmupMaxAttempts {
+		apiKey, err := p.selectKeyWithMode(group.ID, cfg.KeySelectionMode)
+		if err != nil {
+			if last != nil {
+				break
+			}
+			return nil, err
+		}
+		if last != nil && apiKey.ID == last.ID {
+			// Only one active key left
+			break
+		}
+		if p.warmups.admit(apiKey.ID, window) {
+			return apiKey, nil
+		}
+		last = apiKey
+	}
+
+	return last, nil
+}
+
+// SelectKeyForSeed deterministically selects an active key for debugging. The same seed and attempt
+// pick the same key as long as the group's active keys do not change, and successive attempts walk
+// through the keys in ID order so retries still move on to another key. Rotation and warm-up state
+// are left untouched.
+func (p *KeyProvider) SelectKeyForSeed(groupID uint, seed uint64, attempt int) (*models.APIKey, error) {
+	activeKeysListKey := fmt.Sprintf("group:%d:active_keys", groupID)
+	keyIDStrs, err := p.store.LRange(activeKeysListKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active keys from store: %w", err)
+	}
+
+	keyIDs := make([]uint64, 0, len(keyIDStrs))
+	for _, keyIDStr := range keyIDStrs {
+		keyID, err := strconv.ParseUint(keyIDStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key ID '%s': %w", keyIDStr, err)
+		}
+		keyIDs = append(keyIDs, keyID)
+	}
+	if len(keyIDs) == 0 {
+		return nil, app_errors.ErrNoActiveKeys
+	}
+
+	// The list order changes with every rotation, so sort it to make the choice depend on the seed only
+	slices.Sort(keyIDs)
+	keyID := keyIDs[(seed+uint64(attempt))%uint64(len(keyIDs))]
+	return p.loadKey(groupID, uint(keyID))
+}
+
+// selectKeyWithMode selects a key for the group using the given selection mode.
+// Unknown modes fall back to round robin.
+func (p *KeyProvider) selectKeyWithMode(groupID uint, mode string) (*models.APIKey, error) {
+	if mode != KeySelectionLeastFailures {
+		return p.SelectKey(groupID)
+	}
+	return p.selectLeastFailuresKey(groupID)
+}
+
+// selectLeastFailuresKey rotates a few candidates off the active list and returns the one
+// with the lowest recent-failure score. Rotation keeps the list fair for the other modes.
+func (p *KeyProvider) selectLeastFailuresKey(groupID uint) (*models.APIKey, error) {
+	var best *models.APIKey
+	bestScore := math.MaxFloat64
+	seen := make(map[uint]bool, leastFailuresSampleSize)
+
+	for range leastFailuresSampleSize {
+		candidate, err := p.SelectKey(groupID)
+		if err != nil {
+			if best != nil {
+				break
+			}
+			return nil, err
+		}
+		if seen[candidate.ID] {
+			// The active list is shorter than the sample size
+			break
+		}
+		seen[candidate.ID] = true
+
+		score := p.failureScores.score(candidate.ID)
+		if score < bestScore {
+			best = candidate
+			bestScore = score
+		}
+		if score == 0 {
+			break
+		}
+	}
+
+	return best, nil
+}
+
+// GetFailureScores returns the current decayed failure scores of a group's keys, highest first.
+func (p *KeyProvider) GetFailureScores(groupID uint) []KeyFailureScore {
+	return p.failureScores.groupScores(groupID)
+}
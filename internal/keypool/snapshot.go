@@ -0,0 +1,256 @@
+package keypool
+
+import (
+	"aimanager/internal/models"
+	"aimanager/internal/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// keyPoolSnapshotKey is where SaveState persists the snapshot in the store.
+	keyPoolSnapshotKey = "keypool:snapshot"
+	// keyPoolSnapshotMaxAge is how long a saved snapshot is considered fresh enough to restore.
+	keyPoolSnapshotMaxAge  = 24 * time.Hour
+	keyPoolSnapshotVersion = 1
+)
+
+// KeyPoolSnapshot captures the selection state of the key pool: the rotation order of each
+// group's active keys plus the in-memory failure scores and warm-up windows.
+type KeyPoolSnapshot struct {
+	Version       int                    `json:"version"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Groups        []GroupPoolSnapshot    `json:"groups"`
+	FailureScores []FailureScoreSnapshot `json:"failure_scores"`
+	Warmups       []WarmupSnapshot       `json:"warmups"`
+}
+
+// GroupPoolSnapshot is the active key list of a group, head first. The tail is selected next.
+type GroupPoolSnapshot struct {
+	GroupID      uint   `json:"group_id"`
+	ActiveKeyIDs []uint `json:"active_key_ids"`
+}
+
+// FailureScoreSnapshot is a persisted failure score entry.
+type FailureScoreSnapshot struct {
+	KeyID     uint      `json:"key_id"`
+	GroupID   uint      `json:"group_id"`
+	Score     float64   `json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WarmupSnapshot is a persisted warm-up entry.
+type WarmupSnapshot struct {
+	KeyID     uint      `json:"key_id"`
+	GroupID   uint      `json:"group_id"`
+	StartedAt time.Time `json:"started_at"`
+	Successes int       `json:"successes"`
+	Failures  int       `json:"failures"`
+}
+
+// KeyPoolRestoreResult summarizes what ImportState applied.
+type KeyPoolRestoreResult struct {
+	GroupsRestored        int `json:"groups_restored"`
+	FailureScoresRestored int `json:"failure_scores_restored"`
+	WarmupsRestored       int `json:"warmups_restored"`
+}
+
+func (t *failureScoreTracker) snapshot() []FailureScoreSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]FailureScoreSnapshot, 0, len(t.scores))
+	for keyID, entry := range t.scores {
+		result = append(result, FailureScoreSnapshot{
+			KeyID:     keyID,
+			GroupID:   entry.groupID,
+			Score:     entry.value,
+			UpdatedAt: entry.updatedAt,
+		})
+	}
+	return result
+}
+
+func (t *failureScoreTracker) restore(entries []FailureScoreSnapshot, validKeys map[uint]uint) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	restored := 0
+	for _, entry := range entries {
+		if groupID, ok := validKeys[entry.KeyID]; !ok || groupID != entry.GroupID {
+			continue
+		}
+		t.scores[entry.KeyID] = &failureScore{groupID: entry.GroupID, value: entry.Score, updatedAt: entry.UpdatedAt}
+		restored++
+	}
+	return restored
+}
+
+func (t *warmupTracker) snapshot() []WarmupSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]WarmupSnapshot, 0, len(t.entries))
+	for keyID, entry := range t.entries {
+		result = append(result, WarmupSnapshot{
+			KeyID:     keyID,
+			GroupID:   entry.groupID,
+			StartedAt: entry.startedAt,
+			Successes: entry.successes,
+			Failures:  entry.failures,
+		})
+	}
+	return result
+}
+
+func (t *warmupTracker) restore(entries []WarmupSnapshot, validKeys map[uint]uint) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	restored := 0
+	for _, entry := range entries {
+		if groupID, ok := validKeys[entry.KeyID]; !ok || groupID != entry.GroupID {
+			continue
+		}
+		t.entries[entry.KeyID] = &keyWarmup{
+			groupID:   entry.GroupID,
+			startedAt: entry.StartedAt,
+			successes: entry.Successes,
+			failures:  entry.Failures,
+		}
+		restored++
+	}
+	return restored
+}
+
+// ExportState captures the current key pool selection state.
+func (p *KeyProvider) ExportState() (*KeyPoolSnapshot, error) {
+	var groupIDs []uint
+	if err := p.db.Model(&models.Group{}).Pluck("id", &groupIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	snapshot := &KeyPoolSnapshot{
+		Version:       keyPoolSnapshotVersion,
+		CreatedAt:     time.Now(),
+		Groups:        make([]GroupPoolSnapshot, 0, len(groupIDs)),
+		FailureScores: p.failureScores.snapshot(),
+		Warmups:       p.warmups.snapshot(),
+	}
+
+	for _, groupID := range groupIDs {
+		ids, err := p.activeKeyIDs(groupID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		snapshot.Groups = append(snapshot.Groups, GroupPoolSnapshot{GroupID: groupID, ActiveKeyIDs: ids})
+	}
+
+	return snapshot, nil
+}
+
+// ImportState applies a snapshot on top of the current pool. Only keys that are still active are
+// reordered; keys activated since the snapshot keep their place at the head of the list, and
+// failure scores or warm-ups of keys that no longer exist in the same group are dropped.
+// The reorder runs atomically in the store, so keys blacklisted or added while it runs keep
+// their state.
+func (p *KeyProvider) ImportState(snapshot *KeyPoolSnapshot) (*KeyPoolRestoreResult, error) {
+	if snapshot.Version != keyPoolSnapshotVersion {
+		return nil, fmt.Errorf("unsupported key pool snapshot version %d", snapshot.Version)
+	}
+
+	result := &KeyPoolRestoreResult{}
+	validKeys := make(map[uint]uint)
+
+	for _, group := range snapshot.Groups {
+		activeKeysListKey := fmt.Sprintf("group:%d:active_keys", group.GroupID)
+		order := make([]string, len(group.ActiveKeyIDs))
+		for i, id := range group.ActiveKeyIDs {
+			order[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		if err := p.store.LReorder(activeKeysListKey, order); err != nil {
+			return nil, fmt.Errorf("failed to restore active keys of group %d: %w", group.GroupID, err)
+		}
+
+		current, err := p.activeKeyIDs(group.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if len(current) == 0 {
+			continue
+		}
+		for _, id := range current {
+			validKeys[id] = group.GroupID
+		}
+		result.GroupsRestored++
+	}
+
+	result.FailureScoresRestored = p.failureScores.restore(snapshot.FailureScores, validKeys)
+	result.WarmupsRestored = p.warmups.restore(snapshot.Warmups, validKeys)
+
+	return result, nil
+}
+
+// SaveState persists the current snapshot in the store so the next master startup can restore it.
+func (p *KeyProvider) SaveState() error {
+	snapshot, err := p.ExportState()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to serialize key pool snapshot: %w", err)
+	}
+
+	return p.store.Set(keyPoolSnapshotKey, data, keyPoolSnapshotMaxAge)
+}
+
+// ReadSavedState returns the snapshot saved by SaveState, or nil when there is none or it is too old.
+// It must be read before the store is cleared at startup.
+func (p *KeyProvider) ReadSavedState() *KeyPoolSnapshot {
+	data, err := p.store.Get(keyPoolSnapshotKey)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			logrus.WithError(err).Warn("Failed to read saved key pool snapshot")
+		}
+		return nil
+	}
+
+	var snapshot KeyPoolSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		logrus.WithError(err).Warn("Discarding unreadable key pool snapshot")
+		return nil
+	}
+	if time.Since(snapshot.CreatedAt) > keyPoolSnapshotMaxAge {
+		return nil
+	}
+
+	return &snapshot
+}
+
+func (p *KeyProvider) activeKeyIDs(groupID uint) ([]uint, error) {
+	activeKeysListKey := fmt.Sprintf("group:%d:active_keys", groupID)
+	values, err := p.store.LRange(activeKeysListKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read active keys of group %d: %w", groupID, err)
+	}
+
+	ids := make([]uint, 0, len(values))
+	for _, value := range values {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,279 @@
+package keypool
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// keyTiersKey holds the tiers of a group's keys in the store, by key ID. Keys of tier 0 are only
+// listed once their tier was changed, so groups that never used tiers have no entry at all.
+func keyTiersKey(groupID uint) string {
+	return fmt.Sprintf("group:%d:key_tiers", groupID)
+}
+
+const (
+	// keyTiersCacheTTL bounds how long a tier change made on another node takes to apply here.
+	keyTiersCacheTTL = 10 * time.Second
+	// tieredActiveKeysCacheTTL bounds how long a key that became active again waits to be used by
+	// tiered selection. Keys that became inactive are noticed when they are selected.
+	tieredActiveKeysCacheTTL = time.Second
+)
+
+// keyTiersEntry caches the tiers of a group's keys, nil when every key is in tier 0.
+type keyTiersEntry struct {
+	tiers    map[uint]int
+	loadedAt time.Time
+}
+
+// tieredActiveKeys caches a tiered group's active keys split by tier, each tier sorted by key ID.
+type tieredActiveKeys struct {
+	order    []int
+	byTier   map[int][]uint
+	loadedAt time.Time
+}
+
+// keyTierCache keeps tier membership and the tiered active keys per group in memory, so selection
+// does not read them from the store on every request. It is invalidated on tier changes made on this
+// node; changes made elsewhere apply once the entries expire.
+type keyTierCache struct {
+	tiers  sync.Map // groupID -> *keyTiersEntry
+	active sync.Map // groupID -> *tieredActiveKeys
+}
+
+func (c *keyTierCache) invalidate(groupID uint) {
+	c.tiers.Delete(groupID)
+	c.active.Delete(groupID)
+}
+
+// tierCursors keeps the round-robin position within each tier of each group in memory.
+type tierCursors struct {
+	cursors sync.Map // "groupID:tier" -> *atomic.Uint64
+}
+
+func (c *tierCursors) next(groupID uint, tier int) uint64 {
+	value, _ := c.cursors.LoadOrStore(fmt.Sprintf("%d:%d", groupID, tier), new(atomic.Uint64))
+	return value.(*atomic.Uint64).Add(1) - 1
+}
+
+// groupKeyTiers returns the tiers of the group's keys by key ID, or nil if every key is in tier 0.
+func (p *KeyProvider) groupKeyTiers(groupID uint) (map[uint]int, error) {
+	if value, ok := p.tierCache.tiers.Load(groupID); ok {
+		if entry := value.(*keyTiersEntry); time.Since(entry.loadedAt) < keyTiersCacheTTL {
+			return entry.tiers, nil
+		}
+	}
+
+	tiers, err := p.loadKeyTiers(groupID)
+	if err != nil {
+		return nil, err
+	}
+	p.tierCache.tiers.Store(groupID, &keyTiersEntry{tiers: tiers, loadedAt: time.Now()})
+	return tiers, nil
+}
+
+func (p *KeyProvider) loadKeyTiers(groupID uint) (map[uint]int, error) {
+	fields, err := p.store.HGetAll(keyTiersKey(groupID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key tiers of group %d: %w", groupID, err)
+	}
+
+	var tiers map[uint]int
+	for field, value := range fields {
+		tier, err := strconv.Atoi(value)
+		if err != nil || tier == 0 {
+			continue
+		}
+		keyID, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		if tiers == nil {
+			tiers = make(map[uint]int)
+		}
+		tiers[uint(keyID)] = tier
+	}
+	return tiers, nil
+}
+
+// tieredActiveKeys returns the group's active keys split by tier, from the cache while it is fresh.
+func (p *KeyProvider) tieredActiveKeys(groupID uint, tiers map[uint]int) (*tieredActiveKeys, error) {
+	if value, ok := p.tierCache.active.Load(groupID); ok {
+		if entry := value.(*tieredActiveKeys); time.Since(entry.loadedAt) < tieredActiveKeysCacheTTL {
+			return entry, nil
+		}
+	}
+
+	ids, err := p.activeKeyIDs(groupID)
+	if err != nil {
+		return nil, err
+	}
+	entry := &tieredActiveKeys{byTier: make(map[int][]uint), loadedAt: time.Now()}
+	for _, id := range ids {
+		entry.byTier[tiers[id]] = append(entry.byTier[tiers[id]], id)
+	}
+	for tier, keyIDs := range entry.byTier {
+		// The list order changes with every rotation, so sort it to make the turns stable
+		slices.Sort(keyIDs)
+		entry.order = append(entry.order, tier)
+	}
+	slices.Sort(entry.order)
+	p.tierCache.active.Store(groupID, entry)
+	return entry, nil
+}
+
+// selectTieredKey selects a key from the lowest tier that has an active key outside its cooldown,
+// so higher tiers only serve traffic once every key of the lower ones is invalid or cooling down.
+// Within a tier keys are used in turn, honouring the selection mode and warm-up like the untiered
+// selection. If every active key is cooling down, or cooldownMaxAttempts of them were checked without
+// finding a usable one, the one that failed longest ago is used. A key that
+// turns out to be no longer active refreshes the cached active keys and the selection is retried once.
+func (p *KeyProvider) selectTieredKey(group *models.Group, tiers map[uint]int) (*models.APIKey, error) {
+	apiKey, err := p.selectTieredKeyOnce(group, tiers)
+	if err != nil || apiKey.Status == "" || apiKey.Status == models.KeyStatusActive {
+		return apiKey, err
+	}
+	p.tierCache.active.Delete(group.ID)
+	return p.selectTieredKeyOnce(group, tiers)
+}
+
+func (p *KeyProvider) selectTieredKeyOnce(group *models.Group, tiers map[uint]int) (*models.APIKey, error) {
+	active, err := p.tieredActiveKeys(group.ID, tiers)
+	if err != nil {
+		return nil, err
+	}
+	if len(active.order) == 0 {
+		return nil, app_errors.ErrNoActiveKeys
+	}
+	byTier, order := active.byTier, active.order
+
+	cfg := group.EffectiveConfig
+	cooldown := keyCooldown(group) > 0
+	window := time.Duration(cfg.KeyWarmupMinutes) * time.Minute
+	sampleSize := 1
+	if cfg.KeySelectionMode == KeySelectionLeastFailures || window > 0 {
+		sampleSize = leastFailuresSampleSize
+	}
+
+	var fallback uint
+	var fallbackFailedAt time.Time
+	checked := 0
+	for _, tier := range order {
+		candidates := byTier[tier]
+		start := p.tierCursors.next(group.ID, tier)
+
+		usable := make([]uint, 0, sampleSize)
+		for i := range candidates {
+			keyID := candidates[(start+uint64(i))%uint64(len(candidates))]
+			if cooldown {
+				if checked == cooldownMaxAttempts {
+					break
+				}
+				checked++
+				if failedAt, cooling := p.cooldownFailedAt(keyID); cooling {
+					if fallback == 0 || failedAt.Before(fallbackFailedAt) {
+						fallback, fallbackFailedAt = keyID, failedAt
+					}
+					continue
+				}
+			}
+			usable = append(usable, keyID)
+			if len(usable) == sampleSize {
+				break
+			}
+		}
+		if len(usable) == 0 {
+			if checked == cooldownMaxAttempts {
+				break
+			}
+			continue
+		}
+
+		if cfg.KeySelectionMode == KeySelectionLeastFailures {
+			slices.SortStableFunc(usable, func(a, b uint) int {
+				return cmpScore(p.failureScores.score(a), p.failureScores.score(b))
+			})
+		}
+		chosen := usable[0]
+		if window > 0 && !p.warmups.empty() {
+			for _, keyID := range usable {
+				if p.warmups.admit(keyID, window) {
+					chosen = keyID
+					break
+				}
+			}
+		}
+		return p.loadKey(group.ID, chosen)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group":    group.Name,
+		"keyID":    fallback,
+		"failedAt": fallbackFailedAt,
+	}).Debug("All checked tiered keys are cooling down, using the least recently failed one")
+	return p.loadKey(group.ID, fallback)
+}
+
+func cmpScore(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SetKeyTier changes the tier of a single key in the database and the store.
+func (p *KeyProvider) SetKeyTier(ctx context.Context, keyID uint, tier int) (*models.APIKey, error) {
+	var key models.APIKey
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&key, keyID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&key).Update("tier", tier).Error; err != nil {
+			return fmt.Errorf("failed to update key tier in DB: %w", err)
+		}
+		key.Tier = tier
+
+		// Written even for tier 0, to replace the key's previous tier
+		if err := p.store.HSet(keyTiersKey(key.GroupID), map[string]any{strconv.FormatUint(uint64(key.ID), 10): tier}); err != nil {
+			return fmt.Errorf("failed to update key tier in store: %w", err)
+		}
+		p.tierCache.invalidate(key.GroupID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// storeKeyTiers records the tiers of keys outside tier 0 in the store.
+func (p *KeyProvider) storeKeyTiers(groupID uint, keys []models.APIKey) error {
+	values := make(map[string]any)
+	for i := range keys {
+		if keys[i].Tier != 0 {
+			values[strconv.FormatUint(uint64(keys[i].ID), 10)] = keys[i].Tier
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if err := p.store.HSet(keyTiersKey(groupID), values); err != nil {
+		return fmt.Errorf("failed to store key tiers of group %d: %w", groupID, err)
+	}
+	p.tierCache.invalidate(groupID)
+	return nil
+}
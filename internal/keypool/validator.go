@@ -7,6 +7,7 @@ import (
 	"aimanager/internal/models"
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -18,6 +19,7 @@ import (
 type KeyTestResult struct {
 	KeyValue string `json:"key_value"`
 	IsValid  bool   `json:"is_valid"`
+	Model    string `json:"model,omitempty"`
 	Error    string `json:"error,omitempty"`
 }
 
@@ -52,40 +54,94 @@ func NewKeyValidator(params KeyValidatorParams) *KeyValidator {
 
 // ValidateSingleKey performs a validation check on a single API key.
 func (s *KeyValidator) ValidateSingleKey(key *models.APIKey, group *models.Group) (bool, error) {
+	isValid, _, err := s.ValidateSingleKeyWithModel(key, group)
+	return isValid, err
+}
+
+// ValidateSingleKeyWithModel validates a key against the group's test model, then its
+// fallback_test_models in order, and returns the model that succeeded.
+func (s *KeyValidator) ValidateSingleKeyWithModel(key *models.APIKey, group *models.Group) (bool, string, error) {
 	if group.EffectiveConfig.AppUrl == "" {
 		group.EffectiveConfig = s.SettingsManager.GetEffectiveConfig(group.Config)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(group.EffectiveConfig.KeyValidationTimeoutSeconds)*time.Second)
-	defer cancel()
 
 	ch, err := s.channelFactory.GetChannel(group)
 	if err != nil {
-		return false, fmt.Errorf("failed to get channel for group %s: %w", group.Name, err)
+		return false, "", fmt.Errorf("failed to get channel for group %s: %w", group.Name, err)
 	}
 
-	isValid, validationErr := ch.ValidateKey(ctx, key, group)
+	var (
+		isValid       bool
+		validModel    string
+		validationErr error
+	)
+	testModels := validationTestModels(group)
+	for _, testModel := range testModels {
+		isValid, validationErr = s.validateWithModel(ch, key, group, testModel)
+		if isValid {
+			validModel = testModel
+			break
+		}
+	}
 
 	var errorMsg string
 	if !isValid && validationErr != nil {
 		errorMsg = validationErr.Error()
 	}
-	s.keypoolProvider.UpdateStatus(key, group, isValid, errorMsg)
+	s.keypoolProvider.UpdateValidationStatus(key, group, isValid, errorMsg)
 
 	if !isValid {
 		logrus.WithFields(logrus.Fields{
-			"error":    validationErr,
-			"key_id":   key.ID,
-			"group_id": group.ID,
+			"error":        validationErr,
+			"key_id":       key.ID,
+			"group_id":     group.ID,
+			"models_tried": len(testModels),
 		}).Debug("Key validation failed")
-		return false, validationErr
+		return false, "", validationErr
+	}
+
+	if validModel != group.TestModel {
+		logrus.WithFields(logrus.Fields{
+			"key_id":     key.ID,
+			"group_name": group.Name,
+			"test_model": group.TestModel,
+			"used_model": validModel,
+		}).Info("Key validated with a fallback test model, the primary test model may be deprecated")
 	}
 
 	logrus.WithFields(logrus.Fields{
 		"key_id":   key.ID,
 		"is_valid": isValid,
+		"model":    validModel,
 	}).Debug("Key validation successful")
 
-	return true, nil
+	return true, validModel, nil
+}
+
+// validateWithModel runs a single validation request with its own timeout.
+func (s *KeyValidator) validateWithModel(ch channel.ChannelProxy, key *models.APIKey, group *models.Group, testModel string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(group.EffectiveConfig.KeyValidationTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	return ch.ValidateKey(ctx, key, group, testModel)
+}
+
+// validationTestModels returns the group's test model followed by its distinct fallback models.
+// The raw config is read because groups loaded straight from the database have no ParsedConfig.
+func validationTestModels(group *models.Group) []string {
+	testModels := []string{group.TestModel}
+	seen := map[string]bool{group.TestModel: true}
+	fallbacks, _ := group.Config["fallback_test_models"].([]any)
+	for _, item := range fallbacks {
+		model, ok := item.(string)
+		model = strings.TrimSpace(model)
+		if !ok || model == "" || seen[model] {
+			continue
+		}
+		seen[model] = true
+		testModels = append(testModels, model)
+	}
+	return testModels
 }
 
 // TestMultipleKeys performs a synchronous validation for a list of key values within a specific group.
@@ -130,11 +186,12 @@ func (s *KeyValidator) TestMultipleKeys(group *models.Group, keyValues []string)
 
 		apiKey.KeyValue = kv
 
-		isValid, validationErr := s.ValidateSingleKey(&apiKey, group)
+		isValid, validModel, validationErr := s.ValidateSingleKeyWithModel(&apiKey, group)
 
 		results[i] = KeyTestResult{
 			KeyValue: kv,
 			IsValid:  isValid,
+			Model:    validModel,
 			Error:    "",
 		}
 		if validationErr != nil {
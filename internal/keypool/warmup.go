@@ -0,0 +1,149 @@
+package keypool
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// warmupMinProbability is the selection probability of a key that has just been reactivated.
+	warmupMinProbability = 0.1
+	// warmupMaxAttempts bounds how many rotated candidates a selection may skip because of warm-up.
+	warmupMaxAttempts = 5
+	// warmupStaleAfter drops warm-up entries of groups that never consume them (warm-up disabled).
+	warmupStaleAfter = 24 * time.Hour
+)
+
+// KeyWarmupState describes a reactivated key that is still ramping up, exposed for diagnostics.
+type KeyWarmupState struct {
+	KeyID                uint      `json:"key_id"`
+	StartedAt            time.Time `json:"started_at"`
+	Successes            int       `json:"successes"`
+	Failures             int       `json:"failures"`
+	Progress             float64   `json:"progress"`
+	SelectionProbability float64   `json:"selection_probability"`
+}
+
+type keyWarmup struct {
+	groupID   uint
+	startedAt time.Time
+	successes int
+	failures  int
+}
+
+// warmupTracker keeps the reactivation time of keys in memory so that their traffic can be
+// ramped up over the warm-up window. A failure during warm-up restarts the ramp.
+type warmupTracker struct {
+	mu      sync.Mutex
+	entries map[uint]*keyWarmup
+}
+
+func newWarmupTracker() *warmupTracker {
+	return &warmupTracker{entries: make(map[uint]*keyWarmup)}
+}
+
+// warmupProbability ramps linearly from warmupMinProbability to 1 over the window.
+func warmupProbability(elapsed, window time.Duration) float64 {
+	progress := float64(elapsed) / float64(window)
+	return warmupMinProbability + (1-warmupMinProbability)*progress
+}
+
+func (t *warmupTracker) start(groupID, keyID uint) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, entry := range t.entries {
+		if now.Sub(entry.startedAt) > warmupStaleAfter {
+			delete(t.entries, id)
+		}
+	}
+	t.entries[keyID] = &keyWarmup{groupID: groupID, startedAt: now}
+}
+
+func (t *warmupTracker) recordSuccess(keyID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry, ok := t.entries[keyID]; ok {
+		entry.successes++
+	}
+}
+
+func (t *warmupTracker) recordFailure(keyID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry, ok := t.entries[keyID]; ok {
+		entry.failures++
+		entry.startedAt = time.Now()
+	}
+}
+
+func (t *warmupTracker) remove(keyID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.entries, keyID)
+}
+
+func (t *warmupTracker) empty() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.entries) == 0
+}
+
+// admit decides whether a selected key may serve the request given its warm-up progress.
+func (t *warmupTracker) admit(keyID uint, window time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[keyID]
+	if !ok {
+		return true
+	}
+	elapsed := time.Since(entry.startedAt)
+	if elapsed >= window {
+		delete(t.entries, keyID)
+		return true
+	}
+	return rand.Float64() < warmupProbability(elapsed, window)
+}
+
+func (t *warmupTracker) groupStates(groupID uint, window time.Duration) []KeyWarmupState {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var result []KeyWarmupState
+	for keyID, entry := range t.entries {
+		if entry.groupID != groupID {
+			continue
+		}
+		elapsed := now.Sub(entry.startedAt)
+		if window <= 0 || elapsed >= window {
+			continue
+		}
+		result = append(result, KeyWarmupState{
+			KeyID:                keyID,
+			StartedAt:            entry.startedAt,
+			Successes:            entry.successes,
+			Failures:             entry.failures,
+			Progress:             float64(elapsed) / float64(window),
+			SelectionProbability: warmupProbability(elapsed, window),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+	return result
+}
+
+// GetWarmupStates returns the keys of a group that are still warming up after reactivation.
+func (p *KeyProvider) GetWarmupStates(groupID uint, window time.Duration) []KeyWarmupState {
+	return p.warmups.groupStates(groupID, window)
+}
@@ -8,9 +8,11 @@ import (
 	"time"
 
 	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
 	"aimanager/internal/response"
 	"aimanager/internal/services"
 	"aimanager/internal/types"
+	"aimanager/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -162,6 +164,12 @@ func ProxyAuth(gm *services.GroupManager) gin.HandlerFunc {
 		_, existsInGroup := group.ProxyKeysMap[key]
 
 		if existsInEffective || existsInGroup {
+			if !isClientIPAllowed(c, group, key) {
+				response.Error(c, app_errors.ErrClientIPNotAllowed)
+				c.Abort()
+				return
+			}
+			c.Set("proxyKey", key)
 			c.Next()
 			return
 		}
@@ -171,6 +179,33 @@ func ProxyAuth(gm *services.GroupManager) gin.HandlerFunc {
 	}
 }
 
+// isClientIPAllowed checks the client IP against the group's allow-list for the proxy key, falling back
+// to the "*" entry. Keys without an allow-list may be used from anywhere.
+func isClientIPAllowed(c *gin.Context, group *models.Group, key string) bool {
+	networks, ok := group.ProxyKeyNetworks[key]
+	if !ok {
+		networks, ok = group.ProxyKeyNetworks["*"]
+	}
+	if !ok {
+		return true
+	}
+
+	clientIP := utils.ClientIP(c.Request, group.EffectiveConfig.TrustedProxyDepth)
+	if clientIP != nil {
+		for _, network := range networks {
+			if network.Contains(clientIP) {
+				return true
+			}
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group":     group.Name,
+		"client_ip": clientIP.String(),
+	}).Warn("Proxy key used from an IP outside its allow-list")
+	return false
+}
+
 // ProxyRouteDispatcher dispatches special routes before proxy authentication
 func ProxyRouteDispatcher(serverHandler interface{ GetIntegrationInfo(*gin.Context) }) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -18,3 +18,13 @@ type CategorizedSettings struct {
 	CategoryName string              `json:"category_name"`
 	Settings     []SystemSettingInfo `json:"settings"`
 }
+
+// SettingDriftInfo 表示某个配置项在内存快照与数据库之间的对比结果（用于诊断）
+type SettingDriftInfo struct {
+	Key           string `json:"key"`
+	InMemoryValue string `json:"in_memory_value"`
+	DBValue       string `json:"db_value"`
+	InDB          bool   `json:"in_db"`
+	Drifted       bool   `json:"drifted"`
+	Redacted      bool   `json:"redacted"`
+}
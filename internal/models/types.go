@@ -2,15 +2,65 @@ package models
 
 import (
 	"aimanager/internal/types"
+	"net"
 	"time"
 
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // Key状态
 const (
-	KeyStatusActive  = "active"
-	KeyStatusInvalid = "invalid"
+	KeyStatusActive   = "active"
+	KeyStatusInvalid  = "invalid"
+	KeyStatusDisabled = "disabled" // 手动禁用，不参与轮询，也不会被验证恢复
+)
+
+// ParamOverrides 与客户端请求参数的合并方式
+const (
+	ParamOverrideModeOverride = "override" // 服务端覆盖客户端同名参数（默认）
+	ParamOverrideModeDefault  = "default"  // 仅在客户端未提供该参数时注入
+	ParamOverrideModeMerge    = "merge"    // 对象参数深度合并（冲突时服务端优先），其余参数服务端覆盖
+)
+
+// 客户端 Authorization 请求头的转发方式
+const (
+	AuthorizationForwardingDrop    = "drop"    // 丢弃客户端的 Authorization，由渠道设置自己的认证（默认）
+	AuthorizationForwardingHeader  = "header"  // 将客户端的 Authorization 转存到另一个请求头
+	AuthorizationForwardingReplace = "replace" // 始终以 "Bearer <池中密钥>" 替换 Authorization，即使渠道使用其他方式认证
+)
+
+// 上游密钥的注入方式，未设置时沿用渠道默认的认证方式
+const (
+	AuthHeaderModeBearer  = "bearer"    // Authorization: Bearer <密钥>
+	AuthHeaderModeXAPIKey = "x-api-key" // x-api-key: <密钥>
+	AuthHeaderModeCustom  = "custom"    // 由 auth_header_name 指定的请求头，值为密钥本身
+)
+
+// 分组限流模式
+const (
+	RateLimitModeFixedWindow = "fixed_window" // 按整点小时窗口计数，受 max_requests_per_hour 限制（默认）
+	RateLimitModeTokenBucket = "token_bucket" // 令牌桶，按 rate_limit_refill_per_second 补充令牌，最多积累 rate_limit_burst 个
+)
+
+// 请求体改写规则的动作
+const (
+	BodyRuleActionSet    = "set"    // 设置字段，中间对象不存在时自动创建
+	BodyRuleActionRemove = "remove" // 删除字段
+	BodyRuleActionClamp  = "clamp"  // 将数值字段限制在 min/max 之间，非数值字段不变
+)
+
+// 请求体改写规则条件的取值来源
+const (
+	BodyRuleSourceModel  = "model"  // 客户端请求的模型名（重定向前）
+	BodyRuleSourceHeader = "header" // 客户端请求头
+	BodyRuleSourceField  = "field"  // 请求体字段
+)
+
+// 灰度模型路由的变体，记录在请求日志的 model_variant 中
+const (
+	ModelVariantControl = "control" // 命中灰度规则，仍使用原模型
+	ModelVariantCanary  = "canary"  // 命中灰度规则，改用候选模型
 )
 
 // SystemSetting 对应 system_settings 表
@@ -37,11 +87,150 @@ type GroupConfig struct {
 	KeyValidationIntervalMinutes *int       `json:"key_validation_interval_minutes,omitempty"`
 	KeyValidationConcurrency     *int       `json:"key_validation_concurrency,omitempty"`
 	KeyValidationTimeoutSeconds  *int       `json:"key_validation_timeout_seconds,omitempty"`
+	KeyValidationConnectTimeout  *int       `json:"key_validation_connect_timeout,omitempty"`
+	KeyValidationReadTimeout     *int       `json:"key_validation_read_timeout,omitempty"`
 	EnableRequestBodyLogging     *bool      `json:"enable_request_body_logging,omitempty"`
+	StreamBufferSizeKB           *int       `json:"stream_buffer_size_kb,omitempty"`
+	RequestLogRetentionDays      *int       `json:"request_log_retention_days,omitempty"`
+	NormalizeProxyPath           *bool      `json:"normalize_proxy_path,omitempty"`
+	KeySelectionMode             *string    `json:"key_selection_mode,omitempty"`
+	KeyWarmupMinutes             *int       `json:"key_warmup_minutes,omitempty"`
+	RateLimitWarningPercent      *int       `json:"rate_limit_warning_percent,omitempty"`
+	HedgeDelayMs                 *int       `json:"hedge_delay_ms,omitempty"`
+	HedgeBudgetPercent           *int       `json:"hedge_budget_percent,omitempty"`
+	MaxResponseSizeKB            *int       `json:"max_response_size_kb,omitempty"`
+	OversizedResponseMode        *string    `json:"oversized_response_mode,omitempty"`
+	ConcurrencyOverflow          *string    `json:"concurrency_overflow,omitempty"`
+	ConcurrencyWaitTimeoutMs     *int       `json:"concurrency_wait_timeout_ms,omitempty"`
+	UpstreamFailureThreshold     *int       `json:"upstream_failure_threshold,omitempty"`
+	UpstreamCooldownSeconds      *int       `json:"upstream_cooldown_seconds,omitempty"`
+	JointUpstreamKeySelection    *bool      `json:"joint_upstream_key_selection,omitempty"`
+	KeyInvalidateAfterFailures   *int       `json:"key_invalidate_after_failures,omitempty"`
+	KeyRecoverAfterSuccesses     *int       `json:"key_recover_after_successes,omitempty"`
+	AutoPauseFailureRate         *int       `json:"auto_pause_failure_rate,omitempty"`
+	AutoPauseWindowHours         *int       `json:"auto_pause_window_hours,omitempty"`
+	AutoPauseMinRequests         *int       `json:"auto_pause_min_requests,omitempty"`
+	AutoPauseCooldownMinutes     *int       `json:"auto_pause_cooldown_minutes,omitempty"`
 	// 限流和有效期字段
 	ExpiresAt            *string    `json:"expires_at,omitempty"`             // 过期时间（格式: 2006-01-02 15:04:05）
 	MaxRequestsPerHour   *int       `json:"max_requests_per_hour,omitempty"`  // 每小时最大请求次数，0表示不限制
 	MaxRequestsPerMonth  *int       `json:"max_requests_per_month,omitempty"` // 每月最大请求次数，0表示不限制
+	RateLimitExemptKeys  *string    `json:"rate_limit_exempt_keys,omitempty"` // 不受限流约束的代理密钥，逗号分隔
+	// 限流模式: "fixed_window"（默认）或 "token_bucket"；令牌桶模式取代每小时限制，每月限制仍然生效
+	RateLimitMode            *string  `json:"rate_limit_mode,omitempty"`
+	RateLimitRefillPerSecond *float64 `json:"rate_limit_refill_per_second,omitempty"` // 令牌桶每秒补充的令牌数
+	RateLimitBurst           *int     `json:"rate_limit_burst,omitempty"`             // 令牌桶容量，即允许的最大突发请求数
+	// 代理密钥的来源 IP 白名单（代理密钥 -> CIDR 或 IP 列表），"*" 适用于未单独配置的密钥；未配置的密钥不限制来源
+	ProxyKeyAllowedCIDRs map[string][]string `json:"proxy_key_allowed_cidrs,omitempty"`
+	// 分组同时转发中的最大请求数（每个节点单独计数），0表示不限制；超出时按 concurrency_overflow 拒绝或排队
+	MaxConcurrentRequests *int `json:"max_concurrent_requests,omitempty"`
+	// 分组熔断器：窗口内失败率达到 circuit_breaker_failure_rate（百分比）时熔断，冷却期内直接拒绝请求，0表示不启用
+	CircuitBreakerFailureRate     *int `json:"circuit_breaker_failure_rate,omitempty"`
+	CircuitBreakerWindowSeconds   *int `json:"circuit_breaker_window_seconds,omitempty"`   // 统计失败率的时间窗口（秒），默认 60
+	CircuitBreakerMinRequests     *int `json:"circuit_breaker_min_requests,omitempty"`     // 窗口内至少达到该请求数才会熔断，默认 20
+	CircuitBreakerCooldownSeconds *int `json:"circuit_breaker_cooldown_seconds,omitempty"` // 熔断后的冷却时间（秒），之后放行一个探测请求，默认 30
+	// 密钥失败后的冷却时间（秒），冷却中的密钥不参与轮换，0表示不启用
+	KeyCooldownSeconds *int `json:"key_cooldown_seconds,omitempty"`
+	// 请求/响应体大小上限（字节），0表示不限制
+	MaxRequestBodyBytes  *int `json:"max_request_body_bytes,omitempty"`  // 超出时以 413 拒绝，不转发上游
+	MaxResponseBodyBytes *int `json:"max_response_body_bytes,omitempty"` // 超出时中断响应（含流式）并记为失败
+	// max_response_body_bytes 按接收到的原始字节（压缩响应为压缩后大小）限制所有响应；max_response_size_kb
+	// 只限制为检查而缓冲的非流式响应解码后的大小，并按 oversized_response_mode 处理。两者同时设置时先达到的生效
+	// 分组专属上游超时（秒），优先于 request_timeout/connect_timeout；流式请求的请求超时只限制首字节等待时间
+	RequestTimeoutSeconds *int `json:"request_timeout_seconds,omitempty"`
+	ConnectTimeoutSeconds *int `json:"connect_timeout_seconds,omitempty"`
+	// 告警通知字段，活跃密钥占比过低或 24 小时失败率过高时向 Webhook 发送 POST 请求，阈值为 0 表示不检查该项
+	NotificationWebhook            *string `json:"notification_webhook,omitempty"`
+	NotificationActiveKeyPercent   *int    `json:"notification_active_key_percent,omitempty"`   // 活跃密钥占比低于该百分比时告警，默认 20
+	NotificationFailureRatePercent *int    `json:"notification_failure_rate_percent,omitempty"` // 24 小时失败率达到该百分比时告警，默认 50
+	// 重试策略，重试次数由 max_retries 控制；retry_on_status 为空时除 404 外的错误状态码均重试，连接错误总是重试
+	RetryOnStatus  []int `json:"retry_on_status,omitempty"`
+	RetryBackoffMs *int  `json:"retry_backoff_ms,omitempty"` // 首次重试前的等待时间（毫秒），之后每次翻倍，最长 30 秒
+	// 备用测试模型，主测试模型验证失败时按顺序尝试
+	FallbackTestModels []string `json:"fallback_test_models,omitempty"`
+	// 自定义错误响应体，按失败原因配置，支持 ${RESET_AT} 等变量
+	ErrorResponses map[string]map[string]any `json:"error_responses,omitempty"`
+	// 请求参数默认值，仅在客户端未提供时注入
+	DefaultParams map[string]any `json:"default_params,omitempty"`
+	// ParamOverrides 的优先级: "override"、"default" 或 "merge"，为空时按 "override" 处理
+	ParamOverrideMode *string `json:"param_override_mode,omitempty"`
+	// 是否只允许渠道已知的 param_overrides 参数，默认关闭；开启后拒绝渠道未登记的参数以发现拼写错误
+	StrictParamOverrides *bool `json:"strict_param_overrides,omitempty"`
+	// 请求/响应体顶层字段重命名，由渠道的 FieldRenameAdapter 执行
+	FieldRenames *FieldRenameRules `json:"field_renames,omitempty"`
+	// 流式响应的 SSE 事件改写，默认关闭
+	SSERewrite *SSERewriteRules `json:"sse_rewrite,omitempty"`
+	// 请求体条件改写规则，在 param_overrides 之后按顺序执行
+	BodyRules []BodyRule `json:"body_rules,omitempty"`
+	// 模型访问控制，支持 gpt-4* 形式的通配符（* 可跨越 /），按模型重定向后的名称匹配；命中 denied_models 或不在非空的 allowed_models 中时以 403 拒绝
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	DeniedModels  []string `json:"denied_models,omitempty"`
+	// 灰度模型路由，在 body_rules 之后按比例将请求的模型替换为候选模型
+	ModelCanaries []ModelCanary `json:"model_canaries,omitempty"`
+	// 客户端 Authorization 的转发方式: "drop"（默认）、"header" 或 "replace"
+	// 注意: 客户端通常用 Authorization 携带本服务的代理密钥，"header" 会把它一并发给上游，只应对可信上游启用
+	AuthorizationForwarding    *string `json:"authorization_forwarding,omitempty"`
+	AuthorizationForwardHeader *string `json:"authorization_forward_header,omitempty"` // "header" 方式使用的请求头，默认 X-Forwarded-Authorization
+	// 上游密钥的注入方式: "bearer"、"x-api-key" 或 "custom"，未设置时由渠道决定
+	AuthHeaderMode *string `json:"auth_header_mode,omitempty"`
+	AuthHeaderName *string `json:"auth_header_name,omitempty"` // "custom" 方式使用的请求头名称
+	// 调试字段
+	AllowUpstreamOverride   *bool `json:"allow_upstream_override,omitempty"`   // 是否允许通过 X-Upstream-Override 请求头指定上游
+	EnableDiagnosticHeaders *bool `json:"enable_diagnostic_headers,omitempty"` // 是否在响应中返回 X-Served-By 诊断头
+	AllowSeededSelection    *bool `json:"allow_seeded_selection,omitempty"`    // 是否允许通过 X-Debug-Seed 请求头固定上游和密钥的选择，仅用于调试
+	// 会话粘性字段（仅聚合分组），同一会话在 TTL 内固定路由到同一子分组
+	SessionAffinityHeader     *string `json:"session_affinity_header,omitempty"`      // 携带会话标识的请求头，如 X-Session-Id，为空时不启用
+	SessionAffinityTTLSeconds *int    `json:"session_affinity_ttl_seconds,omitempty"` // 会话绑定的有效期（秒），默认 3600
+	// 模型路由（仅聚合分组），将指定模型固定路由到子分组（模型名 -> 子分组 ID），其余模型按权重选择
+	ModelRouting map[string]uint `json:"model_routing,omitempty"`
+	// 隐私字段，开启后不保存该分组的请求日志明细，仅累计小时统计
+	DisableRequestLogging *bool `json:"disable_request_logging,omitempty"`
+	// 防重放字段
+	RequireNonce        *bool `json:"require_nonce,omitempty"`          // 是否要求请求携带 X-Nonce 和 X-Timestamp
+	NonceMaxSkewSeconds *int  `json:"nonce_max_skew_seconds,omitempty"` // 允许的时钟偏差（秒），默认 300，最大 3600
+}
+
+// FieldRenameRules maps top-level JSON field names to new names, for request bodies sent upstream
+// and for non-streaming response bodies returned to the client.
+type FieldRenameRules struct {
+	Request  map[string]string `json:"request,omitempty"`
+	Response map[string]string `json:"response,omitempty"`
+}
+
+// SSERewriteRules controls how streamed server-sent events are rewritten before reaching the client.
+// Event payloads are always forwarded byte for byte; only whole events are dropped or added.
+type SSERewriteRules struct {
+	AllowedEvents []string `json:"allowed_events,omitempty"` // event types to keep, empty keeps all; events without a type are "message"
+	StripComments bool     `json:"strip_comments,omitempty"` // drop ":" comment lines such as keep-alive pings
+	NormalizeDone bool     `json:"normalize_done,omitempty"` // end every stream with exactly one "data: [DONE]"
+	Rechunk       bool     `json:"rechunk,omitempty"`        // flush once per event instead of per upstream read
+}
+
+// BodyRule conditionally edits one field of the JSON request body sent upstream.
+// The action runs only when every condition in When holds; a rule without conditions always runs.
+type BodyRule struct {
+	When   []BodyRuleCondition `json:"when,omitempty"`
+	Action string              `json:"action"`          // "set", "remove" or "clamp"
+	Field  string              `json:"field"`           // dot-separated path, e.g. "max_tokens" or "generationConfig.temperature"
+	Value  any                 `json:"value,omitempty"` // value written by "set"
+	Min    *float64            `json:"min,omitempty"`   // lower bound of "clamp"
+	Max    *float64            `json:"max,omitempty"`   // upper bound of "clamp"
+}
+
+// BodyRuleCondition compares one request attribute with a value.
+type BodyRuleCondition struct {
+	Source string `json:"source"`          // "model", "header" or "field"
+	Name   string `json:"name,omitempty"`  // header name or body field path
+	Op     string `json:"op"`              // eq, ne, in, prefix, suffix, contains, exists, missing, gt, gte, lt, lte
+	Value  any    `json:"value,omitempty"` // operand, unused by exists and missing
+}
+
+// ModelCanary sends Percent percent of the requests for Model to Candidate instead, so the
+// candidate can be compared with the current model using the request logs.
+type ModelCanary struct {
+	Model     string  `json:"model"`     // model requested by the client
+	Candidate string  `json:"candidate"` // model tried on the canary share of requests
+	Percent   float64 `json:"percent"`   // share of requests sent to Candidate, in (0, 100]
 }
 
 // HeaderRule defines a single rule for header manipulation.
@@ -66,11 +255,12 @@ type GroupSubGroup struct {
 
 // SubGroupInfo 用于API响应的子分组信息
 type SubGroupInfo struct {
-	Group       Group `json:"group"`
-	Weight      int   `json:"weight"`
-	TotalKeys   int64 `json:"total_keys"`
-	ActiveKeys  int64 `json:"active_keys"`
-	InvalidKeys int64 `json:"invalid_keys"`
+	Group        Group `json:"group"`
+	Weight       int   `json:"weight"`
+	TotalKeys    int64 `json:"total_keys"`
+	ActiveKeys   int64 `json:"active_keys"`
+	InvalidKeys  int64 `json:"invalid_keys"`
+	DisabledKeys int64 `json:"disabled_keys"`
 }
 
 // ParentAggregateGroupInfo 用于API响应的父聚合分组信息
@@ -104,13 +294,18 @@ type Group struct {
 	APIKeys              []APIKey             `gorm:"foreignKey:GroupID" json:"api_keys"`
 	SubGroups            []GroupSubGroup      `gorm:"-" json:"sub_groups,omitempty"`
 	LastValidatedAt      *time.Time           `json:"last_validated_at"`
+	AutoPausedAt         *time.Time           `json:"auto_paused_at"`        // 因持续失败被自动暂停的时间，为空表示未暂停
+	AutoPauseResumedAt   *time.Time           `json:"auto_pause_resumed_at"` // 最近一次恢复的时间，失败率只统计此后的请求
 	CreatedAt            time.Time            `json:"created_at"`
 	UpdatedAt            time.Time            `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt       `gorm:"index" json:"deleted_at"` // 软删除时间，分组名称在彻底删除前仍被占用
 
 	// For cache
-	ProxyKeysMap      map[string]struct{} `gorm:"-" json:"-"`
-	HeaderRuleList    []HeaderRule        `gorm:"-" json:"-"`
-	ModelRedirectMap  map[string]string   `gorm:"-" json:"-"`
+	ProxyKeysMap      map[string]struct{}     `gorm:"-" json:"-"`
+	ProxyKeyNetworks  map[string][]*net.IPNet `gorm:"-" json:"-"` // 解析后的 proxy_key_allowed_cidrs
+	HeaderRuleList    []HeaderRule            `gorm:"-" json:"-"`
+	ModelRedirectMap  map[string]string       `gorm:"-" json:"-"`
+	ParsedConfig      GroupConfig             `gorm:"-" json:"-"`
 }
 
 // APIKey 对应 api_keys 表
@@ -121,16 +316,30 @@ type APIKey struct {
 	GroupID      uint       `gorm:"not null;index" json:"group_id"`
 	Status       string     `gorm:"type:varchar(50);not null;default:'active';index" json:"status"`
 	Notes        string     `gorm:"type:varchar(255);default:''" json:"notes"`
+	Tags         string     `gorm:"type:varchar(255);default:''" json:"tags"`
+	Tier         int        `gorm:"not null;default:0" json:"tier"` // 密钥层级，数字小的层级优先使用，全部失效或冷却中才使用下一层级
 	RequestCount int64      `gorm:"not null;default:0" json:"request_count"`
 	FailureCount int64      `gorm:"not null;default:0" json:"failure_count"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	// 连续验证结果，用于状态切换的滞后判断
+	ConsecutiveFailures  int64      `gorm:"not null;default:0" json:"consecutive_failures"`
+	ConsecutiveSuccesses int64      `gorm:"not null;default:0" json:"consecutive_successes"`
+	KeySuffixHash        string     `gorm:"type:varchar(128);index;default:''" json:"-"` // 密钥末尾字符的不可逆指纹，用于按片段搜索
+	LastUsedAt           *time.Time `json:"last_used_at"`                                // 最近一次被请求使用的时间，随请求日志批量写入
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
 }
 
 // RequestType 请求类型常量
 const (
 	RequestTypeRetry = "retry"
 	RequestTypeFinal = "final"
+	RequestTypeHedge = "hedge"
+)
+
+// FailureStage 失败阶段常量：响应开始流式输出之前或之后失败
+const (
+	FailureStagePreStream = "pre_stream"
+	FailureStageMidStream = "mid_stream"
 )
 
 // RequestLog 对应 request_logs 表
@@ -155,6 +364,10 @@ type RequestLog struct {
 	UpstreamAddr    string    `gorm:"type:varchar(500)" json:"upstream_addr"`
 	IsStream        bool      `gorm:"not null" json:"is_stream"`
 	RequestBody     string    `gorm:"type:text" json:"request_body"`
+	ModelVariant    string    `gorm:"type:varchar(20);index" json:"model_variant"`
+	RequestID       string    `gorm:"type:varchar(36);index" json:"request_id"`
+	ProxyKeyHash    string    `gorm:"type:varchar(128);index" json:"-"`
+	FailureStage    string    `gorm:"type:varchar(20)" json:"failure_stage,omitempty"`
 }
 
 // StatCard 用于仪表盘的单个统计卡片数据
@@ -203,8 +416,45 @@ type GroupHourlyStat struct {
 	GroupID      uint      `gorm:"not null;uniqueIndex:idx_group_time" json:"group_id"`
 	SuccessCount int64     `gorm:"not null;default:0" json:"success_count"`
 	FailureCount int64     `gorm:"not null;default:0" json:"failure_count"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	// 流式响应开始后才发生的失败，已计入 FailureCount
+	MidStreamFailures int64     `gorm:"not null;default:0" json:"mid_stream_failures"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// GroupDailyStat 对应 group_daily_stats 表，保存超出 stats_hourly_retention_days 后由小时统计汇总而来的每日统计
+type GroupDailyStat struct {
+	ID                uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Day               time.Time `gorm:"type:date;not null;uniqueIndex:idx_group_day" json:"day"` // 当天零点
+	GroupID           uint      `gorm:"not null;uniqueIndex:idx_group_day" json:"group_id"`
+	SuccessCount      int64     `gorm:"not null;default:0" json:"success_count"`
+	FailureCount      int64     `gorm:"not null;default:0" json:"failure_count"`
+	MidStreamFailures int64     `gorm:"not null;default:0" json:"mid_stream_failures"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// GroupKeyCountStat 对应 group_key_count_stats 表，记录每个分组每小时的密钥数量快照
+type GroupKeyCountStat struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Time       time.Time `gorm:"not null;uniqueIndex:idx_group_key_count_time" json:"time"` // 整点时间
+	GroupID    uint      `gorm:"not null;uniqueIndex:idx_group_key_count_time" json:"group_id"`
+	TotalKeys  int64     `gorm:"not null;default:0" json:"total_keys"`
+	ActiveKeys int64     `gorm:"not null;default:0" json:"active_keys"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// GroupUsageReset 对应 group_usage_resets 表，记录每次手动清零分组用量计数的操作，用于审计
+type GroupUsageReset struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	GroupID          uint      `gorm:"not null;index" json:"group_id"`
+	GroupName        string    `gorm:"type:varchar(255)" json:"group_name"`
+	Scope            string    `gorm:"type:varchar(20);not null" json:"scope"`
+	HourlyRowsReset  int64     `gorm:"not null;default:0" json:"hourly_rows_reset"`
+	MonthlyRowsReset int64     `gorm:"not null;default:0" json:"monthly_rows_reset"`
+	AdminIP          string    `gorm:"type:varchar(64)" json:"admin_ip"` // 发起清零的管理端 IP
+	CreatedAt        time.Time `gorm:"not null;index" json:"created_at"`
 }
 
 // GroupMonthlyStat 对应 group_monthly_stats 表，用于存储每个分组每月的请求统计
@@ -0,0 +1,304 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"aimanager/internal/channel"
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// hedgeBudgetWindow is the period over which a group's hedge budget is counted.
+const hedgeBudgetWindow = time.Minute
+
+// errHedgeCancelled is recorded on the attempt that lost the race.
+var errHedgeCancelled = errors.New("hedged attempt cancelled: another attempt responded first")
+
+// hedgeBudget tracks how many requests of a group were hedged in the current window.
+type hedgeBudget struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	requests    int
+	hedges      int
+}
+
+// hedgeAttempt is one in-flight upstream call of a hedged request.
+type hedgeAttempt struct {
+	apiKey      *models.APIKey
+	upstreamURL string
+	resp        *http.Response
+	err         error
+	cancel      context.CancelFunc
+	isHedge     bool
+}
+
+// succeeded reports whether the attempt produced a response the group does not treat as a failure,
+// using the same classification as unhedged attempts.
+func (a *hedgeAttempt) succeeded(group *models.Group) bool {
+	return a.resp != nil && !isFailedAttempt(group, a.resp, a.err)
+}
+
+// discard closes the attempt's response and releases its context.
+func (a *hedgeAttempt) discard() {
+	if a.resp != nil {
+		a.resp.Body.Close()
+	}
+	a.cancel()
+}
+
+// getHedgeBudget returns the budget tracker for a group, rolling the window when it expired.
+func (ps *ProxyServer) getHedgeBudget(groupID uint) *hedgeBudget {
+	value, _ := ps.hedgeBudgets.LoadOrStore(groupID, &hedgeBudget{windowStart: time.Now()})
+	return value.(*hedgeBudget)
+}
+
+// recordHedgeEligible counts a request towards the group's hedge budget.
+func (ps *ProxyServer) recordHedgeEligible(groupID uint) {
+	budget := ps.getHedgeBudget(groupID)
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	if time.Since(budget.windowStart) >= hedgeBudgetWindow {
+		budget.windowStart = time.Now()
+		budget.requests = 0
+		budget.hedges = 0
+	}
+	budget.requests++
+}
+
+// tryAcquireHedge reserves a hedge if the group is still within its budget for the current window.
+// At least one hedge per window is allowed so low-traffic groups can still benefit.
+func (ps *ProxyServer) tryAcquireHedge(group *models.Group) bool {
+	budget := ps.getHedgeBudget(group.ID)
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	allowed := max(budget.requests*group.EffectiveConfig.HedgeBudgetPercent/100, 1)
+	if budget.hedges >= allowed {
+		return false
+	}
+	budget.hedges++
+	return true
+}
+
+// doHedgedRequest sends the prepared request and, if it has not completed within the group's hedge
+// delay, a second one with another key. The first successful attempt is returned and the other is
+// cancelled and logged as a hedge. If every attempt fails, the last failure is returned so the
+// regular retry handling applies to it.
+func (ps *ProxyServer) doHedgedRequest(
+	c *gin.Context,
+	client *http.Client,
+	channelHandler channel.ChannelProxy,
+	originalGroup *models.Group,
+	group *models.Group,
+	req *http.Request,
+	apiKey *models.APIKey,
+	upstreamURL string,
+	bodyBytes []byte,
+	finalBodyBytes []byte,
+	startTime time.Time,
+) *hedgeAttempt {
+	ps.recordHedgeEligible(group.ID)
+
+	results := make(chan *hedgeAttempt, 2)
+	launch := func(attempt *hedgeAttempt, attemptReq *http.Request) {
+		go func() {
+			attempt.resp, attempt.err = client.Do(attemptReq)
+			results <- attempt
+		}()
+	}
+
+	primaryCtx, primaryCancel := context.WithCancel(req.Context())
+	primary := &hedgeAttempt{apiKey: apiKey, upstreamURL: upstreamURL, cancel: primaryCancel}
+	launch(primary, req.WithContext(primaryCtx))
+	inflight := []*hedgeAttempt{primary}
+
+	timer := time.NewTimer(time.Duration(group.EffectiveConfig.HedgeDelayMs) * time.Millisecond)
+	defer timer.Stop()
+
+	hedged := false
+	for {
+		select {
+		case attempt := <-results:
+			inflight = removeHedgeAttempt(inflight, attempt)
+			if !attempt.succeeded(group) && len(inflight) > 0 {
+				// Another attempt is still in flight, give it a chance before failing
+				ps.logHedgeAttempt(c, attempt, channelHandler, originalGroup, group, bodyBytes, startTime)
+				continue
+			}
+			if len(inflight) > 0 {
+				ps.abandonHedgeAttempts(c, results, inflight, channelHandler, originalGroup, group, bodyBytes, startTime)
+			}
+			if attempt.isHedge && attempt.succeeded(group) {
+				logrus.Debugf("Hedged request for group %s won with key %s", group.Name, utils.MaskAPIKey(attempt.apiKey.KeyValue))
+			}
+			return attempt
+		case <-timer.C:
+			if hedged || len(inflight) == 0 || !ps.tryAcquireHedge(group) {
+				continue
+			}
+			hedged = true
+			hedge := ps.startHedgeAttempt(c, channelHandler, originalGroup, group, req, primary, bodyBytes, finalBodyBytes)
+			if hedge == nil {
+				continue
+			}
+			launch(hedge.attempt, hedge.req)
+			inflight = append(inflight, hedge.attempt)
+			logrus.WithFields(logrus.Fields{
+				"group":    group.Name,
+				"delay_ms": group.EffectiveConfig.HedgeDelayMs,
+				"key":      utils.MaskAPIKey(hedge.attempt.apiKey.KeyValue),
+			}).Debug("Sending hedged request")
+		}
+	}
+}
+
+// removeHedgeAttempt drops a completed attempt from the in-flight list.
+func removeHedgeAttempt(inflight []*hedgeAttempt, done *hedgeAttempt) []*hedgeAttempt {
+	remaining := inflight[:0]
+	for _, attempt := range inflight {
+		if attempt != done {
+			remaining = append(remaining, attempt)
+		}
+	}
+	return remaining
+}
+
+// hedgeRequest pairs a prepared hedge request with its attempt state.
+type hedgeRequest struct {
+	attempt *hedgeAttempt
+	req     *http.Request
+}
+
+// startHedgeAttempt prepares the second attempt of a hedged request. It returns nil when no
+// different key or upstream is available, since hedging to the same target would not help.
+func (ps *ProxyServer) startHedgeAttempt(
+	c *gin.Context,
+	channelHandler channel.ChannelProxy,
+	originalGroup *models.Group,
+	group *models.Group,
+	primaryReq *http.Request,
+	primary *hedgeAttempt,
+	bodyBytes []byte,
+	finalBodyBytes []byte,
+) *hedgeRequest {
+	apiKey, err := ps.keyProvider.SelectKeyForGroup(group)
+	if err != nil {
+		logrus.Debugf("Skipping hedge for group %s: %v", group.Name, err)
+		return nil
+	}
+
+	upstreamURL, err := ps.buildUpstreamURL(c, channelHandler, originalGroup, group, apiKey)
+	if err != nil {
+		logrus.Debugf("Skipping hedge for group %s: %v", group.Name, err)
+		return nil
+	}
+	if apiKey.KeyValue == primary.apiKey.KeyValue && upstreamURL == primary.upstreamURL {
+		return nil
+	}
+
+	// The parent context is the request timeout context, so the hedge shares the same deadline
+	ctx, cancel := context.WithCancel(primaryReq.Context())
+	req, err := http.NewRequestWithContext(ctx, primaryReq.Method, upstreamURL, nil)
+	if err != nil {
+		cancel()
+		logrus.Debugf("Skipping hedge for group %s: %v", group.Name, err)
+		return nil
+	}
+	req.Header = primaryReq.Header.Clone()
+
+	// Re-run the redirect for any URL rewriting; the body is taken from the primary request as sent
+	if _, err := channelHandler.ApplyModelRedirect(req, bodyBytes, group); err != nil {
+		cancel()
+		return nil
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(finalBodyBytes))
+	req.ContentLength = int64(len(finalBodyBytes))
+
+	channelHandler.ModifyRequest(req, apiKey, group)
+	channel.ApplyAuthHeaderMode(req, group, apiKey.KeyValue)
+	applyAuthorizationForwarding(req, group, c.Request.Header.Get("Authorization"), apiKey)
+	if len(group.HeaderRuleList) > 0 {
+		headerCtx := utils.NewHeaderVariableContextFromGin(c, group, apiKey)
+		utils.ApplyHeaderRules(req, group.HeaderRuleList, headerCtx)
+	}
+
+	return &hedgeRequest{
+		attempt: &hedgeAttempt{apiKey: apiKey, upstreamURL: upstreamURL, cancel: cancel, isHedge: true},
+		req:     req,
+	}
+}
+
+// abandonHedgeAttempts cancels attempts that lost the race and logs them once they return.
+func (ps *ProxyServer) abandonHedgeAttempts(
+	c *gin.Context,
+	results <-chan *hedgeAttempt,
+	losers []*hedgeAttempt,
+	channelHandler channel.ChannelProxy,
+	originalGroup *models.Group,
+	group *models.Group,
+	bodyBytes []byte,
+	startTime time.Time,
+) {
+	for _, attempt := range losers {
+		attempt.cancel()
+	}
+
+	// gin recycles the context after the handler returns, so log with a copy
+	logCtx := c.Copy()
+	go func() {
+		for range losers {
+			attempt := <-results
+			if attempt.err == nil || !errors.Is(attempt.err, context.Canceled) {
+				ps.logHedgeAttempt(logCtx, attempt, channelHandler, originalGroup, group, bodyBytes, startTime)
+				continue
+			}
+			attempt.discard()
+			ps.logRequest(logCtx, originalGroup, group, attempt.apiKey, startTime, 499, errHedgeCancelled, false, attempt.upstreamURL, channelHandler, bodyBytes, models.RequestTypeHedge)
+		}
+	}()
+}
+
+// logHedgeAttempt records an attempt that completed but is not used for the response, then discards it.
+// Upstream failures still count against the key and the upstream.
+func (ps *ProxyServer) logHedgeAttempt(
+	c *gin.Context,
+	attempt *hedgeAttempt,
+	channelHandler channel.ChannelProxy,
+	originalGroup *models.Group,
+	group *models.Group,
+	bodyBytes []byte,
+	startTime time.Time,
+) {
+	defer attempt.discard()
+	ps.recordUpstreamHealth(c, channelHandler, group, attempt.upstreamURL, attempt.resp, attempt.err)
+
+	statusCode := http.StatusInternalServerError
+	finalErr := attempt.err
+	if attempt.resp != nil {
+		statusCode = attempt.resp.StatusCode
+		if finalErr == nil && !attempt.succeeded(group) {
+			errorBody, _, _ := readLimitedBody(attempt.resp.Body, responseSizeLimit(group))
+			finalErr = errors.New(app_errors.ParseUpstreamError(handleGzipCompression(attempt.resp, errorBody, responseSizeLimit(group))))
+		}
+	}
+	if finalErr == nil {
+		finalErr = errHedgeCancelled
+	} else if !app_errors.IsIgnorableError(finalErr) && countsAgainstKey(attempt.resp, attempt.err) {
+		ps.keyProvider.UpdateStatus(attempt.apiKey, group, false, finalErr.Error())
+	}
+
+	logrus.Debugf("Hedged attempt for group %s with key %s finished unused with status %d", group.Name, utils.MaskAPIKey(attempt.apiKey.KeyValue), statusCode)
+	ps.logRequest(c, originalGroup, group, attempt.apiKey, startTime, statusCode, finalErr, false, attempt.upstreamURL, channelHandler, bodyBytes, models.RequestTypeHedge)
+}
@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"fmt"
+
+	"aimanager/internal/channel"
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkModelAccess rejects requests for models the group does not allow, as given by its
+// allowed_models and denied_models wildcard patterns, where '*' also matches across '/'. The model is
+// checked as it will be sent upstream, after model redirection, so a redirect cannot be used to reach
+// a denied model. For aggregate groups the lists of both the aggregate and the selected sub-group apply.
+func checkModelAccess(c *gin.Context, channelHandler channel.ChannelProxy, originalGroup, group *models.Group, bodyBytes []byte) *app_errors.APIError {
+	model := channelHandler.ExtractModel(c, bodyBytes)
+	if model == "" {
+		return nil
+	}
+	if target, found := group.ModelRedirectMap[model]; found {
+		model = target
+	}
+
+	groups := []*models.Group{group}
+	if originalGroup != group {
+		groups = append(groups, originalGroup)
+	}
+	for _, g := range groups {
+		if !isModelAllowed(g, model) {
+			return app_errors.NewAPIError(app_errors.ErrModelNotAllowed, fmt.Sprintf("Model '%s' is not allowed for this group", model))
+		}
+	}
+	return nil
+}
+
+// isModelAllowed reports whether the group may call the model. Deny patterns win over allow patterns,
+// and an empty allow list allows every model.
+func isModelAllowed(group *models.Group, model string) bool {
+	if matchesAnyModelPattern(group.ParsedConfig.DeniedModels, model) {
+		return false
+	}
+	allowed := group.ParsedConfig.AllowedModels
+	return len(allowed) == 0 || matchesAnyModelPattern(allowed, model)
+}
+
+func matchesAnyModelPattern(patterns []string, model string) bool {
+	for _, pattern := range patterns {
+		if utils.MatchWildcard(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"testing"
+
+	"aimanager/internal/models"
+)
+
+func TestIsModelAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		model   string
+		want    bool
+	}{
+		{"no lists", nil, nil, "gpt-4", true},
+		{"allowed prefix", []string{"gpt-*"}, nil, "gpt-4o", true},
+		{"allowed prefix after provider", []string{"*gpt-*"}, nil, "openai/gpt-4", true},
+		{"not in allow list", []string{"gpt-*"}, nil, "claude-3-opus", false},
+		{"denied across slash", nil, []string{"*opus*"}, "anthropic/claude-opus-4", false},
+		{"deny wins over allow", []string{"*"}, []string{"*opus*"}, "claude-opus-4", false},
+		{"single character", []string{"gpt-?"}, nil, "gpt-4", true},
+		{"single character too short", []string{"gpt-?o"}, nil, "gpt-4", false},
+		{"exact", []string{"gpt-4"}, nil, "gpt-4-turbo", false},
+		{"brackets are literal", []string{"gpt-[4]"}, nil, "gpt-4", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &models.Group{ParsedConfig: models.GroupConfig{AllowedModels: tt.allowed, DeniedModels: tt.denied}}
+			if got := isModelAllowed(group, tt.model); got != tt.want {
+				t.Errorf("isModelAllowed(%v, %v, %q) = %v, want %v", tt.allowed, tt.denied, tt.model, got, tt.want)
+			}
+		})
+	}
+}
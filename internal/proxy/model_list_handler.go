@@ -4,7 +4,6 @@ import (
 	"aimanager/internal/channel"
 	"aimanager/internal/models"
 	"aimanager/internal/utils"
-	"io"
 	"net/http"
 	"strings"
 
@@ -24,14 +23,18 @@ func shouldInterceptModelList(path string, method string) bool {
 		strings.Contains(path, "/v1beta/openai/v1/models")
 }
 
-// handleModelListResponse processes the model list response and applies filtering based on redirect rules
-func (ps *ProxyServer) handleModelListResponse(c *gin.Context, resp *http.Response, group *models.Group, channelHandler channel.ChannelProxy) {
+// handleModelListResponse processes the model list response and applies filtering based on redirect rules.
+// It returns errResponseTooLarge when the body exceeds the group's max_response_size_kb.
+func (ps *ProxyServer) handleModelListResponse(c *gin.Context, resp *http.Response, group *models.Group, channelHandler channel.ChannelProxy) error {
 	// Read the upstream response body
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, oversized, err := readLimitedBody(resp.Body, responseSizeLimit(group))
 	if err != nil {
 		logrus.WithError(err).Error("Failed to read model list response body")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response"})
-		return
+		return nil
+	}
+	if oversized {
+		return ps.handleOversizedResponse(c, resp, group, bodyBytes)
 	}
 
 	// Decompress response data based on Content-Encoding
@@ -47,8 +50,9 @@ func (ps *ProxyServer) handleModelListResponse(c *gin.Context, resp *http.Respon
 	if err != nil {
 		logrus.WithError(err).Error("Failed to transform model list")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process response"})
-		return
+		return nil
 	}
 
 	c.JSON(http.StatusOK, response)
+	return nil
 }
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// apiVersionSegmentPattern matches API version path segments such as "v1", "V1" or "v1Beta".
+var apiVersionSegmentPattern = regexp.MustCompile(`(?i)^v[0-9]+((alpha|beta)[0-9]*)?$`)
+
+// normalizeProxySubPath cleans up the proxied sub-path sent by lenient clients:
+// duplicate slashes are collapsed, a trailing slash is dropped, "." and ".." segments
+// are resolved without climbing above the sub-path and API version segments are lower-cased. Other segments keep their case because model names and
+// method suffixes (e.g. ":generateContent") are case-sensitive upstream.
+func normalizeProxySubPath(subPath string) string {
+	segments := strings.Split(subPath, "/")
+	cleaned := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			if len(cleaned) > 0 {
+				cleaned = cleaned[:len(cleaned)-1]
+			}
+			continue
+		}
+		if apiVersionSegmentPattern.MatchString(segment) {
+			segment = strings.ToLower(segment)
+		}
+		cleaned = append(cleaned, segment)
+	}
+
+	return "/" + strings.Join(cleaned, "/")
+}
+
+// normalizeProxyRequestPath rewrites the request path in place so that routing checks and
+// upstream URL building both see the normalized sub-path.
+func normalizeProxyRequestPath(c *gin.Context) {
+	subPath := c.Param("path")
+	normalized := normalizeProxySubPath(subPath)
+	if normalized == subPath {
+		return
+	}
+
+	prefix := strings.TrimSuffix(c.Request.URL.Path, subPath)
+	c.Request.URL.Path = prefix + normalized
+	c.Request.URL.RawPath = ""
+
+	for i := range c.Params {
+		if c.Params[i].Key == "path" {
+			c.Params[i].Value = normalized
+			break
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"original":   subPath,
+		"normalized": normalized,
+	}).Debug("Normalized proxy request path")
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeProxySubPath(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"already normal", "/v1/chat/completions", "/v1/chat/completions"},
+		{"empty", "", "/"},
+		{"root", "/", "/"},
+		{"trailing slash", "/v1/chat/completions/", "/v1/chat/completions"},
+		{"double slashes", "//v1//chat///completions", "/v1/chat/completions"},
+		{"only slashes", "///", "/"},
+		{"version casing", "/V1/models", "/v1/models"},
+		{"beta version casing", "/V1Beta/models/gemini-pro:generateContent", "/v1beta/models/gemini-pro:generateContent"},
+		{"model case kept", "/v1/models/Qwen/Qwen2-72B", "/v1/models/Qwen/Qwen2-72B"},
+		{"version-like model kept", "/v1/models/v1-large", "/v1/models/v1-large"},
+		{"dot segments", "/v1/./chat/./completions", "/v1/chat/completions"},
+		{"dot-dot segment", "/v1/models/../chat/completions", "/v1/chat/completions"},
+		{"dot-dot above root", "/../../v1/models", "/v1/models"},
+		{"dot-dot only", "/..", "/"},
+		{"dot-dot with double slashes", "/v1//..//models/", "/models"},
+		{"dots inside segment kept", "/v1/models/gpt-4..1/file...txt", "/v1/models/gpt-4..1/file...txt"},
+		{"encoded slash kept", "/v1/models/a%2Fb", "/v1/models/a%2Fb"},
+		{"encoded dot-dot kept", "/v1/%2E%2E/models", "/v1/%2E%2E/models"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeProxySubPath(tt.in); got != tt.want {
+				t.Errorf("normalizeProxySubPath(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeProxyRequestPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		target      string
+		wantPath    string
+		wantRawPath string
+		wantParam   string
+	}{
+		{
+			name:      "trailing slash and casing",
+			target:    "/proxy/test/V1/chat/completions/",
+			wantPath:  "/proxy/test/v1/chat/completions",
+			wantParam: "/v1/chat/completions",
+		},
+		{
+			name:      "dot-dot stays below the group",
+			target:    "/proxy/test/v1/../../../admin",
+			wantPath:  "/proxy/test/admin",
+			wantParam: "/admin",
+		},
+		{
+			// The router matches on the decoded path, so an encoded slash is a separator there
+			name:      "encoded slashes collapsed",
+			target:    "/proxy/test/v1/%2F%2Fmodels",
+			wantPath:  "/proxy/test/v1/models",
+			wantParam: "/v1/models",
+		},
+		{
+			name:        "encoded slash left alone when nothing changes",
+			target:      "/proxy/test/v1/models/a%2Fb",
+			wantPath:    "/proxy/test/v1/models/a/b",
+			wantRawPath: "/proxy/test/v1/models/a%2Fb",
+			wantParam:   "/v1/models/a/b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotRawPath, gotParam string
+			router := gin.New()
+			router.Any("/proxy/:group_name/*path", func(c *gin.Context) {
+				normalizeProxyRequestPath(c)
+				gotPath, gotRawPath, gotParam = c.Request.URL.Path, c.Request.URL.RawPath, c.Param("path")
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			if gotPath != tt.wantPath {
+				t.Errorf("path = %q, want %q", gotPath, tt.wantPath)
+			}
+			if gotRawPath != tt.wantRawPath {
+				t.Errorf("raw path = %q, want %q", gotRawPath, tt.wantRawPath)
+			}
+			if gotParam != tt.wantParam {
+				t.Errorf("path param = %q, want %q", gotParam, tt.wantParam)
+			}
+		})
+	}
+}
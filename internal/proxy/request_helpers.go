@@ -1,17 +1,356 @@
 package proxy
 
 import (
+	"aimanager/internal/channel"
 	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
+	"aimanager/internal/response"
+	"aimanager/internal/services"
+	"aimanager/internal/utils"
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
-	"io"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// rateLimitWarningHeader is set on requests that pass the rate limit but have crossed the group's warning threshold.
+const rateLimitWarningHeader = "X-RateLimit-Warning"
+
+// requestIDHeader carries the ID shared by the request logs of all attempts of a proxied request.
+const requestIDHeader = "X-Request-Id"
+
+// requestIDContextKey holds the request ID for the request logs.
+const requestIDContextKey = "requestID"
+
+// modelVariantContextKey holds the canary variant chosen for the request, recorded in its request logs.
+const modelVariantContextKey = "modelVariant"
+
+// retryCountContextKey holds the number of retries made so far, for structured request logs.
+const retryCountContextKey = "retryCount"
+
+// circuitBreakerProbeContextKey holds the probe token when the request is the group's half-open circuit breaker probe.
+const circuitBreakerProbeContextKey = "circuitBreakerProbe"
+
+// upstreamOverrideHeader lets trusted clients pin a request to one of the group's configured upstreams.
+const upstreamOverrideHeader = "X-Upstream-Override"
+
+// defaultAuthorizationForwardHeader carries the client's Authorization when authorization_forwarding is "header".
+const defaultAuthorizationForwardHeader = "X-Forwarded-Authorization"
+
+// applyAuthorizationForwarding applies the group's authorization_forwarding option once the channel has set
+// its credentials. The client's Authorization was already removed from the upstream request, so "drop"
+// needs nothing here.
+func applyAuthorizationForwarding(req *http.Request, group *models.Group, clientAuth string, apiKey *models.APIKey) {
+	mode := group.ParsedConfig.AuthorizationForwarding
+	if mode == nil {
+		return
+	}
+
+	switch *mode {
+	case models.AuthorizationForwardingHeader:
+		header := defaultAuthorizationForwardHeader
+		if custom := group.ParsedConfig.AuthorizationForwardHeader; custom != nil && *custom != "" {
+			header = *custom
+		}
+		// Never pass through a client-supplied value, it could impersonate another end user
+		req.Header.Del(header)
+		if clientAuth != "" {
+			req.Header.Set(header, clientAuth)
+		}
+	case models.AuthorizationForwardingReplace:
+		req.Header.Set("Authorization", "Bearer "+apiKey.KeyValue)
+	}
+}
+
+// buildUpstreamURL resolves the upstream URL for the request, honoring the X-Upstream-Override
+// header when the group allows it and falling back to weighted selection otherwise.
+func (ps *ProxyServer) buildUpstreamURL(c *gin.Context, channelHandler channel.ChannelProxy, originalGroup, group *models.Group, apiKey *models.APIKey) (string, error) {
+	override := c.GetHeader(upstreamOverrideHeader)
+	if override == "" {
+		return selectUpstreamURL(c, channelHandler, originalGroup, group, apiKey)
+	}
+
+	if allowed := group.ParsedConfig.AllowUpstreamOverride; allowed == nil || !*allowed {
+		logrus.WithFields(logrus.Fields{
+			"group":    group.Name,
+			"override": override,
+		}).Warn("Upstream override header ignored: overrides are not enabled for this group")
+		return selectUpstreamURL(c, channelHandler, originalGroup, group, apiKey)
+	}
+
+	upstreamURL, err := channelHandler.BuildUpstreamURLFor(c.Request.URL, originalGroup.Name, override)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"group":    group.Name,
+			"override": override,
+		}).Warn("Upstream override does not match any configured upstream, falling back to normal selection")
+		return selectUpstreamURL(c, channelHandler, originalGroup, group, apiKey)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group":    group.Name,
+		"upstream": override,
+	}).Info("Using upstream override from request header")
+	return upstreamURL, nil
+}
+
+// selectUpstreamURL picks the upstream by weighted round robin, or jointly with the selected key
+// when the group enables joint_upstream_key_selection. Seeded requests derive the upstream from the
+// seed and the seeded key, so retries move on deterministically together with the key.
+func selectUpstreamURL(c *gin.Context, channelHandler channel.ChannelProxy, originalGroup, group *models.Group, apiKey *models.APIKey) (string, error) {
+	if seed, ok := selectionSeed(c, group); ok && apiKey != nil {
+		return channelHandler.BuildUpstreamURLForSeed(c.Request.URL, originalGroup.Name, seedHash(seed, "upstream", uint64(apiKey.ID)))
+	}
+	if group.EffectiveConfig.JointUpstreamKeySelection && apiKey != nil {
+		return channelHandler.BuildUpstreamURLForKey(c.Request.URL, originalGroup.Name, apiKey.ID)
+	}
+	return channelHandler.BuildUpstreamURL(c.Request.URL, originalGroup.Name)
+}
+
+// recordUpstreamHealth counts the outcome of an upstream attempt towards the upstream's health and the
+// group's circuit breaker: connection errors and 5xx responses are failures, any other response is a
+// success. Errors caused by the client going away say nothing about the upstream and are ignored.
+func (ps *ProxyServer) recordUpstreamHealth(c *gin.Context, channelHandler channel.ChannelProxy, group *models.Group, upstreamURL string, resp *http.Response, err error) {
+	if err != nil && app_errors.IsIgnorableError(err) {
+		return
+	}
+	failed := err != nil || resp == nil || resp.StatusCode >= http.StatusInternalServerError
+	ps.circuitBreaker.Record(group, c.GetString(circuitBreakerProbeContextKey), !failed)
+
+	upstream := channelHandler.UpstreamOf(upstreamURL)
+	if upstream == "" {
+		return
+	}
+
+	if failed {
+		ps.upstreamHealth.RecordUpstreamFailure(group, upstream)
+		return
+	}
+	ps.upstreamHealth.RecordUpstreamSuccess(group.ID, upstream)
+}
+
+// sessionAffinityID returns the session ID the client sent in the group's session_affinity_header,
+// or "" when the group does not use session affinity.
+func sessionAffinityID(c *gin.Context, group *models.Group) string {
+	header := group.ParsedConfig.SessionAffinityHeader
+	if header == nil || *header == "" {
+		return ""
+	}
+	return c.GetHeader(*header)
+}
+
+// writeDeadlineMargin leaves time to write a response that arrived just before the request timeout.
+const writeDeadlineMargin = 30 * time.Second
+
+// extendWriteDeadline keeps the server's write timeout from cutting off an upstream attempt in groups
+// that set request_timeout_seconds; other groups keep the server's timeout. Streams are open-ended,
+// so their deadline is cleared and only the upstream bounds them; other requests get the group's
+// timeout on top of the time already spent.
+func extendWriteDeadline(c *gin.Context, group *models.Group, isStream bool) {
+	timeout := group.ParsedConfig.RequestTimeoutSeconds
+	if timeout == nil || *timeout <= 0 {
+		return
+	}
+	var deadline time.Time
+	if !isStream {
+		deadline = time.Now().Add(time.Duration(*timeout)*time.Second + writeDeadlineMargin)
+	}
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(deadline); err != nil {
+		logrus.WithError(err).Debug("Failed to extend the write deadline for the proxied request")
+	}
+}
+
+// requestBodyLimit returns the group's max_request_body_bytes, 0 meaning unlimited.
+func requestBodyLimit(group *models.Group) int64 {
+	if group.ParsedConfig.MaxRequestBodyBytes == nil {
+		return 0
+	}
+	return int64(*group.ParsedConfig.MaxRequestBodyBytes)
+}
+
+// rejectOversizedRequest answers a request whose body exceeds the group's max_request_body_bytes.
+func rejectOversizedRequest(c *gin.Context, group *models.Group) {
+	logrus.WithFields(logrus.Fields{
+		"group":       group.Name,
+		"limit_bytes": requestBodyLimit(group),
+	}).Warn("Request body exceeds max request body size")
+	response.Error(c, app_errors.ErrRequestTooLarge)
+}
+
+// routedModel returns the requested model when the aggregate group routes models to sub-groups, or ""
+// otherwise. Aggregates have no channel of their own, so the model is extracted with the channel of a
+// sub-group; all sub-groups share the aggregate's channel type.
+func (ps *ProxyServer) routedModel(c *gin.Context, group *models.Group, bodyBytes []byte) string {
+	if group.GroupType != "aggregate" || len(group.ParsedConfig.ModelRouting) == 0 || len(group.SubGroups) == 0 {
+		return ""
+	}
+
+	subGroup, err := ps.groupManager.GetGroupByID(group.SubGroups[0].SubGroupID)
+	if err != nil {
+		return ""
+	}
+	channelHandler, err := ps.channelFactory.GetChannel(subGroup)
+	if err != nil {
+		logrus.WithError(err).WithField("group_name", subGroup.Name).Warn("Failed to get channel for model routing")
+		return ""
+	}
+	return channelHandler.ExtractModel(c, bodyBytes)
+}
+
+// selectionSeedHeader makes upstream and key selection deterministic on groups with allow_seeded_selection.
+// It is a debugging aid for reproducing how a request was routed: seeded requests skip round robin,
+// least_failures, warm-up, joint selection and hedging, so they must not be used for regular traffic.
+const selectionSeedHeader = "X-Debug-Seed"
+
+const maxSelectionSeedLength = 128
+
+// selectionSeed returns the request's selection seed when the group allows seeded selection.
+func selectionSeed(c *gin.Context, group *models.Group) (string, bool) {
+	seed := c.GetHeader(selectionSeedHeader)
+	if seed == "" || len(seed) > maxSelectionSeedLength {
+		return "", false
+	}
+	if allowed := group.ParsedConfig.AllowSeededSelection; allowed == nil || !*allowed {
+		return "", false
+	}
+	return seed, true
+}
+
+// seedHash derives an independent value for each use of a seed.
+func seedHash(seed, purpose string, n uint64) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%s\x00%d", seed, purpose, n)
+	return h.Sum64()
+}
+
+// Replay protection headers. Groups with require_nonce enabled reject requests whose
+// nonce was already seen within the allowed clock skew window.
+const (
+	nonceHeader             = "X-Nonce"
+	nonceTimestampHeader    = "X-Timestamp"
+	defaultNonceSkewSeconds = 300
+	maxNonceLength          = 128
+)
+
+// checkReplayNonce enforces nonce-based replay protection for groups that opt in.
+func (ps *ProxyServer) checkReplayNonce(c *gin.Context, group *models.Group) *app_errors.APIError {
+	if required := group.ParsedConfig.RequireNonce; required == nil || !*required {
+		return nil
+	}
+
+	nonce := c.GetHeader(nonceHeader)
+	if nonce == "" || len(nonce) > maxNonceLength {
+		return app_errors.NewAPIError(app_errors.ErrBadRequest, "Missing or invalid X-Nonce header")
+	}
+
+	timestamp, err := strconv.ParseInt(c.GetHeader(nonceTimestampHeader), 10, 64)
+	if err != nil {
+		return app_errors.NewAPIError(app_errors.ErrBadRequest, "Missing or invalid X-Timestamp header")
+	}
+
+	skew := defaultNonceSkewSeconds
+	if group.ParsedConfig.NonceMaxSkewSeconds != nil && *group.ParsedConfig.NonceMaxSkewSeconds > 0 {
+		skew = *group.ParsedConfig.NonceMaxSkewSeconds
+	}
+	maxSkew := time.Duration(skew) * time.Second
+
+	diff := time.Since(time.Unix(timestamp, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > maxSkew {
+		return app_errors.NewAPIError(app_errors.ErrBadRequest, "X-Timestamp is outside the allowed clock skew")
+	}
+
+	// A timestamp is accepted anywhere in [now-skew, now+skew], so the nonce must be
+	// remembered for the full window to cover every moment it could be replayed.
+	nonceKey := fmt.Sprintf("nonce:%d:%s", group.ID, nonce)
+	ok, err := ps.store.SetNX(nonceKey, []byte("1"), 2*maxSkew)
+	if err != nil {
+		logrus.WithError(err).WithField("group", group.Name).Error("Failed to record request nonce")
+		return app_errors.ErrInternalServer
+	}
+	if !ok {
+		logrus.WithFields(logrus.Fields{
+			"group": group.Name,
+			"nonce": nonce,
+		}).Warn("Rejected replayed request nonce")
+		return app_errors.ErrReplayDetected
+	}
+
+	return nil
+}
+
+// setDiagnosticHeaders exposes which node, upstream and key served the request.
+// Only enabled per group, since it reveals infrastructure details to the client.
+func (ps *ProxyServer) setDiagnosticHeaders(c *gin.Context, originalGroup, group *models.Group, apiKey *models.APIKey, upstreamURL string, retryCount int) {
+	if enabled := originalGroup.ParsedConfig.EnableDiagnosticHeaders; enabled == nil || !*enabled {
+		return
+	}
+
+	c.Header("X-Served-By", ps.nodeID)
+	if parsed, err := url.Parse(upstreamURL); err == nil {
+		c.Header("X-Served-By-Upstream", parsed.Host)
+	}
+	if apiKey != nil {
+		keySuffix := "****"
+		if len(apiKey.KeyValue) > 8 {
+			keySuffix += apiKey.KeyValue[len(apiKey.KeyValue)-4:]
+		}
+		c.Header("X-Served-By-Key", keySuffix)
+	}
+	if group.ID != originalGroup.ID {
+		c.Header("X-Served-By-Group", group.Name)
+	}
+	c.Header("X-Served-By-Retries", strconv.Itoa(retryCount))
+	c.Header("X-Served-By-Retried", strconv.FormatBool(retryCount > 0))
+}
+
+// applyDefaultParams injects the group's default_params into the request body for
+// parameters the client did not send. Client-provided values are never replaced.
+func (ps *ProxyServer) applyDefaultParams(bodyBytes []byte, group *models.Group) ([]byte, error) {
+	defaults := group.ParsedConfig.DefaultParams
+	if len(defaults) == 0 || len(bodyBytes) == 0 {
+		return bodyBytes, nil
+	}
+
+	var requestData map[string]any
+	if err := json.Unmarshal(bodyBytes, &requestData); err != nil {
+		logrus.Warnf("failed to unmarshal request body for default params, passing through: %v", err)
+		return bodyBytes, nil
+	}
+
+	applied := false
+	for key, value := range defaults {
+		if _, exists := requestData[key]; !exists {
+			requestData[key] = value
+			applied = true
+		}
+	}
+	if !applied {
+		return bodyBytes, nil
+	}
+
+	return json.Marshal(requestData)
+}
+
+// applyParamOverrides combines the group's ParamOverrides with the client's request parameters
+// according to param_override_mode:
+//   - "override" (default): the override value always replaces the client value.
+//   - "default": the override is only used when the client did not send the parameter.
+//   - "merge": object parameters are deep-merged, with override values winning on conflicting
+//     leaves; non-object parameters behave as "override".
+//
+// It runs after default_params, so overrides also take precedence over injected defaults.
 func (ps *ProxyServer) applyParamOverrides(bodyBytes []byte, group *models.Group) ([]byte, error) {
 	if len(group.ParamOverrides) == 0 || len(bodyBytes) == 0 {
 		return bodyBytes, nil
@@ -23,13 +362,124 @@ func (ps *ProxyServer) applyParamOverrides(bodyBytes []byte, group *models.Group
 		return bodyBytes, nil
 	}
 
+	mode := models.ParamOverrideModeOverride
+	if group.ParsedConfig.ParamOverrideMode != nil && *group.ParsedConfig.ParamOverrideMode != "" {
+		mode = *group.ParsedConfig.ParamOverrideMode
+	}
+
 	for key, value := range group.ParamOverrides {
-		requestData[key] = value
+		switch mode {
+		case models.ParamOverrideModeDefault:
+			if _, exists := requestData[key]; !exists {
+				requestData[key] = value
+			}
+		case models.ParamOverrideModeMerge:
+			requestData[key] = deepMergeParam(requestData[key], value)
+		default:
+			requestData[key] = value
+		}
 	}
 
 	return json.Marshal(requestData)
 }
 
+// applyBodyRules runs the group's body_rules on the request body, after default_params and param_overrides.
+// Conditions on the model see the model requested by the client, before any model redirect.
+func (ps *ProxyServer) applyBodyRules(c *gin.Context, channelHandler channel.ChannelProxy, bodyBytes []byte, group *models.Group) ([]byte, error) {
+	if len(group.ParsedConfig.BodyRules) == 0 || len(bodyBytes) == 0 {
+		return bodyBytes, nil
+	}
+
+	var requestData map[string]any
+	if err := json.Unmarshal(bodyBytes, &requestData); err != nil {
+		logrus.Warnf("failed to unmarshal request body for body rules, passing through: %v", err)
+		return bodyBytes, nil
+	}
+
+	ruleCtx := utils.BodyRuleContext{
+		Model:  channelHandler.ExtractModel(c, bodyBytes),
+		Header: c.Request.Header,
+	}
+	if !utils.ApplyBodyRules(requestData, group.ParsedConfig.BodyRules, ruleCtx) {
+		return bodyBytes, nil
+	}
+
+	return json.Marshal(requestData)
+}
+
+// applyModelCanary routes the configured share of requests for a canary model to its candidate, after
+// body_rules and before any model redirect. The chosen variant is stored in the context so every log
+// of the request, retries included, records whether the current model or the candidate served it.
+// Only the "model" field of JSON bodies is considered.
+func (ps *ProxyServer) applyModelCanary(c *gin.Context, bodyBytes []byte, group *models.Group) ([]byte, error) {
+	if len(group.ParsedConfig.ModelCanaries) == 0 || len(bodyBytes) == 0 {
+		return bodyBytes, nil
+	}
+
+	var requestData map[string]any
+	if err := json.Unmarshal(bodyBytes, &requestData); err != nil {
+		logrus.Warnf("failed to unmarshal request body for model canary, passing through: %v", err)
+		return bodyBytes, nil
+	}
+
+	model, ok := requestData["model"].(string)
+	if !ok || model == "" {
+		return bodyBytes, nil
+	}
+
+	for _, canary := range group.ParsedConfig.ModelCanaries {
+		if canary.Model != model {
+			continue
+		}
+		if rand.Float64()*100 >= canary.Percent {
+			c.Set(modelVariantContextKey, models.ModelVariantControl)
+			return bodyBytes, nil
+		}
+
+		c.Set(modelVariantContextKey, models.ModelVariantCanary)
+		requestData["model"] = canary.Candidate
+		logrus.WithFields(logrus.Fields{
+			"group":           group.Name,
+			"original_model":  model,
+			"candidate_model": canary.Candidate,
+		}).Debug("Request routed to canary model")
+		return json.Marshal(requestData)
+	}
+
+	return bodyBytes, nil
+}
+
+// deepMergeParam merges override into client when both are objects, recursing into nested
+// objects. In every other case the override value wins.
+func deepMergeParam(client, override any) any {
+	clientMap, clientIsMap := client.(map[string]any)
+	overrideMap, overrideIsMap := override.(map[string]any)
+	if !clientIsMap || !overrideIsMap {
+		return override
+	}
+
+	merged := make(map[string]any, len(clientMap)+len(overrideMap))
+	for key, value := range clientMap {
+		merged[key] = value
+	}
+	for key, value := range overrideMap {
+		merged[key] = deepMergeParam(merged[key], value)
+	}
+	return merged
+}
+
+// adaptRequestBody runs the request body through the channel's body adapters in order.
+func adaptRequestBody(channelHandler channel.ChannelProxy, bodyBytes []byte) ([]byte, error) {
+	for _, adapter := range channelHandler.BodyAdapters() {
+		adapted, err := adapter.AdaptRequest(bodyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("request adapter %s failed: %w", adapter.Name(), err)
+		}
+		bodyBytes = adapted
+	}
+	return bodyBytes, nil
+}
+
 // logUpstreamError provides a centralized way to log errors from upstream interactions.
 func logUpstreamError(context string, err error) {
 	if err == nil {
@@ -42,22 +492,114 @@ func logUpstreamError(context string, err error) {
 	}
 }
 
-// handleGzipCompression checks for gzip encoding and decompresses the body if necessary.
-func handleGzipCompression(resp *http.Response, bodyBytes []byte) []byte {
-	if resp.Header.Get("Content-Encoding") == "gzip" {
-		reader, gzipErr := gzip.NewReader(bytes.NewReader(bodyBytes))
-		if gzipErr != nil {
-			logrus.Warnf("Failed to create gzip reader for error body: %v", gzipErr)
-			return bodyBytes
-		}
-		defer reader.Close()
+// decompressGzipBody decompresses a gzip body, reading at most limit decompressed bytes, or all of
+// them when limit is 0, so a small compressed body cannot expand without bound. It reports whether the
+// decompressed body was longer than the limit.
+func decompressGzipBody(bodyBytes []byte, limit int64) ([]byte, bool, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer reader.Close()
+
+	return readLimitedBody(reader, limit)
+}
+
+// handleGzipCompression checks for gzip encoding and decompresses the body if necessary. Like the raw
+// error body, the decompressed one is cut at limit bytes.
+func handleGzipCompression(resp *http.Response, bodyBytes []byte, limit int64) []byte {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return bodyBytes
+	}
+	decompressedBody, _, err := decompressGzipBody(bodyBytes, limit)
+	if err != nil {
+		logrus.Warnf("Failed to decompress gzip error body: %v", err)
+		return bodyBytes
+	}
+	return decompressedBody
+}
+
+// writeGroupError responds with the group's custom error body for the reason when one is
+// configured, otherwise with the default API error. Hourly, monthly and token bucket limits fall back
+// to the generic rate_limited body.
+func (ps *ProxyServer) writeGroupError(c *gin.Context, group *models.Group, reason string, apiErr *app_errors.APIError, rateLimitErr *app_errors.RateLimitError) {
+	responses := group.ParsedConfig.ErrorResponses
+	body, ok := responses[reason]
+	if !ok && (reason == utils.ErrorReasonHourlyLimit || reason == utils.ErrorReasonMonthlyLimit || reason == utils.ErrorReasonTokenBucket) {
+		body, ok = responses[utils.ErrorReasonRateLimited]
+	}
+	if !ok {
+		response.Error(c, apiErr)
+		return
+	}
 
-		decompressedBody, readAllErr := io.ReadAll(reader)
-		if readAllErr != nil {
-			logrus.Warnf("Failed to decompress gzip error body: %v", readAllErr)
-			return bodyBytes
+	variables := map[string]string{
+		"${REASON}":        reason,
+		"${MESSAGE}":       apiErr.Message,
+		"${STATUS}":        strconv.Itoa(apiErr.HTTPStatus),
+		"${GROUP_NAME}":    group.Name,
+		"${RESET_AT}":      "",
+		"${RESET_AFTER_S}": "",
+		"${LIMIT}":         "",
+		"${USED}":          "",
+	}
+	if rateLimitErr != nil {
+		if !rateLimitErr.ResetAt.IsZero() {
+			variables["${RESET_AT}"] = rateLimitErr.ResetAt.Format(time.RFC3339)
+			variables["${RESET_AFTER_S}"] = strconv.FormatInt(max(int64(time.Until(rateLimitErr.ResetAt).Seconds()), 0), 10)
 		}
-		return decompressedBody
+		if rateLimitErr.Limit > 0 {
+			variables["${LIMIT}"] = strconv.FormatInt(rateLimitErr.Limit, 10)
+			variables["${USED}"] = strconv.FormatInt(rateLimitErr.Used, 10)
+		}
+	}
+
+	c.JSON(apiErr.HTTPStatus, utils.RenderErrorResponseTemplate(body, variables))
+}
+
+// applyRateLimitWarning sets the X-RateLimit-Warning header when the group's usage has crossed its
+// rate_limit_warning_percent threshold. It logs once per limit period and sends the group's
+// notification_webhook once per limit period across all nodes. Traffic is never blocked here.
+func (ps *ProxyServer) applyRateLimitWarning(c *gin.Context, group *models.Group, usage *services.RateLimitUsage) {
+	threshold := group.EffectiveConfig.RateLimitWarningPercent
+	if usage == nil || threshold <= 0 || usage.Ratio*100 < float64(threshold) {
+		return
+	}
+
+	c.Header(rateLimitWarningHeader, fmt.Sprintf("%s; used=%d; limit=%d; reset=%s",
+		usage.Reason, usage.Used, usage.Limit, usage.ResetAt.Format(time.RFC3339)))
+
+	warnKey := fmt.Sprintf("%d:%s", group.ID, usage.Reason)
+	if previous, loaded := ps.rateLimitWarnings.Swap(warnKey, usage.ResetAt); loaded && previous.(time.Time).Equal(usage.ResetAt) {
+		return
+	}
+	logrus.WithFields(logrus.Fields{
+		"group_name": group.Name,
+		"reason":     usage.Reason,
+		"used":       usage.Used,
+		"limit":      usage.Limit,
+		"threshold":  threshold,
+	}).Warn("Group usage crossed rate limit warning threshold")
+
+	if webhook := group.ParsedConfig.NotificationWebhook; webhook == nil || *webhook == "" {
+		return
+	}
+	claimKey := fmt.Sprintf("rate_limit_warning:%s:%d", warnKey, usage.ResetAt.Unix())
+	claimed, err := ps.store.SetNX(claimKey, []byte("1"), max(time.Until(usage.ResetAt), time.Minute))
+	if err != nil {
+		logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to claim rate limit warning webhook")
+		return
+	}
+	if !claimed {
+		return
 	}
-	return bodyBytes
+	ps.alertService.SendEvent(group, services.GroupEventRateLimitWarning, &services.GroupAlert{
+		ThresholdPercent: threshold,
+		RateLimit: &services.GroupAlertRateLimit{
+			Dimension: usage.Reason,
+			Used:      usage.Used,
+			Limit:     usage.Limit,
+			ResetAt:   usage.ResetAt,
+		},
+	})
 }
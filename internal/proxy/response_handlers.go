@@ -1,14 +1,154 @@
 package proxy
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+
+	"aimanager/internal/channel"
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-func (ps *ProxyServer) handleStreamingResponse(c *gin.Context, resp *http.Response) {
+// responseTruncatedHeader marks responses cut at the group's max_response_size_kb in truncate mode.
+const responseTruncatedHeader = "X-Response-Truncated"
+
+// errResponseTooLarge is logged for requests whose buffered response exceeded max_response_size_kb.
+var errResponseTooLarge = errors.New("upstream response exceeds max_response_size_kb")
+
+// responseSizeLimit returns the group's max_response_size_kb in bytes, 0 meaning unlimited. It bounds the
+// decoded size of non-streaming responses buffered for inspection; max_response_body_bytes is the limit
+// for everything forwarded, streams included.
+func responseSizeLimit(group *models.Group) int64 {
+	return int64(group.EffectiveConfig.MaxResponseSizeKB) * 1024
+}
+
+// errResponseBodyTooLarge is logged for responses aborted at the group's max_response_body_bytes.
+var errResponseBodyTooLarge = errors.New("upstream response exceeds max_response_body_bytes")
+
+// errMidStreamFailure marks upstream failures after a streamed response was already sent to the
+// client. Such requests cannot be retried and are not held against the key.
+var errMidStreamFailure = errors.New("upstream stream failed after the response started")
+
+// midStreamError wraps an upstream read error that interrupted a stream. Errors caused by the client
+// going away are not upstream failures and yield nil.
+func midStreamError(err error) error {
+	if app_errors.IsIgnorableError(err) {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", errMidStreamFailure, err)
+}
+
+// failureStage tells whether a failed request failed before or after its stream started.
+func failureStage(isSuccess bool, err error) string {
+	switch {
+	case isSuccess:
+		return ""
+	case errors.Is(err, errMidStreamFailure):
+		return models.FailureStageMidStream
+	default:
+		return models.FailureStagePreStream
+	}
+}
+
+// limitedResponseBody fails reads once the upstream response grows past the group's
+// max_response_body_bytes, so every response handler, streaming or not, stops forwarding there. It counts
+// bytes as received, before any decompression. A buffered response is also subject to max_response_size_kb,
+// and whichever of the two limits is reached first applies.
+type limitedResponseBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+// limitResponseBody wraps resp.Body with the group's max_response_body_bytes. It returns nil when the
+// group has no limit.
+func limitResponseBody(resp *http.Response, group *models.Group) *limitedResponseBody {
+	limit := group.ParsedConfig.MaxResponseBodyBytes
+	if limit == nil || *limit <= 0 {
+		return nil
+	}
+	body := &limitedResponseBody{ReadCloser: resp.Body, remaining: int64(*limit)}
+	resp.Body = body
+	return body
+}
+
+func (b *limitedResponseBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errResponseBodyTooLarge
+	}
+	// Read at most one byte past the limit, which is enough to tell that it was exceeded
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.exceeded = true
+		return n, errResponseBodyTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// readLimitedBody reads at most limit bytes of body, or all of it when limit is 0, and reports
+// whether the body was longer than the limit.
+func readLimitedBody(body io.Reader, limit int64) ([]byte, bool, error) {
+	if limit <= 0 {
+		bodyBytes, err := io.ReadAll(body)
+		return bodyBytes, false, err
+	}
+	bodyBytes, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(bodyBytes)) > limit {
+		return bodyBytes[:limit], true, nil
+	}
+	return bodyBytes, false, nil
+}
+
+// handleOversizedResponse answers a buffered response that exceeded the group's size limit, according
+// to oversized_response_mode: "reject" replaces it with a 502 error, "truncate" writes the received part
+// unprocessed, since inspecting a cut body would fail. A cut encoded body cannot be decoded by the client,
+// so encoded responses are always rejected. Both return errResponseTooLarge for the request log.
+func (ps *ProxyServer) handleOversizedResponse(c *gin.Context, resp *http.Response, group *models.Group, truncated []byte) error {
+	logrus.WithFields(logrus.Fields{
+		"group":    group.Name,
+		"limit_kb": group.EffectiveConfig.MaxResponseSizeKB,
+		"mode":     group.EffectiveConfig.OversizedResponseMode,
+	}).Warn("Upstream response exceeds max response size")
+
+	encoding := resp.Header.Get("Content-Encoding")
+	if group.EffectiveConfig.OversizedResponseMode == "truncate" && (encoding == "" || encoding == "identity") {
+		c.Writer.Header().Set(responseTruncatedHeader, "true")
+		c.Writer.Header().Set("Content-Length", strconv.Itoa(len(truncated)))
+		c.Status(resp.StatusCode)
+		if _, err := c.Writer.Write(truncated); err != nil {
+			logUpstreamError("writing truncated response", err)
+		}
+		return errResponseTooLarge
+	}
+
+	// Upstream headers may already have been copied and no longer describe the body
+	for _, header := range []string{"Content-Length", "Content-Encoding", "Content-Type"} {
+		c.Writer.Header().Del(header)
+	}
+	response.Error(c, app_errors.ErrResponseTooLarge)
+	return errResponseTooLarge
+}
+
+// handleStreamingResponse forwards the upstream stream to the client, flushing after every read.
+// bufferSize controls how many bytes are read per iteration. It returns an errMidStreamFailure when
+// the upstream breaks off the stream.
+func (ps *ProxyServer) handleStreamingResponse(c *gin.Context, resp *http.Response, bufferSize int) error {
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -18,16 +158,16 @@ func (ps *ProxyServer) handleStreamingResponse(c *gin.Context, resp *http.Respon
 	if !ok {
 		logrus.Error("Streaming unsupported by the writer, falling back to normal response")
 		ps.handleNormalResponse(c, resp)
-		return
+		return nil
 	}
 
-	buf := make([]byte, 4*1024)
+	buf := make([]byte, bufferSize)
 	for {
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
 			if _, writeErr := c.Writer.Write(buf[:n]); writeErr != nil {
 				logUpstreamError("writing stream to client", writeErr)
-				return
+				return nil
 			}
 			flusher.Flush()
 		}
@@ -36,9 +176,10 @@ func (ps *ProxyServer) handleStreamingResponse(c *gin.Context, resp *http.Respon
 		}
 		if err != nil {
 			logUpstreamError("reading from upstream", err)
-			return
+			return midStreamError(err)
 		}
 	}
+	return nil
 }
 
 func (ps *ProxyServer) handleNormalResponse(c *gin.Context, resp *http.Response) {
@@ -46,3 +187,50 @@ func (ps *ProxyServer) handleNormalResponse(c *gin.Context, resp *http.Response)
 		logUpstreamError("copying response body", err)
 	}
 }
+
+// handleAdaptedResponse buffers a non-streaming response and runs it through the channel's body
+// adapters before writing it. Headers have already been copied, so the body length is reset here.
+// It returns errResponseTooLarge when the body exceeds the group's max_response_size_kb, before or
+// after gzip decompression.
+func (ps *ProxyServer) handleAdaptedResponse(c *gin.Context, resp *http.Response, adapters []channel.BodyAdapter, group *models.Group) error {
+	limit := responseSizeLimit(group)
+	bodyBytes, oversized, err := readLimitedBody(resp.Body, limit)
+	if err != nil {
+		logUpstreamError("reading response body for adapters", err)
+		return nil
+	}
+	if oversized {
+		return ps.handleOversizedResponse(c, resp, group, bodyBytes)
+	}
+
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		decompressed, oversized, err := decompressGzipBody(bodyBytes, limit)
+		if err != nil {
+			// Adapters cannot work on the encoded body, pass it through unchanged with its encoding
+			logrus.WithError(err).Warn("Failed to decompress gzip response body, returning it unadapted")
+			adapters = nil
+		} else {
+			if oversized {
+				// Still marked as encoded, so it is rejected rather than truncated
+				return ps.handleOversizedResponse(c, resp, group, nil)
+			}
+			bodyBytes = decompressed
+			c.Writer.Header().Del("Content-Encoding")
+		}
+	}
+
+	for _, adapter := range adapters {
+		adapted, err := adapter.AdaptResponse(bodyBytes)
+		if err != nil {
+			logrus.WithError(err).WithField("adapter", adapter.Name()).Warn("Response adapter failed, returning body unchanged by it")
+			continue
+		}
+		bodyBytes = adapted
+	}
+
+	c.Writer.Header().Set("Content-Length", strconv.Itoa(len(bodyBytes)))
+	if _, err := c.Writer.Write(bodyBytes); err != nil {
+		logUpstreamError("writing adapted response", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aimanager/internal/channel"
+	"aimanager/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// packetReader serves a payload the way an upstream connection does: each Read returns at most one
+// packet, however large the caller's buffer is.
+type packetReader struct {
+	payload []byte
+	packet  int
+}
+
+func (r *packetReader) Read(p []byte) (int, error) {
+	if len(r.payload) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.payload[:min(len(r.payload), r.packet)])
+	r.payload = r.payload[n:]
+	return n, nil
+}
+
+func (r *packetReader) Close() error { return nil }
+
+// streamRecorder counts the writes reaching the client and when the first one arrived.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	writes     int
+	firstWrite time.Time
+}
+
+func (w *streamRecorder) Write(p []byte) (int, error) {
+	if w.writes == 0 {
+		w.firstWrite = time.Now()
+	}
+	w.writes++
+	return len(p), nil
+}
+
+// BenchmarkStreamingResponse forwards a 1 MB stream arriving in 16 KB packets with different
+// stream_buffer_size_kb values. Besides throughput it reports the client writes per stream, each a
+// write and flush syscall in production, and the latency until the first byte is written.
+func BenchmarkStreamingResponse(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	const payloadSize, packetSize = 1 << 20, 16 << 10
+	payload := make([]byte, payloadSize)
+	ps := &ProxyServer{}
+
+	for _, sizeKB := range []int{1, 4, 16, 64, 256} {
+		b.Run(fmt.Sprintf("buffer=%dKB", sizeKB), func(b *testing.B) {
+			b.SetBytes(payloadSize)
+			var writes int
+			var firstByte time.Duration
+
+			for range b.N {
+				recorder := &streamRecorder{ResponseRecorder: httptest.NewRecorder()}
+				c, _ := gin.CreateTestContext(recorder)
+				resp := &http.Response{Body: &packetReader{payload: payload, packet: packetSize}}
+
+				start := time.Now()
+				if err := ps.handleStreamingResponse(c, resp, sizeKB*1024); err != nil {
+					b.Fatalf("handleStreamingResponse: %v", err)
+				}
+				writes += recorder.writes
+				firstByte += recorder.firstWrite.Sub(start)
+			}
+
+			b.ReportMetric(float64(writes)/float64(b.N), "writes/op")
+			b.ReportMetric(float64(firstByte.Nanoseconds())/float64(b.N), "ns-first-byte/op")
+		})
+	}
+}
+
+// passthroughAdapter leaves bodies unchanged, so a response takes the adapted response path.
+type passthroughAdapter struct{}
+
+func (passthroughAdapter) Name() string                                   { return "passthrough" }
+func (passthroughAdapter) AdaptRequest(bodyBytes []byte) ([]byte, error)  { return bodyBytes, nil }
+func (passthroughAdapter) AdaptResponse(bodyBytes []byte) ([]byte, error) { return bodyBytes, nil }
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandleAdaptedResponseGzipLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	small := []byte(`{"ok":true}`)
+	// Compresses to about 1 KB, far below the limit, but expands to 1 MB
+	bomb := gzipBytes(t, make([]byte, 1<<20))
+
+	tests := []struct {
+		name       string
+		body       []byte
+		wantStatus int
+		wantErr    error
+		wantBody   []byte
+		// wantEncoding is the Content-Encoding the client receives
+		wantEncoding string
+	}{
+		{"decompressed within limit", gzipBytes(t, small), http.StatusOK, nil, small, ""},
+		{"decompressed over limit", bomb, http.StatusBadGateway, errResponseTooLarge, nil, ""},
+		{"not actually gzip", small, http.StatusOK, nil, small, "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &models.Group{Name: "test"}
+			group.EffectiveConfig.MaxResponseSizeKB = 64
+			group.EffectiveConfig.OversizedResponseMode = "truncate"
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+				Body:       io.NopCloser(bytes.NewReader(tt.body)),
+			}
+			c.Writer.Header().Set("Content-Encoding", "gzip")
+
+			err := (&ProxyServer{}).handleAdaptedResponse(c, resp, []channel.BodyAdapter{passthroughAdapter{}}, group)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handleAdaptedResponse error = %v, want %v", err, tt.wantErr)
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantBody != nil && !bytes.Equal(recorder.Body.Bytes(), tt.wantBody) {
+				t.Errorf("body = %q, want %q", recorder.Body.Bytes(), tt.wantBody)
+			}
+		})
+	}
+}
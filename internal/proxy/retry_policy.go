@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"aimanager/internal/models"
+)
+
+// maxRetryBackoff caps the delay between attempts, however many retries the group allows.
+const maxRetryBackoff = 30 * time.Second
+
+// isFailedAttempt reports whether an upstream attempt failed and may be retried. 404 is passed
+// through to the client unless the group lists it in retry_on_status.
+func isFailedAttempt(group *models.Group, resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if resp == nil || resp.StatusCode < 400 {
+		return false
+	}
+	return resp.StatusCode != http.StatusNotFound || slices.Contains(group.ParsedConfig.RetryOnStatus, http.StatusNotFound)
+}
+
+// countsAgainstKey reports whether a failed attempt counts against its key. A 404 means the path or
+// model is missing upstream, not that the key is bad, even when retry_on_status retries it.
+func countsAgainstKey(resp *http.Response, err error) bool {
+	return err != nil || resp == nil || resp.StatusCode != http.StatusNotFound
+}
+
+// isRetryableFailure reports whether a failed attempt may be retried with another key. Connection
+// errors always may. Without retry_on_status, every failed status may be.
+func isRetryableFailure(group *models.Group, statusCode int, err error) bool {
+	if err != nil || len(group.ParsedConfig.RetryOnStatus) == 0 {
+		return true
+	}
+	return slices.Contains(group.ParsedConfig.RetryOnStatus, statusCode)
+}
+
+// retryBackoff returns the delay before the given retry, counted from 1. It starts at the group's
+// retry_backoff_ms and doubles with every retry, up to maxRetryBackoff.
+func retryBackoff(group *models.Group, retry int) time.Duration {
+	backoffMs := group.ParsedConfig.RetryBackoffMs
+	if backoffMs == nil || *backoffMs <= 0 {
+		return 0
+	}
+	delay := time.Duration(*backoffMs) * time.Millisecond
+	for i := 1; i < retry && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
+
+// waitRetryBackoff sleeps for the delay unless the client goes away first, in which case it returns
+// the context error.
+func waitRetryBackoff(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
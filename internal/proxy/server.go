@@ -8,7 +8,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"aimanager/internal/channel"
@@ -19,9 +24,11 @@ import (
 	"aimanager/internal/models"
 	"aimanager/internal/response"
 	"aimanager/internal/services"
+	"aimanager/internal/store"
 	"aimanager/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,11 +37,24 @@ type ProxyServer struct {
 	keyProvider       *keypool.KeyProvider
 	groupManager      *services.GroupManager
 	subGroupManager   *services.SubGroupManager
+	availability      *services.SubGroupAvailability
 	groupService      *services.GroupService
 	settingsManager   *config.SystemSettingsManager
 	channelFactory    *channel.Factory
 	requestLogService *services.RequestLogService
+	structuredLogger  *services.StructuredRequestLogger
+	globalLimiter     *services.GlobalRateLimiter
+	upstreamHealth    *services.UpstreamHealth
+	requestRate       *services.RequestRateCounter
+	concurrency       *services.ConcurrencyLimiter
+	circuitBreaker    *services.GroupCircuitBreaker
+	alertService      *services.GroupAlertService
 	encryptionSvc     encryption.Service
+	store             store.Store
+	nodeID            string
+
+	rateLimitWarnings sync.Map // "groupID:reason" -> reset time of the period already warned about
+	hedgeBudgets      sync.Map // groupID -> *hedgeBudget
 }
 
 // NewProxyServer creates a new proxy server
@@ -42,21 +62,45 @@ func NewProxyServer(
 	keyProvider *keypool.KeyProvider,
 	groupManager *services.GroupManager,
 	subGroupManager *services.SubGroupManager,
+	availability *services.SubGroupAvailability,
 	groupService *services.GroupService,
 	settingsManager *config.SystemSettingsManager,
 	channelFactory *channel.Factory,
 	requestLogService *services.RequestLogService,
+	structuredLogger *services.StructuredRequestLogger,
+	globalLimiter *services.GlobalRateLimiter,
+	upstreamHealth *services.UpstreamHealth,
+	requestRate *services.RequestRateCounter,
+	concurrency *services.ConcurrencyLimiter,
+	circuitBreaker *services.GroupCircuitBreaker,
+	alertService *services.GroupAlertService,
 	encryptionSvc encryption.Service,
+	store store.Store,
 ) (*ProxyServer, error) {
+	nodeID, err := os.Hostname()
+	if err != nil || nodeID == "" {
+		nodeID = "unknown"
+	}
+
 	return &ProxyServer{
 		keyProvider:       keyProvider,
 		groupManager:      groupManager,
 		subGroupManager:   subGroupManager,
+		availability:      availability,
 		groupService:      groupService,
 		settingsManager:   settingsManager,
 		channelFactory:    channelFactory,
 		requestLogService: requestLogService,
+		structuredLogger:  structuredLogger,
+		globalLimiter:     globalLimiter,
+		upstreamHealth:    upstreamHealth,
+		requestRate:       requestRate,
+		concurrency:       concurrency,
+		circuitBreaker:    circuitBreaker,
+		alertService:      alertService,
 		encryptionSvc:     encryptionSvc,
+		store:             store,
+		nodeID:            nodeID,
 	}, nil
 }
 
@@ -65,20 +109,66 @@ func (ps *ProxyServer) HandleProxy(c *gin.Context) {
 	startTime := time.Now()
 	groupName := c.Param("group_name")
 
+	// Clients can quote the ID to support, who look the request up by it
+	requestID := uuid.NewString()
+	c.Set(requestIDContextKey, requestID)
+	c.Header(requestIDHeader, requestID)
+
+	// Instance-wide ceiling, checked before any per-group work
+	if !ps.globalLimiter.Allow() {
+		c.Header("Retry-After", "1")
+		response.Error(c, app_errors.ErrGlobalRateLimit)
+		return
+	}
+
 	originalGroup, err := ps.groupManager.GetGroupByName(groupName)
 	if err != nil {
 		response.Error(c, app_errors.ParseDBError(err))
 		return
 	}
 
+	// Oversized bodies are rejected without buffering them whole
+	bodyLimit := requestBodyLimit(originalGroup)
+	if bodyLimit > 0 && c.Request.ContentLength > bodyLimit {
+		rejectOversizedRequest(c, originalGroup)
+		return
+	}
+	bodyBytes, oversized, err := readLimitedBody(c.Request.Body, bodyLimit)
+	if err != nil {
+		logrus.Errorf("Failed to read request body: %v", err)
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, "Failed to read request body"))
+		return
+	}
+	c.Request.Body.Close()
+	if oversized {
+		rejectOversizedRequest(c, originalGroup)
+		return
+	}
+
 	// Select sub-group if this is an aggregate group
-	subGroupName, err := ps.subGroupManager.SelectSubGroup(originalGroup)
+	// Skip sub-groups that are paused, circuit-broken or already over their own limits so aggregate traffic falls through to the next one
+	proxyKey := c.GetString("proxyKey")
+	accept := ps.availability.Filter(c.Request.Context(), proxyKey)
+	// Models pinned by model_routing go to their sub-group while it is available
+	subGroupName := ps.subGroupManager.SelectSubGroupForModel(originalGroup, ps.routedModel(c, originalGroup, bodyBytes), accept)
+	if subGroupName == "" {
+		subGroupName, err = ps.subGroupManager.SelectSubGroupForSession(originalGroup, sessionAffinityID(c, originalGroup), accept)
+	}
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"aggregate_group": originalGroup.Name,
 			"error":           err,
 		}).Error("Failed to select sub-group from aggregate")
-		response.Error(c, app_errors.NewAPIError(app_errors.ErrNoKeysAvailable, "No available sub-groups"))
+		ps.writeGroupError(c, originalGroup, utils.ErrorReasonNoKeys, app_errors.NewAPIError(app_errors.ErrNoKeysAvailable, "No available sub-groups"), nil)
+		return
+	}
+
+	if originalGroup.EffectiveConfig.NormalizeProxyPath {
+		normalizeProxyRequestPath(c)
+	}
+
+	if apiErr := ps.checkReplayNonce(c, originalGroup); apiErr != nil {
+		response.Error(c, apiErr)
 		return
 	}
 
@@ -91,11 +181,35 @@ func (ps *ProxyServer) HandleProxy(c *gin.Context) {
 		}
 	}
 
+	if group.AutoPausedAt != nil {
+		ps.writeGroupError(c, originalGroup, utils.ErrorReasonPaused, app_errors.ErrGroupPaused, nil)
+		return
+	}
+
+	allowed, retryAfter, probe := ps.circuitBreaker.Allow(group)
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		ps.writeGroupError(c, originalGroup, utils.ErrorReasonCircuitOpen, app_errors.ErrCircuitOpen, nil)
+		return
+	}
+	if probe != "" {
+		c.Set(circuitBreakerProbeContextKey, probe)
+		// A probe that never reaches upstream must not keep the group blocked until its claim expires
+		defer ps.circuitBreaker.ReleaseProbe(group, probe)
+	}
+
+	if limit := requestBodyLimit(group); group != originalGroup && limit > 0 && int64(len(bodyBytes)) > limit {
+		rejectOversizedRequest(c, group)
+		return
+	}
+
 	// 检查限流和过期
-	if rateLimitErr := ps.groupService.CheckRateLimit(c.Request.Context(), group.ID); rateLimitErr != nil {
-		response.Error(c, rateLimitErr.ToAPIError())
+	usage, rateLimitErr := ps.groupService.CheckRateLimit(c.Request.Context(), group.ID, proxyKey)
+	if rateLimitErr != nil {
+		ps.writeGroupError(c, originalGroup, rateLimitErr.Reason, rateLimitErr.ToAPIError(), rateLimitErr)
 		return
 	}
+	ps.applyRateLimitWarning(c, group, usage)
 
 	channelHandler, err := ps.channelFactory.GetChannel(group)
 	if err != nil {
@@ -103,22 +217,50 @@ func (ps *ProxyServer) HandleProxy(c *gin.Context) {
 		return
 	}
 
-	bodyBytes, err := io.ReadAll(c.Request.Body)
+	finalBodyBytes, err := ps.applyDefaultParams(bodyBytes, group)
 	if err != nil {
-		logrus.Errorf("Failed to read request body: %v", err)
-		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, "Failed to read request body"))
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInternalServer, fmt.Sprintf("Failed to apply default parameters: %v", err)))
 		return
 	}
-	c.Request.Body.Close()
 
-	finalBodyBytes, err := ps.applyParamOverrides(bodyBytes, group)
+	finalBodyBytes, err = ps.applyParamOverrides(finalBodyBytes, group)
 	if err != nil {
 		response.Error(c, app_errors.NewAPIError(app_errors.ErrInternalServer, fmt.Sprintf("Failed to apply parameter overrides: %v", err)))
 		return
 	}
 
+	finalBodyBytes, err = ps.applyBodyRules(c, channelHandler, finalBodyBytes, group)
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInternalServer, fmt.Sprintf("Failed to apply body rules: %v", err)))
+		return
+	}
+
+	finalBodyBytes, err = ps.applyModelCanary(c, finalBodyBytes, group)
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrInternalServer, fmt.Sprintf("Failed to apply model canary: %v", err)))
+		return
+	}
+
+	if apiErr := checkModelAccess(c, channelHandler, originalGroup, group, finalBodyBytes); apiErr != nil {
+		ps.writeGroupError(c, originalGroup, utils.ErrorReasonModelNotAllowed, apiErr, nil)
+		return
+	}
+
 	isStream := channelHandler.IsStreamRequest(c, bodyBytes)
 
+	concurrencyGroups := []*models.Group{group}
+	if group != originalGroup {
+		concurrencyGroups = []*models.Group{originalGroup, group}
+	}
+	release, acquired := ps.concurrency.Acquire(c.Request.Context(), concurrencyGroups...)
+	if !acquired {
+		c.Header("Retry-After", "1")
+		ps.writeGroupError(c, originalGroup, utils.ErrorReasonConcurrency, app_errors.ErrConcurrencyLimit, nil)
+		return
+	}
+	defer release()
+
+	ps.requestRate.Record(group.ID)
 	ps.executeRequestWithRetry(c, channelHandler, originalGroup, group, finalBodyBytes, isStream, startTime, 0)
 }
 
@@ -134,16 +276,29 @@ func (ps *ProxyServer) executeRequestWithRetry(
 	retryCount int,
 ) {
 	cfg := group.EffectiveConfig
-
-	apiKey, err := ps.keyProvider.SelectKey(group.ID)
+	c.Set(retryCountContextKey, retryCount)
+
+	var (
+		apiKey *models.APIKey
+		err    error
+	)
+	seed, seeded := selectionSeed(c, group)
+	if seeded {
+		apiKey, err = ps.keyProvider.SelectKeyForSeed(group.ID, seedHash(seed, "key", 0), retryCount)
+	} else {
+		if retryCount == 0 && c.GetHeader(selectionSeedHeader) != "" {
+			logrus.WithField("group", group.Name).Warn("Selection seed header ignored: seeded selection is not enabled for this group or the seed is too long")
+		}
+		apiKey, err = ps.keyProvider.SelectKeyForGroup(group)
+	}
 	if err != nil {
 		logrus.Errorf("Failed to select a key for group %s on attempt %d: %v", group.Name, retryCount+1, err)
-		response.Error(c, app_errors.NewAPIError(app_errors.ErrNoKeysAvailable, err.Error()))
+		ps.writeGroupError(c, originalGroup, utils.ErrorReasonNoKeys, app_errors.NewAPIError(app_errors.ErrNoKeysAvailable, err.Error()), nil)
 		ps.logRequest(c, originalGroup, group, nil, startTime, http.StatusServiceUnavailable, err, isStream, "", channelHandler, bodyBytes, models.RequestTypeFinal)
 		return
 	}
 
-	upstreamURL, err := channelHandler.BuildUpstreamURL(c.Request.URL, originalGroup.Name)
+	upstreamURL, err := ps.buildUpstreamURL(c, channelHandler, originalGroup, group, apiKey)
 	if err != nil {
 		response.Error(c, app_errors.NewAPIError(app_errors.ErrInternalServer, fmt.Sprintf("Failed to build upstream URL: %v", err)))
 		return
@@ -173,6 +328,10 @@ func (ps *ProxyServer) executeRequestWithRetry(
 	req.Header.Del("Authorization")
 	req.Header.Del("X-Api-Key")
 	req.Header.Del("X-Goog-Api-Key")
+	req.Header.Del(upstreamOverrideHeader)
+	req.Header.Del(selectionSeedHeader)
+	req.Header.Del(nonceHeader)
+	req.Header.Del(nonceTimestampHeader)
 
 	// Apply model redirection
 	finalBodyBytes, err := channelHandler.ApplyModelRedirect(req, bodyBytes, group)
@@ -182,13 +341,23 @@ func (ps *ProxyServer) executeRequestWithRetry(
 		return
 	}
 
-	// Update request body if it was modified by redirection
+	// Apply channel body adapters
+	finalBodyBytes, err = adaptRequestBody(channelHandler, finalBodyBytes)
+	if err != nil {
+		response.Error(c, app_errors.NewAPIError(app_errors.ErrBadRequest, err.Error()))
+		ps.logRequest(c, originalGroup, group, apiKey, startTime, http.StatusBadRequest, err, isStream, upstreamURL, channelHandler, bodyBytes, models.RequestTypeFinal)
+		return
+	}
+
+	// Update request body if it was modified by redirection or adapters
 	if !bytes.Equal(finalBodyBytes, bodyBytes) {
 		req.Body = io.NopCloser(bytes.NewReader(finalBodyBytes))
 		req.ContentLength = int64(len(finalBodyBytes))
 	}
 
 	channelHandler.ModifyRequest(req, apiKey, group)
+	channel.ApplyAuthHeaderMode(req, group, apiKey.KeyValue)
+	applyAuthorizationForwarding(req, group, c.Request.Header.Get("Authorization"), apiKey)
 
 	// Apply custom header rules
 	if len(group.HeaderRuleList) > 0 {
@@ -204,13 +373,24 @@ func (ps *ProxyServer) executeRequestWithRetry(
 		client = channelHandler.GetHTTPClient()
 	}
 
-	resp, err := client.Do(req)
+	extendWriteDeadline(c, group, isStream)
+
+	var resp *http.Response
+	// Which hedged attempt wins depends on timing, so seeded requests are never hedged
+	if !isStream && cfg.HedgeDelayMs > 0 && !seeded {
+		attempt := ps.doHedgedRequest(c, client, channelHandler, originalGroup, group, req, apiKey, upstreamURL, bodyBytes, finalBodyBytes, startTime)
+		resp, err, apiKey, upstreamURL = attempt.resp, attempt.err, attempt.apiKey, attempt.upstreamURL
+	} else {
+		resp, err = client.Do(req)
+	}
 	if resp != nil {
 		defer resp.Body.Close()
 	}
+	ps.recordUpstreamHealth(c, channelHandler, group, upstreamURL, resp, err)
 
-	// Unified error handling for retries. Exclude 404 from being a retryable error.
-	if err != nil || (resp != nil && resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound) {
+	// Unified error handling for retries. Responses only reach the client after this point, so a stream
+	// is never retried once its first byte was sent.
+	if isFailedAttempt(group, resp, err) {
 		if err != nil && app_errors.IsIgnorableError(err) {
 			logrus.Debugf("Client-side ignorable error for key %s, aborting retries: %v", utils.MaskAPIKey(apiKey.KeyValue), err)
 			ps.logRequest(c, originalGroup, group, apiKey, startTime, 499, err, isStream, upstreamURL, channelHandler, bodyBytes, models.RequestTypeFinal)
@@ -229,23 +409,26 @@ func (ps *ProxyServer) executeRequestWithRetry(
 		} else {
 			// HTTP-level error (status >= 400)
 			statusCode = resp.StatusCode
-			errorBody, readErr := io.ReadAll(resp.Body)
+			// Error bodies are only parsed for their message, so an oversized one is simply cut
+			errorBody, _, readErr := readLimitedBody(resp.Body, responseSizeLimit(group))
 			if readErr != nil {
 				logrus.Errorf("Failed to read error body: %v", readErr)
 				errorBody = []byte("Failed to read error body")
 			}
 
-			errorBody = handleGzipCompression(resp, errorBody)
+			errorBody = handleGzipCompression(resp, errorBody, responseSizeLimit(group))
 			errorMessage = string(errorBody)
 			parsedError = app_errors.ParseUpstreamError(errorBody)
 			logrus.Debugf("Request failed with status %d (attempt %d/%d) for key %s. Parsed Error: %s", statusCode, retryCount+1, cfg.MaxRetries, utils.MaskAPIKey(apiKey.KeyValue), parsedError)
 		}
 
 		// 使用解析后的错误信息更新密钥状态
-		ps.keyProvider.UpdateStatus(apiKey, group, false, parsedError)
+		if countsAgainstKey(resp, err) {
+			ps.keyProvider.UpdateStatus(apiKey, group, false, parsedError)
+		}
 
-		// 判断是否为最后一次尝试
-		isLastAttempt := retryCount >= cfg.MaxRetries
+		// 判断是否为最后一次尝试，不在 retry_on_status 中的状态码不重试
+		isLastAttempt := retryCount >= cfg.MaxRetries || !isRetryableFailure(group, statusCode, err)
 		requestType := models.RequestTypeRetry
 		if isLastAttempt {
 			requestType = models.RequestTypeFinal
@@ -257,6 +440,7 @@ func (ps *ProxyServer) executeRequestWithRetry(
 		if isLastAttempt {
 			// 更新统计数据（失败）
 			ps.updateGroupStats(group.ID, false)
+			ps.setDiagnosticHeaders(c, originalGroup, group, apiKey, upstreamURL, retryCount)
 
 			var errorJSON map[string]any
 			if err := json.Unmarshal([]byte(errorMessage), &errorJSON); err == nil {
@@ -267,6 +451,12 @@ func (ps *ProxyServer) executeRequestWithRetry(
 			return
 		}
 
+		if err := waitRetryBackoff(c.Request.Context(), retryBackoff(group, retryCount+1)); err != nil {
+			logrus.Debugf("Client went away during retry backoff for group %s: %v", group.Name, err)
+			ps.logRequest(c, originalGroup, group, apiKey, startTime, 499, err, isStream, upstreamURL, channelHandler, bodyBytes, models.RequestTypeFinal)
+			return
+		}
+
 		ps.executeRequestWithRetry(c, channelHandler, originalGroup, group, bodyBytes, isStream, startTime, retryCount+1)
 		return
 	}
@@ -274,9 +464,17 @@ func (ps *ProxyServer) executeRequestWithRetry(
 	// ps.keyProvider.UpdateStatus(apiKey, group, true) // 请求成功不再重置成功次数，减少IO消耗
 	logrus.Debugf("Request for group %s succeeded on attempt %d with key %s", group.Name, retryCount+1, utils.MaskAPIKey(apiKey.KeyValue))
 
+	ps.setDiagnosticHeaders(c, originalGroup, group, apiKey, upstreamURL, retryCount)
+
 	// Check if this is a model list request (needs special handling)
-	if shouldInterceptModelList(c.Request.URL.Path, c.Request.Method) {
-		ps.handleModelListResponse(c, resp, group, channelHandler)
+	var responseErr error
+	responseBody := limitResponseBody(resp, group)
+	if responseBody != nil && resp.ContentLength > responseBody.remaining {
+		// Known to be oversized before anything is written
+		response.Error(c, app_errors.ErrResponseTooLarge)
+		responseErr = errResponseBodyTooLarge
+	} else if shouldInterceptModelList(c.Request.URL.Path, c.Request.Method) {
+		responseErr = ps.handleModelListResponse(c, resp, group, channelHandler)
 	} else {
 		for key, values := range resp.Header {
 			for _, value := range values {
@@ -285,17 +483,38 @@ func (ps *ProxyServer) executeRequestWithRetry(
 		}
 		c.Status(resp.StatusCode)
 
-		if isStream {
-			ps.handleStreamingResponse(c, resp)
+		// Failures past this point are mid-stream: the client already has part of the response, so the
+		// request is neither retried nor counted against the key
+		if isStream && shouldRewriteStream(group, resp) {
+			responseErr = ps.handleRewrittenStreamingResponse(c, resp, group.ParsedConfig.SSERewrite, cfg.StreamBufferSizeKB*1024)
+		} else if isStream {
+			responseErr = ps.handleStreamingResponse(c, resp, cfg.StreamBufferSizeKB*1024)
+		} else if len(channelHandler.BodyAdapters()) > 0 {
+			responseErr = ps.handleAdaptedResponse(c, resp, channelHandler.BodyAdapters(), group)
 		} else {
 			ps.handleNormalResponse(c, resp)
 		}
 	}
 
-	ps.logRequest(c, originalGroup, group, apiKey, startTime, resp.StatusCode, nil, isStream, upstreamURL, channelHandler, bodyBytes, models.RequestTypeFinal)
+	if responseBody != nil && responseBody.exceeded {
+		logrus.WithFields(logrus.Fields{
+			"group":       group.Name,
+			"limit_bytes": *group.ParsedConfig.MaxResponseBodyBytes,
+		}).Warn("Upstream response exceeds max response body size, aborted")
+		// A stream cut at the limit keeps its mid-stream classification
+		if !errors.Is(responseErr, errResponseBodyTooLarge) {
+			responseErr = errResponseBodyTooLarge
+		}
+	}
+
+	statusCode := resp.StatusCode
+	if responseErr != nil {
+		statusCode = c.Writer.Status()
+	}
+	ps.logRequest(c, originalGroup, group, apiKey, startTime, statusCode, responseErr, isStream, upstreamURL, channelHandler, bodyBytes, models.RequestTypeFinal)
 
 	// 异步更新统计数据
-	ps.updateGroupStats(group.ID, resp.StatusCode < 400)
+	ps.updateGroupStats(group.ID, responseErr == nil && resp.StatusCode < 400)
 }
 
 // logRequest is a helper function to create and record a request log.
@@ -313,10 +532,21 @@ func (ps *ProxyServer) logRequest(
 	bodyBytes []byte,
 	requestType string,
 ) {
+	// Groups that opted out of request logging get no per-request records, structured or stored
+	loggingDisabled := isRequestLoggingDisabled(group) || (originalGroup != nil && isRequestLoggingDisabled(originalGroup))
+	if !loggingDisabled {
+		ps.logStructuredRequest(c, originalGroup, group, apiKey, startTime, statusCode, finalError, isStream, upstreamAddr, channelHandler, bodyBytes, requestType)
+	}
+
 	if ps.requestLogService == nil {
 		return
 	}
 
+	if loggingDisabled {
+		ps.recordStatsOnly(originalGroup, group, apiKey, statusCode, finalError, requestType)
+		return
+	}
+
 	var requestBodyToLog, userAgent string
 
 	if group.EffectiveConfig.EnableRequestBodyLogging {
@@ -339,6 +569,13 @@ func (ps *ProxyServer) logRequest(
 		IsStream:     isStream,
 		UpstreamAddr: utils.TruncateString(upstreamAddr, 500),
 		RequestBody:  requestBodyToLog,
+		ModelVariant: c.GetString(modelVariantContextKey),
+		RequestID:    c.GetString(requestIDContextKey),
+	}
+	logEntry.FailureStage = failureStage(logEntry.IsSuccess, finalError)
+
+	if proxyKey := c.GetString("proxyKey"); proxyKey != "" {
+		logEntry.ProxyKeyHash = ps.encryptionSvc.Hash(proxyKey)
 	}
 
 	// Set parent group
@@ -373,6 +610,107 @@ func (ps *ProxyServer) logRequest(
 	}
 }
 
+// logStructuredRequest emits the structured log line of a proxied request when enabled. The caller skips
+// it for groups that disable request logging. The source IP is resolved like the proxy key allowlist
+// does, honoring trusted_proxy_depth.
+func (ps *ProxyServer) logStructuredRequest(
+	c *gin.Context,
+	originalGroup *models.Group,
+	group *models.Group,
+	apiKey *models.APIKey,
+	startTime time.Time,
+	statusCode int,
+	finalError error,
+	isStream bool,
+	upstreamAddr string,
+	channelHandler channel.ChannelProxy,
+	bodyBytes []byte,
+	requestType string,
+) {
+	if ps.structuredLogger == nil || !ps.structuredLogger.Enabled() {
+		return
+	}
+
+	headers := make(map[string]string, len(c.Request.Header))
+	for name, values := range c.Request.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	fields := logrus.Fields{
+		"request_id":   c.GetString(requestIDContextKey),
+		"group":        group.Name,
+		"request_type": requestType,
+		"retry_count":  c.GetInt(retryCountContextKey),
+		"status_code":  statusCode,
+		"is_success":   finalError == nil && statusCode < 400,
+		"is_stream":    isStream,
+		"duration_ms":  time.Since(startTime).Milliseconds(),
+		"upstream":     upstreamAddr,
+		"method":       c.Request.Method,
+		"path":         c.Request.URL.Path,
+		"source_ip":    "",
+		"headers":      headers,
+	}
+	if originalGroup != nil && originalGroup.ID != group.ID {
+		fields["parent_group"] = originalGroup.Name
+	}
+	// The allowlist checks the requested group, which is the aggregate for sub-group requests
+	trustedProxyDepth := group.EffectiveConfig.TrustedProxyDepth
+	if originalGroup != nil {
+		trustedProxyDepth = originalGroup.EffectiveConfig.TrustedProxyDepth
+	}
+	if ip := utils.ClientIP(c.Request, trustedProxyDepth); ip != nil {
+		fields["source_ip"] = ip.String()
+	}
+	if channelHandler != nil && bodyBytes != nil {
+		fields["model"] = channelHandler.ExtractModel(c, bodyBytes)
+	}
+	if variant := c.GetString(modelVariantContextKey); variant != "" {
+		fields["model_variant"] = variant
+	}
+	if apiKey != nil {
+		fields["key"] = utils.MaskAPIKey(apiKey.KeyValue)
+	}
+	if finalError != nil {
+		fields["error"] = finalError.Error()
+	}
+
+	ps.structuredLogger.Log(fields)
+}
+
+// isRequestLoggingDisabled reports whether the group opted out of request logging.
+func isRequestLoggingDisabled(group *models.Group) bool {
+	return group.ParsedConfig.DisableRequestLogging != nil && *group.ParsedConfig.DisableRequestLogging
+}
+
+// recordStatsOnly counts a request of a group that opted out of request logging. Only the fields the
+// stat counters need are set, so nothing identifying the request or the client leaves this function.
+func (ps *ProxyServer) recordStatsOnly(
+	originalGroup *models.Group,
+	group *models.Group,
+	apiKey *models.APIKey,
+	statusCode int,
+	finalError error,
+	requestType string,
+) {
+	statsEntry := &models.RequestLog{
+		GroupID:     group.ID,
+		IsSuccess:   finalError == nil && statusCode < 400,
+		RequestType: requestType,
+	}
+	statsEntry.FailureStage = failureStage(statsEntry.IsSuccess, finalError)
+	if originalGroup != nil && originalGroup.GroupType == "aggregate" && originalGroup.ID != group.ID {
+		statsEntry.ParentGroupID = originalGroup.ID
+	}
+	if apiKey != nil {
+		statsEntry.KeyHash = ps.encryptionSvc.Hash(apiKey.KeyValue)
+	}
+
+	if err := ps.requestLogService.RecordStatsOnly(statsEntry); err != nil {
+		logrus.Errorf("Failed to record request stats: %v", err)
+	}
+}
+
 // updateGroupStats 异步更新分组统计数据
 func (ps *ProxyServer) updateGroupStats(groupID uint, isSuccess bool) {
 	go func() {
@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"aimanager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// sseDoneEvent is the normalized stream terminator.
+const sseDoneEvent = "data: [DONE]\n\n"
+
+// sseRewriter rewrites a stream event by event. It only decides whether whole events are kept,
+// so data payloads, including partial JSON deltas, are forwarded byte for byte.
+type sseRewriter struct {
+	rules    *models.SSERewriteRules
+	allowed  map[string]struct{}
+	doneSent bool
+}
+
+func newSSERewriter(rules *models.SSERewriteRules) *sseRewriter {
+	r := &sseRewriter{rules: rules}
+	if len(rules.AllowedEvents) > 0 {
+		r.allowed = make(map[string]struct{}, len(rules.AllowedEvents))
+		for _, event := range rules.AllowedEvents {
+			r.allowed[strings.TrimSpace(event)] = struct{}{}
+		}
+	}
+	return r
+}
+
+// shouldRewriteStream reports whether a streaming response can be rewritten. Compressed bodies and
+// non-SSE streams, such as Gemini's JSON array streaming, are passed through untouched.
+func shouldRewriteStream(group *models.Group, resp *http.Response) bool {
+	return group.ParsedConfig.SSERewrite != nil &&
+		resp.Header.Get("Content-Encoding") == "" &&
+		strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// rewriteEvent returns the bytes to send for one event, given its lines including line endings and
+// the blank line that terminated it. A nil result drops the event.
+func (r *sseRewriter) rewriteEvent(lines []string, terminator string) []byte {
+	eventType := "message"
+	var data []string
+	hasFields := false
+	kept := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		content := strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(content, ":") {
+			if !r.rules.StripComments {
+				kept = append(kept, line)
+			}
+			continue
+		}
+
+		hasFields = true
+		kept = append(kept, line)
+		field, value, _ := strings.Cut(content, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil
+	}
+
+	if hasFields && strings.TrimSpace(strings.Join(data, "\n")) == "[DONE]" {
+		if !r.rules.NormalizeDone {
+			return joinSSELines(kept, terminator)
+		}
+		if r.doneSent {
+			return nil
+		}
+		r.doneSent = true
+		return []byte(sseDoneEvent)
+	}
+
+	if hasFields && r.allowed != nil {
+		if _, ok := r.allowed[eventType]; !ok {
+			return nil
+		}
+	}
+
+	return joinSSELines(kept, terminator)
+}
+
+// finish returns the terminator to append once the upstream stream ended cleanly.
+func (r *sseRewriter) finish() []byte {
+	if r.rules.NormalizeDone && !r.doneSent {
+		r.doneSent = true
+		return []byte(sseDoneEvent)
+	}
+	return nil
+}
+
+func joinSSELines(lines []string, terminator string) []byte {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+	buf.WriteString(terminator)
+	return buf.Bytes()
+}
+
+// handleRewrittenStreamingResponse forwards an SSE stream through the group's sse_rewrite rules.
+// Output is always aligned to whole events. Without rechunk, written events are flushed whenever
+// the next read would wait on the upstream; with rechunk, every event is flushed on its own.
+// Like handleStreamingResponse, it returns an errMidStreamFailure when the upstream breaks off the stream.
+func (ps *ProxyServer) handleRewrittenStreamingResponse(c *gin.Context, resp *http.Response, rules *models.SSERewriteRules, bufferSize int) error {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		logrus.Error("Streaming unsupported by the writer, falling back to normal response")
+		ps.handleNormalResponse(c, resp)
+		return nil
+	}
+
+	rewriter := newSSERewriter(rules)
+	reader := bufio.NewReaderSize(resp.Body, bufferSize)
+	pendingFlush := false
+
+	write := func(out []byte) bool {
+		if len(out) == 0 {
+			return true
+		}
+		if _, err := c.Writer.Write(out); err != nil {
+			logUpstreamError("writing stream to client", err)
+			return false
+		}
+		if rules.Rechunk {
+			flusher.Flush()
+		} else {
+			pendingFlush = true
+		}
+		return true
+	}
+
+	var lines []string
+	for {
+		if pendingFlush {
+			if buffered, _ := reader.Peek(reader.Buffered()); bytes.IndexByte(buffered, '\n') < 0 {
+				flusher.Flush()
+				pendingFlush = false
+			}
+		}
+
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if strings.TrimRight(line, "\r\n") == "" && strings.HasSuffix(line, "\n") {
+				if !write(rewriter.rewriteEvent(lines, line)) {
+					return nil
+				}
+				lines = lines[:0]
+			} else {
+				lines = append(lines, line)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logUpstreamError("reading from upstream", err)
+			if pendingFlush {
+				flusher.Flush()
+			}
+			return midStreamError(err)
+		}
+	}
+
+	// An event cut off by the end of the stream is completed so the terminator cannot merge into it
+	if len(lines) > 0 {
+		if !strings.HasSuffix(lines[len(lines)-1], "\n") {
+			lines[len(lines)-1] += "\n"
+		}
+		if !write(rewriter.rewriteEvent(lines, "\n")) {
+			return nil
+		}
+	}
+	if !write(rewriter.finish()) {
+		return nil
+	}
+	flusher.Flush()
+	return nil
+}
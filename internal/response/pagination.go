@@ -27,6 +27,27 @@ type PaginatedResponse struct {
 	Pagination Pagination `json:"pagination"`
 }
 
+// CursorPaginatedResponse is the structure of cursor paginated API responses. NextCursor is empty
+// once the last page has been returned.
+type CursorPaginatedResponse struct {
+	Items      any    `json:"items"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// GetPageSize returns the page_size query parameter, falling back to DefaultPageSize and capped at
+// MaxPageSize.
+func GetPageSize(c *gin.Context) int {
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(DefaultPageSize)))
+	if err != nil || pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return pageSize
+}
+
 // Paginate performs pagination on a GORM query and returns a standardized response.
 // It takes a Gin context, a GORM query builder, and a destination slice for the results.
 func Paginate(c *gin.Context, query *gorm.DB, dest any) (*PaginatedResponse, error) {
@@ -36,13 +57,7 @@ func Paginate(c *gin.Context, query *gorm.DB, dest any) (*PaginatedResponse, err
 		page = 1
 	}
 
-	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(DefaultPageSize)))
-	if err != nil || pageSize <= 0 {
-		pageSize = DefaultPageSize
-	}
-	if pageSize > MaxPageSize {
-		pageSize = MaxPageSize
-	}
+	pageSize := GetPageSize(c)
 
 	// 2. Get total count of items
 	var totalItems int64
@@ -131,23 +131,52 @@ func registerPublicAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Server
 // registerProtectedAPIRoutes 认证API路由
 func registerProtectedAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Server) {
 	api.GET("/channel-types", serverHandler.CommonHandler.GetChannelTypes)
+	api.POST("/upstreams/test", serverHandler.TestUpstream)
 
 	groups := api.Group("/groups")
 	{
 		groups.POST("", serverHandler.CreateGroup)
+		groups.POST("/validate", serverHandler.ValidateGroup)
 		groups.GET("", serverHandler.ListGroups)
 		groups.GET("/list", serverHandler.List)
 		groups.GET("/config-options", serverHandler.GetGroupConfigOptions)
 		groups.GET("/monitor", serverHandler.GetGroupMonitor)
+		groups.GET("/rate-limit-status", serverHandler.GetRateLimitStatus)
 		groups.GET("/monitor/sort-order", serverHandler.GetGroupSortOrder)
 		groups.PUT("/monitor/sort-order", serverHandler.SaveGroupSortOrder)
+		groups.POST("/swap-names", serverHandler.SwapGroupNames)
+		groups.POST("/import-archive", serverHandler.ImportGroupArchive)
+		groups.GET("/export", serverHandler.ExportGroups)
+		groups.POST("/import", serverHandler.ImportGroups)
+		groups.GET("/proxy-key-collisions", serverHandler.GetProxyKeyCollisions)
+		groups.GET("/deleted", serverHandler.ListDeletedGroups)
 		groups.PUT("/:id", serverHandler.UpdateGroup)
 		groups.DELETE("/:id", serverHandler.DeleteGroup)
+		groups.POST("/:id/restore", serverHandler.RestoreGroup)
+		groups.DELETE("/:id/purge", serverHandler.PurgeGroup)
 		groups.GET("/:id/stats", serverHandler.GetGroupStats)
+		groups.GET("/:id/stats/timeseries", serverHandler.GetGroupStatsTimeseries)
+		groups.GET("/:id/usage-projection", serverHandler.GetGroupUsageProjection)
+		groups.GET("/:id/key-count-trend", serverHandler.GetGroupKeyCountTrend)
+		groups.GET("/:id/availability", serverHandler.GetGroupAvailability)
+		groups.GET("/:id/billing-report", serverHandler.GetGroupBillingReport)
+		groups.POST("/:id/resume", serverHandler.ResumeGroup)
+		groups.PUT("/:id/model-redirects", serverHandler.AddModelRedirect)
+		groups.POST("/:id/model-redirects/remove", serverHandler.RemoveModelRedirect)
+		groups.GET("/:id/config-diff", serverHandler.GetGroupConfigDiff)
+		groups.GET("/:id/effective-config", serverHandler.GetGroupEffectiveConfig)
+		groups.POST("/:id/usage/reset", serverHandler.ResetGroupUsage)
+		groups.GET("/:id/usage/resets", serverHandler.ListGroupUsageResets)
 		groups.POST("/:id/copy", serverHandler.CopyGroup)
+		groups.POST("/:id/keys/revalidate-invalid", serverHandler.RevalidateInvalidKeys)
+		groups.GET("/:id/keys/export", serverHandler.ExportGroupKeysCSV)
+		groups.POST("/:id/keys/import-url", serverHandler.ImportKeysFromURL)
 
 		groups.GET("/:id/sub-groups", serverHandler.GetSubGroups)
+		groups.GET("/:id/effective-upstreams", serverHandler.GetEffectiveUpstreams)
+		groups.GET("/:id/routing-weights", serverHandler.GetRoutingWeights)
 		groups.POST("/:id/sub-groups", serverHandler.AddSubGroups)
+		groups.PUT("/:id/sub-groups/weights", serverHandler.UpdateSubGroupWeights)
 		groups.PUT("/:id/sub-groups/:subGroupId/weight", serverHandler.UpdateSubGroupWeight)
 		groups.DELETE("/:id/sub-groups/:subGroupId", serverHandler.DeleteSubGroup)
 		groups.GET("/:id/parent-aggregate-groups", serverHandler.GetParentAggregateGroups)
@@ -158,6 +187,11 @@ func registerProtectedAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Ser
 	{
 		keys.GET("", serverHandler.ListKeysInGroup)
 		keys.GET("/export", serverHandler.ExportKeys)
+		keys.GET("/failure-scores", serverHandler.GetKeyFailureScores)
+		keys.GET("/utilization", serverHandler.GetKeyUtilization)
+		keys.GET("/search", serverHandler.SearchKeys)
+		keys.GET("/pool-state", serverHandler.ExportKeyPoolState)
+		keys.POST("/pool-state", serverHandler.ImportKeyPoolState)
 		keys.POST("/add-multiple", serverHandler.AddMultipleKeys)
 		keys.POST("/add-async", serverHandler.AddMultipleKeysAsync)
 		keys.POST("/delete-multiple", serverHandler.DeleteMultipleKeys)
@@ -166,13 +200,26 @@ func registerProtectedAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Ser
 		keys.POST("/restore-all-invalid", serverHandler.RestoreAllInvalidKeys)
 		keys.POST("/clear-all-invalid", serverHandler.ClearAllInvalidKeys)
 		keys.POST("/clear-all", serverHandler.ClearAllKeys)
+		keys.GET("/orphaned", serverHandler.GetOrphanedKeys)
+		keys.POST("/clear-orphaned", serverHandler.ClearOrphanedKeys)
 		keys.POST("/validate-group", serverHandler.ValidateGroupKeys)
+		keys.POST("/validate-all", serverHandler.ValidateAllKeys)
 		keys.POST("/test-multiple", serverHandler.TestMultipleKeys)
+		keys.POST("/test-models", serverHandler.TestKeyModels)
 		keys.PUT("/:id/notes", serverHandler.UpdateKeyNotes)
+		keys.PUT("/:id/status", serverHandler.UpdateKeyStatus)
+		keys.PUT("/:id/tier", serverHandler.UpdateKeyTier)
+		keys.POST("/:id/disable", serverHandler.DisableKey)
+		keys.POST("/:id/enable", serverHandler.EnableKey)
 	}
 
+	// 登录锁定
+	api.GET("/auth/login-lockouts", serverHandler.GetLoginLockouts)
+	api.POST("/auth/reset-lockout", serverHandler.ResetLoginLockout)
+
 	// Tasks
 	api.GET("/tasks/status", serverHandler.GetTaskStatus)
+	api.POST("/tasks/cancel", serverHandler.CancelTask)
 
 	// 仪表板和日志
 	dashboard := api.Group("/dashboard")
@@ -180,6 +227,7 @@ func registerProtectedAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Ser
 		dashboard.GET("/stats", serverHandler.Stats)
 		dashboard.GET("/chart", serverHandler.Chart)
 		dashboard.GET("/encryption-status", serverHandler.EncryptionStatus)
+		dashboard.GET("/connectivity", serverHandler.Connectivity)
 	}
 
 	// 日志
@@ -187,6 +235,11 @@ func registerProtectedAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Ser
 	{
 		logs.GET("", serverHandler.GetLogs)
 		logs.GET("/export", serverHandler.ExportLogs)
+		logs.GET("/export-ndjson", serverHandler.ExportLogsNDJSON)
+		logs.GET("/models", serverHandler.GetModelUsage)
+		logs.GET("/latency", serverHandler.GetLatencyPercentiles)
+		logs.GET("/diagnose", serverHandler.GetRequestDiagnosis)
+		logs.GET("/tail", serverHandler.TailLogs)
 		logs.DELETE("", serverHandler.ClearLogs)
 	}
 
@@ -195,6 +248,7 @@ func registerProtectedAPIRoutes(api *gin.RouterGroup, serverHandler *handler.Ser
 	{
 		settings.GET("", serverHandler.GetSettings)
 		settings.PUT("", serverHandler.UpdateSettings)
+		settings.GET("/diagnostics", serverHandler.GetSettingsDiagnostics)
 	}
 }
 
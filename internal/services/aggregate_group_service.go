@@ -2,6 +2,11 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"maps"
+	"math"
+	"slices"
+	"sort"
 	"sync"
 
 	app_errors "aimanager/internal/errors"
@@ -40,6 +45,11 @@ func NewAggregateGroupService(db *gorm.DB, groupManager *GroupManager) *Aggregat
 
 // ValidateSubGroups validates sub-groups with an optional existing validation endpoint for consistency check.
 func (s *AggregateGroupService) ValidateSubGroups(ctx context.Context, channelType string, inputs []SubGroupInput, existingEndpoint string) (*AggregateValidationResult, error) {
+	return s.validateSubGroups(s.db.WithContext(ctx), channelType, inputs, existingEndpoint)
+}
+
+// validateSubGroups is ValidateSubGroups against the given db, so it can run inside a transaction.
+func (s *AggregateGroupService) validateSubGroups(db *gorm.DB, channelType string, inputs []SubGroupInput, existingEndpoint string) (*AggregateValidationResult, error) {
 	if len(inputs) == 0 {
 		return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_groups_required", nil)
 	}
@@ -59,7 +69,7 @@ func (s *AggregateGroupService) ValidateSubGroups(ctx context.Context, channelTy
 	}
 
 	var subGroupModels []models.Group
-	if err := s.db.WithContext(ctx).Where("id IN ?", subGroupIDs).Find(&subGroupModels).Error; err != nil {
+	if err := db.Where("id IN ?", subGroupIDs).Find(&subGroupModels).Error; err != nil {
 		return nil, app_errors.ParseDBError(err)
 	}
 
@@ -109,6 +119,35 @@ func (s *AggregateGroupService) ValidateSubGroups(ctx context.Context, channelTy
 	}, nil
 }
 
+// ValidateModelRouting checks that every sub-group the model routing of an aggregate group points to
+// is one of its sub-groups.
+func (s *AggregateGroupService) ValidateModelRouting(ctx context.Context, groupID uint, routing map[string]uint) error {
+	return s.validateModelRouting(s.db.WithContext(ctx), groupID, routing)
+}
+
+// validateModelRouting is ValidateModelRouting against the given db, so it can run inside a transaction.
+func (s *AggregateGroupService) validateModelRouting(db *gorm.DB, groupID uint, routing map[string]uint) error {
+	if len(routing) == 0 {
+		return nil
+	}
+
+	var subGroupIDs []uint
+	if err := db.Model(&models.GroupSubGroup{}).Where("group_id = ?", groupID).Pluck("sub_group_id", &subGroupIDs).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	for _, model := range slices.Sorted(maps.Keys(routing)) {
+		if !slices.Contains(subGroupIDs, routing[model]) {
+			return NewI18nError(app_errors.ErrValidation, "validation.model_routing_sub_group_not_member", map[string]any{
+				"model":        model,
+				"sub_group_id": routing[model],
+			})
+		}
+	}
+
+	return nil
+}
+
 // GetSubGroups returns sub groups for an aggregate group with complete information
 func (s *AggregateGroupService) GetSubGroups(ctx context.Context, groupID uint) ([]models.SubGroupInfo, error) {
 	var group models.Group
@@ -158,17 +197,125 @@ func (s *AggregateGroupService) GetSubGroups(ctx context.Context, groupID uint)
 		}
 
 		subGroups = append(subGroups, models.SubGroupInfo{
-			Group:       subGroup,
-			Weight:      weightMap[subGroup.ID],
-			TotalKeys:   stats.TotalKeys,
-			ActiveKeys:  stats.ActiveKeys,
-			InvalidKeys: stats.InvalidKeys,
+			Group:        subGroup,
+			Weight:       weightMap[subGroup.ID],
+			TotalKeys:    stats.TotalKeys,
+			ActiveKeys:   stats.ActiveKeys,
+			InvalidKeys:  stats.InvalidKeys,
+			DisabledKeys: stats.DisabledKeys,
 		})
 	}
 
 	return subGroups, nil
 }
 
+// EffectiveUpstream is a single upstream reachable through an aggregate group.
+type EffectiveUpstream struct {
+	SubGroupID      uint    `json:"sub_group_id"`
+	SubGroupName    string  `json:"sub_group_name"`
+	URL             string  `json:"url"`
+	SubGroupWeight  int     `json:"sub_group_weight"`
+	UpstreamWeight  int     `json:"upstream_weight"`
+	EffectiveWeight float64 `json:"effective_weight"`
+	Share           float64 `json:"share"`
+}
+
+// GetEffectiveUpstreams flattens the upstreams of all sub-groups of an aggregate group.
+// The effective weight is the sub-group weight multiplied by the upstream's fraction of its sub-group's
+// upstream weight, and share is its percentage of the total effective weight.
+func (s *AggregateGroupService) GetEffectiveUpstreams(ctx context.Context, groupID uint) ([]EffectiveUpstream, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).First(&group, groupID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NewI18nError(app_errors.ErrResourceNotFound, "group.not_found", nil)
+		}
+		return nil, err
+	}
+
+	if group.GroupType != "aggregate" {
+		return nil, NewI18nError(app_errors.ErrBadRequest, "group.not_aggregate", nil)
+	}
+
+	var groupSubGroups []models.GroupSubGroup
+	if err := s.db.WithContext(ctx).Where("group_id = ?", groupID).Order("id asc").Find(&groupSubGroups).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]EffectiveUpstream, 0)
+	if len(groupSubGroups) == 0 {
+		return result, nil
+	}
+
+	subGroupIDs := make([]uint, 0, len(groupSubGroups))
+	for _, gsg := range groupSubGroups {
+		subGroupIDs = append(subGroupIDs, gsg.SubGroupID)
+	}
+
+	var subGroupModels []models.Group
+	if err := s.db.WithContext(ctx).Where("id IN ?", subGroupIDs).Find(&subGroupModels).Error; err != nil {
+		return nil, err
+	}
+	subGroupMap := make(map[uint]models.Group, len(subGroupModels))
+	for _, sg := range subGroupModels {
+		subGroupMap[sg.ID] = sg
+	}
+
+	totalWeight := 0.0
+	for _, gsg := range groupSubGroups {
+		subGroup, ok := subGroupMap[gsg.SubGroupID]
+		if !ok {
+			continue
+		}
+
+		var defs []struct {
+			URL    string `json:"url"`
+			Weight int    `json:"weight"`
+		}
+		if err := json.Unmarshal(subGroup.Upstreams, &defs); err != nil {
+			logrus.WithContext(ctx).WithError(err).
+				WithField("group_id", subGroup.ID).
+				Warn("failed to parse sub-group upstreams, skipping")
+			continue
+		}
+
+		// The sub-group is picked first and then one of its upstreams, so upstream weights only
+		// split the sub-group's weight among its own upstreams
+		upstreamTotal := 0
+		for _, def := range defs {
+			upstreamTotal += def.Weight
+		}
+		if upstreamTotal <= 0 {
+			continue
+		}
+
+		totalWeight += float64(gsg.Weight)
+		for _, def := range defs {
+			effectiveWeight := float64(gsg.Weight) * float64(def.Weight) / float64(upstreamTotal)
+			result = append(result, EffectiveUpstream{
+				SubGroupID:      subGroup.ID,
+				SubGroupName:    subGroup.Name,
+				URL:             def.URL,
+				SubGroupWeight:  gsg.Weight,
+				UpstreamWeight:  def.Weight,
+				EffectiveWeight: effectiveWeight,
+			})
+		}
+	}
+
+	for i := range result {
+		if totalWeight > 0 {
+			result[i].Share = math.Round(result[i].EffectiveWeight/totalWeight*10000) / 100
+		}
+		result[i].EffectiveWeight = math.Round(result[i].EffectiveWeight*100) / 100
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].EffectiveWeight > result[j].EffectiveWeight
+	})
+
+	return result, nil
+}
+
 // AddSubGroups adds new sub groups to an aggregate group
 func (s *AggregateGroupService) AddSubGroups(ctx context.Context, groupID uint, inputs []SubGroupInput) error {
 	var group models.Group
@@ -292,6 +439,117 @@ func (s *AggregateGroupService) UpdateSubGroupWeight(ctx context.Context, groupI
 	return nil
 }
 
+// SetSubGroupWeights updates the weights of several sub groups in one transaction.
+// Sub groups not present in weights keep their current weight; the resulting set must
+// keep at least one positive weight. Returns the updated sub groups.
+func (s *AggregateGroupService) SetSubGroupWeights(ctx context.Context, groupID uint, weights map[uint]int) ([]models.SubGroupInfo, error) {
+	if len(weights) == 0 {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_group_weights_empty", nil)
+	}
+
+	for _, weight := range weights {
+		if weight < 0 {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_group_weight_negative", nil)
+		}
+		if weight > 1000 {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_group_weight_max_exceeded", nil)
+		}
+	}
+
+	return s.updateSubGroupWeights(ctx, groupID, func([]models.GroupSubGroup) map[uint]int {
+		return weights
+	})
+}
+
+// updateSubGroupWeights writes the weights returned by newWeights in one transaction. newWeights gets the
+// sub groups as read inside that transaction, so weights derived from the current ones cannot be based
+// on a stale read. Returns the updated sub groups.
+func (s *AggregateGroupService) updateSubGroupWeights(ctx context.Context, groupID uint, newWeights func([]models.GroupSubGroup) map[uint]int) ([]models.SubGroupInfo, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var group models.Group
+		if err := tx.First(&group, groupID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NewI18nError(app_errors.ErrResourceNotFound, "group.not_found", nil)
+			}
+			return err
+		}
+
+		if group.GroupType != "aggregate" {
+			return NewI18nError(app_errors.ErrBadRequest, "group.not_aggregate", nil)
+		}
+
+		var existingSubGroups []models.GroupSubGroup
+		if err := tx.Where("group_id = ?", groupID).Find(&existingSubGroups).Error; err != nil {
+			return err
+		}
+		if len(existingSubGroups) == 0 {
+			return NewI18nError(app_errors.ErrResourceNotFound, "group.sub_group_not_found", nil)
+		}
+		weights := newWeights(existingSubGroups)
+
+		existing := make(map[uint]bool, len(existingSubGroups))
+		hasPositive := false
+		for _, sg := range existingSubGroups {
+			existing[sg.SubGroupID] = true
+			weight := sg.Weight
+			if newWeight, ok := weights[sg.SubGroupID]; ok {
+				weight = newWeight
+			}
+			if weight > 0 {
+				hasPositive = true
+			}
+		}
+
+		for subGroupID := range weights {
+			if !existing[subGroupID] {
+				return NewI18nError(app_errors.ErrResourceNotFound, "group.sub_group_not_found", nil)
+			}
+		}
+
+		if !hasPositive {
+			return NewI18nError(app_errors.ErrValidation, "validation.sub_group_weights_all_zero", nil)
+		}
+
+		for subGroupID, weight := range weights {
+			if err := tx.Model(&models.GroupSubGroup{}).
+				Where("group_id = ? AND sub_group_id = ?", groupID, subGroupID).
+				Update("weight", weight).Error; err != nil {
+				return app_errors.ParseDBError(err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 触发缓存更新
+	if err := s.groupManager.Invalidate(); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache after updating sub group weights")
+	}
+
+	return s.GetSubGroups(ctx, groupID)
+}
+
+// ScaleSubGroupWeights multiplies every sub group weight by factor, rounding to the nearest
+// integer and clamping to 0..1000. The weights are read and written in one transaction.
+func (s *AggregateGroupService) ScaleSubGroupWeights(ctx context.Context, groupID uint, factor float64) ([]models.SubGroupInfo, error) {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_weight_scale", nil)
+	}
+
+	return s.updateSubGroupWeights(ctx, groupID, func(existingSubGroups []models.GroupSubGroup) map[uint]int {
+		weights := make(map[uint]int, len(existingSubGroups))
+		for _, sg := range existingSubGroups {
+			// Clamp before converting, a large factor would overflow int
+			scaled := math.Min(math.Max(math.Round(float64(sg.Weight)*factor), 0), 1000)
+			weights[sg.SubGroupID] = int(scaled)
+		}
+		return weights
+	})
+}
+
 // DeleteSubGroup removes a sub group from an aggregate group
 func (s *AggregateGroupService) DeleteSubGroup(ctx context.Context, groupID, subGroupID uint) error {
 	var group models.Group
@@ -331,7 +589,7 @@ func (s *AggregateGroupService) CountAggregateGroupsUsingSubGroup(ctx context.Co
 	var count int64
 	err := s.db.WithContext(ctx).
 		Model(&models.GroupSubGroup{}).
-		Where("sub_group_id = ?", subGroupID).
+		Where("sub_group_id = ? AND group_id IN (?)", subGroupID, s.db.Model(&models.Group{}).Select("id")).
 		Count(&count).Error
 
 	if err != nil {
@@ -380,11 +638,12 @@ func (s *AggregateGroupService) GetParentAggregateGroups(ctx context.Context, su
 
 // keyStatsResult stores key statistics for a single group
 type keyStatsResult struct {
-	GroupID     uint
-	TotalKeys   int64
-	ActiveKeys  int64
-	InvalidKeys int64
-	Err         error
+	GroupID      uint
+	TotalKeys    int64
+	ActiveKeys   int64
+	InvalidKeys  int64
+	DisabledKeys int64
+	Err          error
 }
 
 // fetchSubGroupsKeyStats batch fetches key statistics for multiple sub-groups concurrently
@@ -415,35 +674,20 @@ func (s *AggregateGroupService) fetchSubGroupsKeyStats(ctx context.Context, grou
 		go func(gid uint) {
 			defer wg.Done()
 
-			var totalKeys, activeKeys int64
 			result := keyStatsResult{GroupID: gid}
 
-			// Query total keys
-			if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
-				Where("group_id = ?", gid).
-				Count(&totalKeys).Error; err != nil {
+			counts, err := countKeysByStatus(s.db.WithContext(ctx), gid)
+			if err != nil {
 				result.Err = err
-				mu.Lock()
-				results[gid] = result
-				mu.Unlock()
-				return
+			} else {
+				for _, count := range counts {
+					result.TotalKeys += count
+				}
+				result.ActiveKeys = counts[models.KeyStatusActive]
+				result.InvalidKeys = counts[models.KeyStatusInvalid]
+				result.DisabledKeys = counts[models.KeyStatusDisabled]
 			}
 
-			// Query active keys
-			if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
-				Where("group_id = ? AND status = ?", gid, models.KeyStatusActive).
-				Count(&activeKeys).Error; err != nil {
-				result.Err = err
-				mu.Lock()
-				results[gid] = result
-				mu.Unlock()
-				return
-			}
-
-			result.TotalKeys = totalKeys
-			result.ActiveKeys = activeKeys
-			result.InvalidKeys = totalKeys - activeKeys
-
 			mu.Lock()
 			results[gid] = result
 			mu.Unlock()
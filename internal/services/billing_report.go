@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+)
+
+const (
+	// billingReportDateLayout is the format of the report's start and end dates.
+	billingReportDateLayout = "2006-01-02"
+	// DefaultBillingReportDays is the range used when the caller does not specify one.
+	DefaultBillingReportDays = 30
+	// MaxBillingReportDays bounds the number of request logs scanned for one report.
+	MaxBillingReportDays = 366
+)
+
+// BillingReport summarizes a group's traffic over a date range. For aggregate groups the totals and
+// models roll up every sub-group, and each sub-group gets its own section in SubGroups.
+type BillingReport struct {
+	GroupID   uint                `json:"group_id"`
+	GroupName string              `json:"group_name"`
+	GroupType string              `json:"group_type"`
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	Totals    RequestStats        `json:"totals"`
+	Models    []BillingModelUsage `json:"models"`
+	SubGroups []BillingReport     `json:"sub_groups,omitempty"`
+}
+
+// BillingModelUsage is the request count of one model within a billing report.
+type BillingModelUsage struct {
+	Model string `json:"model"`
+	RequestStats
+}
+
+// GetBillingReport builds the billing report of a group for the inclusive date range [startDate, endDate],
+// both formatted as YYYY-MM-DD in server local time. Totals come from the hourly statistics and, for days
+// past stats_hourly_retention_days, from the daily statistics they were rolled up into; the model
+// breakdown comes from final request logs, so it may cover less traffic when logs were skipped or cleaned up.
+func (s *GroupService) GetBillingReport(ctx context.Context, groupID uint, startDate, endDate string) (*BillingReport, error) {
+	start, end, err := parseBillingReportRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "name", "group_type").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	memberGroups := []models.Group{group}
+	if group.GroupType == "aggregate" {
+		subGroupIDs, err := s.aggregateGroupService.GetSubGroupIDs(ctx, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sub-group IDs: %w", err)
+		}
+		memberGroups = nil
+		if len(subGroupIDs) > 0 {
+			if err := s.db.WithContext(ctx).Select("id", "name", "group_type").
+				Where("id IN ?", subGroupIDs).Order("name asc").Find(&memberGroups).Error; err != nil {
+				return nil, app_errors.ParseDBError(err)
+			}
+		}
+	}
+
+	memberIDs := make([]uint, 0, len(memberGroups))
+	for _, member := range memberGroups {
+		memberIDs = append(memberIDs, member.ID)
+	}
+	totals, modelCounts, err := s.queryBillingCounts(ctx, memberIDs, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	newReport := func(g models.Group) BillingReport {
+		return BillingReport{
+			GroupID:   g.ID,
+			GroupName: g.Name,
+			GroupType: g.GroupType,
+			StartDate: start.Format(billingReportDateLayout),
+			EndDate:   end.AddDate(0, 0, -1).Format(billingReportDateLayout),
+		}
+	}
+
+	report := newReport(group)
+	rolledUpModels := make(map[string]*billingCounts)
+	var rolledUp billingCounts
+
+	for _, member := range memberGroups {
+		counts := totals[member.ID]
+		rolledUp.add(counts)
+		for model, c := range modelCounts[member.ID] {
+			if rolledUpModels[model] == nil {
+				rolledUpModels[model] = &billingCounts{}
+			}
+			rolledUpModels[model].add(*c)
+		}
+
+		if group.GroupType == "aggregate" {
+			section := newReport(member)
+			section.Totals = counts.stats()
+			section.Models = billingModelUsages(modelCounts[member.ID])
+			report.SubGroups = append(report.SubGroups, section)
+		}
+	}
+
+	report.Totals = rolledUp.stats()
+	report.Models = billingModelUsages(rolledUpModels)
+	if group.GroupType == "aggregate" && report.SubGroups == nil {
+		report.SubGroups = make([]BillingReport, 0)
+	}
+
+	return &report, nil
+}
+
+// billingCounts accumulates success and failure counts.
+type billingCounts struct {
+	Success int64
+	Failure int64
+}
+
+func (c *billingCounts) add(other billingCounts) {
+	c.Success += other.Success
+	c.Failure += other.Failure
+}
+
+func (c billingCounts) stats() RequestStats {
+	return calculateRequestStats(c.Success+c.Failure, c.Failure)
+}
+
+// queryBillingCounts loads per-group totals from group_hourly_stats and group_daily_stats, and per-group,
+// per-model counts from the final request logs within [start, end). Hourly stats are deleted once rolled
+// up into daily stats, so the two never overlap.
+func (s *GroupService) queryBillingCounts(ctx context.Context, groupIDs []uint, start, end time.Time) (map[uint]billingCounts, map[uint]map[string]*billingCounts, error) {
+	totals := make(map[uint]billingCounts)
+	modelCounts := make(map[uint]map[string]*billingCounts)
+	if len(groupIDs) == 0 {
+		return totals, modelCounts, nil
+	}
+
+	var totalRows []struct {
+		GroupID      uint
+		SuccessCount int64
+		FailureCount int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.GroupHourlyStat{}).
+		Select("group_id, SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+		Where("group_id IN ? AND time >= ? AND time < ?", groupIDs, start, end).
+		Group("group_id").
+		Scan(&totalRows).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+	for _, row := range totalRows {
+		totals[row.GroupID] = billingCounts{Success: row.SuccessCount, Failure: row.FailureCount}
+	}
+
+	var dailyRows []struct {
+		GroupID      uint
+		SuccessCount int64
+		FailureCount int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.GroupDailyStat{}).
+		Select("group_id, SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+		Where("group_id IN ? AND day >= ? AND day < ?", groupIDs, start, end).
+		Group("group_id").
+		Scan(&dailyRows).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+	for _, row := range dailyRows {
+		counts := totals[row.GroupID]
+		counts.add(billingCounts{Success: row.SuccessCount, Failure: row.FailureCount})
+		totals[row.GroupID] = counts
+	}
+
+	var modelRows []struct {
+		GroupID      uint
+		Model        string
+		SuccessCount int64
+		FailureCount int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.RequestLog{}).
+		Select("group_id, model, SUM(CASE WHEN is_success THEN 1 ELSE 0 END) as success_count, SUM(CASE WHEN is_success THEN 0 ELSE 1 END) as failure_count").
+		Where("group_id IN ? AND timestamp >= ? AND timestamp < ? AND request_type = ?", groupIDs, start, end, models.RequestTypeFinal).
+		Group("group_id, model").
+		Scan(&modelRows).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+	for _, row := range modelRows {
+		if modelCounts[row.GroupID] == nil {
+			modelCounts[row.GroupID] = make(map[string]*billingCounts)
+		}
+		modelCounts[row.GroupID][row.Model] = &billingCounts{Success: row.SuccessCount, Failure: row.FailureCount}
+	}
+
+	return totals, modelCounts, nil
+}
+
+// billingModelUsages turns model counts into a list ordered by request count, busiest first.
+func billingModelUsages(counts map[string]*billingCounts) []BillingModelUsage {
+	usages := make([]BillingModelUsage, 0, len(counts))
+	for model, c := range counts {
+		usages = append(usages, BillingModelUsage{Model: model, RequestStats: c.stats()})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].TotalRequests == usages[j].TotalRequests {
+			return usages[i].Model < usages[j].Model
+		}
+		return usages[i].TotalRequests > usages[j].TotalRequests
+	})
+	return usages
+}
+
+// parseBillingReportRange turns inclusive start and end dates into the half-open range [start, end).
+// Missing dates default to the last DefaultBillingReportDays days ending today.
+func parseBillingReportRange(startDate, endDate string) (time.Time, time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	end := today
+	if endDate != "" {
+		parsed, err := time.ParseInLocation(billingReportDateLayout, endDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, NewI18nError(app_errors.ErrValidation, "validation.invalid_billing_report_date", map[string]any{"value": endDate})
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -(DefaultBillingReportDays - 1))
+	if startDate != "" {
+		parsed, err := time.ParseInLocation(billingReportDateLayout, startDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, NewI18nError(app_errors.ErrValidation, "validation.invalid_billing_report_date", map[string]any{"value": startDate})
+		}
+		start = parsed
+	}
+
+	end = end.AddDate(0, 0, 1)
+	if !start.Before(end) || start.AddDate(0, 0, MaxBillingReportDays).Before(end) {
+		return time.Time{}, time.Time{}, NewI18nError(app_errors.ErrValidation, "validation.invalid_billing_report_range", map[string]any{"max": MaxBillingReportDays})
+	}
+
+	return start, end, nil
+}
+
+// WriteCSV writes the report as CSV. Every row carries its section: "total" and "model" rows describe
+// the group itself, "sub_group" and "sub_group_model" rows the sub-groups of an aggregate group.
+func (r *BillingReport) WriteCSV(writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+
+	header := []string{"section", "group_name", "model", "start_date", "end_date", "total_requests", "failed_requests", "failure_rate"}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	writeRow := func(section string, report *BillingReport, model string, stats RequestStats) error {
+		return csvWriter.Write([]string{
+			section,
+			report.GroupName,
+			model,
+			report.StartDate,
+			report.EndDate,
+			strconv.FormatInt(stats.TotalRequests, 10),
+			strconv.FormatInt(stats.FailedRequests, 10),
+			strconv.FormatFloat(stats.FailureRate, 'f', 4, 64),
+		})
+	}
+
+	writeSection := func(totalSection, modelSection string, report *BillingReport) error {
+		if err := writeRow(totalSection, report, "", report.Totals); err != nil {
+			return err
+		}
+		for _, usage := range report.Models {
+			if err := writeRow(modelSection, report, usage.Model, usage.RequestStats); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := writeSection("total", "model", r); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+	for i := range r.SubGroups {
+		if err := writeSection("sub_group", "sub_group_model", &r.SubGroups[i]); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aimanager/internal/config"
+	"aimanager/internal/models"
+)
+
+// Overflow behaviors when a concurrency limit is reached.
+const (
+	ConcurrencyOverflowReject = "reject"
+	ConcurrencyOverflowQueue  = "queue"
+)
+
+// GlobalConcurrencyStats describes the instance-wide concurrency limiter for monitoring.
+type GlobalConcurrencyStats struct {
+	Limit    int `json:"limit"`
+	InFlight int `json:"in_flight"`
+}
+
+// concurrencySlots is a semaphore whose limit is passed on every acquire, so configuration changes apply
+// to the next request. Waiters are served in arrival order: a released slot is handed to the oldest
+// waiter instead of being freed.
+type concurrencySlots struct {
+	mu       sync.Mutex
+	inFlight int
+	waiters  []chan struct{}
+}
+
+// acquire takes a slot if fewer than limit are in use, or always when limit is 0. Otherwise it waits up
+// to wait for a slot, or fails at once when wait is 0.
+func (s *concurrencySlots) acquire(ctx context.Context, limit int, wait time.Duration) bool {
+	s.mu.Lock()
+	if limit <= 0 || s.inFlight < limit {
+		s.inFlight++
+		s.mu.Unlock()
+		return true
+	}
+	if wait <= 0 {
+		s.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	s.waiters = append(s.waiters, ready)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for i, waiter := range s.waiters {
+		if waiter == ready {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			s.mu.Unlock()
+			return false
+		}
+	}
+	s.mu.Unlock()
+	// The slot was handed over while giving up, pass it on
+	s.release()
+	return false
+}
+
+func (s *concurrencySlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiters) > 0 {
+		close(s.waiters[0])
+		s.waiters = s.waiters[1:]
+		return
+	}
+	s.inFlight--
+}
+
+func (s *concurrencySlots) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight
+}
+
+// ConcurrencyLimiter caps the requests being forwarded at the same time, across all groups with
+// global_max_concurrent_requests and per group with max_concurrent_requests. Slots are counted on this
+// instance only, like the global rate limiter, so with several nodes each one allows the limit.
+type ConcurrencyLimiter struct {
+	settingsManager *config.SystemSettingsManager
+	global          concurrencySlots
+	groups          sync.Map // groupID -> *concurrencySlots
+}
+
+// NewConcurrencyLimiter creates a new ConcurrencyLimiter.
+func NewConcurrencyLimiter(settingsManager *config.SystemSettingsManager) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{settingsManager: settingsManager}
+}
+
+// Acquire takes a slot of each given group, e.g. an aggregate group and the sub-group serving the
+// request, and then a global slot. When a limit is reached the request is rejected or waits for a slot,
+// as the group's concurrency_overflow says. Group slots come first so requests queued behind a saturated
+// group never hold global slots other groups could use. On success the returned function releases all
+// slots and must be called once the request is done; on failure nothing is held.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, groups ...*models.Group) (func(), bool) {
+	var held []*concurrencySlots
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].release()
+		}
+	}
+
+	for _, group := range groups {
+		limit := 0
+		if group.ParsedConfig.MaxConcurrentRequests != nil {
+			limit = *group.ParsedConfig.MaxConcurrentRequests
+		}
+		slots := l.groupSlots(group.ID)
+		wait := overflowWait(group.EffectiveConfig.ConcurrencyOverflow, group.EffectiveConfig.ConcurrencyWaitTimeoutMs)
+		if !slots.acquire(ctx, limit, wait) {
+			release()
+			return nil, false
+		}
+		held = append(held, slots)
+	}
+
+	settings := l.settingsManager.GetSettings()
+	if !l.global.acquire(ctx, settings.GlobalMaxConcurrent, overflowWait(settings.ConcurrencyOverflow, settings.ConcurrencyWaitTimeoutMs)) {
+		release()
+		return nil, false
+	}
+	held = append(held, &l.global)
+	return release, true
+}
+
+// InFlight returns the number of requests of the group being forwarded on this instance.
+func (l *ConcurrencyLimiter) InFlight(groupID uint) int {
+	if slots, ok := l.groups.Load(groupID); ok {
+		return slots.(*concurrencySlots).count()
+	}
+	return 0
+}
+
+// Stats returns the configured global limit and the requests being forwarded on this instance.
+func (l *ConcurrencyLimiter) Stats() GlobalConcurrencyStats {
+	return GlobalConcurrencyStats{
+		Limit:    l.settingsManager.GetSettings().GlobalMaxConcurrent,
+		InFlight: l.global.count(),
+	}
+}
+
+func (l *ConcurrencyLimiter) groupSlots(groupID uint) *concurrencySlots {
+	if slots, ok := l.groups.Load(groupID); ok {
+		return slots.(*concurrencySlots)
+	}
+	slots, _ := l.groups.LoadOrStore(groupID, &concurrencySlots{})
+	return slots.(*concurrencySlots)
+}
+
+// overflowWait returns how long to wait for a slot under the overflow behavior, 0 meaning reject.
+func overflowWait(overflow string, waitTimeoutMs int) time.Duration {
+	if overflow != ConcurrencyOverflowQueue {
+		return 0
+	}
+	return time.Duration(waitTimeoutMs) * time.Millisecond
+}
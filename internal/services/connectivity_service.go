@@ -0,0 +1,115 @@
+package services
+
+import (
+	"aimanager/internal/store"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	connectivityProbeKey     = "diagnostics:connectivity_probe"
+	connectivityProbeTTL     = 30 * time.Second
+	connectivityCheckTimeout = 5 * time.Second
+)
+
+// ConnectivityCheck is the result of probing a single backing service.
+type ConnectivityCheck struct {
+	Type      string  `json:"type"`
+	OK        bool    `json:"ok"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ConnectivityReport holds round-trip timings to the database and the cache store.
+type ConnectivityReport struct {
+	Database  ConnectivityCheck `json:"database"`
+	Store     ConnectivityCheck `json:"store"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// ConnectivityService measures connectivity to the infrastructure the application depends on.
+type ConnectivityService struct {
+	db    *gorm.DB
+	store store.Store
+}
+
+// NewConnectivityService creates a new ConnectivityService.
+func NewConnectivityService(db *gorm.DB, store store.Store) *ConnectivityService {
+	return &ConnectivityService{
+		db:    db,
+		store: store,
+	}
+}
+
+// Check runs a trivial query against the database and a set/get/delete round trip against the
+// store, reporting the latency of each.
+func (s *ConnectivityService) Check(ctx context.Context) ConnectivityReport {
+	ctx, cancel := context.WithTimeout(ctx, connectivityCheckTimeout)
+	defer cancel()
+
+	return ConnectivityReport{
+		Database:  s.checkDatabase(ctx),
+		Store:     s.checkStore(),
+		CheckedAt: time.Now(),
+	}
+}
+
+func (s *ConnectivityService) checkDatabase(ctx context.Context) ConnectivityCheck {
+	check := ConnectivityCheck{Type: s.db.Dialector.Name()}
+
+	start := time.Now()
+	var result int
+	err := s.db.WithContext(ctx).Raw("SELECT 1").Scan(&result).Error
+	check.LatencyMs = elapsedMs(start)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.OK = true
+	return check
+}
+
+func (s *ConnectivityService) checkStore() ConnectivityCheck {
+	check := ConnectivityCheck{Type: storeType(s.store)}
+	value := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+
+	start := time.Now()
+	err := s.store.Set(connectivityProbeKey, value, connectivityProbeTTL)
+	if err == nil {
+		var got []byte
+		got, err = s.store.Get(connectivityProbeKey)
+		if err == nil && !bytes.Equal(got, value) {
+			err = fmt.Errorf("store returned a different value than written")
+		}
+	}
+	check.LatencyMs = elapsedMs(start)
+	_ = s.store.Delete(connectivityProbeKey)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.OK = true
+	return check
+}
+
+func storeType(s store.Store) string {
+	switch s.(type) {
+	case *store.RedisStore:
+		return "redis"
+	case *store.MemoryStore:
+		return "memory"
+	default:
+		return fmt.Sprintf("%T", s)
+	}
+}
+
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"aimanager/internal/config"
+	"sync"
+	"time"
+)
+
+// GlobalRateLimitStats describes the instance-wide rate limiter for monitoring.
+type GlobalRateLimitStats struct {
+	Enabled    bool   `json:"enabled"`
+	LimitRPS   int    `json:"limit_rps"`
+	Burst      int    `json:"burst"`
+	CurrentRPS int    `json:"current_rps"`
+	Rejected   uint64 `json:"rejected"`
+}
+
+// GlobalRateLimiter is a token bucket that caps requests per second across all groups on this instance.
+// It re-reads its limits from system settings, so changes apply without a restart.
+type GlobalRateLimiter struct {
+	settingsManager *config.SystemSettingsManager
+
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+
+	// Admitted requests in the current and previous one-second windows, used to report the current rate.
+	windowStart  time.Time
+	windowCount  int
+	previousRate int
+	rejected     uint64
+}
+
+// NewGlobalRateLimiter creates a new GlobalRateLimiter.
+func NewGlobalRateLimiter(settingsManager *config.SystemSettingsManager) *GlobalRateLimiter {
+	return &GlobalRateLimiter{
+		settingsManager: settingsManager,
+	}
+}
+
+// limits returns the configured rate and burst. A rate of 0 disables the limiter.
+func (l *GlobalRateLimiter) limits() (int, int) {
+	settings := l.settingsManager.GetSettings()
+	rps := settings.GlobalRateLimitRPS
+	burst := settings.GlobalRateLimitBurst
+	if burst <= 0 {
+		burst = rps
+	}
+	return rps, burst
+}
+
+// Allow reports whether a request may proceed, consuming a token when it does.
+func (l *GlobalRateLimiter) Allow() bool {
+	rps, burst := l.limits()
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advanceWindow(now)
+
+	if rps <= 0 {
+		l.windowCount++
+		return true
+	}
+
+	if l.lastRefill.IsZero() {
+		l.tokens = float64(burst)
+	} else {
+		l.tokens += now.Sub(l.lastRefill).Seconds() * float64(rps)
+	}
+	l.lastRefill = now
+	if l.tokens > float64(burst) {
+		l.tokens = float64(burst)
+	}
+
+	if l.tokens < 1 {
+		l.rejected++
+		return false
+	}
+
+	l.tokens--
+	l.windowCount++
+	return true
+}
+
+// advanceWindow rolls the rate window forward. Must be called with mu held.
+func (l *GlobalRateLimiter) advanceWindow(now time.Time) {
+	current := now.Truncate(time.Second)
+	if current.Equal(l.windowStart) {
+		return
+	}
+	if current.Sub(l.windowStart) == time.Second {
+		l.previousRate = l.windowCount
+	} else {
+		l.previousRate = 0
+	}
+	l.windowStart = current
+	l.windowCount = 0
+}
+
+// Stats returns the configured limits, the admitted rate over the last full second and the total rejections.
+func (l *GlobalRateLimiter) Stats() GlobalRateLimitStats {
+	rps, burst := l.limits()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advanceWindow(time.Now())
+
+	return GlobalRateLimitStats{
+		Enabled:    rps > 0,
+		LimitRPS:   rps,
+		Burst:      burst,
+		CurrentRPS: l.previousRate,
+		Rejected:   l.rejected,
+	}
+}
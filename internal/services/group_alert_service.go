@@ -0,0 +1,346 @@
+package services
+
+import (
+	"aimanager/internal/config"
+	"aimanager/internal/keypool"
+	"aimanager/internal/models"
+	"aimanager/internal/store"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultAlertActiveKeyPercent is the active key share (%) below which a group with a webhook alerts.
+	DefaultAlertActiveKeyPercent = 20
+	// DefaultAlertFailureRatePercent is the 24h failure rate (%) from which a group with a webhook alerts.
+	DefaultAlertFailureRatePercent = 50
+	// groupAlertFailureWindowHours is the window the failure rate is computed over.
+	groupAlertFailureWindowHours = 24
+	// groupAlertMinRequests is how many requests the window needs before its failure rate counts.
+	groupAlertMinRequests = 20
+	// groupAlertRepeatInterval is how often an alert is sent again while its condition persists.
+	groupAlertRepeatInterval = 24 * time.Hour
+	// groupAlertWebhookTimeout bounds one webhook delivery.
+	groupAlertWebhookTimeout = 10 * time.Second
+)
+
+// Conditions a group alert is sent for.
+const (
+	GroupAlertLowActiveKeys   = "low_active_keys"
+	GroupAlertHighFailureRate = "high_failure_rate"
+)
+
+// State changes a group event is sent for.
+const (
+	GroupEventAutoPaused       = "group_auto_paused"
+	GroupEventResumed          = "group_resumed"
+	GroupEventRateLimitWarning = "rate_limit_warning"
+)
+
+// GroupAlert is the JSON payload posted to a group's notification_webhook. Keys is set for
+// low_active_keys alerts, Requests for high_failure_rate and group_auto_paused events, and RateLimit
+// for rate_limit_warning events.
+type GroupAlert struct {
+	Event            string               `json:"event"`
+	GroupID          uint                 `json:"group_id"`
+	GroupName        string               `json:"group_name"`
+	ThresholdPercent int                  `json:"threshold_percent"`
+	Reason           string               `json:"reason,omitempty"`
+	Keys             *GroupAlertKeys      `json:"keys,omitempty"`
+	Requests         *GroupAlertRequests  `json:"requests,omitempty"`
+	RateLimit        *GroupAlertRateLimit `json:"rate_limit,omitempty"`
+	TriggeredAt      time.Time            `json:"triggered_at"`
+}
+
+// GroupAlertKeys are the key counts behind a low_active_keys alert.
+type GroupAlertKeys struct {
+	Active        int64   `json:"active"`
+	Total         int64   `json:"total"`
+	ActivePercent float64 `json:"active_percent"`
+}
+
+// GroupAlertRequests are the request counts behind a high_failure_rate alert.
+type GroupAlertRequests struct {
+	WindowHours        int     `json:"window_hours"`
+	Total              int64   `json:"total"`
+	Failures           int64   `json:"failures"`
+	FailureRatePercent float64 `json:"failure_rate_percent"`
+}
+
+// GroupAlertRateLimit is the usage behind a rate_limit_warning event.
+type GroupAlertRateLimit struct {
+	Dimension string    `json:"dimension"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// GroupAlertService posts to a group's notification_webhook when the share of its active keys drops
+// below notification_active_key_percent, or its 24h failure rate reaches
+// notification_failure_rate_percent. It checks at the key validation cadence. An alert is sent once
+// when its condition starts and again every groupAlertRepeatInterval while it persists; the debounce
+// is kept in the store, so it survives restarts.
+type GroupAlertService struct {
+	db              *gorm.DB
+	store           store.Store
+	settingsManager *config.SystemSettingsManager
+	client          *http.Client
+	stopCh          chan struct{}
+	wg              sync.WaitGroup
+}
+
+// NewGroupAlertService creates a new GroupAlertService.
+func NewGroupAlertService(db *gorm.DB, store store.Store, settingsManager *config.SystemSettingsManager) *GroupAlertService {
+	return &GroupAlertService{
+		db:              db,
+		store:           store,
+		settingsManager: settingsManager,
+		client:          &http.Client{Timeout: groupAlertWebhookTimeout},
+		stopCh:          make(chan struct{}),
+	}
+}
+
+// Start begins the periodic checks. It only runs on the master node.
+func (s *GroupAlertService) Start() {
+	s.wg.Add(1)
+	go s.run()
+	logrus.Debug("Group alert service started")
+}
+
+// Stop stops the check loop, respecting the context for shutdown timeout.
+func (s *GroupAlertService) Stop(ctx context.Context) {
+	close(s.stopCh)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("GroupAlertService stopped gracefully.")
+	case <-ctx.Done():
+		logrus.Warn("GroupAlertService stop timed out.")
+	}
+}
+
+func (s *GroupAlertService) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(keypool.CronCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkGroups()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// checkGroups evaluates every standard group that has a notification webhook.
+func (s *GroupAlertService) checkGroups() {
+	ctx := context.Background()
+
+	var groups []models.Group
+	if err := s.db.WithContext(ctx).
+		Select("id", "name", "config").
+		Where("group_type <> ?", "aggregate").
+		Find(&groups).Error; err != nil {
+		logrus.WithError(err).Error("Failed to load groups for alert checks")
+		return
+	}
+
+	for i := range groups {
+		group := &groups[i]
+		parseGroupConfig(group)
+		groupConfig := &group.ParsedConfig
+		if groupConfig.NotificationWebhook == nil || *groupConfig.NotificationWebhook == "" {
+			continue
+		}
+		webhook := *groupConfig.NotificationWebhook
+
+		if threshold := alertThreshold(groupConfig.NotificationActiveKeyPercent, DefaultAlertActiveKeyPercent); threshold > 0 {
+			alert, err := s.checkActiveKeys(ctx, group, threshold)
+			if err != nil {
+				logrus.WithError(err).WithField("group_name", group.Name).Error("Failed to check active keys for alerts")
+			} else {
+				s.notify(ctx, group, webhook, GroupAlertLowActiveKeys, alert)
+			}
+		}
+
+		if threshold := alertThreshold(groupConfig.NotificationFailureRatePercent, DefaultAlertFailureRatePercent); threshold > 0 {
+			alert, err := s.checkFailureRate(ctx, group, threshold)
+			if err != nil {
+				logrus.WithError(err).WithField("group_name", group.Name).Error("Failed to check failure rate for alerts")
+			} else {
+				s.notify(ctx, group, webhook, GroupAlertHighFailureRate, alert)
+			}
+		}
+	}
+}
+
+// checkActiveKeys returns an alert if the share of active keys is below the threshold. Groups without
+// keys are not alerted on, since they are not set up yet.
+func (s *GroupAlertService) checkActiveKeys(ctx context.Context, group *models.Group, threshold int) (*GroupAlert, error) {
+	var counts struct {
+		TotalKeys  int64
+		ActiveKeys int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Select("COUNT(*) as total_keys, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as active_keys", models.KeyStatusActive).
+		Where("group_id = ?", group.ID).
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	if counts.TotalKeys == 0 || counts.ActiveKeys*100 >= int64(threshold)*counts.TotalKeys {
+		return nil, nil
+	}
+
+	return &GroupAlert{
+		ThresholdPercent: threshold,
+		Keys: &GroupAlertKeys{
+			Active:        counts.ActiveKeys,
+			Total:         counts.TotalKeys,
+			ActivePercent: percentOf(counts.ActiveKeys, counts.TotalKeys),
+		},
+	}, nil
+}
+
+// checkFailureRate returns an alert if the failure rate of the last 24 hours reached the threshold.
+func (s *GroupAlertService) checkFailureRate(ctx context.Context, group *models.Group, threshold int) (*GroupAlert, error) {
+	var result struct {
+		SuccessCount int64
+		FailureCount int64
+	}
+	startTime, endTime := hourlyStatsWindow(groupAlertFailureWindowHours, false)
+	if err := s.db.WithContext(ctx).Model(&models.GroupHourlyStat{}).
+		Select("SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+		Where("group_id = ? AND time >= ? AND time < ?", group.ID, startTime, endTime).
+		Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	requests := result.SuccessCount + result.FailureCount
+	if requests < groupAlertMinRequests || result.FailureCount*100 < int64(threshold)*requests {
+		return nil, nil
+	}
+
+	return &GroupAlert{
+		ThresholdPercent: threshold,
+		Requests: &GroupAlertRequests{
+			WindowHours:        groupAlertFailureWindowHours,
+			Total:              requests,
+			Failures:           result.FailureCount,
+			FailureRatePercent: percentOf(result.FailureCount, requests),
+		},
+	}, nil
+}
+
+// notify posts the alert unless it was already sent for this condition. A nil alert means the
+// condition cleared, which re-arms it.
+func (s *GroupAlertService) notify(ctx context.Context, group *models.Group, webhook, event string, alert *GroupAlert) {
+	debounceKey := groupAlertDebounceKey(group.ID, event)
+	if alert == nil {
+		if err := s.store.Delete(debounceKey); err != nil {
+			logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to clear group alert debounce")
+		}
+		return
+	}
+
+	first, err := s.store.SetNX(debounceKey, []byte("1"), groupAlertRepeatInterval)
+	if err != nil {
+		logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to check group alert debounce")
+		return
+	}
+	if !first {
+		return
+	}
+
+	alert.Event = event
+	alert.GroupID = group.ID
+	alert.GroupName = group.Name
+	alert.TriggeredAt = time.Now()
+
+	if err := s.postWebhook(ctx, webhook, alert); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"group_name": group.Name, "event": event}).Warn("Failed to deliver group alert webhook")
+		// Retry on the next check instead of waiting out the repeat interval
+		if err := s.store.Delete(debounceKey); err != nil {
+			logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to clear group alert debounce")
+		}
+		return
+	}
+	logrus.WithFields(logrus.Fields{"group_name": group.Name, "event": event}).Info("Group alert webhook delivered")
+}
+
+// SendEvent posts a one-off event, such as a group state change, to the group's notification_webhook.
+// Events are not debounced, callers send them once per change. Delivery happens in the background so
+// callers on the request path are not held up; groups without a webhook are skipped. The webhook is
+// read from group.ParsedConfig, so groups loaded from the database must be parsed first.
+func (s *GroupAlertService) SendEvent(group *models.Group, event string, alert *GroupAlert) {
+	if group.ParsedConfig.NotificationWebhook == nil || *group.ParsedConfig.NotificationWebhook == "" {
+		return
+	}
+	webhook := *group.ParsedConfig.NotificationWebhook
+
+	alert.Event = event
+	alert.GroupID = group.ID
+	alert.GroupName = group.Name
+	alert.TriggeredAt = time.Now()
+
+	go func() {
+		if err := s.postWebhook(context.Background(), webhook, alert); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"group_name": group.Name, "event": event}).Warn("Failed to deliver group event webhook")
+			return
+		}
+		logrus.WithFields(logrus.Fields{"group_name": group.Name, "event": event}).Info("Group event webhook delivered")
+	}()
+}
+
+func (s *GroupAlertService) postWebhook(ctx context.Context, webhook string, alert *GroupAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// alertThreshold returns a percentage from the group config, falling back to def when it is unset.
+func alertThreshold(value *int, def int) int {
+	if value == nil {
+		return def
+	}
+	return *value
+}
+
+// percentOf returns part as a percentage of total, rounded down to two decimals.
+func percentOf(part, total int64) float64 {
+	return float64(part*10000/total) / 100
+}
+
+func groupAlertDebounceKey(groupID uint, event string) string {
+	return fmt.Sprintf("group_alert:%d:%s", groupID, event)
+}
@@ -0,0 +1,626 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"aimanager/internal/encryption"
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// GroupArchiveVersion is the archive format version written by ExportGroups.
+const GroupArchiveVersion = 1
+
+// Per-group outcomes of an archive import.
+const (
+	GroupArchiveStatusCreated = "created"
+	GroupArchiveStatusFailed  = "failed"
+)
+
+// GroupArchive is a migration document holding group definitions and their keys.
+// When KeysEncrypted is set, key values are ciphertexts produced with the source instance's
+// ENCRYPTION_KEY and are decrypted with the key supplied at import time.
+type GroupArchive struct {
+	Version       int                 `json:"version"`
+	KeysEncrypted bool                `json:"keys_encrypted"`
+	Groups        []GroupArchiveEntry `json:"groups"`
+}
+
+// GroupArchiveEntry is one group of an archive. Aggregate groups reference their sub-groups by name,
+// either from the same archive or already present on this instance. Their model_routing is carried in
+// ModelRouting by sub-group name too, since group IDs differ between instances.
+type GroupArchiveEntry struct {
+	Name                string                 `json:"name"`
+	DisplayName         string                 `json:"display_name"`
+	Description         string                 `json:"description"`
+	GroupType           string                 `json:"group_type"`
+	Upstreams           json.RawMessage        `json:"upstreams"`
+	ChannelType         string                 `json:"channel_type"`
+	Sort                int                    `json:"sort"`
+	TestModel           string                 `json:"test_model"`
+	ValidationEndpoint  string                 `json:"validation_endpoint"`
+	ParamOverrides      map[string]any         `json:"param_overrides"`
+	ModelRedirectRules  map[string]string      `json:"model_redirect_rules"`
+	ModelRedirectStrict bool                   `json:"model_redirect_strict"`
+	Config              map[string]any         `json:"config"`
+	HeaderRules         []models.HeaderRule    `json:"header_rules"`
+	ProxyKeys           string                 `json:"proxy_keys"`
+	SubGroups           []GroupArchiveSubGroup `json:"sub_groups,omitempty"`
+	ModelRouting        map[string]string      `json:"model_routing,omitempty"`
+	Keys                []string               `json:"keys,omitempty"`
+}
+
+// GroupArchiveSubGroup references a sub-group of an aggregate group by name.
+type GroupArchiveSubGroup struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// GroupArchiveGroupResult reports what happened to one group of an archive.
+type GroupArchiveGroupResult struct {
+	Name        string `json:"name"`
+	GroupID     uint   `json:"group_id,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	KeysQueued  int    `json:"keys_queued"`
+	KeysSkipped int    `json:"keys_skipped"`
+
+	// Err is the underlying error, kept so callers can translate I18nError messages.
+	Err error `json:"-"`
+}
+
+// GroupArchiveImportResult summarizes an archive import. Key imports run in the background,
+// one group at a time, and report through the task status.
+type GroupArchiveImportResult struct {
+	Created    int                       `json:"created"`
+	Failed     int                       `json:"failed"`
+	KeysQueued int                       `json:"keys_queued"`
+	Groups     []GroupArchiveGroupResult `json:"groups"`
+}
+
+// GroupImportError reports the group that made a transactional import fail.
+type GroupImportError struct {
+	GroupName string
+	Err       error
+}
+
+func (e *GroupImportError) Error() string {
+	return fmt.Sprintf("failed to import group '%s': %v", e.GroupName, e.Err)
+}
+
+func (e *GroupImportError) Unwrap() error {
+	return e.Err
+}
+
+// GroupImportGroupResult reports the name one archive group was imported under.
+type GroupImportGroupResult struct {
+	Name         string `json:"name"`
+	ImportedName string `json:"imported_name"`
+	GroupID      uint   `json:"group_id"`
+	KeysQueued   int    `json:"keys_queued"`
+	KeysSkipped  int    `json:"keys_skipped"`
+}
+
+// GroupImportResult summarizes a transactional import. Key imports run in the background after the
+// groups are committed and report through the task status.
+type GroupImportResult struct {
+	Created    int                      `json:"created"`
+	Renamed    int                      `json:"renamed"`
+	KeysQueued int                      `json:"keys_queued"`
+	Groups     []GroupImportGroupResult `json:"groups"`
+}
+
+// GroupArchiveService exports groups to and imports groups and their keys from a migration archive.
+type GroupArchiveService struct {
+	db                    *gorm.DB
+	groupService          *GroupService
+	aggregateGroupService *AggregateGroupService
+	keyImportService      *KeyImportService
+}
+
+// NewGroupArchiveService creates a new GroupArchiveService.
+func NewGroupArchiveService(
+	db *gorm.DB,
+	groupService *GroupService,
+	aggregateGroupService *AggregateGroupService,
+	keyImportService *KeyImportService,
+) *GroupArchiveService {
+	return &GroupArchiveService{
+		db:                    db,
+		groupService:          groupService,
+		aggregateGroupService: aggregateGroupService,
+		keyImportService:      keyImportService,
+	}
+}
+
+// ImportArchive creates every group of the archive through the regular validated create path,
+// standard groups first so aggregates can reference them, then queues their keys for import.
+// A failing group does not stop the others.
+func (s *GroupArchiveService) ImportArchive(ctx context.Context, archive *GroupArchive, sourceEncryptionKey string) (*GroupArchiveImportResult, error) {
+	if len(archive.Groups) == 0 {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.archive_no_groups", nil)
+	}
+
+	var decrypter encryption.Service
+	if archive.KeysEncrypted {
+		if sourceEncryptionKey == "" {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.archive_source_key_required", nil)
+		}
+		svc, err := encryption.NewService(sourceEncryptionKey)
+		if err != nil {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.archive_source_key_invalid", map[string]any{"error": err.Error()})
+		}
+		decrypter = svc
+	}
+
+	result := &GroupArchiveImportResult{Groups: make([]GroupArchiveGroupResult, len(archive.Groups))}
+	var jobs []KeyImportJob
+
+	for _, i := range archiveImportOrder(archive.Groups) {
+		entry := archive.Groups[i]
+		groupResult := GroupArchiveGroupResult{Name: entry.Name, Status: GroupArchiveStatusFailed}
+
+		group, err := s.createGroup(ctx, &entry)
+		if err != nil {
+			groupResult.Err = err
+			groupResult.Error = err.Error()
+		}
+		if group == nil {
+			result.Groups[i] = groupResult
+			result.Failed++
+			continue
+		}
+		groupResult.GroupID = group.ID
+		groupResult.Status = GroupArchiveStatusCreated
+		result.Created++
+
+		if group.GroupType != "aggregate" && len(entry.Keys) > 0 {
+			records, skipped := decodeArchiveKeys(entry.Keys, decrypter)
+			groupResult.KeysSkipped = skipped
+			if len(records) > 0 {
+				jobs = append(jobs, KeyImportJob{Group: group, Records: records})
+				groupResult.KeysQueued = len(records)
+				result.KeysQueued += len(records)
+			}
+		}
+
+		result.Groups[i] = groupResult
+	}
+
+	if len(jobs) > 0 {
+		s.keyImportService.QueueImports(jobs)
+	}
+
+	return result, nil
+}
+
+// createGroup creates one archive entry and, for aggregates, attaches its sub-groups by name.
+// The group is returned along with the error when only attaching sub-groups failed.
+func (s *GroupArchiveService) createGroup(ctx context.Context, entry *GroupArchiveEntry) (*models.Group, error) {
+	group, err := s.groupService.CreateGroup(ctx, entry.createParams())
+	if err != nil {
+		return nil, err
+	}
+
+	if group.GroupType != "aggregate" || len(entry.SubGroups) == 0 {
+		return group, nil
+	}
+
+	inputs := make([]SubGroupInput, 0, len(entry.SubGroups))
+	subGroupIDs := make(map[string]uint, len(entry.SubGroups))
+	for _, sub := range entry.SubGroups {
+		var subGroup models.Group
+		if err := s.db.WithContext(ctx).Where("name = ?", sub.Name).First(&subGroup).Error; err != nil {
+			return group, fmt.Errorf("group created but sub-group '%s' could not be found: %w", sub.Name, err)
+		}
+		inputs = append(inputs, SubGroupInput{GroupID: subGroup.ID, Weight: sub.Weight})
+		subGroupIDs[strings.TrimSpace(sub.Name)] = subGroup.ID
+	}
+	if err := s.aggregateGroupService.AddSubGroups(ctx, group.ID, inputs); err != nil {
+		return group, fmt.Errorf("group created but sub-groups could not be added: %w", err)
+	}
+
+	if len(entry.ModelRouting) > 0 {
+		if err := s.applyModelRouting(s.db.WithContext(ctx), group, entry.ModelRouting, subGroupIDs); err != nil {
+			return group, fmt.Errorf("group created but model routing could not be set: %w", err)
+		}
+		if err := s.groupService.groupManager.Invalidate(); err != nil {
+			logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache after setting model routing")
+		}
+	}
+
+	return group, nil
+}
+
+// ExportGroups serializes every group into one archive, aggregates with their sub-groups by name.
+// keysOption selects the keys included in plain text, like the copy_keys option of CopyGroup:
+// "none" (the default), "valid_only" or "all".
+func (s *GroupArchiveService) ExportGroups(ctx context.Context, keysOption string) (*GroupArchive, error) {
+	option := strings.TrimSpace(keysOption)
+	if option == "" {
+		option = "none"
+	}
+	if option != "none" && option != "valid_only" && option != "all" {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_export_keys_value", nil)
+	}
+
+	db := s.db.WithContext(ctx)
+
+	var groups []models.Group
+	if err := db.Order("sort asc, id asc").Find(&groups).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	groupNames := make(map[uint]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	var relations []models.GroupSubGroup
+	if err := db.Order("id asc").Find(&relations).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	subGroups := make(map[uint][]GroupArchiveSubGroup)
+	for _, relation := range relations {
+		if name, ok := groupNames[relation.SubGroupID]; ok {
+			subGroups[relation.GroupID] = append(subGroups[relation.GroupID], GroupArchiveSubGroup{Name: name, Weight: relation.Weight})
+		}
+	}
+
+	keys := make(map[uint][]string)
+	if option != "none" {
+		var apiKeys []models.APIKey
+		query := db.Select("id", "group_id", "key_value")
+		if option == "valid_only" {
+			query = query.Where("status = ?", models.KeyStatusActive)
+		}
+		if err := query.Order("id asc").Find(&apiKeys).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+
+		for _, apiKey := range apiKeys {
+			decryptedKey, err := s.groupService.encryptionSvc.Decrypt(apiKey.KeyValue)
+			if err != nil {
+				logrus.WithContext(ctx).WithError(err).WithField("key_id", apiKey.ID).Error("failed to decrypt key during group export, skipping")
+				continue
+			}
+			keys[apiKey.GroupID] = append(keys[apiKey.GroupID], decryptedKey)
+		}
+	}
+
+	archive := &GroupArchive{
+		Version: GroupArchiveVersion,
+		Groups:  make([]GroupArchiveEntry, 0, len(groups)),
+	}
+	for i := range groups {
+		entry, err := newGroupArchiveEntry(&groups[i])
+		if err != nil {
+			return nil, err
+		}
+		entry.SubGroups = subGroups[groups[i].ID]
+		entry.exportModelRouting(groupNames)
+		entry.Keys = keys[groups[i].ID]
+		archive.Groups = append(archive.Groups, *entry)
+	}
+
+	return archive, nil
+}
+
+// ImportGroups recreates the groups of an exported archive in a single transaction, standard groups
+// first so aggregates can reference them. A name that is already taken is remapped like CopyGroup
+// does, and aggregates follow their renamed sub-groups. If any group fails validation nothing is
+// imported and a GroupImportError names the group. Keys are queued once the groups are committed.
+func (s *GroupArchiveService) ImportGroups(ctx context.Context, archive *GroupArchive) (*GroupImportResult, error) {
+	if len(archive.Groups) == 0 {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.archive_no_groups", nil)
+	}
+	if archive.KeysEncrypted {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.group_import_keys_encrypted", nil)
+	}
+
+	created := make([]*models.Group, len(archive.Groups))
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Archive names mapped to the names the groups were imported under
+		importedNames := make(map[string]string, len(archive.Groups))
+		for _, i := range archiveImportOrder(archive.Groups) {
+			entry := &archive.Groups[i]
+			name := strings.TrimSpace(entry.Name)
+			if _, exists := importedNames[name]; exists {
+				return &GroupImportError{GroupName: entry.Name, Err: NewI18nError(app_errors.ErrDuplicateResource, "group.name_exists", nil)}
+			}
+
+			group, err := s.importGroup(tx, entry, importedNames)
+			if err != nil {
+				return &GroupImportError{GroupName: entry.Name, Err: err}
+			}
+			importedNames[name] = group.Name
+			created[i] = group
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.groupService.groupManager.Invalidate(); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache after group import")
+	}
+
+	result := &GroupImportResult{Groups: make([]GroupImportGroupResult, len(archive.Groups))}
+	var jobs []KeyImportJob
+	for i, group := range created {
+		entry := &archive.Groups[i]
+		groupResult := GroupImportGroupResult{
+			Name:         entry.Name,
+			ImportedName: group.Name,
+			GroupID:      group.ID,
+		}
+		result.Created++
+		if group.Name != strings.TrimSpace(entry.Name) {
+			result.Renamed++
+		}
+
+		if group.GroupType != "aggregate" && len(entry.Keys) > 0 {
+			records, skipped := decodeArchiveKeys(entry.Keys, nil)
+			groupResult.KeysSkipped = skipped
+			if len(records) > 0 {
+				jobs = append(jobs, KeyImportJob{Group: group, Records: records})
+				groupResult.KeysQueued = len(records)
+				result.KeysQueued += len(records)
+			}
+		}
+
+		result.Groups[i] = groupResult
+	}
+
+	if len(jobs) > 0 {
+		s.keyImportService.QueueImports(jobs)
+	}
+
+	return result, nil
+}
+
+// importGroup validates and creates one archive entry within the import transaction, remapping its
+// name if it is taken. Aggregate sub-groups are resolved through importedNames first, then by name
+// on this instance.
+func (s *GroupArchiveService) importGroup(tx *gorm.DB, entry *GroupArchiveEntry, importedNames map[string]string) (*models.Group, error) {
+	params := entry.createParams()
+	params.Name = strings.TrimSpace(params.Name)
+
+	var count int64
+	if err := tx.Unscoped().Model(&models.Group{}).Where("name = ?", params.Name).Count(&count).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	if count > 0 {
+		params.Name = s.groupService.generateUniqueGroupName(tx, params.Name)
+		// A renamed copy cannot share the original's proxy keys while global uniqueness is enforced
+		if s.groupService.settingsManager.GetSettings().EnforceUniqueProxyKeys {
+			params.ProxyKeys = ""
+		}
+	}
+
+	group, err := s.groupService.buildGroup(tx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Create(group).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	if group.GroupType != "aggregate" || len(entry.SubGroups) == 0 {
+		return group, nil
+	}
+
+	inputs := make([]SubGroupInput, 0, len(entry.SubGroups))
+	subGroupIDs := make(map[string]uint, len(entry.SubGroups))
+	for _, sub := range entry.SubGroups {
+		archiveName := strings.TrimSpace(sub.Name)
+		subName := archiveName
+		if importedName, ok := importedNames[subName]; ok {
+			subName = importedName
+		}
+		var subGroup models.Group
+		if err := tx.Select("id").Where("name = ?", subName).First(&subGroup).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_group_not_found", nil)
+			}
+			return nil, app_errors.ParseDBError(err)
+		}
+		inputs = append(inputs, SubGroupInput{GroupID: subGroup.ID, Weight: sub.Weight})
+		subGroupIDs[archiveName] = subGroup.ID
+	}
+
+	validated, err := s.aggregateGroupService.validateSubGroups(tx, group.ChannelType, inputs, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range validated.SubGroups {
+		validated.SubGroups[i].GroupID = group.ID
+	}
+	if err := tx.Create(&validated.SubGroups).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	if err := s.applyModelRouting(tx, group, entry.ModelRouting, subGroupIDs); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// applyModelRouting sets the model_routing of an imported aggregate group once its sub-groups exist,
+// mapping the archive's sub-group names to their IDs on this instance.
+func (s *GroupArchiveService) applyModelRouting(db *gorm.DB, group *models.Group, routing map[string]string, subGroupIDs map[string]uint) error {
+	if len(routing) == 0 {
+		return nil
+	}
+
+	routingIDs := make(map[string]uint, len(routing))
+	for model, subName := range routing {
+		id, ok := subGroupIDs[strings.TrimSpace(subName)]
+		if !ok {
+			return NewI18nError(app_errors.ErrValidation, "validation.model_routing_sub_group_not_member", map[string]any{
+				"model":        model,
+				"sub_group_id": subName,
+			})
+		}
+		routingIDs[model] = id
+	}
+	if err := s.aggregateGroupService.validateModelRouting(db, group.ID, routingIDs); err != nil {
+		return err
+	}
+
+	config := make(datatypes.JSONMap, len(group.Config)+1)
+	maps.Copy(config, group.Config)
+	config["model_routing"] = routingIDs
+	if err := db.Model(group).Update("config", config).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+	group.Config = config
+	return nil
+}
+
+// newGroupArchiveEntry converts a stored group into an archive entry, without sub-groups and keys.
+func newGroupArchiveEntry(group *models.Group) (*GroupArchiveEntry, error) {
+	var headerRules []models.HeaderRule
+	if len(group.HeaderRules) > 0 {
+		if err := json.Unmarshal(group.HeaderRules, &headerRules); err != nil {
+			return nil, fmt.Errorf("failed to parse header rules of group %s: %w", group.Name, err)
+		}
+	}
+
+	modelRedirectRules := make(map[string]string, len(group.ModelRedirectRules))
+	for source, target := range group.ModelRedirectRules {
+		if targetModel, ok := target.(string); ok {
+			modelRedirectRules[source] = targetModel
+		}
+	}
+
+	return &GroupArchiveEntry{
+		Name:                group.Name,
+		DisplayName:         group.DisplayName,
+		Description:         group.Description,
+		GroupType:           group.GroupType,
+		Upstreams:           json.RawMessage(group.Upstreams),
+		ChannelType:         group.ChannelType,
+		Sort:                group.Sort,
+		TestModel:           group.TestModel,
+		ValidationEndpoint:  group.ValidationEndpoint,
+		ParamOverrides:      group.ParamOverrides,
+		ModelRedirectRules:  modelRedirectRules,
+		ModelRedirectStrict: group.ModelRedirectStrict,
+		Config:              group.Config,
+		HeaderRules:         headerRules,
+		ProxyKeys:           group.ProxyKeys,
+	}, nil
+}
+
+// exportModelRouting moves the aggregate's model_routing out of the config into ModelRouting, with
+// sub-group IDs replaced by their names.
+func (e *GroupArchiveEntry) exportModelRouting(groupNames map[uint]string) {
+	routing, _ := e.Config["model_routing"].(map[string]any)
+	if len(routing) == 0 {
+		return
+	}
+
+	e.ModelRouting = make(map[string]string, len(routing))
+	for model, idVal := range routing {
+		// Stored configs decode numbers as json.Number
+		var id uint
+		switch v := idVal.(type) {
+		case float64:
+			id = uint(v)
+		case json.Number:
+			n, err := v.Int64()
+			if err != nil {
+				continue
+			}
+			id = uint(n)
+		}
+		if name, ok := groupNames[id]; ok {
+			e.ModelRouting[model] = name
+		}
+	}
+	config := maps.Clone(e.Config)
+	delete(config, "model_routing")
+	e.Config = config
+}
+
+// createParams returns the creation parameters of an archive entry. model_routing is left out of the
+// config: it is set from ModelRouting once the sub-groups exist, and IDs from another instance are
+// meaningless here.
+func (e *GroupArchiveEntry) createParams() GroupCreateParams {
+	config := e.Config
+	if _, ok := config["model_routing"]; ok {
+		config = maps.Clone(config)
+		delete(config, "model_routing")
+	}
+
+	return GroupCreateParams{
+		Name:                e.Name,
+		DisplayName:         e.DisplayName,
+		Description:         e.Description,
+		GroupType:           e.GroupType,
+		Upstreams:           e.Upstreams,
+		ChannelType:         e.ChannelType,
+		Sort:                e.Sort,
+		TestModel:           e.TestModel,
+		ValidationEndpoint:  e.ValidationEndpoint,
+		ParamOverrides:      e.ParamOverrides,
+		ModelRedirectRules:  e.ModelRedirectRules,
+		ModelRedirectStrict: e.ModelRedirectStrict,
+		Config:              config,
+		HeaderRules:         e.HeaderRules,
+		ProxyKeys:           e.ProxyKeys,
+	}
+}
+
+// archiveImportOrder returns the indexes of the archive groups with aggregates last, so their
+// sub-groups already exist when they are created.
+func archiveImportOrder(entries []GroupArchiveEntry) []int {
+	order := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		if entry.GroupType != "aggregate" {
+			order = append(order, i)
+		}
+	}
+	for i, entry := range entries {
+		if entry.GroupType == "aggregate" {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// decodeArchiveKeys turns archive key values into import records, decrypting them when the archive
+// is encrypted. Keys that are empty or fail to decrypt are skipped and counted.
+func decodeArchiveKeys(keys []string, decrypter encryption.Service) ([]KeyImportRecord, int) {
+	records := make([]KeyImportRecord, 0, len(keys))
+	skipped := 0
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key != "" && decrypter != nil {
+			decrypted, err := decrypter.Decrypt(key)
+			if err != nil {
+				skipped++
+				continue
+			}
+			key = decrypted
+		}
+		if key == "" {
+			skipped++
+			continue
+		}
+		records = append(records, KeyImportRecord{Key: key})
+	}
+	return records, skipped
+}
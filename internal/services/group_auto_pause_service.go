@@ -0,0 +1,306 @@
+package services
+
+import (
+	"aimanager/internal/config"
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// groupAutoPauseCheckInterval is how often group failure rates are evaluated.
+	groupAutoPauseCheckInterval = time.Minute
+	// groupAutoPauseBaselineTTL keeps a resume baseline while its hour can still be inside the window.
+	groupAutoPauseBaselineTTL = 25 * time.Hour
+)
+
+// groupAutoPauseBaseline holds the counts already recorded in the hour a group was resumed,
+// so failures from before the pause are not counted again.
+type groupAutoPauseBaseline struct {
+	Hour    time.Time `json:"hour"`
+	Success int64     `json:"success"`
+	Failure int64     `json:"failure"`
+}
+
+// GroupAutoPauseState describes whether a group is currently auto-paused.
+type GroupAutoPauseState struct {
+	GroupID            uint       `json:"group_id"`
+	Paused             bool       `json:"paused"`
+	AutoPausedAt       *time.Time `json:"auto_paused_at"`
+	AutoPauseResumedAt *time.Time `json:"auto_pause_resumed_at"`
+}
+
+// GroupAutoPauseService pauses standard groups whose failure rate stays above the configured
+// threshold, based on group_hourly_stats, and resumes them after the cooldown. Both changes are sent
+// to the group's notification_webhook.
+type GroupAutoPauseService struct {
+	db              *gorm.DB
+	store           store.Store
+	settingsManager *config.SystemSettingsManager
+	groupManager    *GroupManager
+	alertService    *GroupAlertService
+	stopCh          chan struct{}
+	wg              sync.WaitGroup
+}
+
+// NewGroupAutoPauseService creates a new GroupAutoPauseService.
+func NewGroupAutoPauseService(
+	db *gorm.DB,
+	store store.Store,
+	settingsManager *config.SystemSettingsManager,
+	groupManager *GroupManager,
+	alertService *GroupAlertService,
+) *GroupAutoPauseService {
+	return &GroupAutoPauseService{
+		db:              db,
+		store:           store,
+		settingsManager: settingsManager,
+		groupManager:    groupManager,
+		alertService:    alertService,
+		stopCh:          make(chan struct{}),
+	}
+}
+
+// Start begins the periodic evaluation. It only runs on the master node.
+func (s *GroupAutoPauseService) Start() {
+	s.wg.Add(1)
+	go s.run()
+	logrus.Debug("Group auto-pause service started")
+}
+
+// Stop stops the evaluation loop, respecting the context for shutdown timeout.
+func (s *GroupAutoPauseService) Stop(ctx context.Context) {
+	close(s.stopCh)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("GroupAutoPauseService stopped gracefully.")
+	case <-ctx.Done():
+		logrus.Warn("GroupAutoPauseService stop timed out.")
+	}
+}
+
+func (s *GroupAutoPauseService) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(groupAutoPauseCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.evaluateGroups()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// evaluateGroups resumes paused groups whose cooldown elapsed and pauses groups over their threshold.
+func (s *GroupAutoPauseService) evaluateGroups() {
+	ctx := context.Background()
+
+	var groups []models.Group
+	if err := s.db.WithContext(ctx).
+		Select("id", "name", "config", "auto_paused_at", "auto_pause_resumed_at").
+		Where("group_type <> ?", "aggregate").
+		Find(&groups).Error; err != nil {
+		logrus.WithError(err).Error("Failed to load groups for auto-pause evaluation")
+		return
+	}
+
+	changed := false
+	for i := range groups {
+		group := &groups[i]
+		parseGroupConfig(group)
+		cfg := s.settingsManager.GetEffectiveConfig(group.Config)
+
+		if group.AutoPausedAt != nil {
+			cooldown := time.Duration(cfg.AutoPauseCooldownMinutes) * time.Minute
+			if cooldown <= 0 || time.Since(*group.AutoPausedAt) < cooldown {
+				continue
+			}
+			resumed, err := s.resume(ctx, group.ID)
+			if err != nil {
+				logrus.WithError(err).WithField("group_name", group.Name).Error("Failed to resume auto-paused group")
+				continue
+			}
+			if resumed {
+				logrus.WithField("group_name", group.Name).Info("Group resumed after auto-pause cooldown")
+				s.alertService.SendEvent(group, GroupEventResumed, &GroupAlert{Reason: "cooldown_elapsed"})
+				changed = true
+			}
+			continue
+		}
+
+		if cfg.AutoPauseFailureRate <= 0 {
+			continue
+		}
+
+		success, failure, err := s.windowCounts(ctx, group, cfg.AutoPauseWindowHours)
+		if err != nil {
+			logrus.WithError(err).WithField("group_name", group.Name).Error("Failed to read group stats for auto-pause")
+			continue
+		}
+		total := success + failure
+		if total < int64(cfg.AutoPauseMinRequests) || failure*100 < int64(cfg.AutoPauseFailureRate)*total {
+			continue
+		}
+
+		res := s.db.WithContext(ctx).Model(&models.Group{}).
+			Where("id = ? AND auto_paused_at IS NULL", group.ID).
+			Update("auto_paused_at", time.Now())
+		if res.Error != nil {
+			logrus.WithError(res.Error).WithField("group_name", group.Name).Error("Failed to auto-pause group")
+			continue
+		}
+		if res.RowsAffected > 0 {
+			logrus.WithFields(logrus.Fields{
+				"group_name":    group.Name,
+				"requests":      total,
+				"failures":      failure,
+				"threshold_pct": cfg.AutoPauseFailureRate,
+				"window_hours":  cfg.AutoPauseWindowHours,
+			}).Warn("Group auto-paused due to sustained failures")
+			s.alertService.SendEvent(group, GroupEventAutoPaused, &GroupAlert{
+				ThresholdPercent: cfg.AutoPauseFailureRate,
+				Requests: &GroupAlertRequests{
+					WindowHours:        cfg.AutoPauseWindowHours,
+					Total:              total,
+					Failures:           failure,
+					FailureRatePercent: percentOf(failure, total),
+				},
+			})
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := s.groupManager.Invalidate(); err != nil {
+			logrus.WithError(err).Error("Failed to invalidate group cache after auto-pause changes")
+		}
+	}
+}
+
+// windowCounts sums the group's hourly stats over the last hours, ignoring anything recorded
+// before the group was last resumed.
+func (s *GroupAutoPauseService) windowCounts(ctx context.Context, group *models.Group, hours int) (int64, int64, error) {
+	windowStart := time.Now().Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+	if group.AutoPauseResumedAt != nil {
+		if resumeHour := group.AutoPauseResumedAt.Truncate(time.Hour); resumeHour.After(windowStart) {
+			windowStart = resumeHour
+		}
+	}
+
+	var result struct {
+		SuccessCount int64
+		FailureCount int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.GroupHourlyStat{}).
+		Select("SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+		Where("group_id = ? AND time >= ?", group.ID, windowStart).
+		Scan(&result).Error; err != nil {
+		return 0, 0, err
+	}
+
+	if baseline := s.loadBaseline(group.ID); baseline != nil && !baseline.Hour.Before(windowStart) {
+		result.SuccessCount = max(result.SuccessCount-baseline.Success, 0)
+		result.FailureCount = max(result.FailureCount-baseline.Failure, 0)
+	}
+
+	return result.SuccessCount, result.FailureCount, nil
+}
+
+// resume clears the group's pause and records the current hour's counts as the new baseline.
+// It reports whether the group was paused.
+func (s *GroupAutoPauseService) resume(ctx context.Context, groupID uint) (bool, error) {
+	now := time.Now()
+	baseline := groupAutoPauseBaseline{Hour: now.Truncate(time.Hour)}
+
+	var hourlyStat models.GroupHourlyStat
+	err := s.db.WithContext(ctx).Where("group_id = ? AND time = ?", groupID, baseline.Hour).First(&hourlyStat).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	baseline.Success = hourlyStat.SuccessCount
+	baseline.Failure = hourlyStat.FailureCount
+
+	res := s.db.WithContext(ctx).Model(&models.Group{}).
+		Where("id = ? AND auto_paused_at IS NOT NULL", groupID).
+		Updates(map[string]any{"auto_paused_at": nil, "auto_pause_resumed_at": now})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	// A group that was not paused keeps its baseline, so a pending auto-pause is not suppressed
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if data, err := json.Marshal(baseline); err == nil {
+		if err := s.store.Set(groupAutoPauseBaselineKey(groupID), data, groupAutoPauseBaselineTTL); err != nil {
+			logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to store auto-pause baseline")
+		}
+	}
+	return true, nil
+}
+
+func (s *GroupAutoPauseService) loadBaseline(groupID uint) *groupAutoPauseBaseline {
+	data, err := s.store.Get(groupAutoPauseBaselineKey(groupID))
+	if err != nil {
+		return nil
+	}
+	var baseline groupAutoPauseBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil
+	}
+	return &baseline
+}
+
+func groupAutoPauseBaselineKey(groupID uint) string {
+	return fmt.Sprintf("group_auto_pause_baseline:%d", groupID)
+}
+
+// ResumeGroup manually resumes an auto-paused group. Resuming a group that is not paused is a no-op.
+func (s *GroupAutoPauseService) ResumeGroup(ctx context.Context, groupID uint) (*GroupAutoPauseState, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "name", "config").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	parseGroupConfig(&group)
+
+	resumed, err := s.resume(ctx, groupID)
+	if err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	if resumed {
+		logrus.WithField("group_name", group.Name).Info("Auto-paused group resumed manually")
+		s.alertService.SendEvent(&group, GroupEventResumed, &GroupAlert{Reason: "manual"})
+		if err := s.groupManager.Invalidate(); err != nil {
+			logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Select("id", "auto_paused_at", "auto_pause_resumed_at").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	return &GroupAutoPauseState{
+		GroupID:            group.ID,
+		Paused:             group.AutoPausedAt != nil,
+		AutoPausedAt:       group.AutoPausedAt,
+		AutoPauseResumedAt: group.AutoPauseResumedAt,
+	}, nil
+}
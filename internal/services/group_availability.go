@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+)
+
+const (
+	// DefaultAvailabilityHours is the window used when the caller does not specify one.
+	DefaultAvailabilityHours = 30 * 24
+	// MaxAvailabilityHours matches the longest key count trend that can be queried.
+	MaxAvailabilityHours = MaxKeyCountTrendHours
+	// DefaultAvailabilityFailureThreshold is the failure rate (%) from which an hour counts as down.
+	DefaultAvailabilityFailureThreshold = 50
+	// DefaultAvailabilityMinRequests is how many requests an hour needs before its failure rate counts.
+	DefaultAvailabilityMinRequests = 1
+)
+
+// Reasons an hour counts as down.
+const (
+	AvailabilityDownNoActiveKeys    = "no_active_keys"
+	AvailabilityDownHighFailureRate = "high_failure_rate"
+)
+
+// AvailabilityDownInterval is a run of consecutive down hours. End is exclusive.
+type AvailabilityDownInterval struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Hours    int       `json:"hours"`
+	Reasons  []string  `json:"reasons"`
+	Requests int64     `json:"requests"`
+	Failures int64     `json:"failures"`
+}
+
+// GroupAvailability is the share of hours in a window during which a group was up.
+type GroupAvailability struct {
+	GroupID                 uint                       `json:"group_id"`
+	Start                   time.Time                  `json:"start"`
+	End                     time.Time                  `json:"end"`
+	Hours                   int                        `json:"hours"`
+	DownHours               int                        `json:"down_hours"`
+	AvailabilityPercent     float64                    `json:"availability_percent"`
+	FailureThresholdPercent int                        `json:"failure_threshold_percent"`
+	MinRequests             int64                      `json:"min_requests"`
+	HoursWithoutKeyData     int                        `json:"hours_without_key_data"`
+	DownIntervals           []AvailabilityDownInterval `json:"down_intervals"`
+}
+
+// GetGroupAvailability computes a group's availability over the last complete hours. An hour is down
+// when its key count snapshot shows no active key, or when it served at least minRequests requests and
+// failureThreshold percent or more of them failed. Hours without a key count snapshot, e.g. before
+// snapshots were recorded or while the master node was down, are judged on their failure rate only.
+// For aggregate groups the active keys of all sub-groups are summed.
+func (s *GroupService) GetGroupAvailability(ctx context.Context, groupID uint, hours, failureThreshold int, minRequests int64) (*GroupAvailability, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "group_type").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	memberIDs := []uint{group.ID}
+	if group.GroupType == "aggregate" {
+		subGroupIDs, err := s.aggregateGroupService.GetSubGroupIDs(ctx, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sub-group IDs: %w", err)
+		}
+		memberIDs = subGroupIDs
+	}
+
+	end := time.Now().Truncate(time.Hour)
+	start := end.Add(-time.Duration(hours) * time.Hour)
+
+	activeKeysByHour := make(map[int64]int64)
+	if len(memberIDs) > 0 {
+		var keyRows []struct {
+			Time       time.Time
+			ActiveKeys int64
+		}
+		if err := s.db.WithContext(ctx).Model(&models.GroupKeyCountStat{}).
+			Select("time, SUM(active_keys) as active_keys").
+			Where("group_id IN ? AND time >= ? AND time < ?", memberIDs, start, end).
+			Group("time").
+			Scan(&keyRows).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		for _, row := range keyRows {
+			activeKeysByHour[row.Time.Unix()] = row.ActiveKeys
+		}
+	}
+
+	var statRows []models.GroupHourlyStat
+	if err := s.db.WithContext(ctx).
+		Where("group_id = ? AND time >= ? AND time < ?", groupID, start, end).
+		Find(&statRows).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	statsByHour := make(map[int64]models.GroupHourlyStat, len(statRows))
+	for _, row := range statRows {
+		statsByHour[row.Time.Unix()] = row
+	}
+
+	result := &GroupAvailability{
+		GroupID:                 groupID,
+		Start:                   start,
+		End:                     end,
+		Hours:                   hours,
+		FailureThresholdPercent: failureThreshold,
+		MinRequests:             minRequests,
+		DownIntervals:           []AvailabilityDownInterval{},
+	}
+
+	var current *AvailabilityDownInterval
+	for hour := start; hour.Before(end); hour = hour.Add(time.Hour) {
+		stat := statsByHour[hour.Unix()]
+		requests := stat.SuccessCount + stat.FailureCount
+
+		var reasons []string
+		activeKeys, hasKeyData := activeKeysByHour[hour.Unix()]
+		if !hasKeyData {
+			result.HoursWithoutKeyData++
+		} else if activeKeys == 0 {
+			reasons = append(reasons, AvailabilityDownNoActiveKeys)
+		}
+		if requests > 0 && requests >= minRequests && stat.FailureCount*100 >= int64(failureThreshold)*requests {
+			reasons = append(reasons, AvailabilityDownHighFailureRate)
+		}
+
+		if len(reasons) == 0 {
+			current = nil
+			continue
+		}
+
+		result.DownHours++
+		if current == nil {
+			result.DownIntervals = append(result.DownIntervals, AvailabilityDownInterval{Start: hour, Reasons: []string{}})
+			current = &result.DownIntervals[len(result.DownIntervals)-1]
+		}
+		current.End = hour.Add(time.Hour)
+		current.Hours++
+		current.Requests += requests
+		current.Failures += stat.FailureCount
+		for _, reason := range reasons {
+			if !slices.Contains(current.Reasons, reason) {
+				current.Reasons = append(current.Reasons, reason)
+			}
+		}
+	}
+
+	result.AvailabilityPercent = math.Round(float64(hours-result.DownHours)/float64(hours)*10000) / 100
+	return result, nil
+}
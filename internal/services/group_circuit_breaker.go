@@ -0,0 +1,365 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"aimanager/internal/models"
+	"aimanager/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Circuit breaker states of a group.
+const (
+	CircuitBreakerClosed   = "closed"
+	CircuitBreakerOpen     = "open"
+	CircuitBreakerHalfOpen = "half_open"
+)
+
+// Circuit breaker defaults for settings a group leaves unset.
+const (
+	defaultCircuitBreakerWindowSeconds   = 60
+	defaultCircuitBreakerMinRequests     = 20
+	defaultCircuitBreakerCooldownSeconds = 30
+)
+
+const (
+	// circuitBreakerWindowSlots is the number of buckets the failure rate window is split into.
+	circuitBreakerWindowSlots = 6
+	// circuitBreakerRingSlots holds the window, the current bucket and the next one, cleared ahead of use.
+	circuitBreakerRingSlots = circuitBreakerWindowSlots + 2
+)
+
+// CircuitBreakerStatus is the circuit breaker state of a group, for monitoring.
+type CircuitBreakerStatus struct {
+	State                    string     `json:"state"`
+	OpenUntil                *time.Time `json:"open_until,omitempty"`
+	CooldownRemainingSeconds int64      `json:"cooldown_remaining_seconds"`
+	WindowRequests           int64      `json:"window_requests"`
+	WindowFailures           int64      `json:"window_failures"`
+}
+
+// circuitBreakerSettings are a group's circuit breaker settings with defaults applied.
+type circuitBreakerSettings struct {
+	failureRate int
+	window      time.Duration
+	minRequests int64
+	cooldown    time.Duration
+}
+
+// GroupCircuitBreaker fast-fails requests to a group whose recent failure rate is too high, so they do
+// not burn keys against a failing upstream. Upstream attempts are counted in the store over the last
+// circuit_breaker_window_seconds; once circuit_breaker_min_requests were seen and at least
+// circuit_breaker_failure_rate percent failed, the breaker opens for circuit_breaker_cooldown_seconds.
+// After the cooldown it is half-open: a single request, claimed across nodes, is let through as a probe.
+// Only the probe's own outcome decides: a successful probe closes the breaker, a failed one opens it
+// again. Failures are classified like upstream health: connection errors and 5xx responses.
+type GroupCircuitBreaker struct {
+	store store.Store
+	// clearedBuckets holds, per group, the last window bucket this node has claimed or seen claimed
+	clearedBuckets sync.Map // groupID -> *atomic.Int64
+}
+
+// NewGroupCircuitBreaker creates a new GroupCircuitBreaker.
+func NewGroupCircuitBreaker(store store.Store) *GroupCircuitBreaker {
+	return &GroupCircuitBreaker{store: store}
+}
+
+// Allow reports whether a request may be sent to the group. When the cooldown is over the first caller
+// becomes the half-open probe and gets a probe token, which it passes to Record and ReleaseProbe.
+// When refused, it returns how long until requests may be tried again.
+func (b *GroupCircuitBreaker) Allow(group *models.Group) (bool, time.Duration, string) {
+	settings, enabled := newCircuitBreakerSettings(group)
+	if !enabled {
+		return true, 0, ""
+	}
+
+	state, openUntil, err := b.state(group.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to read circuit breaker state")
+		return true, 0, ""
+	}
+	if state == CircuitBreakerClosed {
+		return true, 0, ""
+	}
+	if remaining := time.Until(openUntil); remaining > 0 {
+		return false, remaining, ""
+	}
+
+	// Cooldown over: let a single probe through until it reports back or its claim expires
+	probe := uuid.NewString()
+	claimed, err := b.store.SetNX(circuitBreakerProbeKey(group.ID), []byte(probe), settings.cooldown)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to claim circuit breaker probe")
+		return true, 0, ""
+	}
+	if !claimed {
+		return false, time.Second, ""
+	}
+	if state == CircuitBreakerOpen {
+		if err := b.store.HSet(circuitBreakerKey(group.ID), map[string]any{"state": CircuitBreakerHalfOpen}); err != nil {
+			logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to update circuit breaker state")
+		}
+	}
+	return true, 0, probe
+}
+
+// ReleaseProbe gives up a probe claim that did not decide the breaker, e.g. because the request was
+// rejected before it reached upstream, so the next request can probe without waiting for the claim
+// to expire. Claims already settled by Record or taken over by another probe are left alone.
+func (b *GroupCircuitBreaker) ReleaseProbe(group *models.Group, probe string) {
+	if probe == "" || !b.ownsProbe(group.ID, probe) {
+		return
+	}
+	if err := b.store.Delete(circuitBreakerProbeKey(group.ID)); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Debug("Failed to release circuit breaker probe")
+	}
+}
+
+// IsOpen reports whether the group currently refuses requests, without claiming the half-open probe.
+func (b *GroupCircuitBreaker) IsOpen(group *models.Group) bool {
+	if _, enabled := newCircuitBreakerSettings(group); !enabled {
+		return false
+	}
+	state, openUntil, err := b.state(group.ID)
+	return err == nil && state != CircuitBreakerClosed && time.Now().Before(openUntil)
+}
+
+// Record counts the outcome of an upstream attempt of the group. Once the cooldown is over, only the
+// outcome of the request holding the probe token from Allow decides whether the breaker closes or
+// opens again; probe is empty for every other request.
+func (b *GroupCircuitBreaker) Record(group *models.Group, probe string, success bool) {
+	settings, enabled := newCircuitBreakerSettings(group)
+	if !enabled {
+		return
+	}
+
+	state, openUntil, err := b.state(group.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to read circuit breaker state")
+		return
+	}
+	if state != CircuitBreakerClosed {
+		// Requests already in flight when the breaker opened say nothing about the probe
+		if time.Now().Before(openUntil) {
+			return
+		}
+		// Nor do requests let through before the breaker opened that finish after the cooldown
+		if probe == "" || !b.ownsProbe(group.ID, probe) {
+			return
+		}
+		if success {
+			b.close(group)
+		} else {
+			b.open(group, settings, "half-open probe failed")
+		}
+		return
+	}
+
+	now := time.Now()
+	bucket := now.Unix() / int64(settings.bucketSeconds())
+	b.clearNextBucket(group.ID, bucket, settings)
+	key := circuitBreakerWindowKey(group.ID, bucket)
+	if _, err := b.store.HIncrBy(key, circuitBreakerWindowField(bucket, "total"), 1); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Debug("Failed to record circuit breaker outcome")
+		return
+	}
+	if success {
+		return
+	}
+	if _, err := b.store.HIncrBy(key, circuitBreakerWindowField(bucket, "failures"), 1); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Debug("Failed to record circuit breaker outcome")
+		return
+	}
+
+	total, failures, err := b.windowCounts(group.ID, bucket)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to read circuit breaker window")
+		return
+	}
+	if total >= settings.minRequests && failures*100 >= int64(settings.failureRate)*total {
+		b.open(group, settings, fmt.Sprintf("%d of %d requests failed", failures, total))
+	}
+}
+
+// GetStatus returns the circuit breaker state of the group, or nil when the group does not use one.
+func (b *GroupCircuitBreaker) GetStatus(group *models.Group) *CircuitBreakerStatus {
+	settings, enabled := newCircuitBreakerSettings(group)
+	if !enabled {
+		return nil
+	}
+
+	state, openUntil, err := b.state(group.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to read circuit breaker state")
+		return nil
+	}
+	status := &CircuitBreakerStatus{State: state}
+	if state != CircuitBreakerClosed {
+		if remaining := time.Until(openUntil); remaining > 0 {
+			status.OpenUntil = &openUntil
+			status.CooldownRemainingSeconds = int64(remaining.Round(time.Second).Seconds())
+		} else {
+			// Waiting for or running the probe
+			status.State = CircuitBreakerHalfOpen
+		}
+	}
+
+	bucket := time.Now().Unix() / int64(settings.bucketSeconds())
+	status.WindowRequests, status.WindowFailures, err = b.windowCounts(group.ID, bucket)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to read circuit breaker window")
+	}
+	return status
+}
+
+func (b *GroupCircuitBreaker) open(group *models.Group, settings circuitBreakerSettings, cause string) {
+	openUntil := time.Now().Add(settings.cooldown)
+	if err := b.store.HSet(circuitBreakerKey(group.ID), map[string]any{
+		"state":      CircuitBreakerOpen,
+		"open_until": openUntil.UnixMilli(),
+	}); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to open circuit breaker")
+		return
+	}
+	if err := b.store.Delete(circuitBreakerProbeKey(group.ID)); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Debug("Failed to release circuit breaker probe")
+	}
+	logrus.WithFields(logrus.Fields{
+		"group":    group.Name,
+		"cause":    cause,
+		"cooldown": settings.cooldown,
+	}).Warn("Group circuit breaker opened")
+}
+
+func (b *GroupCircuitBreaker) close(group *models.Group) {
+	// The window still holds the failures that opened the breaker, start it afresh
+	keys := make([]string, 0, circuitBreakerRingSlots+2)
+	for slot := range int64(circuitBreakerRingSlots) {
+		keys = append(keys, circuitBreakerWindowKey(group.ID, slot))
+	}
+	keys = append(keys, circuitBreakerKey(group.ID), circuitBreakerProbeKey(group.ID))
+	if err := b.store.Del(keys...); err != nil {
+		logrus.WithError(err).WithField("group_id", group.ID).Warn("Failed to close circuit breaker")
+		return
+	}
+	logrus.WithField("group", group.Name).Info("Group circuit breaker closed after a successful probe")
+}
+
+// ownsProbe reports whether probe is the current half-open probe claim of the group.
+func (b *GroupCircuitBreaker) ownsProbe(groupID uint, probe string) bool {
+	value, err := b.store.Get(circuitBreakerProbeKey(groupID))
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			logrus.WithError(err).WithField("group_id", groupID).Debug("Failed to read circuit breaker probe")
+		}
+		return false
+	}
+	return string(value) == probe
+}
+
+// state returns the breaker state of the group and, unless closed, the end of its cooldown.
+func (b *GroupCircuitBreaker) state(groupID uint) (string, time.Time, error) {
+	fields, err := b.store.HGetAll(circuitBreakerKey(groupID))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	state := fields["state"]
+	if state != CircuitBreakerOpen && state != CircuitBreakerHalfOpen {
+		return CircuitBreakerClosed, time.Time{}, nil
+	}
+	var openUntil time.Time
+	if until := parseUnixMilli(fields["open_until"]); until != nil {
+		openUntil = *until
+	}
+	return state, openUntil, nil
+}
+
+// windowCounts sums the attempts and failures of the window ending with the given bucket.
+func (b *GroupCircuitBreaker) windowCounts(groupID uint, bucket int64) (int64, int64, error) {
+	var total, failures int64
+	for current := bucket - circuitBreakerWindowSlots + 1; current <= bucket; current++ {
+		fields, err := b.store.HGetAll(circuitBreakerWindowKey(groupID, current))
+		if err != nil {
+			return 0, 0, err
+		}
+		count, _ := strconv.ParseInt(fields[circuitBreakerWindowField(current, "total")], 10, 64)
+		total += count
+		count, _ = strconv.ParseInt(fields[circuitBreakerWindowField(current, "failures")], 10, 64)
+		failures += count
+	}
+	return total, failures, nil
+}
+
+// clearNextBucket deletes the ring slot the next bucket will use, which still holds counts from a full
+// ring ago. Only the first node to claim the bucket deletes it.
+func (b *GroupCircuitBreaker) clearNextBucket(groupID uint, bucket int64, settings circuitBreakerSettings) {
+	value, _ := b.clearedBuckets.LoadOrStore(groupID, &atomic.Int64{})
+	cleared := value.(*atomic.Int64)
+	last := cleared.Load()
+	if last >= bucket || !cleared.CompareAndSwap(last, bucket) {
+		return
+	}
+
+	claimKey := fmt.Sprintf("circuit_breaker_clear:%d:%d", groupID, bucket)
+	ttl := time.Duration(circuitBreakerRingSlots*settings.bucketSeconds()) * time.Second
+	claimed, err := b.store.SetNX(claimKey, []byte("1"), ttl)
+	if err != nil || !claimed {
+		return
+	}
+	if err := b.store.Delete(circuitBreakerWindowKey(groupID, bucket+1)); err != nil {
+		logrus.WithError(err).Debug("Failed to clear circuit breaker bucket")
+	}
+}
+
+// bucketSeconds is the length of one window bucket.
+func (s circuitBreakerSettings) bucketSeconds() int {
+	return max(int(s.window.Seconds())/circuitBreakerWindowSlots, 1)
+}
+
+// newCircuitBreakerSettings returns the group's circuit breaker settings, and whether it uses one.
+func newCircuitBreakerSettings(group *models.Group) (circuitBreakerSettings, bool) {
+	config := &group.ParsedConfig
+	if config.CircuitBreakerFailureRate == nil || *config.CircuitBreakerFailureRate <= 0 {
+		return circuitBreakerSettings{}, false
+	}
+
+	settings := circuitBreakerSettings{
+		failureRate: *config.CircuitBreakerFailureRate,
+		window:      defaultCircuitBreakerWindowSeconds * time.Second,
+		minRequests: defaultCircuitBreakerMinRequests,
+		cooldown:    defaultCircuitBreakerCooldownSeconds * time.Second,
+	}
+	if config.CircuitBreakerWindowSeconds != nil && *config.CircuitBreakerWindowSeconds > 0 {
+		settings.window = time.Duration(*config.CircuitBreakerWindowSeconds) * time.Second
+	}
+	if config.CircuitBreakerMinRequests != nil && *config.CircuitBreakerMinRequests > 0 {
+		settings.minRequests = int64(*config.CircuitBreakerMinRequests)
+	}
+	if config.CircuitBreakerCooldownSeconds != nil && *config.CircuitBreakerCooldownSeconds > 0 {
+		settings.cooldown = time.Duration(*config.CircuitBreakerCooldownSeconds) * time.Second
+	}
+	return settings, true
+}
+
+func circuitBreakerKey(groupID uint) string {
+	return fmt.Sprintf("circuit_breaker:%d", groupID)
+}
+
+func circuitBreakerProbeKey(groupID uint) string {
+	return fmt.Sprintf("circuit_breaker_probe:%d", groupID)
+}
+
+func circuitBreakerWindowKey(groupID uint, bucket int64) string {
+	return fmt.Sprintf("circuit_breaker_window:%d:%d", groupID, bucket%circuitBreakerRingSlots)
+}
+
+func circuitBreakerWindowField(bucket int64, counter string) string {
+	return fmt.Sprintf("%d|%s", bucket, counter)
+}
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+)
+
+// groupConfigValidators 按功能验证分组专属配置字段，这些字段不经过 settingsManager 的验证
+var groupConfigValidators = []func(configMap map[string]any) error{
+	validateRateLimitConfig,
+	validateRequestLimitConfig,
+	validateRetryConfig,
+	validateCircuitBreakerConfig,
+	validateNotificationConfig,
+	validateDebugConfig,
+	validateSessionAffinityConfig,
+	validateModelConfig,
+	validateRequestRewriteConfig,
+	validateAuthHeaderConfig,
+	validateAccessControlConfig,
+	validateErrorResponsesConfig,
+	validateRequestLoggingConfig,
+}
+
+// headerNamePattern 匹配可用作配置项的请求头名称
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9-]{1,100}$")
+
+// numberValue 将 JSON 解码得到的数字转换为 float64，非数字返回 false
+func numberValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// configNumber 读取数字类型的配置字段，字段不存在或为 null 时 ok 为 false
+func configNumber(configMap map[string]any, field string) (value float64, ok bool, err error) {
+	raw, exists := configMap[field]
+	if !exists || raw == nil {
+		return 0, false, nil
+	}
+	value, ok = numberValue(raw)
+	if !ok {
+		return 0, false, fmt.Errorf("%s must be a number", field)
+	}
+	return value, true, nil
+}
+
+// validateConfigInts 验证整数配置字段不小于 min，且不大于 max（max 为 0 表示不限制上限）
+func validateConfigInts(configMap map[string]any, min, max float64, message string, fields ...string) error {
+	for _, field := range fields {
+		value, ok, err := configNumber(configMap, field)
+		if err != nil {
+			return err
+		}
+		if ok && (value < min || (max > 0 && value > max) || value != math.Trunc(value)) {
+			return fmt.Errorf("%s must be %s", field, message)
+		}
+	}
+	return nil
+}
+
+// validateNonNegativeInts 验证配置字段为非负整数
+func validateNonNegativeInts(configMap map[string]any, fields ...string) error {
+	return validateConfigInts(configMap, 0, 0, "a non-negative integer", fields...)
+}
+
+// validatePositiveInts 验证配置字段为正整数
+func validatePositiveInts(configMap map[string]any, fields ...string) error {
+	return validateConfigInts(configMap, 1, 0, "a positive integer", fields...)
+}
+
+// validatePercents 验证配置字段为 0 到 100 之间的整数
+func validatePercents(configMap map[string]any, fields ...string) error {
+	return validateConfigInts(configMap, 0, 100, "an integer between 0 and 100", fields...)
+}
+
+// validateBools 验证配置字段为布尔值
+func validateBools(configMap map[string]any, fields ...string) error {
+	for _, field := range fields {
+		if value, exists := configMap[field]; exists && value != nil {
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("%s must be a boolean", field)
+			}
+		}
+	}
+	return nil
+}
+
+// validateStringList 验证配置字段为非空字符串数组，arrayMessage 和 itemMessage 分别描述数组和元素的要求
+func validateStringList(configMap map[string]any, field, arrayMessage, itemMessage string) error {
+	listVal, exists := configMap[field]
+	if !exists || listVal == nil {
+		return nil
+	}
+	list, ok := listVal.([]any)
+	if !ok {
+		return fmt.Errorf("%s must be %s", field, arrayMessage)
+	}
+	for _, item := range list {
+		value, ok := item.(string)
+		if !ok || strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s must only contain %s", field, itemMessage)
+		}
+	}
+	return nil
+}
+
+// decodeConfigList 将配置字段严格解码为 target，不允许出现未知字段
+func decodeConfigList(value any, target any) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(valueBytes))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
+
+// validateRateLimitConfig 验证限流和有效期配置
+func validateRateLimitConfig(configMap map[string]any) error {
+	// 验证 expires_at 字段
+	if expiresAtVal, exists := configMap["expires_at"]; exists && expiresAtVal != nil {
+		v, ok := expiresAtVal.(string)
+		if !ok {
+			return fmt.Errorf("expires_at must be a string in ISO8601 format")
+		}
+		// 前端发送的格式: 2006-01-02 15:04:05
+		if _, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local); err != nil {
+			return fmt.Errorf("invalid expires_at format '%s': %w", v, err)
+		}
+	}
+
+	// 验证 max_requests_per_hour 和 max_requests_per_month 字段
+	for _, field := range []string{"max_requests_per_hour", "max_requests_per_month"} {
+		limit, ok, err := configNumber(configMap, field)
+		if err != nil {
+			return err
+		}
+		if ok && limit < 0 {
+			return fmt.Errorf("%s must be >= 0", field)
+		}
+	}
+
+	// 验证 rate_limit_mode、rate_limit_refill_per_second 和 rate_limit_burst 字段，令牌桶模式下后两者必填
+	rateLimitMode := models.RateLimitModeFixedWindow
+	if modeVal, exists := configMap["rate_limit_mode"]; exists && modeVal != nil {
+		mode, ok := modeVal.(string)
+		if !ok || (mode != models.RateLimitModeFixedWindow && mode != models.RateLimitModeTokenBucket) {
+			return fmt.Errorf("rate_limit_mode must be one of: %s, %s", models.RateLimitModeFixedWindow, models.RateLimitModeTokenBucket)
+		}
+		rateLimitMode = mode
+	}
+	refill, hasRefill, err := configNumber(configMap, "rate_limit_refill_per_second")
+	if err != nil {
+		return err
+	}
+	if hasRefill && refill <= 0 {
+		return fmt.Errorf("rate_limit_refill_per_second must be > 0")
+	}
+	burst, hasBurst, err := configNumber(configMap, "rate_limit_burst")
+	if err != nil {
+		return err
+	}
+	if hasBurst && (burst < 1 || burst != math.Trunc(burst)) {
+		return fmt.Errorf("rate_limit_burst must be a positive integer")
+	}
+	if rateLimitMode == models.RateLimitModeTokenBucket && (!hasRefill || !hasBurst) {
+		return fmt.Errorf("rate_limit_refill_per_second and rate_limit_burst are required when rate_limit_mode is %s", models.RateLimitModeTokenBucket)
+	}
+
+	// 验证 rate_limit_exempt_keys 字段
+	if exemptVal, exists := configMap["rate_limit_exempt_keys"]; exists && exemptVal != nil {
+		if _, ok := exemptVal.(string); !ok {
+			return fmt.Errorf("rate_limit_exempt_keys must be a comma-separated string")
+		}
+	}
+
+	return nil
+}
+
+// validateRequestLimitConfig 验证单个请求的限制：上游超时、并发数以及请求/响应体大小
+func validateRequestLimitConfig(configMap map[string]any) error {
+	if err := validatePositiveInts(configMap, "request_timeout_seconds", "connect_timeout_seconds"); err != nil {
+		return err
+	}
+	return validateNonNegativeInts(configMap, "max_concurrent_requests", "max_request_body_bytes", "max_response_body_bytes")
+}
+
+// validateRetryConfig 验证重试策略：retry_on_status、retry_backoff_ms 以及失败密钥的冷却时间
+func validateRetryConfig(configMap map[string]any) error {
+	if statusVal, exists := configMap["retry_on_status"]; exists && statusVal != nil {
+		list, ok := statusVal.([]any)
+		if !ok {
+			return fmt.Errorf("retry_on_status must be an array of HTTP status codes")
+		}
+		for _, item := range list {
+			code, ok := item.(float64)
+			if !ok || code != math.Trunc(code) || code < 400 || code > 599 {
+				return fmt.Errorf("retry_on_status must only contain HTTP status codes between 400 and 599")
+			}
+		}
+	}
+	return validateNonNegativeInts(configMap, "retry_backoff_ms", "key_cooldown_seconds")
+}
+
+// validateCircuitBreakerConfig 验证分组熔断器的失败率阈值、统计窗口、最小请求数和冷却时间
+func validateCircuitBreakerConfig(configMap map[string]any) error {
+	if err := validatePercents(configMap, "circuit_breaker_failure_rate"); err != nil {
+		return err
+	}
+	return validatePositiveInts(configMap, "circuit_breaker_window_seconds", "circuit_breaker_min_requests", "circuit_breaker_cooldown_seconds")
+}
+
+// validateNotificationConfig 验证告警 Webhook 地址及其触发阈值
+func validateNotificationConfig(configMap map[string]any) error {
+	if webhookVal, exists := configMap["notification_webhook"]; exists && webhookVal != nil {
+		webhook, ok := webhookVal.(string)
+		if !ok {
+			return fmt.Errorf("notification_webhook must be a string")
+		}
+		if webhook != "" {
+			parsed, err := url.Parse(webhook)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("notification_webhook must be a valid http or https URL")
+			}
+		}
+	}
+	return validatePercents(configMap, "notification_active_key_percent", "notification_failure_rate_percent")
+}
+
+// validateDebugConfig 验证调试开关：指定上游、固定选择种子和诊断响应头
+func validateDebugConfig(configMap map[string]any) error {
+	return validateBools(configMap, "allow_upstream_override", "allow_seeded_selection", "enable_diagnostic_headers")
+}
+
+// validateSessionAffinityConfig 验证会话粘性的请求头名称和绑定有效期
+func validateSessionAffinityConfig(configMap map[string]any) error {
+	if headerVal, exists := configMap["session_affinity_header"]; exists && headerVal != nil {
+		header, ok := headerVal.(string)
+		if !ok {
+			return fmt.Errorf("session_affinity_header must be a string")
+		}
+		if header != "" && !headerNamePattern.MatchString(header) {
+			return fmt.Errorf("session_affinity_header must be a valid header name")
+		}
+	}
+	return validateConfigInts(configMap, 1, MaxSessionAffinityTTLSeconds, fmt.Sprintf("an integer between 1 and %d", MaxSessionAffinityTTLSeconds), "session_affinity_ttl_seconds")
+}
+
+// validateModelConfig 验证与模型相关的配置：模型路由、访问控制、灰度路由和备用测试模型
+func validateModelConfig(configMap map[string]any) error {
+	if routingVal, exists := configMap["model_routing"]; exists && routingVal != nil {
+		routing, ok := routingVal.(map[string]any)
+		if !ok {
+			return fmt.Errorf("model_routing must be an object mapping model names to sub-group IDs")
+		}
+		for model, idVal := range routing {
+			if model == "" || model != strings.TrimSpace(model) {
+				return fmt.Errorf("model_routing must only contain non-empty model names without surrounding spaces")
+			}
+			if id, ok := numberValue(idVal); !ok || id < 1 || id != math.Trunc(id) {
+				return fmt.Errorf("model_routing.%s must be a sub-group ID", model)
+			}
+		}
+	}
+
+	// 模式只使用 '*' 和 '?' 通配符，任何非空字符串都是合法的
+	for _, field := range []string{"allowed_models", "denied_models"} {
+		if err := validateStringList(configMap, field, "an array of model name patterns", "non-empty model name patterns"); err != nil {
+			return err
+		}
+	}
+
+	if canariesVal, exists := configMap["model_canaries"]; exists && canariesVal != nil {
+		var canaries []models.ModelCanary
+		if err := decodeConfigList(canariesVal, &canaries); err != nil {
+			return fmt.Errorf("model_canaries must be an array of canary rules: %v", err)
+		}
+		if len(canaries) > maxModelCanaries {
+			return fmt.Errorf("model_canaries allows at most %d rules", maxModelCanaries)
+		}
+		seen := make(map[string]bool, len(canaries))
+		for i, canary := range canaries {
+			for _, name := range []string{canary.Model, canary.Candidate} {
+				if name == "" || name != strings.TrimSpace(name) || len(name) > 255 {
+					return fmt.Errorf("model_canaries rule %d: model and candidate must be non-empty model names of at most 255 characters without surrounding spaces", i+1)
+				}
+			}
+			if canary.Model == canary.Candidate {
+				return fmt.Errorf("model_canaries rule %d: candidate must differ from model", i+1)
+			}
+			if canary.Percent <= 0 || canary.Percent > 100 {
+				return fmt.Errorf("model_canaries rule %d: percent must be greater than 0 and at most 100", i+1)
+			}
+			if seen[canary.Model] {
+				return fmt.Errorf("model_canaries rule %d: model '%s' already has a canary rule", i+1, canary.Model)
+			}
+			seen[canary.Model] = true
+		}
+	}
+
+	return validateStringList(configMap, "fallback_test_models", "an array of model names", "non-empty model names")
+}
+
+// validateRequestRewriteConfig 验证对转发请求和响应的改写：默认参数、参数覆盖方式、字段重命名、SSE 改写和请求体规则
+func validateRequestRewriteConfig(configMap map[string]any) error {
+	if defaultsVal, exists := configMap["default_params"]; exists && defaultsVal != nil {
+		defaults, ok := defaultsVal.(map[string]any)
+		if !ok {
+			return fmt.Errorf("default_params must be a JSON object")
+		}
+		for key, value := range defaults {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("default_params contains an empty parameter name")
+			}
+			if value == nil {
+				return fmt.Errorf("default_params value for '%s' must not be null", key)
+			}
+			if _, err := json.Marshal(value); err != nil {
+				return fmt.Errorf("default_params value for '%s' is not a valid JSON value: %w", key, err)
+			}
+		}
+	}
+
+	if modeVal, exists := configMap["param_override_mode"]; exists && modeVal != nil {
+		mode, ok := modeVal.(string)
+		if !ok || (mode != models.ParamOverrideModeOverride && mode != models.ParamOverrideModeDefault && mode != models.ParamOverrideModeMerge) {
+			return fmt.Errorf("param_override_mode must be one of: %s, %s, %s", models.ParamOverrideModeOverride, models.ParamOverrideModeDefault, models.ParamOverrideModeMerge)
+		}
+	}
+	if err := validateBools(configMap, "strict_param_overrides"); err != nil {
+		return err
+	}
+
+	if renamesVal, exists := configMap["field_renames"]; exists && renamesVal != nil {
+		renames, ok := renamesVal.(map[string]any)
+		if !ok {
+			return fmt.Errorf("field_renames must be an object with 'request' and/or 'response' mappings")
+		}
+		for direction, mappingVal := range renames {
+			if direction != "request" && direction != "response" {
+				return fmt.Errorf("field_renames has unknown key '%s', supported: request, response", direction)
+			}
+			mapping, ok := mappingVal.(map[string]any)
+			if !ok {
+				return fmt.Errorf("field_renames.%s must be an object mapping field names to new names", direction)
+			}
+			targets := make(map[string]string, len(mapping))
+			for from, toVal := range mapping {
+				to, ok := toVal.(string)
+				if !ok || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+					return fmt.Errorf("field_renames.%s must only map non-empty field names", direction)
+				}
+				if other, exists := targets[to]; exists {
+					return fmt.Errorf("field_renames.%s maps both '%s' and '%s' to '%s'", direction, other, from, to)
+				}
+				targets[to] = from
+			}
+		}
+	}
+
+	if rewriteVal, exists := configMap["sse_rewrite"]; exists && rewriteVal != nil {
+		rewrite, ok := rewriteVal.(map[string]any)
+		if !ok {
+			return fmt.Errorf("sse_rewrite must be an object")
+		}
+		for option := range rewrite {
+			switch option {
+			case "allowed_events":
+				if err := validateStringList(rewrite, option, "an array of event types", "non-empty event types"); err != nil {
+					return fmt.Errorf("sse_rewrite.%v", err)
+				}
+			case "strip_comments", "normalize_done", "rechunk":
+				if err := validateBools(rewrite, option); err != nil {
+					return fmt.Errorf("sse_rewrite.%v", err)
+				}
+			default:
+				return fmt.Errorf("sse_rewrite has unknown option '%s', supported: allowed_events, strip_comments, normalize_done, rechunk", option)
+			}
+		}
+	}
+
+	if rulesVal, exists := configMap["body_rules"]; exists && rulesVal != nil {
+		var rules []models.BodyRule
+		if err := decodeConfigList(rulesVal, &rules); err != nil {
+			return fmt.Errorf("body_rules must be an array of rules: %v", err)
+		}
+		if err := utils.ValidateBodyRules(rules); err != nil {
+			return fmt.Errorf("body_rules: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateAuthHeaderConfig 验证客户端 Authorization 的转发方式和上游密钥的注入方式
+func validateAuthHeaderConfig(configMap map[string]any) error {
+	forwarding := ""
+	if forwardingVal, exists := configMap["authorization_forwarding"]; exists && forwardingVal != nil {
+		var ok bool
+		forwarding, ok = forwardingVal.(string)
+		if !ok || (forwarding != models.AuthorizationForwardingDrop && forwarding != models.AuthorizationForwardingHeader && forwarding != models.AuthorizationForwardingReplace) {
+			return fmt.Errorf("authorization_forwarding must be one of: %s, %s, %s", models.AuthorizationForwardingDrop, models.AuthorizationForwardingHeader, models.AuthorizationForwardingReplace)
+		}
+	}
+
+	// 转发使用的请求头不能是会被当作凭据或影响路由的请求头
+	if headerVal, exists := configMap["authorization_forward_header"]; exists && headerVal != nil {
+		header, ok := headerVal.(string)
+		if !ok {
+			return fmt.Errorf("authorization_forward_header must be a string")
+		}
+		if !headerNamePattern.MatchString(header) {
+			return fmt.Errorf("authorization_forward_header must be a valid header name")
+		}
+		switch http.CanonicalHeaderKey(header) {
+		case "Authorization", "Proxy-Authorization", "X-Api-Key", "X-Goog-Api-Key", "Cookie", "Host":
+			return fmt.Errorf("authorization_forward_header cannot be '%s'", header)
+		}
+	}
+
+	mode := ""
+	if modeVal, exists := configMap["auth_header_mode"]; exists && modeVal != nil {
+		var ok bool
+		mode, ok = modeVal.(string)
+		if !ok || (mode != models.AuthHeaderModeBearer && mode != models.AuthHeaderModeXAPIKey && mode != models.AuthHeaderModeCustom) {
+			return fmt.Errorf("auth_header_mode must be one of: %s, %s, %s", models.AuthHeaderModeBearer, models.AuthHeaderModeXAPIKey, models.AuthHeaderModeCustom)
+		}
+	}
+
+	// auth_header_name 在 "custom" 方式下必填，且不能是会影响请求本身的请求头
+	headerName := ""
+	if nameVal, exists := configMap["auth_header_name"]; exists && nameVal != nil {
+		var ok bool
+		headerName, ok = nameVal.(string)
+		if !ok {
+			return fmt.Errorf("auth_header_name must be a string")
+		}
+		if !headerNamePattern.MatchString(headerName) {
+			return fmt.Errorf("auth_header_name must be a valid header name")
+		}
+		switch http.CanonicalHeaderKey(headerName) {
+		case "Host", "Cookie", "Content-Type", "Content-Length", "Transfer-Encoding", "Connection":
+			return fmt.Errorf("auth_header_name cannot be '%s'", headerName)
+		}
+	}
+	if mode == models.AuthHeaderModeCustom && headerName == "" {
+		return fmt.Errorf("auth_header_name is required when auth_header_mode is %s", models.AuthHeaderModeCustom)
+	}
+	// replace 总会写入 Authorization，与非 bearer 的 auth_header_mode 同时使用会让密钥发送两次
+	if forwarding == models.AuthorizationForwardingReplace && mode != "" && mode != models.AuthHeaderModeBearer {
+		return fmt.Errorf("authorization_forwarding %s cannot be combined with auth_header_mode %s", models.AuthorizationForwardingReplace, mode)
+	}
+
+	return nil
+}
+
+// validateAccessControlConfig 验证代理密钥的来源 IP 白名单和防重放设置
+func validateAccessControlConfig(configMap map[string]any) error {
+	if cidrsVal, exists := configMap["proxy_key_allowed_cidrs"]; exists && cidrsVal != nil {
+		cidrsMap, ok := cidrsVal.(map[string]any)
+		if !ok {
+			return fmt.Errorf("proxy_key_allowed_cidrs must be an object mapping proxy keys to CIDR lists")
+		}
+		for proxyKey, listVal := range cidrsMap {
+			if strings.TrimSpace(proxyKey) == "" {
+				return fmt.Errorf("proxy_key_allowed_cidrs must not contain an empty proxy key")
+			}
+			list, ok := listVal.([]any)
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("proxy_key_allowed_cidrs for a proxy key must be a non-empty array of CIDRs")
+			}
+			for _, item := range list {
+				cidr, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("proxy_key_allowed_cidrs must only contain CIDR strings")
+				}
+				if _, err := utils.ParseIPNetwork(cidr); err != nil {
+					return fmt.Errorf("proxy_key_allowed_cidrs has invalid CIDR '%s'", cidr)
+				}
+			}
+		}
+	}
+
+	if err := validateBools(configMap, "require_nonce"); err != nil {
+		return err
+	}
+	// nonce 需保存两倍偏差时长，因此限制上限
+	skew, ok, err := configNumber(configMap, "nonce_max_skew_seconds")
+	if err != nil {
+		return err
+	}
+	if ok && (skew < 1 || skew > maxNonceSkewSeconds) {
+		return fmt.Errorf("nonce_max_skew_seconds must be between 1 and %d", maxNonceSkewSeconds)
+	}
+	return nil
+}
+
+// validateErrorResponsesConfig 验证按失败原因配置的自定义错误响应体
+func validateErrorResponsesConfig(configMap map[string]any) error {
+	responsesVal, exists := configMap["error_responses"]
+	if !exists || responsesVal == nil {
+		return nil
+	}
+	responses, ok := responsesVal.(map[string]any)
+	if !ok {
+		return fmt.Errorf("error_responses must be a JSON object keyed by failure reason")
+	}
+	for reason, body := range responses {
+		if !slices.Contains(utils.ErrorResponseReasons, reason) {
+			return fmt.Errorf("error_responses has unknown reason '%s', supported: %s", reason, strings.Join(utils.ErrorResponseReasons, ", "))
+		}
+		if err := utils.ValidateErrorResponseTemplate(body); err != nil {
+			return fmt.Errorf("error_responses.%s: %w", reason, err)
+		}
+	}
+	return nil
+}
+
+// validateRequestLoggingConfig 验证 disable_request_logging，禁用请求日志时不允许显式开启请求体记录
+func validateRequestLoggingConfig(configMap map[string]any) error {
+	disableVal, exists := configMap["disable_request_logging"]
+	if !exists || disableVal == nil {
+		return nil
+	}
+	disable, ok := disableVal.(bool)
+	if !ok {
+		return fmt.Errorf("disable_request_logging must be a boolean")
+	}
+	if bodyLogging, _ := configMap["enable_request_body_logging"].(bool); disable && bodyLogging {
+		return fmt.Errorf("enable_request_body_logging cannot be enabled when disable_request_logging is true")
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sources of an effective group setting, from lowest to highest precedence.
+const (
+	EffectiveConfigSourceDefault  = "default"
+	EffectiveConfigSourceSystem   = "system"
+	EffectiveConfigSourcePreset   = "preset"
+	EffectiveConfigSourceOverride = "override"
+)
+
+// EffectiveConfigEntry is the resolved value of one group setting and where it comes from.
+// InheritedValue is what the group would use without its override.
+type EffectiveConfigEntry struct {
+	Key            string `json:"key"`
+	Value          any    `json:"value"`
+	Source         string `json:"source"`
+	InheritedValue any    `json:"inherited_value"`
+}
+
+// GroupEffectiveConfig lists every group setting with the value the proxy uses at runtime.
+type GroupEffectiveConfig struct {
+	GroupID     uint                   `json:"group_id"`
+	GroupName   string                 `json:"group_name"`
+	ChannelType string                 `json:"channel_type"`
+	Config      []EffectiveConfigEntry `json:"config"`
+	Channel     []EffectiveConfigEntry `json:"channel"`
+}
+
+// GetGroupEffectiveConfig resolves every GroupConfig field of a group the same way the group cache does:
+// built-in defaults, then system settings, then the group's own overrides. Group-only options have no
+// system value; unset ones are reported with a nil value, meaning the built-in behaviour applies.
+func (s *GroupService) GetGroupEffectiveConfig(ctx context.Context, groupID uint) (*GroupEffectiveConfig, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	result := &GroupEffectiveConfig{
+		GroupID:     group.ID,
+		GroupName:   group.Name,
+		ChannelType: group.ChannelType,
+		Config:      []EffectiveConfigEntry{},
+		Channel:     []EffectiveConfigEntry{},
+	}
+	for _, setting := range s.resolveGroupSettings(ctx, &group) {
+		result.Config = append(result.Config, setting.effectiveEntry())
+	}
+	for _, setting := range resolveChannelPresets(&group) {
+		result.Channel = append(result.Channel, setting.effectiveEntry())
+	}
+
+	return result, nil
+}
+
+// resolvedSetting is one setting of a group resolved against its defaults. The effective config and
+// the config diff are both built from it, so they agree on defaults and on what counts as an override.
+type resolvedSetting struct {
+	key string
+	// value is what the group uses at runtime, nil for unset group-only options
+	value  any
+	source string
+	// inherited is what the group would use without its override
+	inherited any
+	// defaultValue is the built-in default or channel preset; hasDefault is false for group-only options
+	defaultValue any
+	hasDefault   bool
+	// override is the group's own value as stored, nil when the group does not set it
+	override any
+}
+
+func (r resolvedSetting) effectiveEntry() EffectiveConfigEntry {
+	return EffectiveConfigEntry{Key: r.key, Value: r.value, Source: r.source, InheritedValue: r.inherited}
+}
+
+func (r resolvedSetting) diffEntry() ConfigDiffEntry {
+	return ConfigDiffEntry{Key: r.key, Value: r.override, DefaultValue: r.defaultValue}
+}
+
+// diverges reports whether the group sets the setting to something other than its default.
+func (r resolvedSetting) diverges() bool {
+	return r.override != nil && (!r.hasDefault || !jsonEqual(r.override, r.defaultValue))
+}
+
+// resolveGroupSettings resolves every GroupConfig field of the group, in field order.
+func (s *GroupService) resolveGroupSettings(ctx context.Context, group *models.Group) []resolvedSetting {
+	var groupConfig models.GroupConfig
+	if len(group.Config) > 0 {
+		if configBytes, err := json.Marshal(group.Config); err != nil {
+			logrus.WithContext(ctx).WithError(err).WithField("group_id", group.ID).Warn("Failed to marshal group config for effective config")
+		} else if err := json.Unmarshal(configBytes, &groupConfig); err != nil {
+			// The group cache falls back to system settings in this case, so report the same
+			logrus.WithContext(ctx).WithError(err).WithField("group_id", group.ID).Warn("Failed to parse group config for effective config")
+			groupConfig = models.GroupConfig{}
+		}
+	}
+
+	systemValue := reflect.ValueOf(s.settingsManager.GetSettings())
+	defaultValue := reflect.ValueOf(utils.DefaultSystemSettings())
+	effectiveValue := reflect.ValueOf(s.settingsManager.GetEffectiveConfig(group.Config))
+
+	configValue := reflect.ValueOf(groupConfig)
+	configType := configValue.Type()
+	settings := make([]resolvedSetting, 0, configType.NumField())
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		groupField := configValue.Field(i)
+		setting := resolvedSetting{key: key, source: EffectiveConfigSourceDefault, override: group.Config[key]}
+
+		if effectiveField := effectiveValue.FieldByName(field.Name); effectiveField.IsValid() {
+			setting.value = effectiveField.Interface()
+			setting.inherited = systemValue.FieldByName(field.Name).Interface()
+			setting.defaultValue = defaultValue.FieldByName(field.Name).Interface()
+			setting.hasDefault = true
+			// Overrides of a mismatching type are ignored by GetEffectiveConfig, so check the type too
+			if groupField.Kind() == reflect.Ptr && !groupField.IsNil() && groupField.Elem().Type() == effectiveField.Type() {
+				setting.source = EffectiveConfigSourceOverride
+			} else if !reflect.DeepEqual(setting.inherited, setting.defaultValue) {
+				setting.source = EffectiveConfigSourceSystem
+			}
+			settings = append(settings, setting)
+			continue
+		}
+
+		if isGroupConfigFieldSet(groupField) {
+			if groupField.Kind() == reflect.Ptr {
+				setting.value = groupField.Elem().Interface()
+			} else {
+				setting.value = groupField.Interface()
+			}
+			setting.source = EffectiveConfigSourceOverride
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// resolveChannelPresets resolves the group fields that default to a preset of its channel type.
+func resolveChannelPresets(group *models.Group) []resolvedSetting {
+	presetEndpoint := utils.GetValidationEndpoint(&models.Group{ChannelType: group.ChannelType})
+	endpoint := resolvedSetting{
+		key:          "validation_endpoint",
+		value:        presetEndpoint,
+		source:       EffectiveConfigSourcePreset,
+		inherited:    presetEndpoint,
+		defaultValue: presetEndpoint,
+		hasDefault:   true,
+	}
+	if group.ValidationEndpoint != "" {
+		endpoint.value = group.ValidationEndpoint
+		endpoint.source = EffectiveConfigSourceOverride
+		endpoint.override = group.ValidationEndpoint
+	}
+
+	redirectStrict := resolvedSetting{
+		key:          "model_redirect_strict",
+		value:        group.ModelRedirectStrict,
+		source:       EffectiveConfigSourcePreset,
+		inherited:    false,
+		defaultValue: false,
+		hasDefault:   true,
+	}
+	if group.ModelRedirectStrict {
+		redirectStrict.source = EffectiveConfigSourceOverride
+		redirectStrict.override = true
+	}
+
+	return []resolvedSetting{endpoint, redirectStrict}
+}
+
+// isGroupConfigFieldSet reports whether a group-only GroupConfig field carries a value.
+func isGroupConfigFieldSet(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !field.IsNil()
+	case reflect.Map, reflect.Slice:
+		return field.Len() > 0
+	default:
+		return !field.IsZero()
+	}
+}
@@ -9,6 +9,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -16,6 +21,9 @@ import (
 
 const GroupUpdateChannel = "groups:updated"
 
+// groupCacheRefreshRecheckInterval is how often the refresh loop re-reads settings while periodic refresh is disabled.
+const groupCacheRefreshRecheckInterval = time.Minute
+
 // GroupManager manages the caching of group data.
 type GroupManager struct {
 	syncer          *syncer.CacheSyncer[map[string]*models.Group]
@@ -23,6 +31,9 @@ type GroupManager struct {
 	store           store.Store
 	settingsManager *config.SystemSettingsManager
 	subGroupManager *SubGroupManager
+	stopChan        chan struct{}
+	wg              sync.WaitGroup
+	stopOnce        sync.Once
 }
 
 // NewGroupManager creates a new, uninitialized GroupManager.
@@ -37,6 +48,7 @@ func NewGroupManager(
 		store:           store,
 		settingsManager: settingsManager,
 		subGroupManager: subGroupManager,
+		stopChan:        make(chan struct{}),
 	}
 }
 
@@ -72,6 +84,26 @@ func (gm *GroupManager) Initialize() error {
 			g.EffectiveConfig = gm.settingsManager.GetEffectiveConfig(g.Config)
 			g.ProxyKeysMap = utils.StringToSet(g.ProxyKeys, ",")
 
+			// Parse group-only config fields (rate limits, debug flags, etc.)
+			parseGroupConfig(&g)
+
+			// Parse proxy key IP allow-lists; invalid entries are rejected on save, so they are only skipped here
+			if len(g.ParsedConfig.ProxyKeyAllowedCIDRs) > 0 {
+				g.ProxyKeyNetworks = make(map[string][]*net.IPNet, len(g.ParsedConfig.ProxyKeyAllowedCIDRs))
+				for proxyKey, cidrs := range g.ParsedConfig.ProxyKeyAllowedCIDRs {
+					networks := make([]*net.IPNet, 0, len(cidrs))
+					for _, cidr := range cidrs {
+						network, err := utils.ParseIPNetwork(cidr)
+						if err != nil {
+							logrus.WithError(err).WithField("group_name", g.Name).Warn("Invalid proxy key allowed CIDR, skipping")
+							continue
+						}
+						networks = append(networks, network)
+					}
+					g.ProxyKeyNetworks[strings.TrimSpace(proxyKey)] = networks
+				}
+			}
+
 			// Parse header rules with error handling
 			if len(group.HeaderRules) > 0 {
 				if err := json.Unmarshal(group.HeaderRules, &g.HeaderRuleList); err != nil {
@@ -145,10 +177,55 @@ func (gm *GroupManager) Initialize() error {
 	if err != nil {
 		return fmt.Errorf("failed to create group syncer: %w", err)
 	}
+	syncer.SetInvalidateWindow(func() time.Duration {
+		return time.Duration(gm.settingsManager.GetSettings().GroupCacheInvalidateWindowMs) * time.Millisecond
+	})
 	gm.syncer = syncer
+
+	gm.wg.Add(1)
+	go gm.refreshLoop()
+
 	return nil
 }
 
+// refreshLoop periodically reloads the local group cache as a safety net against missed
+// invalidations. Each node waits a jittered interval so reloads don't synchronize across nodes.
+func (gm *GroupManager) refreshLoop() {
+	defer gm.wg.Done()
+
+	for {
+		delay, enabled := gm.nextRefreshDelay()
+		select {
+		case <-time.After(delay):
+			if !enabled {
+				continue
+			}
+			if err := gm.syncer.Reload(); err != nil {
+				logrus.WithError(err).Warn("Periodic group cache refresh failed")
+			}
+		case <-gm.stopChan:
+			return
+		}
+	}
+}
+
+// nextRefreshDelay returns the wait before the next periodic refresh and whether refresh is enabled.
+func (gm *GroupManager) nextRefreshDelay() (time.Duration, bool) {
+	settings := gm.settingsManager.GetSettings()
+	if settings.GroupCacheRefreshIntervalSeconds <= 0 {
+		return groupCacheRefreshRecheckInterval, false
+	}
+
+	delay := time.Duration(settings.GroupCacheRefreshIntervalSeconds) * time.Second
+	if jitter := settings.GroupCacheRefreshJitterSeconds; jitter > 0 {
+		delay += time.Duration(rand.Intn(2*jitter+1)-jitter) * time.Second
+	}
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return delay, true
+}
+
 // GetGroupByName retrieves a single group by its name from the cache.
 func (gm *GroupManager) GetGroupByName(name string) (*models.Group, error) {
 	if gm.syncer == nil {
@@ -163,7 +240,22 @@ func (gm *GroupManager) GetGroupByName(name string) (*models.Group, error) {
 	return group, nil
 }
 
-// Invalidate triggers a cache reload across all instances.
+// GetGroupByID retrieves a single group by its ID from the cache.
+func (gm *GroupManager) GetGroupByID(id uint) (*models.Group, error) {
+	if gm.syncer == nil {
+		return nil, fmt.Errorf("GroupManager is not initialized")
+	}
+
+	for _, group := range gm.syncer.Get() {
+		if group.ID == id {
+			return group, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// Invalidate triggers a cache reload across all instances. Calls within the configured
+// invalidate window are merged, so bursts of group changes cause a single reload per node.
 func (gm *GroupManager) Invalidate() error {
 	if gm.syncer == nil {
 		return fmt.Errorf("GroupManager is not initialized")
@@ -171,9 +263,98 @@ func (gm *GroupManager) Invalidate() error {
 	return gm.syncer.Invalidate()
 }
 
-// Stop gracefully stops the GroupManager's background syncer.
+// Stop gracefully stops the GroupManager's background syncer. It is safe to call more than once.
 func (gm *GroupManager) Stop(ctx context.Context) {
-	if gm.syncer != nil {
-		gm.syncer.Stop()
+	gm.stopOnce.Do(func() {
+		close(gm.stopChan)
+		gm.wg.Wait()
+		if gm.syncer != nil {
+			gm.syncer.Stop()
+		}
+	})
+}
+
+// parseGroupConfig decodes the group's config into ParsedConfig. Groups loaded straight from the
+// database need it before their group-only fields are read; a config that fails to parse is left empty.
+func parseGroupConfig(group *models.Group) {
+	group.ParsedConfig = models.GroupConfig{}
+	if len(group.Config) == 0 {
+		return
+	}
+	configBytes, err := json.Marshal(group.Config)
+	if err == nil {
+		err = json.Unmarshal(configBytes, &group.ParsedConfig)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to parse group config")
+		group.ParsedConfig = models.GroupConfig{}
+	}
+}
+
+// InsecureUpstream identifies a group upstream that is not served over HTTPS.
+type InsecureUpstream struct {
+	GroupID   uint
+	GroupName string
+	URL       string
+}
+
+// isHTTPSUpstream reports whether an upstream URL uses the https scheme.
+func isHTTPSUpstream(rawURL string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(rawURL)), "https://")
+}
+
+// FindInsecureUpstreams lists every configured upstream that does not use HTTPS.
+func (gm *GroupManager) FindInsecureUpstreams() ([]InsecureUpstream, error) {
+	var groups []models.Group
+	if err := gm.db.Select("id", "name", "upstreams").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to load groups for upstream scan: %w", err)
+	}
+
+	var insecure []InsecureUpstream
+	for _, group := range groups {
+		if len(group.Upstreams) == 0 {
+			continue
+		}
+		var defs []struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(group.Upstreams, &defs); err != nil {
+			logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to parse upstreams during HTTPS scan")
+			continue
+		}
+		for _, def := range defs {
+			if !isHTTPSUpstream(def.URL) {
+				insecure = append(insecure, InsecureUpstream{GroupID: group.ID, GroupName: group.Name, URL: def.URL})
+			}
+		}
+	}
+
+	return insecure, nil
+}
+
+// WarnInsecureUpstreams logs existing non-HTTPS upstreams at startup. Existing groups keep working;
+// when HTTPS is required they are flagged as violations and must be fixed before their next update.
+func (gm *GroupManager) WarnInsecureUpstreams() {
+	insecure, err := gm.FindInsecureUpstreams()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to scan for non-HTTPS upstreams")
+		return
+	}
+	if len(insecure) == 0 {
+		return
+	}
+
+	required := gm.settingsManager.GetSettings().RequireHTTPSUpstreams
+	for _, upstream := range insecure {
+		entry := logrus.WithFields(logrus.Fields{
+			"group_id":   upstream.GroupID,
+			"group_name": upstream.GroupName,
+			"upstream":   upstream.URL,
+		})
+		if required {
+			entry.Error("Upstream violates require_https_upstreams")
+		} else {
+			entry.Warn("Upstream is not using HTTPS")
+		}
 	}
 }
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"aimanager/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// requestRateWindowSeconds is the window of the average request rate.
+	requestRateWindowSeconds = 60
+	// requestRateSlots is the size of the ring of per-second buckets. It holds the averaging window,
+	// the current second and the next second, which is cleared ahead of use.
+	requestRateSlots = requestRateWindowSeconds + 2
+)
+
+// GroupRequestRate is the recent request rate of a group.
+type GroupRequestRate struct {
+	CurrentRPS int64   `json:"current_rps"`
+	AvgRPS1m   float64 `json:"avg_rps_1m"`
+}
+
+// RequestRateCounter counts proxied requests per group in a ring of per-second buckets in the store,
+// so every node contributes to and reads the same rates. Each bucket is a hash keyed by slot, with one
+// "groupID|second" field per group; the bucket of the next second is deleted once per second, by
+// whichever node claims that second first.
+type RequestRateCounter struct {
+	store store.Store
+	// clearedSecond is the last second this node has claimed or seen claimed, to claim at most once a second
+	clearedSecond atomic.Int64
+}
+
+// NewRequestRateCounter creates a new RequestRateCounter.
+func NewRequestRateCounter(store store.Store) *RequestRateCounter {
+	return &RequestRateCounter{store: store}
+}
+
+// Record counts one request of the group in the current second.
+func (r *RequestRateCounter) Record(groupID uint) {
+	second := time.Now().Unix()
+	r.clearNextSlot(second)
+
+	field := requestRateField(groupID, second)
+	if _, err := r.store.HIncrBy(requestRateKey(second), field, 1); err != nil {
+		logrus.WithError(err).WithField("group_id", groupID).Debug("Failed to record request rate")
+	}
+}
+
+// clearNextSlot deletes the bucket the next second will use, which still holds counts from a full ring
+// ago. Only the first node to claim the second deletes it.
+func (r *RequestRateCounter) clearNextSlot(second int64) {
+	last := r.clearedSecond.Load()
+	if last >= second || !r.clearedSecond.CompareAndSwap(last, second) {
+		return
+	}
+
+	claimKey := fmt.Sprintf("request_rate_clear:%d", second)
+	claimed, err := r.store.SetNX(claimKey, []byte("1"), requestRateSlots*time.Second)
+	if err != nil || !claimed {
+		return
+	}
+	if err := r.store.Delete(requestRateKey(second + 1)); err != nil {
+		logrus.WithError(err).Debug("Failed to clear request rate bucket")
+	}
+}
+
+// Rates returns the request rate of every group with requests in the window. CurrentRPS is the count
+// of the last complete second and AvgRPS1m the average over the last 60 complete seconds.
+func (r *RequestRateCounter) Rates() (map[uint]GroupRequestRate, error) {
+	now := time.Now().Unix()
+	rates := make(map[uint]GroupRequestRate)
+	for second := now - requestRateWindowSeconds; second < now; second++ {
+		fields, err := r.store.HGetAll(requestRateKey(second))
+		if err != nil {
+			return nil, err
+		}
+		for field, value := range fields {
+			groupID, fieldSecond, ok := parseRequestRateField(field)
+			if !ok || fieldSecond != second {
+				continue
+			}
+			count, _ := strconv.ParseInt(value, 10, 64)
+			rate := rates[groupID]
+			rate.AvgRPS1m += float64(count)
+			if second == now-1 {
+				rate.CurrentRPS = count
+			}
+			rates[groupID] = rate
+		}
+	}
+
+	for groupID, rate := range rates {
+		rate.AvgRPS1m = math.Round(rate.AvgRPS1m/requestRateWindowSeconds*100) / 100
+		rates[groupID] = rate
+	}
+	return rates, nil
+}
+
+// SumRequestRates adds up the rates of the given groups, e.g. the sub-groups of an aggregate group.
+func SumRequestRates(rates map[uint]GroupRequestRate, groupIDs []uint) GroupRequestRate {
+	var sum GroupRequestRate
+	for _, groupID := range groupIDs {
+		sum.CurrentRPS += rates[groupID].CurrentRPS
+		sum.AvgRPS1m += rates[groupID].AvgRPS1m
+	}
+	return sum
+}
+
+func requestRateKey(second int64) string {
+	return fmt.Sprintf("request_rate:%d", second%requestRateSlots)
+}
+
+func requestRateField(groupID uint, second int64) string {
+	return fmt.Sprintf("%d|%d", groupID, second)
+}
+
+func parseRequestRateField(field string) (uint, int64, bool) {
+	groupIDStr, secondStr, found := strings.Cut(field, "|")
+	if !found {
+		return 0, 0, false
+	}
+	groupID, err := strconv.ParseUint(groupIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	second, err := strconv.ParseInt(secondStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint(groupID), second, true
+}
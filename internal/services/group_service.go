@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -58,7 +60,24 @@ type GroupService struct {
 	keyImportSvc          *KeyImportService
 	encryptionSvc         encryption.Service
 	aggregateGroupService *AggregateGroupService
+	upstreamHealth        *UpstreamHealth
+	rateLimitBucket       *RateLimitBucket
 	channelRegistry       []string
+	rateLimitCache        sync.Map // "groupID:proxyKey" -> rateLimitCacheEntry
+}
+
+// rateLimitCacheTTL bounds how stale a cached rate limit decision may be during sub-group selection.
+const rateLimitCacheTTL = 5 * time.Second
+
+// maxModelCanaries caps the model_canaries rules of a group.
+const maxModelCanaries = 32
+
+// maxNonceSkewSeconds caps nonce_max_skew_seconds. Nonces are kept in the store for twice the skew.
+const maxNonceSkewSeconds = 3600
+
+type rateLimitCacheEntry struct {
+	err       *app_errors.RateLimitError
+	expiresAt time.Time
 }
 
 // NewGroupService constructs a GroupService.
@@ -70,6 +89,8 @@ func NewGroupService(
 	keyImportSvc *KeyImportService,
 	encryptionSvc encryption.Service,
 	aggregateGroupService *AggregateGroupService,
+	upstreamHealth *UpstreamHealth,
+	rateLimitBucket *RateLimitBucket,
 ) *GroupService {
 	return &GroupService{
 		db:                    db,
@@ -79,6 +100,8 @@ func NewGroupService(
 		keyImportSvc:          keyImportSvc,
 		encryptionSvc:         encryptionSvc,
 		aggregateGroupService: aggregateGroupService,
+		upstreamHealth:        upstreamHealth,
+		rateLimitBucket:       rateLimitBucket,
 		channelRegistry:       channel.GetChannels(),
 	}
 }
@@ -127,9 +150,10 @@ type GroupUpdateParams struct {
 
 // KeyStats captures aggregated API key statistics for a group.
 type KeyStats struct {
-	TotalKeys   int64 `json:"total_keys"`
-	ActiveKeys  int64 `json:"active_keys"`
-	InvalidKeys int64 `json:"invalid_keys"`
+	TotalKeys    int64 `json:"total_keys"`
+	ActiveKeys   int64 `json:"active_keys"`
+	InvalidKeys  int64 `json:"invalid_keys"`
+	DisabledKeys int64 `json:"disabled_keys"`
 }
 
 // RequestStats captures request success and failure ratios over a time window.
@@ -137,6 +161,8 @@ type RequestStats struct {
 	TotalRequests  int64   `json:"total_requests"`
 	FailedRequests int64   `json:"failed_requests"`
 	FailureRate    float64 `json:"failure_rate"`
+	// MidStreamFailures are the failed requests that broke off after a stream had started.
+	MidStreamFailures int64 `json:"mid_stream_failures"`
 }
 
 // GroupStats aggregates all per-group metrics for dashboard usage.
@@ -164,6 +190,53 @@ type ConfigOption struct {
 
 // CreateGroup validates and persists a new group.
 func (s *GroupService) CreateGroup(ctx context.Context, params GroupCreateParams) (*models.Group, error) {
+	group, err := s.buildGroup(s.db.WithContext(ctx), params)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return nil, app_errors.ErrDatabase
+	}
+
+	if err := tx.Create(group).Error; err != nil {
+		tx.Rollback()
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	if err := s.groupManager.Invalidate(); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+	}
+
+	return group, nil
+}
+
+// ValidateGroup runs the validation of CreateGroup without persisting anything and returns the group
+// as it would be stored. A name that is already taken is reported as the duplicate CreateGroup would hit.
+func (s *GroupService) ValidateGroup(ctx context.Context, params GroupCreateParams) (*models.Group, error) {
+	group, err := s.buildGroup(s.db.WithContext(ctx), params)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Unscoped().Model(&models.Group{}).Where("name = ?", group.Name).Count(&count).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	if count > 0 {
+		return nil, app_errors.ErrDuplicateResource
+	}
+
+	return group, nil
+}
+
+// buildGroup validates the creation parameters against db and returns the cleaned group, not yet persisted.
+func (s *GroupService) buildGroup(db *gorm.DB, params GroupCreateParams) (*models.Group, error) {
 	name := strings.TrimSpace(params.Name)
 	if !isValidGroupName(name) {
 		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_group_name", nil)
@@ -209,7 +282,7 @@ func (s *GroupService) CreateGroup(ctx context.Context, params GroupCreateParams
 		}
 	}
 
-	cleanedConfig, err := s.validateAndCleanConfig(params.Config)
+	cleanedConfig, err := s.validateAndCleanConfig(params.Config, groupType)
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +305,15 @@ func (s *GroupService) CreateGroup(ctx context.Context, params GroupCreateParams
 		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_model_redirect", map[string]any{"error": err.Error()})
 	}
 
-	group := models.Group{
+	if err := validateParamOverrides(channelType, params.ParamOverrides, cleanedConfig); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkProxyKeysUnique(db, 0, params.ProxyKeys); err != nil {
+		return nil, err
+	}
+
+	group := &models.Group{
 		Name:                name,
 		DisplayName:         strings.TrimSpace(params.DisplayName),
 		Description:         strings.TrimSpace(params.Description),
@@ -250,25 +331,11 @@ func (s *GroupService) CreateGroup(ctx context.Context, params GroupCreateParams
 		ProxyKeys:           strings.TrimSpace(params.ProxyKeys),
 	}
 
-	tx := s.db.WithContext(ctx).Begin()
-	if err := tx.Error; err != nil {
-		return nil, app_errors.ErrDatabase
-	}
-
-	if err := tx.Create(&group).Error; err != nil {
-		tx.Rollback()
-		return nil, app_errors.ParseDBError(err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return nil, app_errors.ParseDBError(err)
-	}
-
-	if err := s.groupManager.Invalidate(); err != nil {
-		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+	if err := s.validateModelRouting(db, group); err != nil {
+		return nil, err
 	}
 
-	return &group, nil
+	return group, nil
 }
 
 // ListGroups returns all groups without sub-group relations.
@@ -310,11 +377,13 @@ func (s *GroupService) UpdateGroup(ctx context.Context, id uint, params GroupUpd
 		group.Description = strings.TrimSpace(*params.Description)
 	}
 
+	upstreamsChanged := false
 	if params.HasUpstreams {
 		cleanedUpstreams, err := s.validateAndCleanUpstreams(params.Upstreams)
 		if err != nil {
 			return nil, err
 		}
+		upstreamsChanged = !bytes.Equal(group.Upstreams, cleanedUpstreams)
 		group.Upstreams = cleanedUpstreams
 	}
 
@@ -397,14 +466,21 @@ func (s *GroupService) UpdateGroup(ctx context.Context, id uint, params GroupUpd
 	}
 
 	if params.Config != nil {
-		cleanedConfig, err := s.validateAndCleanConfig(params.Config)
+		cleanedConfig, err := s.validateAndCleanConfig(params.Config, group.GroupType)
 		if err != nil {
 			return nil, err
 		}
 		group.Config = cleanedConfig
+
+		if err := s.validateModelRouting(s.db.WithContext(ctx), &group); err != nil {
+			return nil, err
+		}
 	}
 
 	if params.ProxyKeys != nil {
+		if err := s.checkProxyKeysUnique(s.db.WithContext(ctx), group.ID, *params.ProxyKeys); err != nil {
+			return nil, err
+		}
 		group.ProxyKeys = strings.TrimSpace(*params.ProxyKeys)
 	}
 
@@ -419,6 +495,13 @@ func (s *GroupService) UpdateGroup(ctx context.Context, id uint, params GroupUpd
 		group.HeaderRules = headerRulesJSON
 	}
 
+	// Overrides are checked whenever anything they are checked against changes
+	if params.ParamOverrides != nil || params.ChannelType != nil || params.Config != nil {
+		if err := validateParamOverrides(group.ChannelType, group.ParamOverrides, group.Config); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Save(&group).Error; err != nil {
 		return nil, app_errors.ParseDBError(err)
 	}
@@ -427,6 +510,10 @@ func (s *GroupService) UpdateGroup(ctx context.Context, id uint, params GroupUpd
 		return nil, app_errors.ErrDatabase
 	}
 
+	if upstreamsChanged {
+		s.upstreamHealth.Reset(group.ID)
+	}
+
 	if err := s.groupManager.Invalidate(); err != nil {
 		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
 	}
@@ -434,8 +521,123 @@ func (s *GroupService) UpdateGroup(ctx context.Context, id uint, params GroupUpd
 	return &group, nil
 }
 
-// DeleteGroup removes a group and associated resources.
+// DeleteGroup soft-deletes a group. Its keys stay in the database but leave the store, so the group
+// serves no traffic until RestoreGroup brings it back. Its memberships in aggregate groups are dropped,
+// so no aggregate routes to it; an aggregate's own sub-group list is kept for its restore.
 func (s *GroupService) DeleteGroup(ctx context.Context, id uint) error {
+	var keyIDs []uint
+	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).Where("group_id = ?", id).Pluck("id", &keyIDs).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return app_errors.ErrDatabase
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var group models.Group
+	if err := tx.First(&group, id).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	if err := tx.Where("sub_group_id = ?", id).Delete(&models.GroupSubGroup{}).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	if err := tx.Delete(&group).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	if len(keyIDs) > 0 {
+		if err := s.keyService.KeyProvider.RemoveKeysFromStore(id, keyIDs); err != nil {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"groupID":  id,
+				"keyCount": len(keyIDs),
+			}).WithError(err).Error("failed to remove keys from memory store, rolling back transaction")
+			return NewI18nError(app_errors.ErrDatabase, "error.delete_group_cache", nil)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return app_errors.ErrDatabase
+	}
+	tx = nil
+
+	s.upstreamHealth.Reset(id)
+
+	if err := s.groupManager.Invalidate(); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+	}
+
+	return nil
+}
+
+// ListDeletedGroups returns the soft-deleted groups, most recently deleted first.
+func (s *GroupService) ListDeletedGroups(ctx context.Context) ([]models.Group, error) {
+	var groups []models.Group
+	if err := s.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&groups).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	return groups, nil
+}
+
+// RestoreGroup brings back a soft-deleted group and reloads its keys into the store. Memberships in
+// aggregate groups that were dropped on deletion are not restored.
+func (s *GroupService) RestoreGroup(ctx context.Context, id uint) (*models.Group, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return nil, app_errors.ErrDatabase
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var group models.Group
+	if err := tx.Unscoped().First(&group, id).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	if !group.DeletedAt.Valid {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.group_not_deleted", nil)
+	}
+
+	// Another group may have taken the proxy keys while this one was deleted
+	if err := s.checkProxyKeysUnique(tx, group.ID, group.ProxyKeys); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Unscoped().Model(&group).Update("deleted_at", nil).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	group.DeletedAt = gorm.DeletedAt{}
+
+	if err := s.keyService.KeyProvider.LoadGroupKeysToStore(tx, id); err != nil {
+		logrus.WithContext(ctx).WithField("groupID", id).WithError(err).Error("failed to load keys into memory store, rolling back transaction")
+		return nil, NewI18nError(app_errors.ErrDatabase, "error.restore_group_cache", nil)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, app_errors.ErrDatabase
+	}
+	tx = nil
+
+	if err := s.groupManager.Invalidate(); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+	}
+
+	return &group, nil
+}
+
+// PurgeGroup permanently removes a group, deleted or not, together with its keys, sub-group relations
+// and key count snapshots.
+func (s *GroupService) PurgeGroup(ctx context.Context, id uint) error {
 	var apiKeys []models.APIKey
 	if err := s.db.WithContext(ctx).Where("group_id = ?", id).Find(&apiKeys).Error; err != nil {
 		return app_errors.ParseDBError(err)
@@ -457,7 +659,7 @@ func (s *GroupService) DeleteGroup(ctx context.Context, id uint) error {
 	}()
 
 	var group models.Group
-	if err := tx.First(&group, id).Error; err != nil {
+	if err := tx.Unscoped().First(&group, id).Error; err != nil {
 		return app_errors.ParseDBError(err)
 	}
 
@@ -469,7 +671,11 @@ func (s *GroupService) DeleteGroup(ctx context.Context, id uint) error {
 		return app_errors.ErrDatabase
 	}
 
-	if err := tx.Delete(&models.Group{}, id).Error; err != nil {
+	if err := tx.Where("group_id = ?", id).Delete(&models.GroupKeyCountStat{}).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	if err := tx.Unscoped().Delete(&models.Group{}, id).Error; err != nil {
 		return app_errors.ParseDBError(err)
 	}
 
@@ -488,6 +694,8 @@ func (s *GroupService) DeleteGroup(ctx context.Context, id uint) error {
 	}
 	tx = nil
 
+	s.upstreamHealth.Reset(id)
+
 	if err := s.groupManager.Invalidate(); err != nil {
 		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
 	}
@@ -495,8 +703,39 @@ func (s *GroupService) DeleteGroup(ctx context.Context, id uint) error {
 	return nil
 }
 
+// Group name conflict strategies used when a copied group's name is already taken.
+const (
+	GroupNameConflictFail      = "fail"
+	GroupNameConflictRename    = "rename"
+	GroupNameConflictOverwrite = "overwrite"
+)
+
+// normalizeGroupNameConflictStrategy validates the strategy and applies the default (rename).
+func normalizeGroupNameConflictStrategy(strategy string) (string, error) {
+	strategy = strings.TrimSpace(strategy)
+	switch strategy {
+	case "":
+		return GroupNameConflictRename, nil
+	case GroupNameConflictFail, GroupNameConflictRename, GroupNameConflictOverwrite:
+		return strategy, nil
+	default:
+		return "", NewI18nError(app_errors.ErrValidation, "validation.invalid_name_conflict_strategy", nil)
+	}
+}
+
+// GroupCopyOverrides replaces fields of the source group in its copy. Empty fields keep the source's values.
+// A different channel type also resets the test model, validation endpoint and model redirects, which name
+// models and paths of the source's channel, to the presets of the new one.
+type GroupCopyOverrides struct {
+	ChannelType string
+	Upstreams   json.RawMessage
+}
+
 // CopyGroup duplicates a group and optionally copies active keys.
-func (s *GroupService) CopyGroup(ctx context.Context, sourceGroupID uint, copyKeysOption string) (*models.Group, error) {
+// conflictStrategy decides what happens when the copy's name already exists:
+// "rename" (default) picks a free suffix, "fail" aborts, "overwrite" replaces the existing group's config.
+// The overrides are validated like in CreateGroup before anything is written.
+func (s *GroupService) CopyGroup(ctx context.Context, sourceGroupID uint, copyKeysOption string, conflictStrategy string, overrides GroupCopyOverrides) (*models.Group, error) {
 	option := strings.TrimSpace(copyKeysOption)
 	if option == "" {
 		option = "all"
@@ -505,11 +744,44 @@ func (s *GroupService) CopyGroup(ctx context.Context, sourceGroupID uint, copyKe
 		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_copy_keys_value", nil)
 	}
 
+	strategy, err := normalizeGroupNameConflictStrategy(conflictStrategy)
+	if err != nil {
+		return nil, err
+	}
+
 	var sourceGroup models.Group
 	if err := s.db.WithContext(ctx).First(&sourceGroup, sourceGroupID).Error; err != nil {
 		return nil, app_errors.ParseDBError(err)
 	}
 
+	channelType := sourceGroup.ChannelType
+	if overrides.ChannelType != "" {
+		// An aggregate's channel type must match its sub-groups, which the copy does not change
+		if sourceGroup.GroupType == "aggregate" && strings.TrimSpace(overrides.ChannelType) != sourceGroup.ChannelType {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.aggregate_channel_type", nil)
+		}
+		channelType = strings.TrimSpace(overrides.ChannelType)
+		if !s.isValidChannelType(channelType) {
+			supported := strings.Join(s.channelRegistry, ", ")
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_channel_type", map[string]any{"types": supported})
+		}
+		if err := validateParamOverrides(channelType, sourceGroup.ParamOverrides, sourceGroup.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	upstreams := sourceGroup.Upstreams
+	if len(overrides.Upstreams) > 0 {
+		if sourceGroup.GroupType == "aggregate" {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.aggregate_no_upstreams", nil)
+		}
+		cleaned, err := s.validateAndCleanUpstreams(overrides.Upstreams)
+		if err != nil {
+			return nil, err
+		}
+		upstreams = cleaned
+	}
+
 	tx := s.db.WithContext(ctx).Begin()
 	if err := tx.Error; err != nil {
 		return nil, app_errors.ErrDatabase
@@ -520,18 +792,86 @@ func (s *GroupService) CopyGroup(ctx context.Context, sourceGroupID uint, copyKe
 		}
 	}()
 
+	targetName := sourceGroup.Name + "_copy"
+	var existing models.Group
+	existingFound := true
+	if err := tx.Unscoped().Where("name = ?", targetName).First(&existing).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, app_errors.ParseDBError(err)
+		}
+		existingFound = false
+	}
+
+	// A soft-deleted group keeps its name until purged and is never overwritten
+	if existingFound && (strategy == GroupNameConflictFail || (strategy == GroupNameConflictOverwrite && existing.DeletedAt.Valid)) {
+		return nil, NewI18nError(app_errors.ErrDuplicateResource, "group.name_exists", nil)
+	}
+
 	newGroup := sourceGroup
-	newGroup.ID = 0
-	newGroup.Name = s.generateUniqueGroupName(ctx, sourceGroup.Name)
+	newGroup.ChannelType = channelType
+	newGroup.Upstreams = upstreams
+	if channelType != sourceGroup.ChannelType {
+		if testModel := utils.DefaultTestModel(channelType); testModel != "" {
+			newGroup.TestModel = testModel
+		}
+		newGroup.ValidationEndpoint = ""
+		newGroup.ModelRedirectRules = datatypes.JSONMap{}
+		newGroup.ModelRedirectStrict = false
+	}
 	if sourceGroup.DisplayName != "" {
 		newGroup.DisplayName = sourceGroup.DisplayName + " Copy"
 	}
-	newGroup.CreatedAt = time.Time{}
-	newGroup.UpdatedAt = time.Time{}
 	newGroup.LastValidatedAt = nil
+	newGroup.AutoPausedAt = nil
+	newGroup.AutoPauseResumedAt = nil
+	// A copy cannot share the source's proxy keys while global uniqueness is enforced
+	if s.settingsManager.GetSettings().EnforceUniqueProxyKeys {
+		newGroup.ProxyKeys = ""
+	}
 
-	if err := tx.Create(&newGroup).Error; err != nil {
-		return nil, app_errors.ParseDBError(err)
+	if existingFound && strategy == GroupNameConflictOverwrite {
+		if existing.GroupType != sourceGroup.GroupType {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.overwrite_group_type_mismatch", nil)
+		}
+		// The overwritten aggregate keeps its sub-groups, which must match the copied channel type
+		if existing.GroupType == "aggregate" && existing.ChannelType != newGroup.ChannelType {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_group_channel_mismatch", nil)
+		}
+		if existing.GroupType != "aggregate" && existing.ChannelType != newGroup.ChannelType {
+			count, err := s.aggregateGroupService.CountAggregateGroupsUsingSubGroup(ctx, existing.ID)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, NewI18nError(app_errors.ErrValidation, "validation.sub_group_referenced_cannot_modify", map[string]any{"count": count})
+			}
+		}
+		newGroup.ID = existing.ID
+		newGroup.Name = existing.Name
+		newGroup.CreatedAt = existing.CreatedAt
+		newGroup.LastValidatedAt = existing.LastValidatedAt
+		newGroup.AutoPausedAt = existing.AutoPausedAt
+		newGroup.AutoPauseResumedAt = existing.AutoPauseResumedAt
+		if err := tx.Omit("APIKeys").Save(&newGroup).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"sourceGroupId": sourceGroup.ID,
+			"targetGroupId": existing.ID,
+			"targetName":    existing.Name,
+		}).Info("overwriting existing group config during group copy")
+	} else {
+		newGroup.ID = 0
+		newGroup.Name = targetName
+		if existingFound {
+			newGroup.Name = s.generateUniqueGroupName(tx, sourceGroup.Name)
+		}
+		newGroup.CreatedAt = time.Time{}
+		newGroup.UpdatedAt = time.Time{}
+
+		if err := tx.Create(&newGroup).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
 	}
 
 	var sourceKeyValues []string
@@ -566,7 +906,7 @@ func (s *GroupService) CopyGroup(ctx context.Context, sourceGroupID uint, copyKe
 
 	if len(sourceKeyValues) > 0 {
 		keysText := strings.Join(sourceKeyValues, "\n")
-		if _, err := s.keyImportSvc.StartImportTask(&newGroup, keysText); err != nil {
+		if _, err := s.keyImportSvc.StartImportTask(&newGroup, keysText, 0, false); err != nil {
 			logrus.WithContext(ctx).WithFields(logrus.Fields{
 				"groupId":  newGroup.ID,
 				"keyCount": len(sourceKeyValues),
@@ -582,8 +922,78 @@ func (s *GroupService) CopyGroup(ctx context.Context, sourceGroupID uint, copyKe
 	return &newGroup, nil
 }
 
+// SwapGroupNames exchanges the names of two groups in a single transaction so that
+// their proxy endpoints flip atomically. A temporary name is used to satisfy the
+// unique constraint on groups.name during the swap.
+func (s *GroupService) SwapGroupNames(ctx context.Context, idA, idB uint) (*models.Group, *models.Group, error) {
+	if idA == idB {
+		return nil, nil, NewI18nError(app_errors.ErrValidation, "validation.swap_same_group", nil)
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return nil, nil, app_errors.ErrDatabase
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var groupA, groupB models.Group
+	if err := tx.First(&groupA, idA).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+	if err := tx.First(&groupB, idB).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+
+	nameA, nameB := groupA.Name, groupB.Name
+	tempName := fmt.Sprintf("__swap_%d_%d", groupA.ID, time.Now().UnixNano())
+
+	renames := []struct {
+		id   uint
+		name string
+	}{
+		{groupA.ID, tempName},
+		{groupB.ID, nameA},
+		{groupA.ID, nameB},
+	}
+	for _, r := range renames {
+		if err := tx.Model(&models.Group{}).Where("id = ?", r.id).Update("name", r.name).Error; err != nil {
+			return nil, nil, app_errors.ParseDBError(err)
+		}
+	}
+
+	if err := tx.First(&groupA, idA).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+	if err := tx.First(&groupB, idB).Error; err != nil {
+		return nil, nil, app_errors.ParseDBError(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, app_errors.ErrDatabase
+	}
+	tx = nil
+
+	if err := s.groupManager.Invalidate(); err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"group_a_id": groupA.ID,
+		"group_b_id": groupB.ID,
+		"group_a":    groupA.Name,
+		"group_b":    groupB.Name,
+	}).Info("swapped group names")
+
+	return &groupA, &groupB, nil
+}
+
 // GetGroupStats returns aggregated usage statistics for a group.
-func (s *GroupService) GetGroupStats(ctx context.Context, groupID uint) (*GroupStats, error) {
+// excludeCurrentHour drops the in-progress hour from the windows for smoother rolling numbers.
+func (s *GroupService) GetGroupStats(ctx context.Context, groupID uint, excludeCurrentHour bool) (*GroupStats, error) {
 	var group models.Group
 	if err := s.db.WithContext(ctx).First(&group, groupID).Error; err != nil {
 		return nil, app_errors.ParseDBError(err)
@@ -591,14 +1001,14 @@ func (s *GroupService) GetGroupStats(ctx context.Context, groupID uint) (*GroupS
 
 	// 根据分组类型选择不同的统计逻辑
 	if group.GroupType == "aggregate" {
-		return s.getAggregateGroupStats(ctx, groupID)
+		return s.getAggregateGroupStats(ctx, groupID, excludeCurrentHour)
 	}
 
-	return s.getStandardGroupStats(ctx, groupID)
+	return s.getStandardGroupStats(ctx, groupID, excludeCurrentHour)
 }
 
 // GetGroupListStats returns simplified statistics for group list display (24h and 7d only).
-func (s *GroupService) GetGroupListStats(ctx context.Context, groupID uint) (*GroupListStats, error) {
+func (s *GroupService) GetGroupListStats(ctx context.Context, groupID uint, excludeCurrentHour bool) (*GroupListStats, error) {
 	var group models.Group
 	if err := s.db.WithContext(ctx).First(&group, groupID).Error; err != nil {
 		return nil, app_errors.ParseDBError(err)
@@ -608,11 +1018,11 @@ func (s *GroupService) GetGroupListStats(ctx context.Context, groupID uint) (*Gr
 
 	// 根据分组类型选择不同的统计逻辑
 	if group.GroupType == "aggregate" {
-		return s.getAggregateGroupListStats(ctx, groupID)
+		return s.getAggregateGroupListStats(ctx, groupID, excludeCurrentHour)
 	}
 
 	// 获取24小时统计
-	stats24h, err := s.queryGroupHourlyStats(ctx, groupID, 24)
+	stats24h, err := s.queryGroupHourlyStats(ctx, groupID, 24, excludeCurrentHour)
 	if err != nil {
 		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to fetch 24-hour stats for group list")
 		stats.Stats24Hour = RequestStats{}
@@ -621,7 +1031,7 @@ func (s *GroupService) GetGroupListStats(ctx context.Context, groupID uint) (*Gr
 	}
 
 	// 获取7天统计
-	stats7d, err := s.queryGroupHourlyStats(ctx, groupID, 7*24)
+	stats7d, err := s.queryGroupHourlyStats(ctx, groupID, 7*24, excludeCurrentHour)
 	if err != nil {
 		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to fetch 7-day stats for group list")
 		stats.Stats7Day = RequestStats{}
@@ -630,7 +1040,7 @@ func (s *GroupService) GetGroupListStats(ctx context.Context, groupID uint) (*Gr
 	}
 
 	// 获取30天统计
-	stats30d, err := s.queryGroupHourlyStats(ctx, groupID, 30*24)
+	stats30d, err := s.queryGroupHourlyStats(ctx, groupID, 30*24, excludeCurrentHour)
 	if err != nil {
 		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to fetch 30-day stats for group list")
 		stats.Stats30Day = RequestStats{}
@@ -641,75 +1051,104 @@ func (s *GroupService) GetGroupListStats(ctx context.Context, groupID uint) (*Gr
 	return stats, nil
 }
 
+// hourlyStatsWindow returns the [start, end) range covering the last hours hourly buckets.
+// The in-progress hour is the last bucket unless excludeCurrentHour is set, in which case the
+// window ends at the start of the current hour.
+func hourlyStatsWindow(hours int, excludeCurrentHour bool) (time.Time, time.Time) {
+	endTime := time.Now().Truncate(time.Hour)
+	if !excludeCurrentHour {
+		endTime = endTime.Add(time.Hour) // Include current hour
+	}
+	return endTime.Add(-time.Duration(hours) * time.Hour), endTime
+}
+
 // queryGroupHourlyStats queries aggregated hourly statistics from group_hourly_stats table
-func (s *GroupService) queryGroupHourlyStats(ctx context.Context, groupID uint, hours int) (RequestStats, error) {
+func (s *GroupService) queryGroupHourlyStats(ctx context.Context, groupID uint, hours int, excludeCurrentHour bool) (RequestStats, error) {
 	var result struct {
-		SuccessCount int64
-		FailureCount int64
+		SuccessCount      int64
+		FailureCount      int64
+		MidStreamFailures int64
 	}
 
-	now := time.Now()
-	currentHour := now.Truncate(time.Hour)
-	endTime := currentHour.Add(time.Hour) // Include current hour
-	startTime := endTime.Add(-time.Duration(hours) * time.Hour)
+	startTime, endTime := hourlyStatsWindow(hours, excludeCurrentHour)
 
 	if err := s.db.WithContext(ctx).Model(&models.GroupHourlyStat{}).
-		Select("SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+		Select("SUM(success_count) as success_count, SUM(failure_count) as failure_count, SUM(mid_stream_failures) as mid_stream_failures").
 		Where("group_id = ? AND time >= ? AND time < ?", groupID, startTime, endTime).
 		Scan(&result).Error; err != nil {
 		return RequestStats{}, err
 	}
 
-	return calculateRequestStats(result.SuccessCount+result.FailureCount, result.FailureCount), nil
+	stats := calculateRequestStats(result.SuccessCount+result.FailureCount, result.FailureCount)
+	stats.MidStreamFailures = result.MidStreamFailures
+	return stats, nil
 }
 
 // queryAggregateGroupHourlyStats queries aggregated hourly statistics for multiple sub-groups
-func (s *GroupService) queryAggregateGroupHourlyStats(ctx context.Context, subGroupIDs []uint, hours int) (RequestStats, error) {
+func (s *GroupService) queryAggregateGroupHourlyStats(ctx context.Context, subGroupIDs []uint, hours int, excludeCurrentHour bool) (RequestStats, error) {
 	var result struct {
-		SuccessCount int64
-		FailureCount int64
+		SuccessCount      int64
+		FailureCount      int64
+		MidStreamFailures int64
 	}
 
-	now := time.Now()
-	currentHour := now.Truncate(time.Hour)
-	endTime := currentHour.Add(time.Hour) // Include current hour
-	startTime := endTime.Add(-time.Duration(hours) * time.Hour)
+	startTime, endTime := hourlyStatsWindow(hours, excludeCurrentHour)
 
 	if err := s.db.WithContext(ctx).Model(&models.GroupHourlyStat{}).
-		Select("SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+		Select("SUM(success_count) as success_count, SUM(failure_count) as failure_count, SUM(mid_stream_failures) as mid_stream_failures").
 		Where("group_id IN ? AND time >= ? AND time < ?", subGroupIDs, startTime, endTime).
 		Scan(&result).Error; err != nil {
 		return RequestStats{}, err
 	}
 
-	return calculateRequestStats(result.SuccessCount+result.FailureCount, result.FailureCount), nil
+	stats := calculateRequestStats(result.SuccessCount+result.FailureCount, result.FailureCount)
+	stats.MidStreamFailures = result.MidStreamFailures
+	return stats, nil
 }
 
 // fetchKeyStats retrieves API key statistics for a group
 func (s *GroupService) fetchKeyStats(ctx context.Context, groupID uint) (KeyStats, error) {
-	var totalKeys, activeKeys int64
-
-	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
-		Where("group_id = ?", groupID).
-		Count(&totalKeys).Error; err != nil {
-		return KeyStats{}, fmt.Errorf("failed to get total keys: %w", err)
+	counts, err := countKeysByStatus(s.db.WithContext(ctx), groupID)
+	if err != nil {
+		return KeyStats{}, fmt.Errorf("failed to get key counts: %w", err)
 	}
 
-	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
-		Where("group_id = ? AND status = ?", groupID, models.KeyStatusActive).
-		Count(&activeKeys).Error; err != nil {
-		return KeyStats{}, fmt.Errorf("failed to get active keys: %w", err)
+	var totalKeys int64
+	for _, count := range counts {
+		totalKeys += count
 	}
 
 	return KeyStats{
-		TotalKeys:   totalKeys,
-		ActiveKeys:  activeKeys,
-		InvalidKeys: totalKeys - activeKeys,
+		TotalKeys:    totalKeys,
+		ActiveKeys:   counts[models.KeyStatusActive],
+		InvalidKeys:  counts[models.KeyStatusInvalid],
+		DisabledKeys: counts[models.KeyStatusDisabled],
 	}, nil
 }
 
+// countKeysByStatus returns the number of keys of the group per status.
+func countKeysByStatus(db *gorm.DB, groupID uint) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&models.APIKey{}).
+		Select("status, COUNT(*) as count").
+		Where("group_id = ?", groupID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
 // fetchRequestStats retrieves request statistics for multiple time periods
-func (s *GroupService) fetchRequestStats(ctx context.Context, groupID uint, stats *GroupStats) []error {
+func (s *GroupService) fetchRequestStats(ctx context.Context, groupID uint, stats *GroupStats, excludeCurrentHour bool) []error {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var errs []error
@@ -731,7 +1170,7 @@ func (s *GroupService) fetchRequestStats(ctx context.Context, groupID uint, stat
 		go func(hours int, name string, setter func(RequestStats)) {
 			defer wg.Done()
 
-			res, err := s.queryGroupHourlyStats(ctx, groupID, hours)
+			res, err := s.queryGroupHourlyStats(ctx, groupID, hours, excludeCurrentHour)
 			if err != nil {
 				mu.Lock()
 				errs = append(errs, fmt.Errorf("failed to get %s stats: %w", name, err))
@@ -749,7 +1188,7 @@ func (s *GroupService) fetchRequestStats(ctx context.Context, groupID uint, stat
 	return errs
 }
 
-func (s *GroupService) getStandardGroupStats(ctx context.Context, groupID uint) (*GroupStats, error) {
+func (s *GroupService) getStandardGroupStats(ctx context.Context, groupID uint, excludeCurrentHour bool) (*GroupStats, error) {
 	stats := &GroupStats{}
 	var allErrors []error
 
@@ -764,7 +1203,7 @@ func (s *GroupService) getStandardGroupStats(ctx context.Context, groupID uint)
 	}
 
 	// Fetch request statistics (common for all groups)
-	if errs := s.fetchRequestStats(ctx, groupID, stats); len(errs) > 0 {
+	if errs := s.fetchRequestStats(ctx, groupID, stats, excludeCurrentHour); len(errs) > 0 {
 		allErrors = append(allErrors, errs...)
 	}
 
@@ -782,7 +1221,7 @@ func (s *GroupService) getStandardGroupStats(ctx context.Context, groupID uint)
 }
 
 // getAggregateGroupListStats returns simplified statistics for aggregate group list display.
-func (s *GroupService) getAggregateGroupListStats(ctx context.Context, groupID uint) (*GroupListStats, error) {
+func (s *GroupService) getAggregateGroupListStats(ctx context.Context, groupID uint, excludeCurrentHour bool) (*GroupListStats, error) {
 	subGroupIDs, err := s.aggregateGroupService.GetSubGroupIDs(ctx, groupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sub-group IDs: %w", err)
@@ -795,7 +1234,7 @@ func (s *GroupService) getAggregateGroupListStats(ctx context.Context, groupID u
 	stats := &GroupListStats{}
 
 	// 聚合24小时统计
-	stats24h, err := s.queryAggregateGroupHourlyStats(ctx, subGroupIDs, 24)
+	stats24h, err := s.queryAggregateGroupHourlyStats(ctx, subGroupIDs, 24, excludeCurrentHour)
 	if err != nil {
 		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to fetch 24-hour stats for aggregate group list")
 		stats.Stats24Hour = RequestStats{}
@@ -804,7 +1243,7 @@ func (s *GroupService) getAggregateGroupListStats(ctx context.Context, groupID u
 	}
 
 	// 聚合7天统计
-	stats7d, err := s.queryAggregateGroupHourlyStats(ctx, subGroupIDs, 7*24)
+	stats7d, err := s.queryAggregateGroupHourlyStats(ctx, subGroupIDs, 7*24, excludeCurrentHour)
 	if err != nil {
 		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to fetch 7-day stats for aggregate group list")
 		stats.Stats7Day = RequestStats{}
@@ -815,11 +1254,11 @@ func (s *GroupService) getAggregateGroupListStats(ctx context.Context, groupID u
 	return stats, nil
 }
 
-func (s *GroupService) getAggregateGroupStats(ctx context.Context, groupID uint) (*GroupStats, error) {
+func (s *GroupService) getAggregateGroupStats(ctx context.Context, groupID uint, excludeCurrentHour bool) (*GroupStats, error) {
 	stats := &GroupStats{}
 
 	// Aggregate groups only need request statistics, not key statistics
-	if errs := s.fetchRequestStats(ctx, groupID, stats); len(errs) > 0 {
+	if errs := s.fetchRequestStats(ctx, groupID, stats, excludeCurrentHour); len(errs) > 0 {
 		logrus.WithContext(ctx).WithError(errs[0]).Error("errors occurred while fetching aggregate group stats")
 		// Return partial stats if we have some data
 		if stats.Stats24Hour.TotalRequests > 0 || stats.Stats7Day.TotalRequests > 0 || stats.Stats30Day.TotalRequests > 0 {
@@ -883,8 +1322,83 @@ func (s *GroupService) GetGroupConfigOptions() ([]ConfigOption, error) {
 	return options, nil
 }
 
-// validateAndCleanConfig verifies GroupConfig overrides.
-func (s *GroupService) validateAndCleanConfig(configMap map[string]any) (map[string]any, error) {
+// ConfigDiffEntry describes a single group setting that diverges from its default.
+type ConfigDiffEntry struct {
+	Key          string `json:"key"`
+	Value        any    `json:"value"`
+	DefaultValue any    `json:"default_value"`
+}
+
+// GroupConfigDiff lists only what a group overrides compared to system defaults and channel presets.
+type GroupConfigDiff struct {
+	GroupID            uint                `json:"group_id"`
+	ChannelType        string              `json:"channel_type"`
+	Config             []ConfigDiffEntry   `json:"config"`
+	Channel            []ConfigDiffEntry   `json:"channel"`
+	ParamOverrides     map[string]any      `json:"param_overrides,omitempty"`
+	HeaderRules        []models.HeaderRule `json:"header_rules,omitempty"`
+	ModelRedirectRules map[string]any      `json:"model_redirect_rules,omitempty"`
+}
+
+// GetGroupConfigDiff compares a group's overrides against DefaultSystemSettings and the
+// presets of its channel type, returning only the diverging fields.
+// Group-only options have no system default and are reported whenever they are set.
+func (s *GroupService) GetGroupConfigDiff(ctx context.Context, groupID uint) (*GroupConfigDiff, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	diff := &GroupConfigDiff{
+		GroupID:     group.ID,
+		ChannelType: group.ChannelType,
+		Config:      []ConfigDiffEntry{},
+		Channel:     []ConfigDiffEntry{},
+	}
+
+	settings := s.resolveGroupSettings(ctx, &group)
+	sort.Slice(settings, func(i, j int) bool { return settings[i].key < settings[j].key })
+	for _, setting := range settings {
+		if setting.diverges() {
+			diff.Config = append(diff.Config, setting.diffEntry())
+		}
+	}
+	for _, setting := range resolveChannelPresets(&group) {
+		if setting.diverges() {
+			diff.Channel = append(diff.Channel, setting.diffEntry())
+		}
+	}
+
+	if len(group.ParamOverrides) > 0 {
+		diff.ParamOverrides = group.ParamOverrides
+	}
+	if len(group.ModelRedirectRules) > 0 {
+		diff.ModelRedirectRules = group.ModelRedirectRules
+	}
+	if len(group.HeaderRules) > 0 {
+		var headerRules []models.HeaderRule
+		if err := json.Unmarshal(group.HeaderRules, &headerRules); err != nil {
+			logrus.WithContext(ctx).WithError(err).WithField("group_id", group.ID).Warn("Failed to parse header rules for config diff")
+		} else if len(headerRules) > 0 {
+			diff.HeaderRules = headerRules
+		}
+	}
+
+	return diff, nil
+}
+
+// jsonEqual compares two values by their JSON encoding, so that e.g. a stored float64 matches an int default.
+func jsonEqual(a, b any) bool {
+	aBytes, errA := json.Marshal(a)
+	bBytes, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
+
+// validateAndCleanConfig verifies GroupConfig overrides for a group of the given type.
+func (s *GroupService) validateAndCleanConfig(configMap map[string]any, groupType string) (map[string]any, error) {
 	if configMap == nil {
 		return nil, nil
 	}
@@ -907,22 +1421,77 @@ func (s *GroupService) validateAndCleanConfig(configMap map[string]any) (map[str
 		}
 	}
 
-	// 验证限流配置
-	if err := s.validateRateLimitConfig(configMap); err != nil {
-		return nil, NewI18nError(app_errors.ErrValidation, "error.invalid_config_format", map[string]any{"error": err.Error()})
+	// 会话亲和只在聚合分组选择子分组时生效
+	if groupType != "aggregate" {
+		for _, key := range []string{"session_affinity_header", "session_affinity_ttl_seconds"} {
+			if value, exists := configMap[key]; exists && value != nil && value != "" {
+				return nil, NewI18nError(app_errors.ErrValidation, "validation.session_affinity_aggregate_only", nil)
+			}
+		}
 	}
 
-	// 限流配置字段（不参与 settingsManager 的验证）
-	rateLimitFields := map[string]bool{
-		"expires_at":             true,
-		"max_requests_per_hour":  true,
-		"max_requests_per_month": true,
+	// 按功能验证分组专属配置
+	for _, validate := range groupConfigValidators {
+		if err := validate(configMap); err != nil {
+			return nil, NewI18nError(app_errors.ErrValidation, "error.invalid_config_format", map[string]any{"error": err.Error()})
+		}
 	}
 
-	// 过滤掉限流配置字段后再进行 settingsManager 验证
+	// 分组专属配置字段（不参与 settingsManager 的验证）
+	groupOnlyFields := map[string]bool{
+		"expires_at":                        true,
+		"max_requests_per_hour":             true,
+		"max_requests_per_month":            true,
+		"rate_limit_exempt_keys":            true,
+		"proxy_key_allowed_cidrs":           true,
+		"key_cooldown_seconds":              true,
+		"max_concurrent_requests":           true,
+		"max_request_body_bytes":            true,
+		"max_response_body_bytes":           true,
+		"request_timeout_seconds":           true,
+		"connect_timeout_seconds":           true,
+		"retry_on_status":                   true,
+		"retry_backoff_ms":                  true,
+		"notification_webhook":              true,
+		"notification_active_key_percent":   true,
+		"notification_failure_rate_percent": true,
+		"allow_upstream_override":           true,
+		"allow_seeded_selection":            true,
+		"session_affinity_header":           true,
+		"session_affinity_ttl_seconds":      true,
+		"model_routing":                     true,
+		"require_nonce":                     true,
+		"nonce_max_skew_seconds":            true,
+		"enable_diagnostic_headers":         true,
+		"default_params":                    true,
+		"fallback_test_models":              true,
+		"error_responses":                   true,
+		"param_override_mode":               true,
+		"strict_param_overrides":            true,
+		"field_renames":                     true,
+		"sse_rewrite":                       true,
+		"body_rules":                        true,
+		"model_canaries":                    true,
+		"allowed_models":                    true,
+		"denied_models":                     true,
+		"disable_request_logging":           true,
+		"authorization_forwarding":          true,
+		"authorization_forward_header":      true,
+		"auth_header_mode":                  true,
+		"auth_header_name":                  true,
+		"rate_limit_mode":                   true,
+		"rate_limit_refill_per_second":      true,
+		"rate_limit_burst":                  true,
+		"circuit_breaker_failure_rate":      true,
+		"circuit_breaker_window_seconds":    true,
+		"circuit_breaker_min_requests":      true,
+		"circuit_breaker_cooldown_seconds":  true,
+	}
+
+	// 过滤掉分组专属配置字段后再进行 settingsManager 验证
 	configForSettingsValidation := make(map[string]any)
 	for k, v := range configMap {
-		if !rateLimitFields[k] {
+		if !groupOnlyFields[k] {
 			configForSettingsValidation[k] = v
 		}
 	}
@@ -941,6 +1510,12 @@ func (s *GroupService) validateAndCleanConfig(configMap map[string]any) (map[str
 		return nil, NewI18nError(app_errors.ErrValidation, "error.invalid_config_format", map[string]any{"error": err.Error()})
 	}
 
+	// 禁用请求日志的分组强制关闭请求体记录，不继承系统设置
+	if validatedConfig.DisableRequestLogging != nil && *validatedConfig.DisableRequestLogging {
+		bodyLogging := false
+		validatedConfig.EnableRequestBodyLogging = &bodyLogging
+	}
+
 	validatedBytes, err := json.Marshal(validatedConfig)
 	if err != nil {
 		return nil, NewI18nError(app_errors.ErrValidation, "error.invalid_config_format", map[string]any{"error": err.Error()})
@@ -954,60 +1529,28 @@ func (s *GroupService) validateAndCleanConfig(configMap map[string]any) (map[str
 	return finalMap, nil
 }
 
-// validateRateLimitConfig 验证限流和有效期配置
-func (s *GroupService) validateRateLimitConfig(configMap map[string]any) error {
-	if configMap == nil {
+// validateModelRouting checks that model_routing is only set on aggregate groups and only routes to
+// their own sub-groups. A group that is being created has no sub-groups yet, so membership is only
+// checked once it exists; until then the proxy ignores routes to groups that are not its sub-groups.
+func (s *GroupService) validateModelRouting(db *gorm.DB, group *models.Group) error {
+	routingVal, _ := group.Config["model_routing"].(map[string]any)
+	if len(routingVal) == 0 {
 		return nil
 	}
-
-	// 验证 expires_at 字段
-	if expiresAtVal, exists := configMap["expires_at"]; exists && expiresAtVal != nil {
-		switch v := expiresAtVal.(type) {
-		case string:
-			// 前端发送的格式: 2006-01-02 15:04:05
-			parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local)
-			if err != nil {
-				return fmt.Errorf("invalid expires_at format '%s': %w", v, err)
-			}
-			_ = parsedTime // 验证通过
-		default:
-			return fmt.Errorf("expires_at must be a string in ISO8601 format")
-		}
+	if group.GroupType != "aggregate" {
+		return NewI18nError(app_errors.ErrValidation, "validation.model_routing_aggregate_only", nil)
 	}
-
-	// 验证 max_requests_per_hour 字段
-	if hourlyVal, exists := configMap["max_requests_per_hour"]; exists && hourlyVal != nil {
-		switch v := hourlyVal.(type) {
-		case float64:
-			if v < 0 {
-				return fmt.Errorf("max_requests_per_hour must be >= 0")
-			}
-		case int:
-			if v < 0 {
-				return fmt.Errorf("max_requests_per_hour must be >= 0")
-			}
-		default:
-			return fmt.Errorf("max_requests_per_hour must be a number")
-		}
+	if group.ID == 0 {
+		return nil
 	}
 
-	// 验证 max_requests_per_month 字段
-	if monthlyVal, exists := configMap["max_requests_per_month"]; exists && monthlyVal != nil {
-		switch v := monthlyVal.(type) {
-		case float64:
-			if v < 0 {
-				return fmt.Errorf("max_requests_per_month must be >= 0")
-			}
-		case int:
-			if v < 0 {
-				return fmt.Errorf("max_requests_per_month must be >= 0")
-			}
-		default:
-			return fmt.Errorf("max_requests_per_month must be a number")
+	routing := make(map[string]uint, len(routingVal))
+	for model, idVal := range routingVal {
+		if id, ok := idVal.(float64); ok {
+			routing[model] = uint(id)
 		}
 	}
-
-	return nil
+	return s.aggregateGroupService.validateModelRouting(db, group.ID, routing)
 }
 
 // normalizeHeaderRules deduplicates and normalises header rules.
@@ -1071,6 +1614,9 @@ func (s *GroupService) validateAndCleanUpstreams(upstreams json.RawMessage) (dat
 		if !strings.HasPrefix(defs[i].URL, "http://") && !strings.HasPrefix(defs[i].URL, "https://") {
 			return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_upstreams", map[string]any{"error": fmt.Sprintf("invalid URL format for upstream: %s", defs[i].URL)})
 		}
+		if s.settingsManager.GetSettings().RequireHTTPSUpstreams && !isHTTPSUpstream(defs[i].URL) {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.https_upstream_required", map[string]any{"url": defs[i].URL})
+		}
 		if defs[i].Weight < 0 {
 			return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_upstreams", map[string]any{"error": "upstream weight must be a non-negative integer"})
 		}
@@ -1103,9 +1649,10 @@ func calculateRequestStats(total, failed int64) RequestStats {
 	return stats
 }
 
-func (s *GroupService) generateUniqueGroupName(ctx context.Context, baseName string) string {
+// generateUniqueGroupName returns the first free "<baseName>_copy[_N]" name in db.
+func (s *GroupService) generateUniqueGroupName(db *gorm.DB, baseName string) string {
 	var groups []models.Group
-	if err := s.db.WithContext(ctx).Select("name").Find(&groups).Error; err != nil {
+	if err := db.Unscoped().Select("name").Find(&groups).Error; err != nil {
 		return baseName + "_copy"
 	}
 
@@ -1152,6 +1699,25 @@ func isValidValidationEndpoint(endpoint string) bool {
 	return true
 }
 
+// validateParamOverrides rejects param_overrides the channel does not know, which are usually typos,
+// when the group config sets strict_param_overrides. It is opt-in because OpenAI-compatible upstreams
+// accept vendor parameters no allow-list can cover.
+func validateParamOverrides(channelType string, overrides map[string]any, config datatypes.JSONMap) error {
+	if strict, ok := config["strict_param_overrides"].(bool); !ok || !strict {
+		return nil
+	}
+
+	unknown := channel.UnknownParams(channelType, overrides)
+	if len(unknown) == 0 {
+		return nil
+	}
+	return NewI18nError(app_errors.ErrValidation, "validation.unknown_param_overrides", map[string]any{
+		"params":  strings.Join(unknown, ", "),
+		"channel": channelType,
+		"valid":   strings.Join(channel.AllowedParams(channelType), ", "),
+	})
+}
+
 // isValidChannelType checks channel type against registered channels.
 func (s *GroupService) isValidChannelType(channelType string) bool {
 	for _, t := range s.channelRegistry {
@@ -1190,11 +1756,128 @@ func validateModelRedirectRules(rules map[string]string) error {
 	return nil
 }
 
+// ProxyKeyCollisionGroup identifies a group that declares a colliding proxy key.
+type ProxyKeyCollisionGroup struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// ProxyKeyCollision is a proxy key declared by more than one group.
+type ProxyKeyCollision struct {
+	MaskedKey string                   `json:"masked_key"`
+	Groups    []ProxyKeyCollisionGroup `json:"groups"`
+}
+
+// FindProxyKeyCollisions scans all groups' proxy keys and reports keys shared by more than one group.
+func (s *GroupService) FindProxyKeyCollisions(ctx context.Context) ([]ProxyKeyCollision, error) {
+	var groups []models.Group
+	if err := s.db.WithContext(ctx).Select("id", "name", "proxy_keys").Where("proxy_keys <> ''").Order("id asc").Find(&groups).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	owners := make(map[string][]ProxyKeyCollisionGroup)
+	var keyOrder []string
+	for _, group := range groups {
+		for key := range utils.StringToSet(group.ProxyKeys, ",") {
+			if _, seen := owners[key]; !seen {
+				keyOrder = append(keyOrder, key)
+			}
+			owners[key] = append(owners[key], ProxyKeyCollisionGroup{ID: group.ID, Name: group.Name})
+		}
+	}
+
+	collisions := make([]ProxyKeyCollision, 0)
+	for _, key := range keyOrder {
+		if len(owners[key]) < 2 {
+			continue
+		}
+		collisions = append(collisions, ProxyKeyCollision{
+			MaskedKey: utils.MaskAPIKey(key),
+			Groups:    owners[key],
+		})
+	}
+
+	return collisions, nil
+}
+
+// checkProxyKeysUnique rejects proxy keys already used by another group when enforce_unique_proxy_keys is enabled.
+func (s *GroupService) checkProxyKeysUnique(db *gorm.DB, groupID uint, proxyKeys string) error {
+	if !s.settingsManager.GetSettings().EnforceUniqueProxyKeys {
+		return nil
+	}
+
+	keys := utils.StringToSet(proxyKeys, ",")
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var groups []models.Group
+	if err := db.Select("id", "name", "proxy_keys").Where("id <> ? AND proxy_keys <> ''", groupID).Find(&groups).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	for _, group := range groups {
+		for key := range utils.StringToSet(group.ProxyKeys, ",") {
+			if _, exists := keys[key]; exists {
+				return NewI18nError(app_errors.ErrValidation, "validation.proxy_key_conflict", map[string]any{
+					"key":   utils.MaskAPIKey(key),
+					"group": group.Name,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckRateLimitCached 与 CheckRateLimit 相同，但结果会缓存 rateLimitCacheTTL，且不消耗令牌桶的令牌，
+// 供聚合分组选择子分组时频繁调用。最终放行前仍应调用 CheckRateLimit 做精确检查。
+func (s *GroupService) CheckRateLimitCached(ctx context.Context, groupID uint, proxyKey string) *app_errors.RateLimitError {
+	cacheKey := fmt.Sprintf("%d:%s", groupID, proxyKey)
+	now := time.Now()
+	if cached, ok := s.rateLimitCache.Load(cacheKey); ok {
+		entry := cached.(rateLimitCacheEntry)
+		if now.Before(entry.expiresAt) {
+			return entry.err
+		}
+	}
+
+	_, rateLimitErr := s.checkRateLimit(ctx, groupID, proxyKey, false)
+	s.rateLimitCache.Store(cacheKey, rateLimitCacheEntry{err: rateLimitErr, expiresAt: now.Add(rateLimitCacheTTL)})
+	return rateLimitErr
+}
+
+// RateLimitUsage 分组当前最接近上限的限流维度的用量
+type RateLimitUsage struct {
+	Reason  string    // "hourly_limit" 或 "monthly_limit"
+	Limit   int64     // 限制值
+	Used    int64     // 已使用量
+	Ratio   float64   // Used / Limit
+	ResetAt time.Time // 重置时间
+}
+
+// observe 记录一个限流维度的用量，保留比例最高的那个
+func (u *RateLimitUsage) observe(reason string, used, limit int64, resetAt time.Time) *RateLimitUsage {
+	ratio := float64(used) / float64(limit)
+	if u != nil && u.Ratio >= ratio {
+		return u
+	}
+	return &RateLimitUsage{Reason: reason, Limit: limit, Used: used, Ratio: ratio, ResetAt: resetAt}
+}
+
 // CheckRateLimit 检查分组是否超过限流或过期
-func (s *GroupService) CheckRateLimit(ctx context.Context, groupID uint) *app_errors.RateLimitError {
+// proxyKey 为本次请求使用的代理密钥，若在分组的 rate_limit_exempt_keys 中则跳过限流检查（过期检查仍然生效）。
+// 未超限时返回用量比例最高的限流维度，未配置限流或已豁免时为 nil，供代理层发出软限流预警。
+// 令牌桶模式下放行的请求会消耗一个令牌。
+func (s *GroupService) CheckRateLimit(ctx context.Context, groupID uint, proxyKey string) (*RateLimitUsage, *app_errors.RateLimitError) {
+	return s.checkRateLimit(ctx, groupID, proxyKey, true)
+}
+
+// checkRateLimit 实现 CheckRateLimit，consume 为 false 时只查看令牌桶而不消耗令牌
+func (s *GroupService) checkRateLimit(ctx context.Context, groupID uint, proxyKey string, consume bool) (*RateLimitUsage, *app_errors.RateLimitError) {
 	var group models.Group
-	if err := s.db.WithContext(ctx).Select("config").First(&group, groupID).Error; err != nil {
-		return nil // 如果获取分组失败，不做限流检查
+	if err := s.db.WithContext(ctx).Select("id", "name", "config").First(&group, groupID).Error; err != nil {
+		return nil, nil // 如果获取分组失败，不做限流检查
 	}
 
 	// 解析配置
@@ -1210,15 +1893,30 @@ func (s *GroupService) CheckRateLimit(ctx context.Context, groupID uint) *app_er
 	if config.ExpiresAt != nil && *config.ExpiresAt != "" {
 		expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", *config.ExpiresAt, time.Local)
 		if err == nil && now.After(expiresAt) {
-			return &app_errors.RateLimitError{
+			return nil, &app_errors.RateLimitError{
 				Reason:  "expired",
 				ResetAt: expiresAt,
 			}
 		}
 	}
 
-	// 2. 检查每小时限制
-	if config.MaxRequestsPerHour != nil && *config.MaxRequestsPerHour > 0 {
+	// 豁免密钥跳过限流检查，并记录日志以便审计
+	if proxyKey != "" && config.RateLimitExemptKeys != nil {
+		if _, exempt := utils.StringToSet(*config.RateLimitExemptKeys, ",")[proxyKey]; exempt {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"group_id":   group.ID,
+				"group_name": group.Name,
+				"proxy_key":  utils.MaskAPIKey(proxyKey),
+			}).Info("Rate limit bypassed for exempt proxy key")
+			return nil, nil
+		}
+	}
+
+	var usage *RateLimitUsage
+	tokenBucket := usesTokenBucket(&config)
+
+	// 2. 检查每小时限制，令牌桶模式下由令牌桶取代
+	if !tokenBucket && config.MaxRequestsPerHour != nil && *config.MaxRequestsPerHour > 0 {
 		currentHour := now.Truncate(time.Hour)
 		var hourlyStat models.GroupHourlyStat
 		if err := s.db.WithContext(ctx).
@@ -1226,13 +1924,14 @@ func (s *GroupService) CheckRateLimit(ctx context.Context, groupID uint) *app_er
 			First(&hourlyStat).Error; err == nil {
 			totalRequests := hourlyStat.SuccessCount + hourlyStat.FailureCount
 			if totalRequests >= int64(*config.MaxRequestsPerHour) {
-				return &app_errors.RateLimitError{
+				return nil, &app_errors.RateLimitError{
 					Reason:  "hourly_limit",
 					Limit:   int64(*config.MaxRequestsPerHour),
 					Used:    totalRequests,
 					ResetAt: currentHour.Add(time.Hour),
 				}
 			}
+			usage = usage.observe("hourly_limit", totalRequests, int64(*config.MaxRequestsPerHour), currentHour.Add(time.Hour))
 		}
 	}
 
@@ -1246,17 +1945,377 @@ func (s *GroupService) CheckRateLimit(ctx context.Context, groupID uint) *app_er
 			if monthlyStat.RequestCount >= int64(*config.MaxRequestsPerMonth) {
 				// 计算下个月初作为重置时间
 				nextMonth := currentMonth.AddDate(0, 1, 0)
-				return &app_errors.RateLimitError{
+				return nil, &app_errors.RateLimitError{
 					Reason:  "monthly_limit",
 					Limit:   int64(*config.MaxRequestsPerMonth),
 					Used:    monthlyStat.RequestCount,
 					ResetAt: nextMonth,
 				}
 			}
+			usage = usage.observe("monthly_limit", monthlyStat.RequestCount, int64(*config.MaxRequestsPerMonth), currentMonth.AddDate(0, 1, 0))
 		}
 	}
 
-	return nil
+	// 4. 检查令牌桶，放在最后以免被其他限制拒绝的请求消耗令牌
+	if tokenBucket {
+		allowed, nextTokenAt, err := s.rateLimitBucket.Take(groupID, *config.RateLimitRefillPerSecond, *config.RateLimitBurst, consume)
+		if err != nil {
+			// 与读取分组失败时一致，令牌桶不可用时不做限流
+			logrus.WithContext(ctx).WithError(err).WithField("group_id", groupID).Warn("Failed to check token bucket rate limit")
+		} else if !allowed {
+			return nil, &app_errors.RateLimitError{
+				Reason:  "token_bucket_limit",
+				Limit:   int64(*config.RateLimitBurst),
+				Used:    int64(*config.RateLimitBurst),
+				ResetAt: nextTokenAt,
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// usesTokenBucket 判断分组是否使用令牌桶限流，配置校验保证此时补充速率和容量均已设置
+func usesTokenBucket(config *models.GroupConfig) bool {
+	return config.RateLimitMode != nil && *config.RateLimitMode == models.RateLimitModeTokenBucket &&
+		config.RateLimitRefillPerSecond != nil && *config.RateLimitRefillPerSecond > 0 &&
+		config.RateLimitBurst != nil && *config.RateLimitBurst > 0
+}
+
+// 批量限流状态
+const (
+	RateLimitStatusOK      = "ok"
+	RateLimitStatusNear    = "near"
+	RateLimitStatusLimited = "limited"
+	RateLimitStatusExpired = "expired"
+
+	// defaultRateLimitNearPercent 未配置 rate_limit_warning_percent 时判定 "near" 的用量比例
+	defaultRateLimitNearPercent = 80
+)
+
+// RateLimitWindowStatus 单个限流维度的用量
+type RateLimitWindowStatus struct {
+	Limit   int64     `json:"limit"`
+	Used    int64     `json:"used"`
+	Ratio   float64   `json:"ratio"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// GroupRateLimitStatus 分组当前的限流状态
+type GroupRateLimitStatus struct {
+	GroupID     uint                   `json:"group_id"`
+	GroupName   string                 `json:"group_name"`
+	DisplayName string                 `json:"display_name"`
+	Status      string                 `json:"status"`
+	Reason      string                 `json:"reason,omitempty"` // 触发 limited/near/expired 的维度
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	Hourly      *RateLimitWindowStatus `json:"hourly,omitempty"`
+	Monthly     *RateLimitWindowStatus `json:"monthly,omitempty"`
+}
+
+// GetAllRateLimitStatus 一次性评估所有分组的限流状态，规则与 CheckRateLimit 一致（不考虑豁免密钥和令牌桶）。
+// 当前小时和当月的统计各用一次查询批量读取。"near" 的阈值为分组的 rate_limit_warning_percent，未配置时为 80%。
+func (s *GroupService) GetAllRateLimitStatus(ctx context.Context) ([]GroupRateLimitStatus, error) {
+	var groups []models.Group
+	if err := s.db.WithContext(ctx).Select("id", "name", "display_name", "config").Order("sort asc, id desc").Find(&groups).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	now := time.Now()
+	currentHour := now.Truncate(time.Hour)
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	groupIDs := make([]uint, len(groups))
+	for i := range groups {
+		groupIDs[i] = groups[i].ID
+	}
+
+	hourlyUsed := make(map[uint]int64, len(groups))
+	monthlyUsed := make(map[uint]int64, len(groups))
+	if len(groupIDs) > 0 {
+		var hourlyStats []models.GroupHourlyStat
+		if err := s.db.WithContext(ctx).Where("group_id IN ? AND time = ?", groupIDs, currentHour).Find(&hourlyStats).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		for _, stat := range hourlyStats {
+			hourlyUsed[stat.GroupID] = stat.SuccessCount + stat.FailureCount
+		}
+
+		var monthlyStats []models.GroupMonthlyStat
+		if err := s.db.WithContext(ctx).Where("group_id IN ? AND month = ?", groupIDs, currentMonth).Find(&monthlyStats).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		for _, stat := range monthlyStats {
+			monthlyUsed[stat.GroupID] = stat.RequestCount
+		}
+	}
+
+	result := make([]GroupRateLimitStatus, 0, len(groups))
+	for i := range groups {
+		group := &groups[i]
+		status := GroupRateLimitStatus{
+			GroupID:     group.ID,
+			GroupName:   group.Name,
+			DisplayName: group.DisplayName,
+			Status:      RateLimitStatusOK,
+		}
+
+		var config models.GroupConfig
+		if group.Config != nil {
+			configBytes, _ := json.Marshal(group.Config)
+			_ = json.Unmarshal(configBytes, &config)
+		}
+
+		nearPercent := s.settingsManager.GetEffectiveConfig(group.Config).RateLimitWarningPercent
+		if nearPercent <= 0 {
+			nearPercent = defaultRateLimitNearPercent
+		}
+
+		if !usesTokenBucket(&config) && config.MaxRequestsPerHour != nil && *config.MaxRequestsPerHour > 0 {
+			status.Hourly = newRateLimitWindowStatus(hourlyUsed[group.ID], int64(*config.MaxRequestsPerHour), currentHour.Add(time.Hour))
+		}
+		if config.MaxRequestsPerMonth != nil && *config.MaxRequestsPerMonth > 0 {
+			status.Monthly = newRateLimitWindowStatus(monthlyUsed[group.ID], int64(*config.MaxRequestsPerMonth), currentMonth.AddDate(0, 1, 0))
+		}
+
+		// 优先级: expired > limited > near > ok，与 CheckRateLimit 的检查顺序一致
+		for _, window := range []struct {
+			reason string
+			status *RateLimitWindowStatus
+		}{{"hourly_limit", status.Hourly}, {"monthly_limit", status.Monthly}} {
+			if window.status == nil {
+				continue
+			}
+			if window.status.Used >= window.status.Limit {
+				if status.Status != RateLimitStatusLimited {
+					status.Status = RateLimitStatusLimited
+					status.Reason = window.reason
+				}
+			} else if status.Status == RateLimitStatusOK && window.status.Ratio*100 >= float64(nearPercent) {
+				status.Status = RateLimitStatusNear
+				status.Reason = window.reason
+			}
+		}
+
+		if config.ExpiresAt != nil && *config.ExpiresAt != "" {
+			if expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", *config.ExpiresAt, time.Local); err == nil {
+				status.ExpiresAt = &expiresAt
+				if now.After(expiresAt) {
+					status.Status = RateLimitStatusExpired
+					status.Reason = "expired"
+				}
+			}
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// newRateLimitWindowStatus 构造单个限流维度的用量
+func newRateLimitWindowStatus(used, limit int64, resetAt time.Time) *RateLimitWindowStatus {
+	return &RateLimitWindowStatus{
+		Limit:   limit,
+		Used:    used,
+		Ratio:   math.Round(float64(used)/float64(limit)*10000) / 10000,
+		ResetAt: resetAt,
+	}
+}
+
+// MonthlyUsageProjection 月度用量的线性预测结果
+type MonthlyUsageProjection struct {
+	CurrentUsage    int64      `json:"current_usage"`
+	MonthlyLimit    int64      `json:"monthly_limit"`
+	ProjectedUsage  int64      `json:"projected_usage"`
+	DaysElapsed     float64    `json:"days_elapsed"`
+	DaysInMonth     int        `json:"days_in_month"`
+	WillExceedLimit bool       `json:"will_exceed_limit"`
+	LimitReachedAt  *time.Time `json:"limit_reached_at,omitempty"` // 预计达到限额的时间
+}
+
+// ProjectMonthlyUsage 根据本月已用量和已过去的时间，线性推算到月底的用量。
+// monthlyLimit <= 0 表示不限制，此时只返回预测值。
+func ProjectMonthlyUsage(currentUsage, monthlyLimit int64, now time.Time) *MonthlyUsageProjection {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	elapsed := now.Sub(monthStart)
+
+	projection := &MonthlyUsageProjection{
+		CurrentUsage:   currentUsage,
+		MonthlyLimit:   monthlyLimit,
+		ProjectedUsage: currentUsage,
+		DaysElapsed:    math.Round(elapsed.Hours()/24*100) / 100,
+		DaysInMonth:    monthEnd.AddDate(0, 0, -1).Day(),
+	}
+
+	if elapsed > 0 && currentUsage > 0 {
+		ratePerSecond := float64(currentUsage) / elapsed.Seconds()
+		projection.ProjectedUsage = int64(math.Round(ratePerSecond * monthEnd.Sub(monthStart).Seconds()))
+
+		if monthlyLimit > 0 {
+			if currentUsage >= monthlyLimit {
+				projection.WillExceedLimit = true
+			} else {
+				reachedAt := monthStart.Add(time.Duration(float64(monthlyLimit) / ratePerSecond * float64(time.Second)))
+				if reachedAt.Before(monthEnd) {
+					projection.WillExceedLimit = true
+					projection.LimitReachedAt = &reachedAt
+				}
+			}
+		}
+	}
+
+	return projection
+}
+
+// GetMonthlyUsageProjection 读取分组本月的 GroupMonthlyStat，返回到月底的用量预测。
+func (s *GroupService) GetMonthlyUsageProjection(ctx context.Context, groupID uint) (*MonthlyUsageProjection, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "config").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	var monthlyLimit int64
+	if len(group.Config) > 0 {
+		var config models.GroupConfig
+		configBytes, err := json.Marshal(group.Config)
+		if err == nil {
+			err = json.Unmarshal(configBytes, &config)
+		}
+		if err != nil {
+			return nil, NewI18nError(app_errors.ErrValidation, "error.invalid_config_format", map[string]any{"error": err.Error()})
+		}
+		if config.MaxRequestsPerMonth != nil && *config.MaxRequestsPerMonth > 0 {
+			monthlyLimit = int64(*config.MaxRequestsPerMonth)
+		}
+	}
+
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	var monthlyStat models.GroupMonthlyStat
+	err := s.db.WithContext(ctx).
+		Where("group_id = ? AND month = ?", groupID, currentMonth).
+		First(&monthlyStat).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	return ProjectMonthlyUsage(monthlyStat.RequestCount, monthlyLimit, now), nil
+}
+
+// Usage reset scopes accepted by ResetGroupUsage.
+const (
+	UsageResetScopeHourly  = "hourly"
+	UsageResetScopeMonthly = "monthly"
+	UsageResetScopeBoth    = "both"
+)
+
+// ResetGroupUsageResult 描述一次用量计数清零的结果
+type ResetGroupUsageResult struct {
+	Scope             string `json:"scope"`
+	HourlyRowsReset   int64  `json:"hourly_rows_reset"`
+	MonthlyRowsReset  int64  `json:"monthly_rows_reset"`
+	HourlyPeriodStart string `json:"hourly_period_start,omitempty"`
+	MonthlyPeriod     string `json:"monthly_period,omitempty"`
+}
+
+// ResetGroupUsage 将分组当前小时/当月的用量计数清零，立即影响限流判断。
+// 每次清零与发起的管理端 IP 一同写入 group_usage_resets 表，供审计查询。
+func (s *GroupService) ResetGroupUsage(ctx context.Context, groupID uint, scope, adminIP string) (*ResetGroupUsageResult, error) {
+	if scope != UsageResetScopeHourly && scope != UsageResetScopeMonthly && scope != UsageResetScopeBoth {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_usage_reset_scope", nil)
+	}
+
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "name").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	now := time.Now()
+	currentHour := now.Truncate(time.Hour)
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	result := &ResetGroupUsageResult{Scope: scope}
+
+	tx := s.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return nil, app_errors.ErrDatabase
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if scope == UsageResetScopeHourly || scope == UsageResetScopeBoth {
+		res := tx.Model(&models.GroupHourlyStat{}).
+			Where("group_id = ? AND time = ?", groupID, currentHour).
+			Updates(map[string]any{"success_count": 0, "failure_count": 0})
+		if res.Error != nil {
+			return nil, app_errors.ParseDBError(res.Error)
+		}
+		result.HourlyRowsReset = res.RowsAffected
+		result.HourlyPeriodStart = currentHour.Format(time.RFC3339)
+	}
+
+	if scope == UsageResetScopeMonthly || scope == UsageResetScopeBoth {
+		res := tx.Model(&models.GroupMonthlyStat{}).
+			Where("group_id = ? AND month = ?", groupID, currentMonth).
+			Updates(map[string]any{"request_count": 0, "success_count": 0, "failure_count": 0})
+		if res.Error != nil {
+			return nil, app_errors.ParseDBError(res.Error)
+		}
+		result.MonthlyRowsReset = res.RowsAffected
+		result.MonthlyPeriod = currentMonth.Format("2006-01")
+	}
+
+	audit := models.GroupUsageReset{
+		GroupID:          group.ID,
+		GroupName:        group.Name,
+		Scope:            scope,
+		HourlyRowsReset:  result.HourlyRowsReset,
+		MonthlyRowsReset: result.MonthlyRowsReset,
+		AdminIP:          adminIP,
+		CreatedAt:        now,
+	}
+	if err := tx.Create(&audit).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, app_errors.ErrDatabase
+	}
+	tx = nil
+
+	// 清除缓存的限流结果，使重置立即生效
+	prefix := fmt.Sprintf("%d:", groupID)
+	s.rateLimitCache.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			s.rateLimitCache.Delete(key)
+		}
+		return true
+	})
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"group_id":           group.ID,
+		"group_name":         group.Name,
+		"scope":              scope,
+		"hourly_rows_reset":  result.HourlyRowsReset,
+		"monthly_rows_reset": result.MonthlyRowsReset,
+		"admin_ip":           adminIP,
+		"source":             "manual",
+	}).Warn("Group usage counters reset manually")
+
+	return result, nil
+}
+
+// ListGroupUsageResets 返回分组的手动用量清零记录，最近的在前
+func (s *GroupService) ListGroupUsageResets(ctx context.Context, groupID uint) ([]models.GroupUsageReset, error) {
+	var resets []models.GroupUsageReset
+	if err := s.db.WithContext(ctx).Where("group_id = ?", groupID).Order("created_at desc").Limit(100).Find(&resets).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	return resets, nil
 }
 
 // IncrementGroupMonthlyStat 增加分组的月度统计
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+)
+
+// DefaultStatsTimeseriesHours is the window used when the caller does not specify one.
+const DefaultStatsTimeseriesHours = 168
+
+// HourlyStatPoint is a group's request counts during one hour.
+type HourlyStatPoint struct {
+	Time         time.Time `json:"time"`
+	SuccessCount int64     `json:"success_count"`
+	FailureCount int64     `json:"failure_count"`
+}
+
+// GetGroupStatsTimeseries returns a group's hourly request counts over the last hours, oldest first.
+// Hours without requests are filled in with zero counts, so the series has exactly one point per hour.
+// Like GetGroupStats, the window ends with the in-progress hour unless excludeCurrentHour is set, and
+// the counts of aggregate groups are summed over their sub-groups.
+func (s *GroupService) GetGroupStatsTimeseries(ctx context.Context, groupID uint, hours int, excludeCurrentHour bool) ([]HourlyStatPoint, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "group_type").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	memberIDs := []uint{group.ID}
+	if group.GroupType == "aggregate" {
+		subGroupIDs, err := s.aggregateGroupService.GetSubGroupIDs(ctx, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sub-group IDs: %w", err)
+		}
+		memberIDs = subGroupIDs
+	}
+
+	startTime, endTime := hourlyStatsWindow(hours, excludeCurrentHour)
+
+	countsByHour := make(map[int64]HourlyStatPoint)
+	if len(memberIDs) > 0 {
+		var rows []HourlyStatPoint
+		if err := s.db.WithContext(ctx).Model(&models.GroupHourlyStat{}).
+			Select("time, SUM(success_count) as success_count, SUM(failure_count) as failure_count").
+			Where("group_id IN ? AND time >= ? AND time < ?", memberIDs, startTime, endTime).
+			Group("time").
+			Scan(&rows).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		for _, row := range rows {
+			countsByHour[row.Time.Unix()] = row
+		}
+	}
+
+	points := make([]HourlyStatPoint, 0, hours)
+	for hour := startTime; hour.Before(endTime); hour = hour.Add(time.Hour) {
+		point := countsByHour[hour.Unix()]
+		point.Time = hour
+		points = append(points, point)
+	}
+	return points, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keyCountSnapshotCheckInterval is how often the service checks whether a new hour started.
+const keyCountSnapshotCheckInterval = time.Minute
+
+// KeyCountStatsService records an hourly snapshot of each group's total and active key counts
+// into group_key_count_stats, so pool size can be correlated with request failures.
+type KeyCountStatsService struct {
+	db       *gorm.DB
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	lastHour time.Time
+}
+
+// NewKeyCountStatsService creates a new KeyCountStatsService.
+func NewKeyCountStatsService(db *gorm.DB) *KeyCountStatsService {
+	return &KeyCountStatsService{
+		db:     db,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start begins recording snapshots. It only runs on the master node.
+func (s *KeyCountStatsService) Start() {
+	s.wg.Add(1)
+	go s.run()
+	logrus.Debug("Key count stats service started")
+}
+
+// Stop stops the snapshot loop, respecting the context for shutdown timeout.
+func (s *KeyCountStatsService) Stop(ctx context.Context) {
+	close(s.stopCh)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("KeyCountStatsService stopped gracefully.")
+	case <-ctx.Done():
+		logrus.Warn("KeyCountStatsService stop timed out.")
+	}
+}
+
+func (s *KeyCountStatsService) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(keyCountSnapshotCheckInterval)
+	defer ticker.Stop()
+
+	s.snapshotIfNewHour()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.snapshotIfNewHour()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// snapshotIfNewHour records the current hour's snapshot the first time it runs within that hour.
+// A failed snapshot is retried on the next check.
+func (s *KeyCountStatsService) snapshotIfNewHour() {
+	hour := time.Now().Truncate(time.Hour)
+	if hour.Equal(s.lastHour) {
+		return
+	}
+	if err := s.recordSnapshot(context.Background(), hour); err != nil {
+		logrus.WithError(err).Error("Failed to record key count snapshot")
+		return
+	}
+	s.lastHour = hour
+}
+
+// recordSnapshot stores the key counts of every standard group for the given hour, replacing any
+// snapshot already recorded for that hour.
+func (s *KeyCountStatsService) recordSnapshot(ctx context.Context, hour time.Time) error {
+	var groupIDs []uint
+	if err := s.db.WithContext(ctx).Model(&models.Group{}).
+		Where("group_type <> ?", "aggregate").
+		Pluck("id", &groupIDs).Error; err != nil {
+		return err
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	var rows []struct {
+		GroupID    uint
+		TotalKeys  int64
+		ActiveKeys int64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Select("group_id, COUNT(*) as total_keys, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as active_keys", models.KeyStatusActive).
+		Where("group_id IN ?", groupIDs).
+		Group("group_id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	// Groups without keys are recorded too, so a pool emptying out shows up as zero
+	snapshots := make([]models.GroupKeyCountStat, len(groupIDs))
+	indexByGroup := make(map[uint]int, len(groupIDs))
+	for i, groupID := range groupIDs {
+		snapshots[i] = models.GroupKeyCountStat{Time: hour, GroupID: groupID}
+		indexByGroup[groupID] = i
+	}
+	for _, row := range rows {
+		if i, ok := indexByGroup[row.GroupID]; ok {
+			snapshots[i].TotalKeys = row.TotalKeys
+			snapshots[i].ActiveKeys = row.ActiveKeys
+		}
+	}
+
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "time"}, {Name: "group_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total_keys", "active_keys", "updated_at"}),
+	}).CreateInBatches(snapshots, 500).Error
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+)
+
+const (
+	// DefaultKeyCountTrendHours is the window used when the caller does not specify one.
+	DefaultKeyCountTrendHours = 168
+	// MaxKeyCountTrendHours bounds the number of snapshots returned by one query.
+	MaxKeyCountTrendHours = 90 * 24
+)
+
+// KeyCountPoint is a group's key counts at the start of one hour.
+type KeyCountPoint struct {
+	Time        time.Time `json:"time"`
+	TotalKeys   int64     `json:"total_keys"`
+	ActiveKeys  int64     `json:"active_keys"`
+	InvalidKeys int64     `json:"invalid_keys"`
+}
+
+// GetKeyCountTrend returns the hourly key count snapshots of a group over the last hours, oldest first.
+// For aggregate groups the counts of all sub-groups are summed per hour. Hours without a snapshot,
+// e.g. while the master node was down, are omitted rather than filled in.
+func (s *GroupService) GetKeyCountTrend(ctx context.Context, groupID uint, hours int) ([]KeyCountPoint, error) {
+	var group models.Group
+	if err := s.db.WithContext(ctx).Select("id", "group_type").First(&group, groupID).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	memberIDs := []uint{group.ID}
+	if group.GroupType == "aggregate" {
+		subGroupIDs, err := s.aggregateGroupService.GetSubGroupIDs(ctx, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sub-group IDs: %w", err)
+		}
+		memberIDs = subGroupIDs
+	}
+
+	points := make([]KeyCountPoint, 0)
+	if len(memberIDs) == 0 {
+		return points, nil
+	}
+
+	since := time.Now().Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+	if err := s.db.WithContext(ctx).Model(&models.GroupKeyCountStat{}).
+		Select("time, SUM(total_keys) as total_keys, SUM(active_keys) as active_keys").
+		Where("group_id IN ? AND time >= ?", memberIDs, since).
+		Group("time").
+		Order("time asc").
+		Scan(&points).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	for i := range points {
+		points[i].InvalidKeys = points[i].TotalKeys - points[i].ActiveKeys
+	}
+	return points, nil
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"aimanager/internal/models"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Key import formats. Plain text keeps the original behaviour of one or more keys per line.
+const (
+	KeyImportFormatText  = "text"
+	KeyImportFormatCSV   = "csv"
+	KeyImportFormatJSONL = "jsonl"
+)
+
+var (
+	// ErrNoValidKeys is returned when an import input holds no valid key.
+	ErrNoValidKeys = errors.New("no valid keys found")
+	// ErrInvalidKeyImport is returned when a structured import input cannot be read at all.
+	ErrInvalidKeyImport = errors.New("invalid key import input")
+)
+
+const (
+	maxKeyMetadataLength = 255
+	// maxReportedRowErrors bounds the row errors stored in the task result.
+	maxReportedRowErrors = 100
+)
+
+// KeyImportRecord is a single key with optional metadata to import.
+type KeyImportRecord struct {
+	Key    string
+	Tags   string
+	Notes  string
+	Status string
+	Tier   int
+}
+
+// KeyImportRowError describes why a row of a structured import was rejected.
+type KeyImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// keyImportRow is the JSON lines representation of a row. Tags may be a string or an array.
+type keyImportRow struct {
+	Key    string `json:"key"`
+	Tags   any    `json:"tags"`
+	Notes  string `json:"notes"`
+	Status string `json:"status"`
+}
+
+// NormalizeKeyImportFormat maps an empty format to plain text and rejects unknown formats.
+func NormalizeKeyImportFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "", KeyImportFormatText:
+		return KeyImportFormatText, nil
+	case KeyImportFormatCSV, KeyImportFormatJSONL:
+		return format, nil
+	case "ndjson":
+		return KeyImportFormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+// ParseStructuredKeys parses CSV (with a header row containing at least a "key" column) or
+// JSON lines input into records. Invalid rows are skipped and reported with their line number.
+func ParseStructuredKeys(text, format string) ([]KeyImportRecord, []KeyImportRowError, error) {
+	switch format {
+	case KeyImportFormatCSV:
+		return parseCSVKeys(text)
+	case KeyImportFormatJSONL:
+		return parseJSONLKeys(text)
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported import format: %s", ErrInvalidKeyImport, format)
+	}
+}
+
+func parseCSVKeys(text string) ([]KeyImportRecord, []KeyImportRowError, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to read CSV header: %v", ErrInvalidKeyImport, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["key"]; !ok {
+		return nil, nil, fmt.Errorf("%w: CSV header must contain a 'key' column", ErrInvalidKeyImport)
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var records []KeyImportRecord
+	var rowErrors []KeyImportRowError
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// FieldPos is only valid after a successful read, parse errors carry their own line
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = appendRowError(rowErrors, parseErr.Line, parseErr.Err.Error())
+				continue
+			}
+			return nil, nil, fmt.Errorf("%w: failed to read CSV: %v", ErrInvalidKeyImport, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+
+		record, err := newKeyImportRecord(field(row, "key"), splitTags(field(row, "tags")), field(row, "notes"), field(row, "status"))
+		if err != nil {
+			rowErrors = appendRowError(rowErrors, line, err.Error())
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, rowErrors, nil
+}
+
+func parseJSONLKeys(text string) ([]KeyImportRecord, []KeyImportRowError, error) {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []KeyImportRecord
+	var rowErrors []KeyImportRowError
+	line := 0
+rows:
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var row keyImportRow
+		if err := json.Unmarshal([]byte(raw), &row); err != nil {
+			rowErrors = appendRowError(rowErrors, line, fmt.Sprintf("invalid JSON: %v", err))
+			continue
+		}
+
+		var tags []string
+		switch v := row.Tags.(type) {
+		case nil:
+		case string:
+			tags = splitTags(v)
+		case []any:
+			for _, item := range v {
+				tag, ok := item.(string)
+				if !ok {
+					rowErrors = appendRowError(rowErrors, line, "tags must be strings")
+					continue rows
+				}
+				tags = append(tags, tag)
+			}
+		default:
+			rowErrors = appendRowError(rowErrors, line, "tags must be a string or an array of strings")
+			continue
+		}
+
+		record, err := newKeyImportRecord(row.Key, tags, row.Notes, row.Status)
+		if err != nil {
+			rowErrors = appendRowError(rowErrors, line, err.Error())
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to read JSON lines: %v", ErrInvalidKeyImport, err)
+	}
+
+	return records, rowErrors, nil
+}
+
+// newKeyImportRecord validates and normalizes the fields of a single row.
+func newKeyImportRecord(key string, tags []string, notes, status string) (KeyImportRecord, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return KeyImportRecord{}, fmt.Errorf("key is required")
+	}
+
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status != "" && status != models.KeyStatusActive && status != models.KeyStatusInvalid {
+		return KeyImportRecord{}, fmt.Errorf("status must be '%s' or '%s'", models.KeyStatusActive, models.KeyStatusInvalid)
+	}
+
+	notes = strings.TrimSpace(notes)
+	if utf8.RuneCountInString(notes) > maxKeyMetadataLength {
+		return KeyImportRecord{}, fmt.Errorf("notes length must be <= %d characters", maxKeyMetadataLength)
+	}
+
+	var normalizedTags []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(normalizedTags, tag) {
+			normalizedTags = append(normalizedTags, tag)
+		}
+	}
+	joinedTags := strings.Join(normalizedTags, ",")
+	if utf8.RuneCountInString(joinedTags) > maxKeyMetadataLength {
+		return KeyImportRecord{}, fmt.Errorf("tags length must be <= %d characters", maxKeyMetadataLength)
+	}
+
+	return KeyImportRecord{
+		Key:    key,
+		Tags:   joinedTags,
+		Notes:  notes,
+		Status: status,
+	}, nil
+}
+
+// splitTags splits a tag list separated by commas or semicolons.
+func splitTags(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
+}
+
+func appendRowError(rowErrors []KeyImportRowError, row int, message string) []KeyImportRowError {
+	if len(rowErrors) >= maxReportedRowErrors {
+		return rowErrors
+	}
+	return append(rowErrors, KeyImportRowError{Row: row, Error: message})
+}
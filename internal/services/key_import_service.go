@@ -1,37 +1,64 @@
 package services
 
 import (
+	"aimanager/internal/config"
+	"aimanager/internal/httpclient"
 	"aimanager/internal/models"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 // KeyImportResult holds the result of an import task.
 type KeyImportResult struct {
-	AddedCount   int `json:"added_count"`
-	IgnoredCount int `json:"ignored_count"`
+	AddedCount   int                 `json:"added_count"`
+	IgnoredCount int                 `json:"ignored_count"`
+	InvalidRows  int                 `json:"invalid_rows,omitempty"`
+	RowErrors    []KeyImportRowError `json:"row_errors,omitempty"`
+	// Keys skipped because another group already has them, and how many each such group had
+	SkippedDuplicates int            `json:"skipped_duplicates,omitempty"`
+	DuplicateGroups   map[string]int `json:"duplicate_groups,omitempty"`
 }
 
+// KeyImportJob is a batch of keys to import into one group.
+type KeyImportJob struct {
+	Group   *models.Group
+	Records []KeyImportRecord
+}
+
+const (
+	// importQueuePollInterval is how often a queued import checks whether the task slot is free.
+	importQueuePollInterval = 2 * time.Second
+	// importQueueMaxWait bounds how long a queued import waits for another task to finish.
+	importQueueMaxWait = 30 * time.Minute
+)
+
 // KeyImportService handles the asynchronous import of a large number of keys.
 type KeyImportService struct {
-	TaskService *TaskService
-	KeyService  *KeyService
+	TaskService     *TaskService
+	KeyService      *KeyService
+	SettingsManager *config.SystemSettingsManager
+	ClientManager   *httpclient.HTTPClientManager
 }
 
 // NewKeyImportService creates a new KeyImportService.
-func NewKeyImportService(taskService *TaskService, keyService *KeyService) *KeyImportService {
+func NewKeyImportService(taskService *TaskService, keyService *KeyService, settingsManager *config.SystemSettingsManager, clientManager *httpclient.HTTPClientManager) *KeyImportService {
 	return &KeyImportService{
-		TaskService: taskService,
-		KeyService:  keyService,
+		TaskService:     taskService,
+		KeyService:      keyService,
+		SettingsManager: settingsManager,
+		ClientManager:   clientManager,
 	}
 }
 
-// StartImportTask initiates a new asynchronous key import task.
-func (s *KeyImportService) StartImportTask(group *models.Group, keysText string) (*TaskStatus, error) {
+// StartImportTask initiates a new asynchronous key import task. The keys are added to the given tier.
+// With skipDuplicatesAcrossGroups, keys already present in any other group are skipped.
+func (s *KeyImportService) StartImportTask(group *models.Group, keysText string, tier int, skipDuplicatesAcrossGroups bool) (*TaskStatus, error) {
 	keys := s.KeyService.ParseKeysFromText(keysText)
 	if len(keys) == 0 {
-		return nil, fmt.Errorf("no valid keys found in the input text")
+		return nil, fmt.Errorf("%w in the input text", ErrNoValidKeys)
 	}
 
 	initialStatus, err := s.TaskService.StartTask(TaskTypeKeyImport, group.Name, len(keys))
@@ -39,19 +66,97 @@ func (s *KeyImportService) StartImportTask(group *models.Group, keysText string)
 		return nil, err
 	}
 
-	go s.runImport(group, keys)
+	records := make([]KeyImportRecord, len(keys))
+	for i, key := range keys {
+		records[i] = KeyImportRecord{Key: key, Tier: tier}
+	}
+
+	go s.runImport(group, records, 0, nil, skipDuplicatesAcrossGroups)
+
+	return initialStatus, nil
+}
+
+// StartStructuredImportTask initiates an asynchronous import of CSV or JSON lines input that
+// carries per-key tags, notes and initial status. Invalid rows are skipped and reported in the
+// task result; the task fails to start only when no row is valid. The keys are added to the given tier.
+// With skipDuplicatesAcrossGroups, keys already present in any other group are skipped.
+func (s *KeyImportService) StartStructuredImportTask(group *models.Group, text, format string, tier int, skipDuplicatesAcrossGroups bool) (*TaskStatus, error) {
+	records, rowErrors, err := ParseStructuredKeys(text, format)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Tier = tier
+	}
+	if len(records) == 0 {
+		if len(rowErrors) > 0 {
+			return nil, fmt.Errorf("%w in the input text, first error at row %d: %s", ErrNoValidKeys, rowErrors[0].Row, rowErrors[0].Error)
+		}
+		return nil, fmt.Errorf("%w in the input text", ErrNoValidKeys)
+	}
+
+	initialStatus, err := s.TaskService.StartTask(TaskTypeKeyImport, group.Name, len(records))
+	if err != nil {
+		return nil, err
+	}
+
+	go s.runImport(group, records, len(rowErrors), rowErrors, skipDuplicatesAcrossGroups)
 
 	return initialStatus, nil
 }
 
-func (s *KeyImportService) runImport(group *models.Group, keys []string) {
+// QueueImports runs the jobs in the background one after another. Only one task can run at a time,
+// so each job waits for the task slot to be free before starting.
+func (s *KeyImportService) QueueImports(jobs []KeyImportJob) {
+	go func() {
+		for _, job := range jobs {
+			if !s.waitForTaskSlot(job.Group, len(job.Records)) {
+				logrus.Errorf("Gave up waiting to import %d keys into group %s: another task is still running", len(job.Records), job.Group.Name)
+				continue
+			}
+			s.runImport(job.Group, job.Records, 0, nil, false)
+		}
+	}()
+}
+
+// waitForTaskSlot starts an import task for the group, retrying while another task is running.
+func (s *KeyImportService) waitForTaskSlot(group *models.Group, total int) bool {
+	deadline := time.Now().Add(importQueueMaxWait)
+	for {
+		if _, err := s.TaskService.StartTask(TaskTypeKeyImport, group.Name, total); err == nil {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(importQueuePollInterval)
+	}
+}
+
+func (s *KeyImportService) runImport(group *models.Group, records []KeyImportRecord, invalidRows int, rowErrors []KeyImportRowError, skipDuplicatesAcrossGroups bool) {
 	progressCallback := func(processed int) {
 		if err := s.TaskService.UpdateProgress(processed); err != nil {
 			logrus.Warnf("Failed to update task progress for group %d: %v", group.ID, err)
 		}
 	}
 
-	addedCount, ignoredCount, err := s.KeyService.processAndCreateKeys(group.ID, keys, progressCallback)
+	var duplicateGroups map[string]int
+	skippedDuplicates := 0
+	if skipDuplicatesAcrossGroups {
+		var err error
+		records, duplicateGroups, err = s.skipKeysInOtherGroups(group.ID, records)
+		if err != nil {
+			if endErr := s.TaskService.EndTask(nil, err); endErr != nil {
+				logrus.Errorf("Failed to end task with error for group %d: %v (original error: %v)", group.ID, endErr, err)
+			}
+			return
+		}
+		for _, count := range duplicateGroups {
+			skippedDuplicates += count
+		}
+	}
+
+	addedCount, ignoredCount, err := s.KeyService.processAndCreateKeyRecords(group.ID, records, progressCallback)
 	if err != nil {
 		if endErr := s.TaskService.EndTask(nil, err); endErr != nil {
 			logrus.Errorf("Failed to end task with error for group %d: %v (original error: %v)", group.ID, endErr, err)
@@ -60,11 +165,43 @@ func (s *KeyImportService) runImport(group *models.Group, keys []string) {
 	}
 
 	result := KeyImportResult{
-		AddedCount:   addedCount,
-		IgnoredCount: ignoredCount,
+		AddedCount:        addedCount,
+		IgnoredCount:      ignoredCount,
+		InvalidRows:       invalidRows,
+		RowErrors:         rowErrors,
+		SkippedDuplicates: skippedDuplicates,
+		DuplicateGroups:   duplicateGroups,
 	}
 
 	if endErr := s.TaskService.EndTask(result, nil); endErr != nil {
 		logrus.Errorf("Failed to end task with success result for group %d: %v", group.ID, endErr)
 	}
 }
+
+// skipKeysInOtherGroups drops the records whose key another group already has, looked up by the keyed
+// hash of the key. It returns the remaining records and how many keys each other group already had.
+func (s *KeyImportService) skipKeysInOtherGroups(groupID uint, records []KeyImportRecord) ([]KeyImportRecord, map[string]int, error) {
+	hashes := make([]string, len(records))
+	for i, record := range records {
+		hashes[i] = s.KeyService.EncryptionSvc.Hash(strings.TrimSpace(record.Key))
+	}
+
+	owners, err := s.KeyService.FindKeysInOtherGroups(groupID, hashes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check keys in other groups: %w", err)
+	}
+	if len(owners) == 0 {
+		return records, nil, nil
+	}
+
+	duplicateGroups := make(map[string]int)
+	remaining := make([]KeyImportRecord, 0, len(records))
+	for i, record := range records {
+		if groupName, ok := owners[hashes[i]]; ok {
+			duplicateGroups[groupName]++
+			continue
+		}
+		remaining = append(remaining, record)
+	}
+	return remaining, duplicateGroups, nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/httpclient"
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxKeyImportURLBytes caps the size of a key list fetched from a URL.
+	maxKeyImportURLBytes = 10 * 1024 * 1024
+	// keyImportURLTimeout bounds the whole fetch of a key list, including reading the body.
+	keyImportURLTimeout = 60 * time.Second
+)
+
+// KeyImportURLParams describes a remote key list to import. At most one of BearerToken and Username
+// may be set; Username and Password are sent as basic auth.
+type KeyImportURLParams struct {
+	URL                        string
+	Format                     string
+	Tier                       int
+	BearerToken                string
+	Username                   string
+	Password                   string
+	SkipDuplicatesAcrossGroups bool
+}
+
+// StartURLImportTask validates the URL and starts an import task that fetches the key list server-side
+// and feeds it into the regular import. Fetch and parse errors end the task and are reported in its
+// status, since they only occur after the task has started.
+func (s *KeyImportService) StartURLImportTask(group *models.Group, params KeyImportURLParams) (*TaskStatus, error) {
+	parsed, err := url.Parse(strings.TrimSpace(params.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_import_url", nil)
+	}
+	if params.BearerToken != "" && params.Username != "" {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.import_url_auth_conflict", nil)
+	}
+	if !isPublicImportHost(parsed.Hostname()) {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.import_url_not_public", nil)
+	}
+	format, err := NormalizeKeyImportFormat(params.Format)
+	if err != nil {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_import_format", nil)
+	}
+
+	initialStatus, err := s.TaskService.StartTask(TaskTypeKeyImport, group.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		records, rowErrors, err := s.fetchImportRecords(parsed.String(), format, params)
+		if err != nil {
+			logrus.WithError(err).WithField("group", group.Name).Warn("Failed to import keys from URL")
+			if endErr := s.TaskService.EndTask(nil, err); endErr != nil {
+				logrus.Errorf("Failed to end task with error for group %d: %v (original error: %v)", group.ID, endErr, err)
+			}
+			return
+		}
+		for i := range records {
+			records[i].Tier = params.Tier
+		}
+		if err := s.TaskService.SetTotal(len(records)); err != nil {
+			logrus.Warnf("Failed to set task total for group %d: %v", group.ID, err)
+		}
+		s.runImport(group, records, len(rowErrors), rowErrors, params.SkipDuplicatesAcrossGroups)
+	}()
+
+	return initialStatus, nil
+}
+
+// isPublicImportHost resolves the host of an import URL and reports whether all its addresses are public,
+// so an obviously internal URL fails before the task starts. Hosts that do not resolve are left to fail
+// in the task. The fetch itself checks every address it connects to, see fetchImportRecords.
+func isPublicImportHost(host string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return true
+	}
+	for _, addr := range addrs {
+		if !httpclient.IsPublicIP(addr.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchImportRecords downloads the key list and parses it in the given format. The client refuses to
+// connect to private, loopback and link-local addresses, also after redirects, and does not use the
+// configured proxy.
+func (s *KeyImportService) fetchImportRecords(rawURL, format string, params KeyImportURLParams) ([]KeyImportRecord, []KeyImportRowError, error) {
+	settings := s.SettingsManager.GetSettings()
+	client := s.ClientManager.GetClient(&httpclient.Config{
+		ConnectTimeout:        time.Duration(settings.ConnectTimeout) * time.Second,
+		RequestTimeout:        keyImportURLTimeout,
+		IdleConnTimeout:       time.Duration(settings.IdleConnTimeout) * time.Second,
+		MaxIdleConns:          settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+		ResponseHeaderTimeout: keyImportURLTimeout,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		BlockPrivateAddresses: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), keyImportURLTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request for key list: %w", err)
+	}
+	if params.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+params.BearerToken)
+	} else if params.Username != "" {
+		req.SetBasicAuth(params.Username, params.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch key list: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("failed to fetch key list: upstream returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxKeyImportURLBytes+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read key list: %w", err)
+	}
+	if len(body) > maxKeyImportURLBytes {
+		return nil, nil, fmt.Errorf("key list exceeds the %d byte limit", maxKeyImportURLBytes)
+	}
+
+	if format == KeyImportFormatText {
+		keys := s.KeyService.ParseKeysFromText(string(body))
+		if len(keys) == 0 {
+			return nil, nil, fmt.Errorf("%w in the fetched key list", ErrNoValidKeys)
+		}
+		records := make([]KeyImportRecord, len(keys))
+		for i, key := range keys {
+			records[i] = KeyImportRecord{Key: key}
+		}
+		return records, nil, nil
+	}
+
+	records, rowErrors, err := ParseStructuredKeys(string(body), format)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		if len(rowErrors) > 0 {
+			return nil, nil, fmt.Errorf("%w in the fetched key list, first error at row %d: %s", ErrNoValidKeys, rowErrors[0].Row, rowErrors[0].Error)
+		}
+		return nil, nil, fmt.Errorf("%w in the fetched key list", ErrNoValidKeys)
+	}
+	return records, rowErrors, nil
+}
@@ -8,6 +8,7 @@ import (
 	"aimanager/internal/types"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -25,6 +26,7 @@ type ManualValidationResult struct {
 type KeyManualValidationService struct {
 	DB              *gorm.DB
 	Validator       *keypool.KeyValidator
+	KeyProvider     *keypool.KeyProvider
 	TaskService     *TaskService
 	SettingsManager *config.SystemSettingsManager
 	ConfigManager   types.ConfigManager
@@ -32,10 +34,11 @@ type KeyManualValidationService struct {
 }
 
 // NewKeyManualValidationService creates a new KeyManualValidationService.
-func NewKeyManualValidationService(db *gorm.DB, validator *keypool.KeyValidator, taskService *TaskService, settingsManager *config.SystemSettingsManager, configManager types.ConfigManager, encryptionSvc encryption.Service) *KeyManualValidationService {
+func NewKeyManualValidationService(db *gorm.DB, validator *keypool.KeyValidator, keyProvider *keypool.KeyProvider, taskService *TaskService, settingsManager *config.SystemSettingsManager, configManager types.ConfigManager, encryptionSvc encryption.Service) *KeyManualValidationService {
 	return &KeyManualValidationService{
 		DB:              db,
 		Validator:       validator,
+		KeyProvider:     keyProvider,
 		TaskService:     taskService,
 		SettingsManager: settingsManager,
 		ConfigManager:   configManager,
@@ -46,7 +49,8 @@ func NewKeyManualValidationService(db *gorm.DB, validator *keypool.KeyValidator,
 // StartValidationTask starts a new manual validation task for a given group.
 func (s *KeyManualValidationService) StartValidationTask(group *models.Group, status string) (*TaskStatus, error) {
 	var keys []models.APIKey
-	query := s.DB.Where("group_id = ?", group.ID)
+	// Manually disabled keys stay out of validation, so they cannot be reactivated by it
+	query := s.DB.Where("group_id = ? AND status <> ?", group.ID, models.KeyStatusDisabled)
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
@@ -80,7 +84,7 @@ func (s *KeyManualValidationService) runValidation(group *models.Group, keys []m
 	logrus.WithFields(logFields).Info("Starting manual validation")
 
 	jobs := make(chan models.APIKey, len(keys))
-	results := make(chan bool, len(keys))
+	results := make(chan keyValidationOutcome, len(keys))
 
 	concurrency := group.EffectiveConfig.KeyValidationConcurrency
 
@@ -104,9 +108,9 @@ func (s *KeyManualValidationService) runValidation(group *models.Group, keys []m
 	processedCount := 0
 	lastUpdateTime := time.Now()
 
-	for isValid := range results {
+	for outcome := range results {
 		processedCount++
-		if isValid {
+		if outcome.valid {
 			validCount++
 		}
 
@@ -137,15 +141,114 @@ func (s *KeyManualValidationService) runValidation(group *models.Group, keys []m
 	logrus.Infof("Manual validation finished for group %s: %+v", group.Name, result)
 }
 
+// InvalidRevalidationResult holds the result of revalidating a group's invalid keys.
+type InvalidRevalidationResult struct {
+	TotalKeys        int   `json:"total_keys"`
+	RestoredKeys     int64 `json:"restored_keys"`
+	StillInvalidKeys int   `json:"still_invalid_keys"`
+}
+
+// StartInvalidRevalidationTask starts a task that validates only the group's invalid keys and restores
+// those that pass to active right away, without waiting for key_recover_after_successes. Active and
+// disabled keys are not touched. It returns a nil status without starting a task if the group has no
+// invalid keys.
+func (s *KeyManualValidationService) StartInvalidRevalidationTask(group *models.Group) (*TaskStatus, error) {
+	var keys []models.APIKey
+	if err := s.DB.Where("group_id = ? AND status = ?", group.ID, models.KeyStatusInvalid).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get invalid keys for group %s: %w", group.Name, err)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	taskStatus, err := s.TaskService.StartTask(TaskTypeKeyValidation, group.Name, len(keys))
+	if err != nil {
+		return nil, err
+	}
+
+	go s.runInvalidRevalidation(group, keys)
+
+	return taskStatus, nil
+}
+
+func (s *KeyManualValidationService) runInvalidRevalidation(group *models.Group, keys []models.APIKey) {
+	logrus.WithFields(logrus.Fields{"group": group.Name, "keys": len(keys)}).Info("Starting revalidation of invalid keys")
+
+	jobs := make(chan models.APIKey, len(keys))
+	results := make(chan keyValidationOutcome, len(keys))
+
+	var wg sync.WaitGroup
+	for range group.EffectiveConfig.KeyValidationConcurrency {
+		wg.Add(1)
+		go s.validationWorker(&wg, group, jobs, results)
+	}
+
+	for _, key := range keys {
+		jobs <- key
+	}
+	close(jobs)
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var validIDs []uint
+	processedCount := 0
+	lastUpdateTime := time.Now()
+
+	for outcome := range results {
+		processedCount++
+		if outcome.valid {
+			validIDs = append(validIDs, outcome.key.ID)
+		}
+
+		if time.Since(lastUpdateTime) > time.Second {
+			if err := s.TaskService.UpdateProgress(processedCount); err != nil {
+				logrus.Warnf("Failed to update task progress: %v", err)
+			}
+			lastUpdateTime = time.Now()
+		}
+	}
+
+	if err := s.TaskService.UpdateProgress(processedCount); err != nil {
+		logrus.Warnf("Failed to update final task progress: %v", err)
+	}
+
+	// Only keys that are still invalid are restored, so keys disabled while the task ran stay disabled
+	restored, restoreErr := s.KeyProvider.RestoreKeysByID(group.ID, validIDs)
+	if restoreErr != nil {
+		logrus.WithError(restoreErr).WithField("group", group.Name).Error("Failed to restore revalidated keys")
+	}
+
+	result := InvalidRevalidationResult{
+		TotalKeys:        len(keys),
+		RestoredKeys:     restored,
+		StillInvalidKeys: len(keys) - len(validIDs),
+	}
+
+	if err := s.TaskService.EndTask(result, restoreErr); err != nil {
+		logrus.Errorf("Failed to end task for group %s: %v", group.Name, err)
+	}
+	logrus.Infof("Revalidation of invalid keys finished for group %s: %+v", group.Name, result)
+}
+
+// keyValidationOutcome 包含单个密钥的验证结果
+type keyValidationOutcome struct {
+	key   models.APIKey
+	valid bool
+}
+
 // validationResult 包含验证结果信息
-func (s *KeyManualValidationService) validationWorker(wg *sync.WaitGroup, group *models.Group, jobs <-chan models.APIKey, results chan<- bool) {
+func (s *KeyManualValidationService) validationWorker(wg *sync.WaitGroup, group *models.Group, jobs <-chan models.APIKey, results chan<- keyValidationOutcome) {
 	defer wg.Done()
 	for key := range jobs {
 		// Decrypt the key before validation
 		decryptedKey, err := s.EncryptionSvc.Decrypt(key.KeyValue)
 		if err != nil {
 			logrus.WithError(err).WithField("key_id", key.ID).Error("Manual validation: Failed to decrypt key for validation, marking as invalid")
-			results <- false
+			results <- keyValidationOutcome{key: key, valid: false}
 			continue
 		}
 
@@ -154,6 +257,157 @@ func (s *KeyManualValidationService) validationWorker(wg *sync.WaitGroup, group
 		keyForValidation.KeyValue = decryptedKey
 
 		isValid, _ := s.Validator.ValidateSingleKey(&keyForValidation, group)
-		results <- isValid
+		results <- keyValidationOutcome{key: key, valid: isValid}
+	}
+}
+
+// GlobalValidationProgress 跨分组验证任务的聚合进度，同时作为最终结果
+type GlobalValidationProgress struct {
+	GroupsTotal      int    `json:"groups_total"`
+	GroupsDone       int    `json:"groups_done"`
+	KeysChecked      int    `json:"keys_checked"`
+	KeysNewlyInvalid int    `json:"keys_newly_invalid"`
+	CurrentGroup     string `json:"current_group,omitempty"`
+	Cancelled        bool   `json:"cancelled"`
+}
+
+// StartGlobalValidationTask starts a single task that validates every key in every standard group.
+// Groups are processed one at a time, each with its own key_validation_concurrency.
+func (s *KeyManualValidationService) StartGlobalValidationTask() (*TaskStatus, error) {
+	var groups []models.Group
+	if err := s.DB.Where("group_type != ? OR group_type IS NULL", "aggregate").Order("sort asc, id asc").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get groups for global validation: %w", err)
+	}
+
+	groupIDs := make([]uint, len(groups))
+	for i := range groups {
+		groupIDs[i] = groups[i].ID
+	}
+
+	var totalKeys int64
+	if len(groupIDs) > 0 {
+		if err := s.DB.Model(&models.APIKey{}).Where("group_id IN ?", groupIDs).Count(&totalKeys).Error; err != nil {
+			return nil, fmt.Errorf("failed to count keys for global validation: %w", err)
+		}
+	}
+	if totalKeys == 0 {
+		return nil, fmt.Errorf("no keys to validate")
+	}
+
+	taskStatus, err := s.TaskService.StartTask(TaskTypeGlobalKeyValidation, "", int(totalKeys))
+	if err != nil {
+		return nil, err
+	}
+
+	go s.runGlobalValidation(groups)
+
+	return taskStatus, nil
+}
+
+func (s *KeyManualValidationService) runGlobalValidation(groups []models.Group) {
+	logrus.WithField("groups", len(groups)).Info("Starting global key validation")
+
+	progress := GlobalValidationProgress{GroupsTotal: len(groups)}
+
+	// Poll the cancellation flag in the background so workers only check an atomic.
+	var cancelled atomic.Bool
+	stopPolling := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if s.TaskService.IsCancelRequested() {
+					cancelled.Store(true)
+					return
+				}
+			case <-stopPolling:
+				return
+			}
+		}
+	}()
+	defer close(stopPolling)
+
+	lastUpdateTime := time.Now()
+	for i := range groups {
+		if cancelled.Load() {
+			break
+		}
+
+		group := &groups[i]
+		group.EffectiveConfig = s.SettingsManager.GetEffectiveConfig(group.Config)
+		progress.CurrentGroup = group.Name
+
+		var keys []models.APIKey
+		if err := s.DB.Where("group_id = ? AND status <> ?", group.ID, models.KeyStatusDisabled).Find(&keys).Error; err != nil {
+			logrus.WithError(err).WithField("group", group.Name).Error("Global validation: failed to load keys, skipping group")
+			progress.GroupsDone++
+			continue
+		}
+
+		jobs := make(chan models.APIKey)
+		results := make(chan keyValidationOutcome, len(keys))
+
+		var wg sync.WaitGroup
+		for range group.EffectiveConfig.KeyValidationConcurrency {
+			wg.Add(1)
+			go s.validationWorker(&wg, group, jobs, results)
+		}
+
+		go func() {
+			defer close(jobs)
+			for _, key := range keys {
+				if cancelled.Load() {
+					return
+				}
+				jobs <- key
+			}
+		}()
+
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		var failedActiveIDs []uint
+		for outcome := range results {
+			progress.KeysChecked++
+			if !outcome.valid && outcome.key.Status == models.KeyStatusActive {
+				failedActiveIDs = append(failedActiveIDs, outcome.key.ID)
+			}
+
+			if time.Since(lastUpdateTime) > time.Second {
+				if err := s.TaskService.UpdateProgressWithDetail(progress.KeysChecked, progress); err != nil {
+					logrus.Warnf("Failed to update task progress: %v", err)
+				}
+				lastUpdateTime = time.Now()
+			}
+		}
+
+		// 只有真正被拉黑的密钥才算作新失效
+		if len(failedActiveIDs) > 0 {
+			var newlyInvalid int64
+			if err := s.DB.Model(&models.APIKey{}).
+				Where("id IN ? AND status = ?", failedActiveIDs, models.KeyStatusInvalid).
+				Count(&newlyInvalid).Error; err != nil {
+				logrus.WithError(err).WithField("group", group.Name).Warn("Global validation: failed to count newly invalid keys")
+			} else {
+				progress.KeysNewlyInvalid += int(newlyInvalid)
+			}
+		}
+
+		progress.GroupsDone++
+	}
+
+	progress.CurrentGroup = ""
+	progress.Cancelled = cancelled.Load()
+
+	if err := s.TaskService.UpdateProgressWithDetail(progress.KeysChecked, progress); err != nil {
+		logrus.Warnf("Failed to update final task progress: %v", err)
+	}
+	if err := s.TaskService.EndTask(progress, nil); err != nil {
+		logrus.Errorf("Failed to end global validation task: %v", err)
 	}
+	logrus.Infof("Global key validation finished: %+v", progress)
 }
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"math/rand"
+	"strings"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/keypool"
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultModelMatrixSampleSize is the number of keys probed when the caller does not specify one.
+	DefaultModelMatrixSampleSize = 5
+	// MaxModelMatrixSampleSize bounds the number of keys probed in one matrix.
+	MaxModelMatrixSampleSize = 20
+	// MaxModelMatrixModels bounds the number of models probed in one matrix.
+	MaxModelMatrixModels = 10
+	// modelMatrixCandidateLimit bounds the number of active keys the sample is drawn from.
+	modelMatrixCandidateLimit = 1000
+)
+
+// KeyModelMatrix reports which of the candidate models each sampled key of a group can access.
+type KeyModelMatrix struct {
+	GroupID uint                        `json:"group_id"`
+	Models  []string                    `json:"models"`
+	Keys    []keypool.KeyModelMatrixRow `json:"keys"`
+	// ModelAvailability counts, per model, how many sampled keys can access it
+	ModelAvailability map[string]int `json:"model_availability"`
+}
+
+// TestModelMatrix probes a random sample of the group's active keys with every candidate model.
+// Without candidates, the group's test model and fallback test models are used. Key statuses are
+// not changed by the probes.
+func (s *KeyService) TestModelMatrix(group *models.Group, candidateModels []string, sampleSize int) (*KeyModelMatrix, error) {
+	if group.GroupType == "aggregate" {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.model_matrix_aggregate_group", nil)
+	}
+	if sampleSize <= 0 {
+		sampleSize = DefaultModelMatrixSampleSize
+	}
+	if sampleSize > MaxModelMatrixSampleSize {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.model_matrix_invalid_sample_size", map[string]any{"max": MaxModelMatrixSampleSize})
+	}
+
+	testModels := make([]string, 0, len(candidateModels))
+	seen := make(map[string]bool, len(candidateModels))
+	for _, model := range candidateModels {
+		model = strings.TrimSpace(model)
+		if model != "" && !seen[model] {
+			seen[model] = true
+			testModels = append(testModels, model)
+		}
+	}
+	if len(testModels) == 0 {
+		testModels = keypool.DefaultProbeModels(group)
+	}
+	if len(testModels) > MaxModelMatrixModels {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.model_matrix_too_many_models", map[string]any{"max": MaxModelMatrixModels})
+	}
+
+	var candidates []models.APIKey
+	if err := s.DB.Select("id", "key_value").
+		Where("group_id = ? AND status = ?", group.ID, models.KeyStatusActive).
+		Order("id asc").Limit(modelMatrixCandidateLimit).
+		Find(&candidates).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	if len(candidates) == 0 {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.model_matrix_no_active_keys", nil)
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	sample := make([]models.APIKey, 0, sampleSize)
+	for _, key := range candidates {
+		if len(sample) == sampleSize {
+			break
+		}
+		keyValue, err := s.EncryptionSvc.Decrypt(key.KeyValue)
+		if err != nil {
+			logrus.WithError(err).WithField("key_id", key.ID).Warn("Failed to decrypt key for model matrix, skipping")
+			continue
+		}
+		key.KeyValue = keyValue
+		sample = append(sample, key)
+	}
+
+	rows, err := s.KeyValidator.ProbeModels(group, sample, testModels)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &KeyModelMatrix{
+		GroupID:           group.ID,
+		Models:            testModels,
+		Keys:              rows,
+		ModelAvailability: make(map[string]int, len(testModels)),
+	}
+	for _, model := range testModels {
+		matrix.ModelAvailability[model] = 0
+	}
+	for _, row := range rows {
+		for _, result := range row.Results {
+			if result.Available {
+				matrix.ModelAvailability[result.Model]++
+			}
+		}
+	}
+	return matrix, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"strings"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// keyFingerprintSuffixLength is the number of trailing characters covered by the suffix fingerprint,
+	// matching what providers and MaskAPIKey usually reveal.
+	keyFingerprintSuffixLength = 4
+	// keySearchBatchSize bounds the number of keys decrypted at once while searching.
+	keySearchBatchSize = 1000
+	// keySearchMinFragmentLength is the minimum combined prefix and suffix length of a search.
+	keySearchMinFragmentLength = 4
+	// MaxKeySearchMatches caps the number of matches returned by one search.
+	MaxKeySearchMatches = 100
+)
+
+// KeySearchMatch is a key matching a fragment search. The key value is always masked.
+type KeySearchMatch struct {
+	KeyID     uint   `json:"key_id"`
+	GroupID   uint   `json:"group_id"`
+	GroupName string `json:"group_name"`
+	MaskedKey string `json:"masked_key"`
+	Status    string `json:"status"`
+}
+
+// KeySearchResult lists the keys matching a fragment search across all groups.
+type KeySearchResult struct {
+	Matches     []KeySearchMatch `json:"matches"`
+	ScannedKeys int              `json:"scanned_keys"`
+	Truncated   bool             `json:"truncated"`
+}
+
+// keySuffixFingerprint returns the non-reversible fingerprint of the key's trailing characters,
+// or "" when the key is too short to have one.
+func (s *KeyService) keySuffixFingerprint(key string) string {
+	if len(key) < keyFingerprintSuffixLength {
+		return ""
+	}
+	return s.EncryptionSvc.Hash("suffix:" + key[len(key)-keyFingerprintSuffixLength:])
+}
+
+// SearchKeysByFragment finds keys in any group that start with prefix and end with suffix.
+// When the suffix is long enough, candidates are narrowed with the stored suffix fingerprint so only
+// a handful of keys are decrypted; otherwise keys are decrypted in bounded batches. Keys stored before
+// fingerprints existed are always scanned and get their fingerprint filled in along the way.
+func (s *KeyService) SearchKeysByFragment(prefix, suffix string) (*KeySearchResult, error) {
+	prefix = strings.TrimSpace(prefix)
+	suffix = strings.TrimSpace(suffix)
+	if len(prefix)+len(suffix) < keySearchMinFragmentLength {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.key_search_fragment_too_short", map[string]any{"min": keySearchMinFragmentLength})
+	}
+
+	query := s.DB.Model(&models.APIKey{}).Select("id", "key_value", "key_suffix_hash", "group_id", "status")
+	if len(suffix) >= keyFingerprintSuffixLength {
+		query = query.Where("key_suffix_hash = ? OR key_suffix_hash = ''", s.keySuffixFingerprint(suffix))
+	}
+
+	result := &KeySearchResult{Matches: make([]KeySearchMatch, 0)}
+	var lastID uint
+	for !result.Truncated {
+		var keys []models.APIKey
+		if err := query.Session(&gorm.Session{}).Where("id > ?", lastID).Order("id asc").Limit(keySearchBatchSize).Find(&keys).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		if len(keys) == 0 {
+			break
+		}
+		lastID = keys[len(keys)-1].ID
+		result.ScannedKeys += len(keys)
+
+		backfill := make(map[uint]string)
+		for _, key := range keys {
+			keyValue, err := s.EncryptionSvc.Decrypt(key.KeyValue)
+			if err != nil {
+				logrus.WithError(err).WithField("key_id", key.ID).Warn("Failed to decrypt key during key search, skipping")
+				continue
+			}
+			if key.KeySuffixHash == "" {
+				if fingerprint := s.keySuffixFingerprint(keyValue); fingerprint != "" {
+					backfill[key.ID] = fingerprint
+				}
+			}
+			if !strings.HasPrefix(keyValue, prefix) || !strings.HasSuffix(keyValue, suffix) {
+				continue
+			}
+			if len(result.Matches) >= MaxKeySearchMatches {
+				result.Truncated = true
+				break
+			}
+			result.Matches = append(result.Matches, KeySearchMatch{
+				KeyID:     key.ID,
+				GroupID:   key.GroupID,
+				MaskedKey: utils.MaskAPIKey(keyValue),
+				Status:    key.Status,
+			})
+		}
+
+		for id, fingerprint := range backfill {
+			if err := s.DB.Model(&models.APIKey{}).Where("id = ?", id).Update("key_suffix_hash", fingerprint).Error; err != nil {
+				logrus.WithError(err).WithField("key_id", id).Warn("Failed to store key suffix fingerprint")
+			}
+		}
+	}
+
+	if err := s.fillKeySearchGroupNames(result.Matches); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fillKeySearchGroupNames sets the group name of every match.
+func (s *KeyService) fillKeySearchGroupNames(matches []KeySearchMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	groupIDs := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		groupIDs = append(groupIDs, match.GroupID)
+	}
+	var groups []models.Group
+	if err := s.DB.Select("id", "name").Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
+		return app_errors.ParseDBError(err)
+	}
+
+	names := make(map[uint]string, len(groups))
+	for _, group := range groups {
+		names[group.ID] = group.Name
+	}
+	for i := range matches {
+		matches[i].GroupName = names[matches[i].GroupID]
+	}
+	return nil
+}
@@ -4,11 +4,16 @@ import (
 	"aimanager/internal/encryption"
 	"aimanager/internal/keypool"
 	"aimanager/internal/models"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -19,6 +24,9 @@ const (
 	chunkSize      = 500
 )
 
+// MaxKeyTier is the highest tier a key can be placed in. Tier 0 is used first.
+const MaxKeyTier = 100
+
 // AddKeysResult holds the result of adding multiple keys.
 type AddKeysResult struct {
 	AddedCount   int   `json:"added_count"`
@@ -86,11 +94,25 @@ func (s *KeyService) AddMultipleKeys(groupID uint, keysText string) (*AddKeysRes
 	}, nil
 }
 
-// processAndCreateKeys is the lowest-level reusable function for adding keys.
+// processAndCreateKeys adds plain keys as active keys without metadata.
 func (s *KeyService) processAndCreateKeys(
 	groupID uint,
 	keys []string,
 	progressCallback func(processed int),
+) (addedCount int, ignoredCount int, err error) {
+	records := make([]KeyImportRecord, len(keys))
+	for i, key := range keys {
+		records[i] = KeyImportRecord{Key: key}
+	}
+	return s.processAndCreateKeyRecords(groupID, records, progressCallback)
+}
+
+// processAndCreateKeyRecords is the lowest-level reusable function for adding keys.
+// Records with an empty status are created as active.
+func (s *KeyService) processAndCreateKeyRecords(
+	groupID uint,
+	records []KeyImportRecord,
+	progressCallback func(processed int),
 ) (addedCount int, ignoredCount int, err error) {
 	// 1. Get existing key hashes in the group for deduplication
 	var existingHashes []string
@@ -106,8 +128,8 @@ func (s *KeyService) processAndCreateKeys(
 	var newKeysToCreate []models.APIKey
 	uniqueNewKeys := make(map[string]bool)
 
-	for _, keyVal := range keys {
-		trimmedKey := strings.TrimSpace(keyVal)
+	for _, record := range records {
+		trimmedKey := strings.TrimSpace(record.Key)
 		if trimmedKey == "" || uniqueNewKeys[trimmedKey] || !s.isValidKeyFormat(trimmedKey) {
 			continue
 		}
@@ -124,17 +146,26 @@ func (s *KeyService) processAndCreateKeys(
 			continue
 		}
 
+		status := record.Status
+		if status == "" {
+			status = models.KeyStatusActive
+		}
+
 		uniqueNewKeys[trimmedKey] = true
 		newKeysToCreate = append(newKeysToCreate, models.APIKey{
-			GroupID:  groupID,
-			KeyValue: encryptedKey,
-			KeyHash:  keyHash,
-			Status:   models.KeyStatusActive,
+			GroupID:       groupID,
+			KeyValue:      encryptedKey,
+			KeyHash:       keyHash,
+			KeySuffixHash: s.keySuffixFingerprint(trimmedKey),
+			Status:        status,
+			Notes:         record.Notes,
+			Tags:          record.Tags,
+			Tier:          record.Tier,
 		})
 	}
 
 	if len(newKeysToCreate) == 0 {
-		return 0, len(keys), nil
+		return 0, len(records), nil
 	}
 
 	// 3. Use KeyProvider to add keys in chunks
@@ -145,7 +176,7 @@ func (s *KeyService) processAndCreateKeys(
 		}
 		chunk := newKeysToCreate[i:end]
 		if err := s.KeyProvider.AddKeys(groupID, chunk); err != nil {
-			return addedCount, len(keys) - addedCount, err
+			return addedCount, len(records) - addedCount, err
 		}
 		addedCount += len(chunk)
 
@@ -154,7 +185,57 @@ func (s *KeyService) processAndCreateKeys(
 		}
 	}
 
-	return addedCount, len(keys) - addedCount, nil
+	return addedCount, len(records) - addedCount, nil
+}
+
+// FindKeysInOtherGroups returns, for each of the given key hashes already present in another group
+// than groupID, the name of one such group. Keys of deleted groups are not considered.
+func (s *KeyService) FindKeysInOtherGroups(groupID uint, keyHashes []string) (map[string]string, error) {
+	found := make(map[string]string)
+	groupNames := make(map[uint]string)
+	for i := 0; i < len(keyHashes); i += chunkSize {
+		end := min(i+chunkSize, len(keyHashes))
+		var keys []models.APIKey
+		if err := s.DB.Model(&models.APIKey{}).
+			Select("key_hash", "group_id").
+			Where("key_hash IN ? AND group_id <> ?", keyHashes[i:end], groupID).
+			Find(&keys).Error; err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		// Groups are looked up through the model so soft-deleted ones are left out and no table name
+		// has to be quoted by hand ("groups" is reserved in MySQL)
+		var missing []uint
+		for _, key := range keys {
+			if _, ok := groupNames[key.GroupID]; !ok && !slices.Contains(missing, key.GroupID) {
+				missing = append(missing, key.GroupID)
+			}
+		}
+		if len(missing) > 0 {
+			var groups []models.Group
+			if err := s.DB.Select("id", "name").Where("id IN ?", missing).Find(&groups).Error; err != nil {
+				return nil, err
+			}
+			for _, id := range missing {
+				groupNames[id] = ""
+			}
+			for _, group := range groups {
+				groupNames[group.ID] = group.Name
+			}
+		}
+
+		for _, key := range keys {
+			if name := groupNames[key.GroupID]; name != "" {
+				if _, ok := found[key.KeyHash]; !ok {
+					found[key.KeyHash] = name
+				}
+			}
+		}
+	}
+	return found, nil
 }
 
 // ParseKeysFromText parses a string of keys from various formats into a string slice.
@@ -241,6 +322,28 @@ func (s *KeyService) RestoreAllInvalidKeys(groupID uint) (int64, error) {
 	return s.KeyProvider.RestoreKeys(groupID)
 }
 
+// SetKeyStatus manually marks a single key as active or invalid, bypassing validation.
+func (s *KeyService) SetKeyStatus(ctx context.Context, keyID uint, status string) (*models.APIKey, error) {
+	return s.KeyProvider.SetKeyStatus(ctx, keyID, status)
+}
+
+// DisableKey manually takes a single key out of rotation. Unlike invalid keys, disabled keys are
+// never reactivated by successful requests or validation.
+func (s *KeyService) DisableKey(ctx context.Context, keyID uint) (*models.APIKey, error) {
+	return s.KeyProvider.SetKeyStatus(ctx, keyID, models.KeyStatusDisabled)
+}
+
+// EnableKey returns a manually disabled key to rotation as an active key. Invalid keys are left alone
+// and fail with keypool.ErrKeyNotDisabled.
+func (s *KeyService) EnableKey(ctx context.Context, keyID uint) (*models.APIKey, error) {
+	return s.KeyProvider.EnableKey(ctx, keyID)
+}
+
+// SetKeyTier moves a single key to another tier.
+func (s *KeyService) SetKeyTier(ctx context.Context, keyID uint, tier int) (*models.APIKey, error) {
+	return s.KeyProvider.SetKeyTier(ctx, keyID, tier)
+}
+
 // ClearAllInvalidKeys deletes all 'inactive' keys from a group.
 func (s *KeyService) ClearAllInvalidKeys(groupID uint) (int64, error) {
 	return s.KeyProvider.RemoveInvalidKeys(groupID)
@@ -333,12 +436,53 @@ func (s *KeyService) TestMultipleKeys(group *models.Group, keysText string) ([]k
 	return allResults, nil
 }
 
+// StreamKeysToCSV writes the group's decrypted keys as CSV, one batch at a time, so large groups are
+// not held in memory. When activeOnly is set, only active keys are written.
+func (s *KeyService) StreamKeysToCSV(groupID uint, activeOnly bool, writer io.Writer) error {
+	query := s.DB.Model(&models.APIKey{}).Where("group_id = ?", groupID).
+		Select("id, key_value, status, tier, last_used_at").Order("id asc")
+	if activeOnly {
+		query = query.Where("status = ?", models.KeyStatusActive)
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"key_value", "status", "tier", "last_used_at"}); err != nil {
+		return err
+	}
+
+	var keys []models.APIKey
+	err := query.FindInBatches(&keys, chunkSize, func(tx *gorm.DB, batch int) error {
+		for _, key := range keys {
+			decryptedKey, err := s.EncryptionSvc.Decrypt(key.KeyValue)
+			if err != nil {
+				logrus.WithError(err).WithField("key_id", key.ID).Error("Failed to decrypt key for CSV export, skipping")
+				continue
+			}
+			lastUsedAt := ""
+			if key.LastUsedAt != nil {
+				lastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+			}
+			if err := csvWriter.Write([]string{decryptedKey, key.Status, strconv.Itoa(key.Tier), lastUsedAt}); err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
 // StreamKeysToWriter fetches keys from the database in batches and writes them to the provided writer.
 func (s *KeyService) StreamKeysToWriter(groupID uint, statusFilter string, writer io.Writer) error {
 	query := s.DB.Model(&models.APIKey{}).Where("group_id = ?", groupID).Select("id, key_value")
 
 	switch statusFilter {
-	case models.KeyStatusActive, models.KeyStatusInvalid:
+	case models.KeyStatusActive, models.KeyStatusInvalid, models.KeyStatusDisabled:
 		query = query.Where("status = ?", statusFilter)
 	case "all":
 	default:
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"aimanager/internal/models"
+)
+
+const (
+	// DefaultKeyUtilizationHours is the window used when the caller does not specify one.
+	DefaultKeyUtilizationHours = 24
+	// MaxKeyUtilizationHours bounds the number of request logs scanned for one report.
+	MaxKeyUtilizationHours = 168
+)
+
+// KeyUtilization describes how much of a group's key pool actually served traffic in a window.
+type KeyUtilization struct {
+	GroupID            uint                   `json:"group_id"`
+	WindowHours        int                    `json:"window_hours"`
+	TotalKeys          int64                  `json:"total_keys"`
+	ActiveKeys         int64                  `json:"active_keys"`
+	UsedKeys           int                    `json:"used_keys"`
+	UsedActiveKeys     int                    `json:"used_active_keys"`
+	UtilizationRatio   float64                `json:"utilization_ratio"`
+	PeakConcurrentKeys int                    `json:"peak_concurrent_keys"`
+	PeakAt             *time.Time             `json:"peak_at,omitempty"`
+	Hourly             []KeyUtilizationBucket `json:"hourly"`
+}
+
+// KeyUtilizationBucket holds the key usage of one hour in the window.
+type KeyUtilizationBucket struct {
+	Hour             time.Time `json:"hour"`
+	Requests         int64     `json:"requests"`
+	UsedKeys         int       `json:"used_keys"`
+	UtilizationRatio float64   `json:"utilization_ratio"`
+}
+
+// GetKeyUtilization computes, from the request logs of the last hours, how many of the group's keys
+// served traffic and the peak number of keys busy within the same minute. Ratios are relative to the
+// current number of active keys. Logs are streamed one key at a time and reduced to per-minute and
+// per-hour counters, so memory does not grow with the number of requests in the window.
+func (s *KeyService) GetKeyUtilization(groupID uint, hours int) (*KeyUtilization, error) {
+	if hours <= 0 {
+		hours = DefaultKeyUtilizationHours
+	}
+	hours = min(hours, MaxKeyUtilizationHours)
+
+	result := &KeyUtilization{
+		GroupID:     groupID,
+		WindowHours: hours,
+		Hourly:      make([]KeyUtilizationBucket, 0, hours),
+	}
+
+	var activeHashes []string
+	if err := s.DB.Model(&models.APIKey{}).Where("group_id = ?", groupID).Count(&result.TotalKeys).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Model(&models.APIKey{}).
+		Where("group_id = ? AND status = ?", groupID, models.KeyStatusActive).
+		Pluck("key_hash", &activeHashes).Error; err != nil {
+		return nil, err
+	}
+	result.ActiveKeys = int64(len(activeHashes))
+	active := make(map[string]struct{}, len(activeHashes))
+	for _, hash := range activeHashes {
+		active[hash] = struct{}{}
+	}
+
+	now := time.Now()
+	windowStart := now.Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+
+	// Rows of one key are adjacent, so only the current key's usage has to be held
+	rows, err := s.DB.Model(&models.RequestLog{}).
+		Select("key_hash, timestamp, duration").
+		Where("group_id = ? AND timestamp >= ? AND key_hash <> ''", groupID, windowStart).
+		Order("key_hash").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request logs: %w", err)
+	}
+	defer rows.Close()
+
+	usage := newKeyUsageCounter(windowStart, hours)
+	currentKey := ""
+	for rows.Next() {
+		var keyHash string
+		var finishedAt time.Time
+		var durationMs int64
+		if err := rows.Scan(&keyHash, &finishedAt, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan request log: %w", err)
+		}
+
+		if keyHash != currentKey {
+			usage.finishKey()
+			currentKey = keyHash
+			result.UsedKeys++
+			if _, ok := active[keyHash]; ok {
+				result.UsedActiveKeys++
+			}
+		}
+		// Logs are written when a request finishes, so the request started duration earlier
+		usage.add(finishedAt.Add(-time.Duration(durationMs)*time.Millisecond), finishedAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read request logs: %w", err)
+	}
+	usage.finishKey()
+
+	result.UtilizationRatio = utilizationRatio(result.UsedActiveKeys, result.ActiveKeys)
+	result.PeakConcurrentKeys, result.PeakAt = usage.peak()
+
+	for i := range hours {
+		hour := windowStart.Add(time.Duration(i) * time.Hour)
+		result.Hourly = append(result.Hourly, KeyUtilizationBucket{
+			Hour:             hour,
+			Requests:         usage.hourlyRequests[i],
+			UsedKeys:         usage.hourlyKeys[i],
+			UtilizationRatio: utilizationRatio(usage.hourlyKeys[i], result.ActiveKeys),
+		})
+	}
+
+	return result, nil
+}
+
+// keyUsageCounter counts, per minute and per hour of a window, how many keys were in use. Keys are
+// added one after another; the minutes and hours of the current key are marked until finishKey adds
+// them to the counts, so a key is counted once per minute and hour however many requests it served.
+type keyUsageCounter struct {
+	start time.Time
+	// Per-minute counts of keys with a request in flight
+	minuteKeys []int
+	// Per-hour counts of keys that finished a request, and of finished requests
+	hourlyKeys     []int
+	hourlyRequests []int64
+
+	keyMinutes []bool
+	keyHours   []bool
+}
+
+func newKeyUsageCounter(start time.Time, hours int) *keyUsageCounter {
+	minutes := hours * 60
+	return &keyUsageCounter{
+		start:          start,
+		minuteKeys:     make([]int, minutes),
+		hourlyKeys:     make([]int, hours),
+		hourlyRequests: make([]int64, hours),
+		keyMinutes:     make([]bool, minutes),
+		keyHours:       make([]bool, hours),
+	}
+}
+
+// add records a request of the current key. Requests are bucketed by finish time and mark every
+// minute of the window they were in flight.
+func (u *keyUsageCounter) add(startedAt, finishedAt time.Time) {
+	if hour := int(finishedAt.Sub(u.start) / time.Hour); hour >= 0 && hour < len(u.hourlyRequests) {
+		u.hourlyRequests[hour]++
+		u.keyHours[hour] = true
+	}
+
+	first := max(int(startedAt.Sub(u.start)/time.Minute), 0)
+	last := min(int(finishedAt.Sub(u.start)/time.Minute), len(u.keyMinutes)-1)
+	for minute := first; minute <= last; minute++ {
+		u.keyMinutes[minute] = true
+	}
+}
+
+// finishKey adds the marked minutes and hours of the current key to the counts.
+func (u *keyUsageCounter) finishKey() {
+	for i, used := range u.keyMinutes {
+		if used {
+			u.minuteKeys[i]++
+			u.keyMinutes[i] = false
+		}
+	}
+	for i, used := range u.keyHours {
+		if used {
+			u.hourlyKeys[i]++
+			u.keyHours[i] = false
+		}
+	}
+}
+
+// peak returns the largest number of keys in use within one minute, and the first minute it occurred.
+func (u *keyUsageCounter) peak() (int, *time.Time) {
+	peak := 0
+	var peakAt *time.Time
+	for i, keys := range u.minuteKeys {
+		if keys > peak {
+			peak = keys
+			at := u.start.Add(time.Duration(i) * time.Minute)
+			peakAt = &at
+		}
+	}
+	return peak, peakAt
+}
+
+// utilizationRatio returns used/total rounded to four decimals, or 0 when there are no keys.
+func utilizationRatio(used int, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(int64(float64(used)/float64(total)*10000+0.5)) / 10000
+}
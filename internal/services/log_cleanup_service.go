@@ -4,11 +4,20 @@ import (
 	"aimanager/internal/config"
 	"aimanager/internal/models"
 	"context"
+	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// hourlyStatsRollupBatchSize 每个事务汇总并删除的小时统计行数
+	hourlyStatsRollupBatchSize = 1000
+	// minHourlyStatsRetentionDays 小时统计的最短保留天数，覆盖 30 天统计窗口和当月用量
+	minHourlyStatsRetentionDays = 31
 )
 
 // LogCleanupService 负责清理过期的请求日志
@@ -61,11 +70,15 @@ func (s *LogCleanupService) run() {
 
 	// 启动时先执行一次清理
 	s.cleanupExpiredLogs()
+	s.rollupHourlyStats()
+	s.cleanupKeyCountStats()
 
 	for {
 		select {
 		case <-ticker.C:
 			s.cleanupExpiredLogs()
+			s.rollupHourlyStats()
+			s.cleanupKeyCountStats()
 		case <-s.stopCh:
 			return
 		}
@@ -73,33 +86,207 @@ func (s *LogCleanupService) run() {
 }
 
 // cleanupExpiredLogs 清理过期的请求日志
+// 设置了 request_log_retention_days 覆盖的分组按各自的保留天数清理（相同天数的分组合并为一次删除），
+// 其余分组使用全局保留天数。保留天数 <= 0 表示不清理。
 func (s *LogCleanupService) cleanupExpiredLogs() {
 	// 获取日志保留天数配置
 	settings := s.settingsManager.GetSettings()
-	retentionDays := settings.RequestLogRetentionDays
+	globalRetentionDays := settings.RequestLogRetentionDays
 
-	if retentionDays <= 0 {
-		logrus.Debug("Log retention is disabled (retention_days <= 0)")
+	overrides, err := s.loadGroupRetentionOverrides()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load group log retention overrides")
+		return
+	}
+
+	// 按保留天数对覆盖分组进行归并
+	groupsByRetention := make(map[int][]uint)
+	overriddenGroupIDs := make([]uint, 0, len(overrides))
+	for groupID, days := range overrides {
+		overriddenGroupIDs = append(overriddenGroupIDs, groupID)
+		if days > 0 {
+			groupsByRetention[days] = append(groupsByRetention[days], groupID)
+		}
+	}
+
+	for retentionDays, groupIDs := range groupsByRetention {
+		cutoffTime := time.Now().AddDate(0, 0, -retentionDays).UTC()
+		query := s.db.Where("group_id IN ? AND timestamp < ?", groupIDs, cutoffTime)
+		s.deleteExpiredLogs(query, cutoffTime, retentionDays, len(groupIDs))
+	}
+
+	if globalRetentionDays <= 0 {
+		logrus.Debug("Global log retention is disabled (retention_days <= 0)")
 		return
 	}
 
 	// 计算过期时间点
-	cutoffTime := time.Now().AddDate(0, 0, -retentionDays).UTC()
+	cutoffTime := time.Now().AddDate(0, 0, -globalRetentionDays).UTC()
+	query := s.db.Where("timestamp < ?", cutoffTime)
+	if len(overriddenGroupIDs) > 0 {
+		query = query.Where("group_id NOT IN ?", overriddenGroupIDs)
+	}
+	s.deleteExpiredLogs(query, cutoffTime, globalRetentionDays, 0)
+}
+
+// loadGroupRetentionOverrides 返回设置了日志保留天数覆盖的分组 ID 与天数
+func (s *LogCleanupService) loadGroupRetentionOverrides() (map[uint]int, error) {
+	var groups []models.Group
+	if err := s.db.Select("id", "name", "config").Find(&groups).Error; err != nil {
+		return nil, err
+	}
 
+	overrides := make(map[uint]int)
+	for _, group := range groups {
+		if len(group.Config) == 0 {
+			continue
+		}
+		var groupConfig models.GroupConfig
+		configBytes, err := json.Marshal(group.Config)
+		if err == nil {
+			err = json.Unmarshal(configBytes, &groupConfig)
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to parse group config for log retention")
+			continue
+		}
+		if groupConfig.RequestLogRetentionDays != nil {
+			overrides[group.ID] = *groupConfig.RequestLogRetentionDays
+		}
+	}
+	return overrides, nil
+}
+
+// deleteExpiredLogs 执行一次删除并记录结果，groupCount 为 0 表示全局清理
+func (s *LogCleanupService) deleteExpiredLogs(query *gorm.DB, cutoffTime time.Time, retentionDays int, groupCount int) {
 	// 执行删除操作
-	result := s.db.Where("timestamp < ?", cutoffTime).Delete(&models.RequestLog{})
+	result := query.Delete(&models.RequestLog{})
 	if result.Error != nil {
-		logrus.WithError(result.Error).Error("Failed to cleanup expired request logs")
+		logrus.WithError(result.Error).WithField("retention_days", retentionDays).Error("Failed to cleanup expired request logs")
 		return
 	}
 
 	if result.RowsAffected > 0 {
-		logrus.WithFields(logrus.Fields{
+		fields := logrus.Fields{
 			"deleted_count":  result.RowsAffected,
 			"cutoff_time":    cutoffTime.Format(time.RFC3339),
 			"retention_days": retentionDays,
-		}).Info("Successfully cleaned up expired request logs")
+		}
+		if groupCount > 0 {
+			fields["group_count"] = groupCount
+		}
+		logrus.WithFields(fields).Info("Successfully cleaned up expired request logs")
 	} else {
-		logrus.Debug("No expired request logs found to cleanup")
+		logrus.WithField("retention_days", retentionDays).Debug("No expired request logs found to cleanup")
+	}
+}
+
+// rollupHourlyStats 将早于 stats_hourly_retention_days 的小时统计按天累加到 group_daily_stats 后删除。
+// 截止时间对齐到当天零点，每批的累加与删除在同一事务中完成，因此重复执行或中途失败都不会重复计数。
+func (s *LogCleanupService) rollupHourlyStats() {
+	settings := s.settingsManager.GetSettings()
+	retentionDays := settings.StatsHourlyRetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	// 30 天统计、当月用量、时间序列和可用率只读取小时统计，保留期不能短于它们覆盖的范围，
+	// 否则可用率会把已汇总掉的小时当作无请求的正常小时
+	retentionDays = max(retentionDays, minHourlyStatsRetentionDays, (settings.StatsTimeseriesMaxHours+23)/24+1, MaxAvailabilityHours/24+1)
+
+	now := time.Now()
+	cutoffTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -retentionDays)
+
+	var rolledUp int64
+	for {
+		count, err := s.rollupHourlyStatsBatch(cutoffTime)
+		if err != nil {
+			logrus.WithError(err).WithField("retention_days", retentionDays).Error("Failed to roll up hourly stats")
+			return
+		}
+		rolledUp += count
+		if count < hourlyStatsRollupBatchSize {
+			break
+		}
+	}
+
+	if rolledUp > 0 {
+		logrus.WithFields(logrus.Fields{
+			"rolled_up_count": rolledUp,
+			"cutoff_time":     cutoffTime.Format(time.RFC3339),
+			"retention_days":  retentionDays,
+		}).Info("Successfully rolled up expired hourly stats into daily stats")
+	}
+}
+
+// rollupHourlyStatsBatch 汇总并删除一批早于 cutoffTime 的小时统计，返回处理的行数
+func (s *LogCleanupService) rollupHourlyStatsBatch(cutoffTime time.Time) (int64, error) {
+	var count int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var hourlyStats []models.GroupHourlyStat
+		if err := tx.Where("time < ?", cutoffTime).Order("id asc").Limit(hourlyStatsRollupBatchSize).Find(&hourlyStats).Error; err != nil {
+			return err
+		}
+		if len(hourlyStats) == 0 {
+			return nil
+		}
+
+		type dayKey struct {
+			GroupID uint
+			Day     time.Time
+		}
+		daily := make(map[dayKey]*models.GroupDailyStat)
+		ids := make([]uint, 0, len(hourlyStats))
+		for _, stat := range hourlyStats {
+			ids = append(ids, stat.ID)
+			t := stat.Time.Local()
+			key := dayKey{GroupID: stat.GroupID, Day: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)}
+			dayStat, ok := daily[key]
+			if !ok {
+				dayStat = &models.GroupDailyStat{Day: key.Day, GroupID: key.GroupID}
+				daily[key] = dayStat
+			}
+			dayStat.SuccessCount += stat.SuccessCount
+			dayStat.FailureCount += stat.FailureCount
+			dayStat.MidStreamFailures += stat.MidStreamFailures
+		}
+
+		for _, dayStat := range daily {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "day"}, {Name: "group_id"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"success_count":       gorm.Expr("group_daily_stats.success_count + ?", dayStat.SuccessCount),
+					"failure_count":       gorm.Expr("group_daily_stats.failure_count + ?", dayStat.FailureCount),
+					"mid_stream_failures": gorm.Expr("group_daily_stats.mid_stream_failures + ?", dayStat.MidStreamFailures),
+					"updated_at":          time.Now(),
+				}),
+			}).Create(dayStat).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("id IN ?", ids).Delete(&models.GroupHourlyStat{}).Error; err != nil {
+			return err
+		}
+		count = int64(len(hourlyStats))
+		return nil
+	})
+	return count, err
+}
+
+// cleanupKeyCountStats 删除超出密钥数量趋势最长查询范围的快照，以及已永久删除分组的快照。
+// 软删除的分组仍可恢复，保留其快照。
+func (s *LogCleanupService) cleanupKeyCountStats() {
+	cutoffTime := time.Now().Truncate(time.Hour).Add(-time.Duration(MaxKeyCountTrendHours) * time.Hour)
+	result := s.db.Where("time < ? OR group_id NOT IN (?)", cutoffTime, s.db.Unscoped().Model(&models.Group{}).Select("id")).
+		Delete(&models.GroupKeyCountStat{})
+	if result.Error != nil {
+		logrus.WithError(result.Error).Error("Failed to cleanup key count stats")
+		return
+	}
+	if result.RowsAffected > 0 {
+		logrus.WithFields(logrus.Fields{
+			"deleted_count": result.RowsAffected,
+			"cutoff_time":   cutoffTime.Format(time.RFC3339),
+		}).Info("Successfully cleaned up key count stats")
 	}
 }
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"aimanager/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dimensions latency percentiles can be broken down by, in addition to the group.
+const (
+	LatencyGroupByUpstream = "upstream"
+	LatencyGroupByModel    = "model"
+)
+
+const (
+	// defaultLatencyWindow is used when no start_time filter is given.
+	defaultLatencyWindow = 24 * time.Hour
+	// maxLatencySamples bounds the durations kept per bucket. Larger buckets keep a uniform random
+	// sample, so memory stays bounded while percentiles remain close to the exact values.
+	maxLatencySamples = 10000
+)
+
+// LatencyPercentiles summarizes the request durations of one group, optionally narrowed to one
+// upstream and/or model. Durations are the end-to-end request durations recorded in the request logs.
+type LatencyPercentiles struct {
+	GroupID   uint    `json:"group_id"`
+	GroupName string  `json:"group_name"`
+	Upstream  string  `json:"upstream,omitempty"`
+	Model     string  `json:"model,omitempty"`
+	Count     int64   `json:"count"`
+	Sampled   bool    `json:"sampled"`
+	AvgMs     float64 `json:"avg_ms"`
+	P50Ms     int64   `json:"p50_ms"`
+	P95Ms     int64   `json:"p95_ms"`
+	P99Ms     int64   `json:"p99_ms"`
+	MaxMs     int64   `json:"max_ms"`
+}
+
+type latencyBucketKey struct {
+	groupID  uint
+	upstream string
+	model    string
+}
+
+type latencyBucket struct {
+	result  LatencyPercentiles
+	total   int64
+	samples []int64
+}
+
+// IsValidLatencyGroupBy reports whether a latency breakdown dimension is supported.
+func IsValidLatencyGroupBy(dimension string) bool {
+	return dimension == LatencyGroupByUpstream || dimension == LatencyGroupByModel
+}
+
+// GetLatencyPercentiles returns p50/p95/p99 request durations per group, further broken down by the
+// given dimensions. It accepts the same filters as the log list; without start_time only the last
+// 24 hours are considered, without request_type only final requests and without is_success only
+// successful requests, so fast failures do not pull the percentiles down.
+// Upstreams are reported as scheme and host, since logged upstream addresses include the request path.
+func (s *LogService) GetLatencyPercentiles(c *gin.Context, groupBy []string) ([]LatencyPercentiles, error) {
+	byUpstream := slices.Contains(groupBy, LatencyGroupByUpstream)
+	byModel := slices.Contains(groupBy, LatencyGroupByModel)
+
+	query := s.DB.Model(&models.RequestLog{}).Scopes(s.logFiltersScope(c))
+	if c.Query("start_time") == "" {
+		query = query.Where("timestamp >= ?", time.Now().Add(-defaultLatencyWindow))
+	}
+	if c.Query("request_type") == "" {
+		query = query.Where("request_type = ?", models.RequestTypeFinal)
+	}
+	if c.Query("is_success") == "" {
+		query = query.Where("is_success = ?", true)
+	}
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+		if groupID, err := strconv.Atoi(groupIDStr); err == nil {
+			query = query.Where("group_id = ?", groupID)
+		}
+	}
+
+	rows, err := query.Select("group_id, group_name, upstream_addr, model, duration").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request durations: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := make(map[latencyBucketKey]*latencyBucket)
+	var order []latencyBucketKey
+	for rows.Next() {
+		var (
+			groupID      uint
+			groupName    string
+			upstreamAddr string
+			model        string
+			duration     int64
+		)
+		if err := rows.Scan(&groupID, &groupName, &upstreamAddr, &model, &duration); err != nil {
+			return nil, fmt.Errorf("failed to scan request duration: %w", err)
+		}
+
+		key := latencyBucketKey{groupID: groupID}
+		if byUpstream {
+			key.upstream = upstreamBase(upstreamAddr)
+		}
+		if byModel {
+			key.model = model
+		}
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &latencyBucket{result: LatencyPercentiles{
+				GroupID:   groupID,
+				GroupName: groupName,
+				Upstream:  key.upstream,
+				Model:     key.model,
+			}}
+			buckets[key] = bucket
+			order = append(order, key)
+		}
+		bucket.add(duration)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read request durations: %w", err)
+	}
+
+	slices.SortFunc(order, func(a, b latencyBucketKey) int {
+		return cmp.Or(
+			cmp.Compare(a.groupID, b.groupID),
+			cmp.Compare(a.upstream, b.upstream),
+			cmp.Compare(a.model, b.model),
+		)
+	})
+
+	result := make([]LatencyPercentiles, 0, len(order))
+	for _, key := range order {
+		result = append(result, buckets[key].finish())
+	}
+	return result, nil
+}
+
+// add records one duration, replacing a random sample once the bucket is full (reservoir sampling).
+func (b *latencyBucket) add(duration int64) {
+	b.result.Count++
+	b.total += duration
+	b.result.MaxMs = max(b.result.MaxMs, duration)
+
+	if len(b.samples) < maxLatencySamples {
+		b.samples = append(b.samples, duration)
+		return
+	}
+	b.result.Sampled = true
+	if i := rand.Int63n(b.result.Count); i < maxLatencySamples {
+		b.samples[i] = duration
+	}
+}
+
+func (b *latencyBucket) finish() LatencyPercentiles {
+	slices.Sort(b.samples)
+	b.result.AvgMs = math.Round(float64(b.total)/float64(b.result.Count)*100) / 100
+	b.result.P50Ms = nearestRank(b.samples, 50)
+	b.result.P95Ms = nearestRank(b.samples, 95)
+	b.result.P99Ms = nearestRank(b.samples, 99)
+	return b.result
+}
+
+// nearestRank returns the p-th percentile of sorted values using the nearest-rank method.
+func nearestRank(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// upstreamBase reduces a logged upstream address to its scheme and host.
+func upstreamBase(addr string) string {
+	parsed, err := url.Parse(addr)
+	if err != nil || parsed.Host == "" {
+		return addr
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
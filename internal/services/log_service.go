@@ -2,11 +2,17 @@ package services
 
 import (
 	"aimanager/internal/encryption"
+	app_errors "aimanager/internal/errors"
 	"aimanager/internal/models"
+	"aimanager/internal/utils"
+	"encoding/base64"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +20,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ndjsonFlushEvery is how many NDJSON lines are written between flushes to the client.
+const ndjsonFlushEvery = 500
+
 // ExportableLogKey defines the structure for the data to be exported to CSV.
 type ExportableLogKey struct {
 	KeyValue   string `gorm:"column:key_value"`
@@ -59,6 +68,9 @@ func (s *LogService) logFiltersScope(c *gin.Context) func(db *gorm.DB) *gorm.DB
 		if requestType := c.Query("request_type"); requestType != "" {
 			db = db.Where("request_type = ?", requestType)
 		}
+		if modelVariant := c.Query("model_variant"); modelVariant != "" {
+			db = db.Where("model_variant = ?", modelVariant)
+		}
 		if statusCodeStr := c.Query("status_code"); statusCodeStr != "" {
 			if statusCode, err := strconv.Atoi(statusCodeStr); err == nil {
 				db = db.Where("status_code = ?", statusCode)
@@ -84,9 +96,56 @@ func (s *LogService) logFiltersScope(c *gin.Context) func(db *gorm.DB) *gorm.DB
 	}
 }
 
-// GetLogsQuery returns a GORM query for fetching logs with filters.
-func (s *LogService) GetLogsQuery(c *gin.Context) *gorm.DB {
-	return s.DB.Model(&models.RequestLog{}).Scopes(s.logFiltersScope(c))
+// ValidateLogFilters rejects malformed start_time and end_time filters. logFiltersScope skips filters it
+// cannot parse, which for a time bound would widen a query, or a clear, to all logs.
+func ValidateLogFilters(c *gin.Context) error {
+	for _, param := range []string{"start_time", "end_time"} {
+		if value := c.Query(param); value != "" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return NewI18nError(app_errors.ErrValidation, "validation.invalid_log_time_filter", map[string]any{"param": param})
+			}
+		}
+	}
+	return nil
+}
+
+// GetLogsQuery returns a GORM query for fetching logs with filters. A non-empty cursor, as returned by
+// EncodeLogCursor, restricts the query to logs after it in (timestamp desc, id desc) order.
+func (s *LogService) GetLogsQuery(c *gin.Context, cursor string) (*gorm.DB, error) {
+	if err := ValidateLogFilters(c); err != nil {
+		return nil, err
+	}
+	query := s.DB.Model(&models.RequestLog{}).Scopes(s.logFiltersScope(c))
+	if cursor == "" {
+		return query, nil
+	}
+
+	timestamp, id, err := decodeLogCursor(cursor)
+	if err != nil {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_cursor", nil)
+	}
+	return query.Where("timestamp < ? OR (timestamp = ? AND id < ?)", timestamp, timestamp, id), nil
+}
+
+// EncodeLogCursor returns the opaque cursor pointing after the given log.
+func EncodeLogCursor(log *models.RequestLog) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(log.Timestamp.Format(time.RFC3339Nano) + "|" + log.ID))
+}
+
+func decodeLogCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	timestampStr, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return time.Time{}, "", fmt.Errorf("malformed log cursor")
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, timestampStr)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return timestamp, id, nil
 }
 
 // StreamLogKeysToCSV fetches unique keys from logs based on filters and streams them as a CSV.
@@ -154,6 +213,54 @@ func (s *LogService) StreamLogKeysToCSV(c *gin.Context, writer io.Writer) error
 	return nil
 }
 
+// StreamLogsToNDJSON streams the logs matching the filters as newline-delimited JSON, one log per
+// line. Rows are read with a cursor so memory use does not grow with the result size. Keys are
+// decrypted, or masked when maskKeys is set.
+func (s *LogService) StreamLogsToNDJSON(c *gin.Context, writer io.Writer, maskKeys bool) error {
+	query, err := s.GetLogsQuery(c, "")
+	if err != nil {
+		return err
+	}
+	rows, err := query.Order("timestamp desc").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	encoder := json.NewEncoder(writer)
+	flusher, _ := writer.(http.Flusher)
+
+	written := 0
+	for rows.Next() {
+		var log models.RequestLog
+		if err := s.DB.ScanRows(rows, &log); err != nil {
+			return fmt.Errorf("failed to scan log row: %w", err)
+		}
+
+		if log.KeyValue != "" {
+			if decrypted, err := s.EncryptionSvc.Decrypt(log.KeyValue); err != nil {
+				logrus.WithError(err).WithField("log_id", log.ID).Error("Failed to decrypt key for NDJSON export")
+				log.KeyValue = "failed-to-decrypt"
+			} else if maskKeys {
+				log.KeyValue = utils.MaskAPIKey(decrypted)
+			} else {
+				log.KeyValue = decrypted
+			}
+		}
+
+		if err := encoder.Encode(&log); err != nil {
+			return fmt.Errorf("failed to write log line: %w", err)
+		}
+
+		written++
+		if flusher != nil && written%ndjsonFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+
+	return rows.Err()
+}
+
 // CountFilteredLogs returns the count of logs matching the filters.
 func (s *LogService) CountFilteredLogs(c *gin.Context) (int64, error) {
 	var count int64
@@ -169,3 +276,72 @@ func (s *LogService) DeleteFilteredLogs(c *gin.Context) (int64, error) {
 	}
 	return result.RowsAffected, nil
 }
+
+// defaultModelUsageWindow is used when no start_time filter is given.
+const defaultModelUsageWindow = 7 * 24 * time.Hour
+
+// ModelUsage holds request counts for a single model within a group.
+type ModelUsage struct {
+	Model        string `json:"model"`
+	RequestCount int64  `json:"request_count"`
+	SuccessCount int64  `json:"success_count"`
+}
+
+// GroupModelUsage lists the distinct models requested through a group.
+type GroupModelUsage struct {
+	GroupID   uint         `json:"group_id"`
+	GroupName string       `json:"group_name"`
+	Models    []ModelUsage `json:"models"`
+}
+
+// GetModelUsageByGroup returns the distinct models seen in request logs per group, with request counts.
+// It accepts the same filters as the log list; without start_time only the last 7 days are considered,
+// and without request_type only final requests are counted so retries do not inflate the numbers.
+func (s *LogService) GetModelUsageByGroup(c *gin.Context) ([]GroupModelUsage, error) {
+	query := s.DB.Model(&models.RequestLog{}).Scopes(s.logFiltersScope(c)).Where("model != ''")
+	if c.Query("start_time") == "" {
+		query = query.Where("timestamp >= ?", time.Now().Add(-defaultModelUsageWindow))
+	}
+	if c.Query("request_type") == "" {
+		query = query.Where("request_type = ?", models.RequestTypeFinal)
+	}
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+		if groupID, err := strconv.Atoi(groupIDStr); err == nil {
+			query = query.Where("group_id = ?", groupID)
+		}
+	}
+
+	var rows []struct {
+		GroupID      uint
+		GroupName    string
+		Model        string
+		RequestCount int64
+		SuccessCount int64
+	}
+	err := query.
+		Select("group_id, MAX(group_name) as group_name, model, COUNT(*) as request_count, SUM(CASE WHEN is_success THEN 1 ELSE 0 END) as success_count").
+		Group("group_id, model").
+		Order("group_id asc, request_count desc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query model usage: %w", err)
+	}
+
+	result := make([]GroupModelUsage, 0)
+	indexByGroup := make(map[uint]int)
+	for _, row := range rows {
+		idx, ok := indexByGroup[row.GroupID]
+		if !ok {
+			result = append(result, GroupModelUsage{GroupID: row.GroupID, GroupName: row.GroupName})
+			idx = len(result) - 1
+			indexByGroup[row.GroupID] = idx
+		}
+		result[idx].Models = append(result[idx].Models, ModelUsage{
+			Model:        row.Model,
+			RequestCount: row.RequestCount,
+			SuccessCount: row.SuccessCount,
+		})
+	}
+
+	return result, nil
+}
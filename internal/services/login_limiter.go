@@ -2,6 +2,7 @@
 package services
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -10,78 +11,239 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// LoginLimiter manages global login attempt limiting to prevent brute force attacks
+// maxTrackedLoginIPs bounds how many source IPs with failed logins are remembered. When it is reached,
+// expired entries are dropped first, then the IP whose last failure is oldest.
+const maxTrackedLoginIPs = 10000
+
+// loginAttempts is the failed login state of one source IP.
+type loginAttempts struct {
+	failedAttempts int
+	lockoutUntil   time.Time
+	lastFailure    time.Time
+}
+
+// LoginLockout describes the failed login state of one source IP.
+type LoginLockout struct {
+	IP             string     `json:"ip"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockoutUntil   *time.Time `json:"lockout_until,omitempty"`
+}
+
+// LoginLimiterStatus is the state of the login limiter. The global counters are only used when
+// LOGIN_GLOBAL_MAX_FAILED_ATTEMPTS is above 0.
+type LoginLimiterStatus struct {
+	GlobalFailedAttempts int            `json:"global_failed_attempts"`
+	GlobalLockoutUntil   *time.Time     `json:"global_lockout_until,omitempty"`
+	IPs                  []LoginLockout `json:"ips"`
+}
+
+// LoginLimiter limits failed login attempts per source IP to prevent brute force attacks, so that one
+// client cannot lock out the others. A global cap also locks all logins after too many failures across
+// all IPs within LOGIN_GLOBAL_FAILURE_WINDOW, which catches attacks spread over many IPs.
 type LoginLimiter struct {
-	configManager      types.ConfigManager
-	failedAttempts     int
-	lockoutUntil       time.Time
-	mutex              sync.RWMutex
+	configManager        types.ConfigManager
+	attempts             map[string]*loginAttempts
+	globalFailedAttempts int
+	globalWindowStart    time.Time
+	globalLockoutUntil   time.Time
+	mutex                sync.RWMutex
 }
 
 // NewLoginLimiter creates a new login limiter
 func NewLoginLimiter(configManager types.ConfigManager) *LoginLimiter {
 	return &LoginLimiter{
 		configManager: configManager,
+		attempts:      make(map[string]*loginAttempts),
 	}
 }
 
-// CheckLogin checks if login is allowed and returns remaining lockout time if locked
-func (ll *LoginLimiter) CheckLogin() (bool, time.Duration) {
+// CheckLogin checks if login is allowed from the IP and returns remaining lockout time if locked
+func (ll *LoginLimiter) CheckLogin(ip string) (bool, time.Duration) {
 	ll.mutex.RLock()
 	defer ll.mutex.RUnlock()
 
-	if ll.lockoutUntil.After(time.Now()) {
-		remaining := time.Until(ll.lockoutUntil)
-		return false, remaining
+	now := time.Now()
+	lockoutUntil := ll.globalLockoutUntil
+	if entry, ok := ll.attempts[ip]; ok && entry.lockoutUntil.After(lockoutUntil) {
+		lockoutUntil = entry.lockoutUntil
+	}
+	if lockoutUntil.After(now) {
+		return false, lockoutUntil.Sub(now)
 	}
 
 	return true, 0
 }
 
-// RecordSuccess records a successful login and resets the failed attempt counter
-func (ll *LoginLimiter) RecordSuccess() {
+// RecordSuccess records a successful login and resets the failed attempt counters of the IP and the
+// global cap
+func (ll *LoginLimiter) RecordSuccess(ip string) {
 	ll.mutex.Lock()
 	defer ll.mutex.Unlock()
 
-	ll.failedAttempts = 0
-	ll.lockoutUntil = time.Time{}
-	logrus.Debug("Login successful, failed attempts counter reset")
+	delete(ll.attempts, ip)
+	ll.globalFailedAttempts = 0
+	ll.globalLockoutUntil = time.Time{}
+	logrus.Debugf("Login successful from %s, failed attempts counter reset", ip)
 }
 
-// RecordFailure records a failed login attempt and locks if threshold reached
-func (ll *LoginLimiter) RecordFailure() (bool, time.Duration) {
+// RecordFailure records a failed login attempt from the IP and locks if a threshold is reached
+func (ll *LoginLimiter) RecordFailure(ip string) (bool, time.Duration) {
 	ll.mutex.Lock()
 	defer ll.mutex.Unlock()
 
 	authConfig := ll.configManager.GetAuthConfig()
-	ll.failedAttempts++
-	logrus.Debugf("Login failed, attempt count: %d/%d", ll.failedAttempts, authConfig.MaxFailedAttempts)
-
-	// Check if threshold reached
-	if ll.failedAttempts >= authConfig.MaxFailedAttempts {
-		ll.lockoutUntil = time.Now().Add(time.Duration(authConfig.LockoutDuration) * time.Second)
-		duration := time.Duration(authConfig.LockoutDuration) * time.Second
-		logrus.Warnf("Login locked due to %d failed attempts. Locked for %v", ll.failedAttempts, duration)
-		return true, duration
+	duration := time.Duration(authConfig.LockoutDuration) * time.Second
+	now := time.Now()
+
+	entry, ok := ll.attempts[ip]
+	if !ok {
+		if len(ll.attempts) >= maxTrackedLoginIPs {
+			ll.evict(now, duration)
+		}
+		entry = &loginAttempts{}
+		ll.attempts[ip] = entry
+	}
+	entry.failedAttempts++
+	entry.lastFailure = now
+	logrus.Debugf("Login failed from %s, attempt count: %d/%d", ip, entry.failedAttempts, authConfig.MaxFailedAttempts)
+
+	locked := false
+	if entry.failedAttempts >= authConfig.MaxFailedAttempts {
+		entry.lockoutUntil = now.Add(duration)
+		locked = true
+		logrus.Warnf("Login locked for %s due to %d failed attempts. Locked for %v", ip, entry.failedAttempts, duration)
+	}
+
+	if authConfig.GlobalMaxFailedAttempts > 0 {
+		// Failures are counted in fixed windows, so scattered typos never add up to a lockout
+		window := time.Duration(authConfig.GlobalFailureWindow) * time.Second
+		if window > 0 && now.Sub(ll.globalWindowStart) > window {
+			ll.globalFailedAttempts = 0
+			ll.globalWindowStart = now
+		}
+		ll.globalFailedAttempts++
+		if ll.globalFailedAttempts >= authConfig.GlobalMaxFailedAttempts {
+			ll.globalLockoutUntil = now.Add(duration)
+			locked = true
+			logrus.Warnf("All logins locked due to %d failed attempts across all IPs. Locked for %v", ll.globalFailedAttempts, duration)
+		}
 	}
 
+	if locked {
+		return true, duration
+	}
 	return false, 0
 }
 
-// Reset clears the failed attempts counter (for admin use)
-func (ll *LoginLimiter) Reset() {
+// evict makes room for a new IP, dropping the entries that are no longer locked and whose last failure
+// is older than the lockout duration, or else the unlocked entry with the oldest failure. Locked entries
+// are only dropped when every entry is locked.
+func (ll *LoginLimiter) evict(now time.Time, lockoutDuration time.Duration) {
+	var oldestIP, oldestUnlockedIP string
+	for ip, entry := range ll.attempts {
+		locked := entry.lockoutUntil.After(now)
+		if !locked && now.Sub(entry.lastFailure) > lockoutDuration {
+			delete(ll.attempts, ip)
+			continue
+		}
+		if oldestIP == "" || entry.lastFailure.Before(ll.attempts[oldestIP].lastFailure) {
+			oldestIP = ip
+		}
+		if !locked && (oldestUnlockedIP == "" || entry.lastFailure.Before(ll.attempts[oldestUnlockedIP].lastFailure)) {
+			oldestUnlockedIP = ip
+		}
+	}
+	if len(ll.attempts) < maxTrackedLoginIPs {
+		return
+	}
+	if oldestUnlockedIP != "" {
+		delete(ll.attempts, oldestUnlockedIP)
+	} else {
+		delete(ll.attempts, oldestIP)
+	}
+}
+
+// Reset clears all failed attempt counters (for admin use) and returns the state before the reset
+func (ll *LoginLimiter) Reset() LoginLimiterStatus {
 	ll.mutex.Lock()
 	defer ll.mutex.Unlock()
 
-	ll.failedAttempts = 0
-	ll.lockoutUntil = time.Time{}
+	previous := ll.status()
+	ll.attempts = make(map[string]*loginAttempts)
+	ll.globalFailedAttempts = 0
+	ll.globalLockoutUntil = time.Time{}
 	logrus.Info("Login limiter reset by admin")
+	return previous
 }
 
-// GetStatus returns current failed attempts and lockout status
-func (ll *LoginLimiter) GetStatus() (int, time.Time) {
+// ResetIP clears the failed attempts and lockout of one IP (for admin use) and returns its state before
+// the reset. The global cap is cleared too, since while it is locked the IP could still not log in. It
+// reports whether there was anything to reset: the IP was tracked or all logins were locked.
+func (ll *LoginLimiter) ResetIP(ip string) (LoginLockout, bool) {
+	ll.mutex.Lock()
+	defer ll.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := ll.attempts[ip]
+	if !ok && !ll.globalLockoutUntil.After(now) {
+		return LoginLockout{}, false
+	}
+	previous := LoginLockout{IP: ip}
+	if ok {
+		previous = newLoginLockout(ip, entry, now)
+		delete(ll.attempts, ip)
+	}
+	ll.globalFailedAttempts = 0
+	ll.globalLockoutUntil = time.Time{}
+	logrus.Infof("Login lockout of %s and the global lockout reset by admin", ip)
+	return previous, true
+}
+
+// GetStatus returns the global failed attempts and lockout, and the failed attempts and lockout of each
+// tracked IP, locked IPs first
+func (ll *LoginLimiter) GetStatus() LoginLimiterStatus {
 	ll.mutex.RLock()
 	defer ll.mutex.RUnlock()
 
-	return ll.failedAttempts, ll.lockoutUntil
+	return ll.status()
+}
+
+func (ll *LoginLimiter) status() LoginLimiterStatus {
+	now := time.Now()
+	status := LoginLimiterStatus{
+		GlobalFailedAttempts: ll.globalFailedAttempts,
+		IPs:                  make([]LoginLockout, 0, len(ll.attempts)),
+	}
+	// Failures of a past window no longer count towards the global cap
+	window := time.Duration(ll.configManager.GetAuthConfig().GlobalFailureWindow) * time.Second
+	if window > 0 && now.Sub(ll.globalWindowStart) > window {
+		status.GlobalFailedAttempts = 0
+	}
+	if ll.globalLockoutUntil.After(now) {
+		until := ll.globalLockoutUntil
+		status.GlobalLockoutUntil = &until
+	}
+	for ip, entry := range ll.attempts {
+		status.IPs = append(status.IPs, newLoginLockout(ip, entry, now))
+	}
+	sort.Slice(status.IPs, func(i, j int) bool {
+		a, b := status.IPs[i], status.IPs[j]
+		if (a.LockoutUntil != nil) != (b.LockoutUntil != nil) {
+			return a.LockoutUntil != nil
+		}
+		if a.FailedAttempts != b.FailedAttempts {
+			return a.FailedAttempts > b.FailedAttempts
+		}
+		return a.IP < b.IP
+	})
+	return status
+}
+
+func newLoginLockout(ip string, entry *loginAttempts, now time.Time) LoginLockout {
+	lockout := LoginLockout{IP: ip, FailedAttempts: entry.failedAttempts}
+	if entry.lockoutUntil.After(now) {
+		until := entry.lockoutUntil
+		lockout.LockoutUntil = &until
+	}
+	return lockout
 }
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// modelRedirectUpdateAttempts is how often a single-rule edit is retried when another request
+// changed the group between reading and writing it.
+const modelRedirectUpdateAttempts = 3
+
+// AddModelRedirect creates or replaces the redirect rule of one source model, leaving every other rule untouched.
+// When expectedTarget is set, the edit only applies if the rule's current target still matches it, with ""
+// meaning the rule must not exist yet; otherwise ErrEditConflict is returned.
+func (s *GroupService) AddModelRedirect(ctx context.Context, groupID uint, source, target string, expectedTarget *string) (map[string]string, error) {
+	source = strings.TrimSpace(source)
+	target = strings.TrimSpace(target)
+	return s.updateModelRedirectRule(ctx, groupID, source, expectedTarget, func(rules map[string]string) error {
+		rules[source] = target
+		return nil
+	})
+}
+
+// RemoveModelRedirect deletes the redirect rule of one source model, leaving every other rule untouched.
+// expectedTarget works as in AddModelRedirect.
+func (s *GroupService) RemoveModelRedirect(ctx context.Context, groupID uint, source string, expectedTarget *string) (map[string]string, error) {
+	source = strings.TrimSpace(source)
+	return s.updateModelRedirectRule(ctx, groupID, source, expectedTarget, func(rules map[string]string) error {
+		if _, exists := rules[source]; !exists {
+			return NewI18nError(app_errors.ErrResourceNotFound, "validation.model_redirect_not_found", map[string]any{"model": source})
+		}
+		delete(rules, source)
+		return nil
+	})
+}
+
+// updateModelRedirectRule applies mutate to the group's current rules and stores the result. The write only
+// succeeds if the group was not updated since it was read, so concurrent edits of other rules are never lost;
+// in that case the edit is re-applied to the fresh rules.
+func (s *GroupService) updateModelRedirectRule(ctx context.Context, groupID uint, source string, expectedTarget *string, mutate func(rules map[string]string) error) (map[string]string, error) {
+	if source == "" {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_model_redirect", map[string]any{"error": "model name cannot be empty"})
+	}
+
+	for attempt := 0; attempt < modelRedirectUpdateAttempts; attempt++ {
+		var group models.Group
+		if err := s.db.WithContext(ctx).Select("id", "group_type", "model_redirect_rules", "updated_at").First(&group, groupID).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		if group.GroupType == "aggregate" {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.aggregate_no_model_redirect", nil)
+		}
+
+		rules := make(map[string]string, len(group.ModelRedirectRules)+1)
+		for from, to := range group.ModelRedirectRules {
+			if toStr, ok := to.(string); ok {
+				rules[from] = toStr
+			}
+		}
+
+		if expectedTarget != nil && rules[source] != strings.TrimSpace(*expectedTarget) {
+			return nil, NewI18nError(app_errors.ErrEditConflict, "validation.model_redirect_conflict", map[string]any{"model": source, "current": rules[source]})
+		}
+
+		if err := mutate(rules); err != nil {
+			return nil, err
+		}
+		if err := validateModelRedirectRules(rules); err != nil {
+			return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_model_redirect", map[string]any{"error": err.Error()})
+		}
+
+		res := s.db.WithContext(ctx).Model(&models.Group{}).
+			Where("id = ? AND updated_at = ?", group.ID, group.UpdatedAt).
+			Updates(map[string]any{
+				"model_redirect_rules": convertToJSONMap(rules),
+				"updated_at":           time.Now(),
+			})
+		if res.Error != nil {
+			return nil, app_errors.ParseDBError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		if err := s.groupManager.Invalidate(); err != nil {
+			logrus.WithContext(ctx).WithError(err).Error("failed to invalidate group cache")
+		}
+		return rules, nil
+	}
+
+	return nil, NewI18nError(app_errors.ErrEditConflict, "validation.model_redirect_busy", nil)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MaxOrphanedKeysListed caps the number of orphaned keys listed in one report; the counts cover all of them.
+const MaxOrphanedKeysListed = 500
+
+// OrphanedKey is a key whose group no longer exists. The key value is always masked.
+type OrphanedKey struct {
+	KeyID     uint      `json:"key_id"`
+	GroupID   uint      `json:"group_id"`
+	MaskedKey string    `json:"masked_key"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrphanedKeysReport summarizes keys referencing non-existent groups.
+type OrphanedKeysReport struct {
+	Total          int64          `json:"total"`
+	CountByGroupID map[uint]int64 `json:"count_by_group_id"`
+	Keys           []OrphanedKey  `json:"keys"`
+	Truncated      bool           `json:"truncated"`
+}
+
+// FindOrphanedKeys reports keys whose group_id has no matching group, as left behind by manual
+// database edits or failed migrations.
+func (s *KeyService) FindOrphanedKeys() (*OrphanedKeysReport, error) {
+	// Keys of soft-deleted groups are kept for a restore and are not orphaned
+	orphaned := s.DB.Model(&models.APIKey{}).Where("group_id NOT IN (?)", s.DB.Unscoped().Model(&models.Group{}).Select("id"))
+
+	var counts []struct {
+		GroupID uint
+		Count   int64
+	}
+	if err := orphaned.Session(&gorm.Session{}).Select("group_id, COUNT(*) as count").Group("group_id").Scan(&counts).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	report := &OrphanedKeysReport{
+		CountByGroupID: make(map[uint]int64, len(counts)),
+		Keys:           make([]OrphanedKey, 0),
+	}
+	for _, row := range counts {
+		report.CountByGroupID[row.GroupID] = row.Count
+		report.Total += row.Count
+	}
+	if report.Total == 0 {
+		return report, nil
+	}
+
+	var keys []models.APIKey
+	if err := orphaned.Session(&gorm.Session{}).
+		Select("id", "key_value", "group_id", "status", "created_at").
+		Order("id asc").Limit(MaxOrphanedKeysListed).
+		Find(&keys).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	report.Truncated = report.Total > int64(len(keys))
+
+	for _, key := range keys {
+		masked := "failed-to-decrypt"
+		if keyValue, err := s.EncryptionSvc.Decrypt(key.KeyValue); err == nil {
+			masked = utils.MaskAPIKey(keyValue)
+		} else {
+			logrus.WithError(err).WithField("key_id", key.ID).Warn("Failed to decrypt orphaned key")
+		}
+		report.Keys = append(report.Keys, OrphanedKey{
+			KeyID:     key.ID,
+			GroupID:   key.GroupID,
+			MaskedKey: masked,
+			Status:    key.Status,
+			CreatedAt: key.CreatedAt,
+		})
+	}
+
+	return report, nil
+}
+
+// RemoveOrphanedKeys deletes keys referencing non-existent groups from the database and the key pool.
+func (s *KeyService) RemoveOrphanedKeys() (int64, error) {
+	return s.KeyProvider.RemoveOrphanedKeys()
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+	"aimanager/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestDiagnosisWindow is how far from the given time a request is searched when it is looked up by
+// time and proxy key, since clients rarely know the exact second.
+const RequestDiagnosisWindow = 2 * time.Minute
+
+// maxRequestDiagnosisCandidates caps the logs considered in a lookup by time and proxy key.
+const maxRequestDiagnosisCandidates = 100
+
+// Failure reasons of a diagnosed request, derived from its final attempt.
+const (
+	RequestFailureClientCancelled  = "client_cancelled"
+	RequestFailureNoKeys           = "no_keys"
+	RequestFailureResponseTooLarge = "response_too_large"
+	RequestFailureStreamBroken     = "stream_interrupted"
+	RequestFailureBadRequest       = "bad_request"
+	RequestFailureUpstreamAuth     = "upstream_auth"
+	RequestFailureUpstreamLimited  = "upstream_rate_limited"
+	RequestFailureUpstreamError    = "upstream_error"
+	RequestFailureOther            = "failed"
+)
+
+// RequestAttempt is one logged attempt of a proxied request. The key is always masked.
+type RequestAttempt struct {
+	LogID           string    `json:"log_id"`
+	Timestamp       time.Time `json:"timestamp"`
+	RequestType     string    `json:"request_type"`
+	GroupID         uint      `json:"group_id"`
+	GroupName       string    `json:"group_name"`
+	ParentGroupName string    `json:"parent_group_name,omitempty"`
+	Upstream        string    `json:"upstream"`
+	MaskedKey       string    `json:"masked_key"`
+	Model           string    `json:"model"`
+	ModelVariant    string    `json:"model_variant,omitempty"`
+	StatusCode      int       `json:"status_code"`
+	IsSuccess       bool      `json:"is_success"`
+	Duration        int64     `json:"duration_ms"`
+	ErrorMessage    string    `json:"error_message,omitempty"`
+}
+
+// RequestDiagnosis explains the outcome of a single proxied request from its request logs and the
+// current state of the group and key involved.
+type RequestDiagnosis struct {
+	RequestID   string           `json:"request_id,omitempty"`
+	Final       RequestAttempt   `json:"final"`
+	Attempts    []RequestAttempt `json:"attempts"`
+	Retries     int              `json:"retries"`
+	Hedged      bool             `json:"hedged"`
+	Reason      string           `json:"reason,omitempty"`
+	KeyStatus   string           `json:"key_status,omitempty"`
+	GroupPaused bool             `json:"group_paused"`
+	// OtherMatches counts further requests found in a lookup by time and proxy key
+	OtherMatches int `json:"other_matches,omitempty"`
+}
+
+// DiagnoseRequestByID looks a request up by the request ID returned to the client in X-Request-Id,
+// or by the ID of any of its request logs.
+func (s *LogService) DiagnoseRequestByID(id string) (*RequestDiagnosis, error) {
+	var logs []models.RequestLog
+	if err := s.DB.Where("request_id = ?", id).Order("timestamp asc").Find(&logs).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+
+	if len(logs) == 0 {
+		var log models.RequestLog
+		if err := s.DB.Where("id = ?", id).Limit(1).Find(&log).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+		if log.ID == "" {
+			return nil, NewI18nError(app_errors.ErrResourceNotFound, "log.request_not_found", nil)
+		}
+		logs = []models.RequestLog{log}
+		if log.RequestID != "" {
+			if err := s.DB.Where("request_id = ?", log.RequestID).Order("timestamp asc").Find(&logs).Error; err != nil {
+				return nil, app_errors.ParseDBError(err)
+			}
+		}
+	}
+
+	return s.buildRequestDiagnosis(logs), nil
+}
+
+// DiagnoseRequestAt looks up the request sent with the proxy key closest to the given time, within
+// RequestDiagnosisWindow. Failed requests are preferred, since those are the ones support is asked about.
+func (s *LogService) DiagnoseRequestAt(at time.Time, proxyKey string) (*RequestDiagnosis, error) {
+	var candidates []models.RequestLog
+	if err := s.DB.
+		Where("proxy_key_hash = ? AND timestamp BETWEEN ? AND ?", s.EncryptionSvc.Hash(proxyKey), at.Add(-RequestDiagnosisWindow), at.Add(RequestDiagnosisWindow)).
+		Where("request_type = ?", models.RequestTypeFinal).
+		Order("timestamp asc").
+		Limit(maxRequestDiagnosisCandidates).
+		Find(&candidates).Error; err != nil {
+		return nil, app_errors.ParseDBError(err)
+	}
+	if len(candidates) == 0 {
+		return nil, NewI18nError(app_errors.ErrResourceNotFound, "log.request_not_found", nil)
+	}
+
+	distance := func(log models.RequestLog) time.Duration {
+		return max(log.Timestamp.Sub(at), at.Sub(log.Timestamp))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].IsSuccess != candidates[j].IsSuccess {
+			return !candidates[i].IsSuccess
+		}
+		return distance(candidates[i]) < distance(candidates[j])
+	})
+
+	final := candidates[0]
+	logs := []models.RequestLog{final}
+	if final.RequestID != "" {
+		if err := s.DB.Where("request_id = ?", final.RequestID).Order("timestamp asc").Find(&logs).Error; err != nil {
+			return nil, app_errors.ParseDBError(err)
+		}
+	}
+
+	diagnosis := s.buildRequestDiagnosis(logs)
+	diagnosis.OtherMatches = len(candidates) - 1
+	return diagnosis, nil
+}
+
+// buildRequestDiagnosis assembles the diagnosis from the logs of one request, ordered by time.
+func (s *LogService) buildRequestDiagnosis(logs []models.RequestLog) *RequestDiagnosis {
+	diagnosis := &RequestDiagnosis{
+		RequestID: logs[0].RequestID,
+		Attempts:  make([]RequestAttempt, 0, len(logs)),
+	}
+
+	finalIndex := len(logs) - 1
+	for i, log := range logs {
+		diagnosis.Attempts = append(diagnosis.Attempts, s.newRequestAttempt(&log))
+		switch log.RequestType {
+		case models.RequestTypeRetry:
+			diagnosis.Retries++
+		case models.RequestTypeHedge:
+			diagnosis.Hedged = true
+		case models.RequestTypeFinal:
+			finalIndex = i
+		}
+	}
+	diagnosis.Final = diagnosis.Attempts[finalIndex]
+
+	final := logs[finalIndex]
+	if !final.IsSuccess {
+		diagnosis.Reason = classifyRequestFailure(&final)
+	}
+
+	if final.KeyHash != "" {
+		var key models.APIKey
+		if err := s.DB.Select("status").Where("key_hash = ? AND group_id = ?", final.KeyHash, final.GroupID).Limit(1).Find(&key).Error; err != nil {
+			logrus.WithError(err).Warn("Failed to load key status for request diagnosis")
+		}
+		diagnosis.KeyStatus = key.Status
+	}
+
+	var group models.Group
+	if err := s.DB.Select("auto_paused_at").Where("id = ?", final.GroupID).Limit(1).Find(&group).Error; err != nil {
+		logrus.WithError(err).Warn("Failed to load group state for request diagnosis")
+	}
+	diagnosis.GroupPaused = group.AutoPausedAt != nil
+
+	return diagnosis
+}
+
+func (s *LogService) newRequestAttempt(log *models.RequestLog) RequestAttempt {
+	maskedKey := ""
+	if log.KeyValue != "" {
+		if keyValue, err := s.EncryptionSvc.Decrypt(log.KeyValue); err == nil {
+			maskedKey = utils.MaskAPIKey(keyValue)
+		} else {
+			maskedKey = "failed-to-decrypt"
+		}
+	}
+
+	return RequestAttempt{
+		LogID:           log.ID,
+		Timestamp:       log.Timestamp,
+		RequestType:     log.RequestType,
+		GroupID:         log.GroupID,
+		GroupName:       log.GroupName,
+		ParentGroupName: log.ParentGroupName,
+		Upstream:        log.UpstreamAddr,
+		MaskedKey:       maskedKey,
+		Model:           log.Model,
+		ModelVariant:    log.ModelVariant,
+		StatusCode:      log.StatusCode,
+		IsSuccess:       log.IsSuccess,
+		Duration:        log.Duration,
+		ErrorMessage:    log.ErrorMessage,
+	}
+}
+
+// classifyRequestFailure derives why a failed request failed from its final log.
+func classifyRequestFailure(log *models.RequestLog) string {
+	switch {
+	case log.StatusCode == 499:
+		return RequestFailureClientCancelled
+	case log.KeyHash == "" && log.StatusCode == http.StatusServiceUnavailable:
+		return RequestFailureNoKeys
+	case strings.Contains(log.ErrorMessage, "max_response_size_kb"), strings.Contains(log.ErrorMessage, "max_response_body_bytes"):
+		return RequestFailureResponseTooLarge
+	case log.FailureStage == models.FailureStageMidStream:
+		return RequestFailureStreamBroken
+	case log.StatusCode == http.StatusBadRequest:
+		return RequestFailureBadRequest
+	case log.StatusCode == http.StatusUnauthorized || log.StatusCode == http.StatusForbidden:
+		return RequestFailureUpstreamAuth
+	case log.StatusCode == http.StatusTooManyRequests:
+		return RequestFailureUpstreamLimited
+	case log.StatusCode >= 500:
+		return RequestFailureUpstreamError
+	default:
+		return RequestFailureOther
+	}
+}
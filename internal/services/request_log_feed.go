@@ -0,0 +1,76 @@
+package services
+
+import (
+	"aimanager/internal/models"
+	"sync"
+)
+
+// requestLogFeedBufferSize is the per-subscriber buffer. Slow subscribers drop events
+// instead of blocking the request path.
+const requestLogFeedBufferSize = 64
+
+type requestLogSubscriber struct {
+	groupID uint
+	ch      chan models.RequestLog
+}
+
+// RequestLogFeed is an in-process pub/sub that fans out recorded request logs to live subscribers.
+// It only sees requests handled by the current instance.
+type RequestLogFeed struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[uint64]*requestLogSubscriber
+}
+
+// NewRequestLogFeed creates a new RequestLogFeed.
+func NewRequestLogFeed() *RequestLogFeed {
+	return &RequestLogFeed{
+		subscribers: make(map[uint64]*requestLogSubscriber),
+	}
+}
+
+// Subscribe registers a subscriber for logs of the given group (matched against both the
+// group and its parent aggregate group). The returned function must be called to unsubscribe.
+func (f *RequestLogFeed) Subscribe(groupID uint) (<-chan models.RequestLog, func()) {
+	sub := &requestLogSubscriber{
+		groupID: groupID,
+		ch:      make(chan models.RequestLog, requestLogFeedBufferSize),
+	}
+
+	f.mu.Lock()
+	f.nextID++
+	id := f.nextID
+	f.subscribers[id] = sub
+	f.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			f.mu.Lock()
+			delete(f.subscribers, id)
+			f.mu.Unlock()
+		})
+	}
+
+	return sub.ch, unsubscribe
+}
+
+// Publish delivers a copy of the log to all matching subscribers without blocking.
+func (f *RequestLogFeed) Publish(log *models.RequestLog) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if len(f.subscribers) == 0 {
+		return
+	}
+
+	for _, sub := range f.subscribers {
+		if sub.groupID != log.GroupID && sub.groupID != log.ParentGroupID {
+			continue
+		}
+		select {
+		case sub.ch <- *log:
+		default:
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,32 +24,169 @@ const (
 	DefaultLogFlushBatchSize = 200
 )
 
+// Request log backpressure policies, applied when the log buffer is full.
+const (
+	LogBackpressureDrop    = "drop"
+	LogBackpressureBlock   = "block"
+	LogBackpressureDisable = "disable"
+
+	// detailedLoggingPause is how long detailed logging stays off after an overflow under the disable policy.
+	detailedLoggingPause = time.Minute
+)
+
+// RequestLogBackpressureStats reports how the backpressure policy has affected request logging since startup.
+// Every node buffers its own logs, so the counts cover the node that serves the request only.
+type RequestLogBackpressureStats struct {
+	Policy          string     `json:"policy"`
+	BufferSize      int        `json:"buffer_size"`
+	BufferedLogs    int        `json:"buffered_logs"`
+	DroppedLogs     uint64     `json:"dropped_logs"`
+	SkippedLogs     uint64     `json:"skipped_logs"`
+	DisabledPeriods uint64     `json:"disabled_periods"`
+	DisabledUntil   *time.Time `json:"disabled_until,omitempty"`
+}
+
+type hourlyStatKey struct {
+	Time    time.Time
+	GroupID uint
+}
+
+type hourlyStatCounts struct {
+	Success, Failure, MidStreamFailure int64
+}
+
+// logStatCounters holds the aggregated counters derived from request logs.
+type logStatCounters struct {
+	keys     map[string]int64
+	lastUsed map[string]time.Time
+	hourly   map[hourlyStatKey]hourlyStatCounts
+}
+
+func newLogStatCounters() *logStatCounters {
+	return &logStatCounters{
+		keys:     make(map[string]int64),
+		lastUsed: make(map[string]time.Time),
+		hourly:   make(map[hourlyStatKey]hourlyStatCounts),
+	}
+}
+
+func (c *logStatCounters) add(log *models.RequestLog) {
+	if log.KeyHash != "" {
+		if log.IsSuccess {
+			c.keys[log.KeyHash]++
+		}
+		if log.Timestamp.After(c.lastUsed[log.KeyHash]) {
+			c.lastUsed[log.KeyHash] = log.Timestamp
+		}
+	}
+
+	if log.RequestType == models.RequestTypeRetry {
+		return
+	}
+	hourlyTime := log.Timestamp.Truncate(time.Hour)
+	c.addHourly(hourlyStatKey{Time: hourlyTime, GroupID: log.GroupID}, log)
+	if log.ParentGroupID > 0 {
+		c.addHourly(hourlyStatKey{Time: hourlyTime, GroupID: log.ParentGroupID}, log)
+	}
+}
+
+func (c *logStatCounters) addHourly(key hourlyStatKey, log *models.RequestLog) {
+	counts := c.hourly[key]
+	if log.IsSuccess {
+		counts.Success++
+	} else {
+		counts.Failure++
+		if log.FailureStage == models.FailureStageMidStream {
+			counts.MidStreamFailure++
+		}
+	}
+	c.hourly[key] = counts
+}
+
+func (c *logStatCounters) empty() bool {
+	return len(c.lastUsed) == 0 && len(c.hourly) == 0
+}
+
 // RequestLogService is responsible for managing request logs.
 type RequestLogService struct {
 	db              *gorm.DB
 	store           store.Store
 	settingsManager *config.SystemSettingsManager
+	feed            *RequestLogFeed
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
 	ticker          *time.Ticker
+	isMaster        bool
+
+	buffer          chan *models.RequestLog
+	droppedLogs     atomic.Uint64
+	skippedLogs     atomic.Uint64
+	disabledPeriods atomic.Uint64
+	disabledUntil   atomic.Int64
+	reportedDropped uint64
+	reportedSkipped uint64
+
+	// pendingStats collects stat counters of logs skipped while detailed logging is disabled,
+	// and of requests of groups that opted out of request logging
+	pendingStatsMu sync.Mutex
+	pendingStats   *logStatCounters
 }
 
 // NewRequestLogService creates a new RequestLogService instance
-func NewRequestLogService(db *gorm.DB, store store.Store, sm *config.SystemSettingsManager) *RequestLogService {
+func NewRequestLogService(db *gorm.DB, store store.Store, sm *config.SystemSettingsManager, feed *RequestLogFeed) *RequestLogService {
 	return &RequestLogService{
 		db:              db,
 		store:           store,
 		settingsManager: sm,
+		feed:            feed,
 		stopChan:        make(chan struct{}),
+		pendingStats:    newLogStatCounters(),
 	}
 }
 
-// Start initializes the service and starts the periodic flush routine
-func (s *RequestLogService) Start() {
-	s.wg.Add(1)
+// Start initializes the service and starts the buffered writer and the periodic flush routine. It runs
+// on every node so the backpressure policy applies everywhere; only the master moves the logs cached
+// in the store into the database.
+func (s *RequestLogService) Start(isMaster bool) {
+	s.isMaster = isMaster
+	bufferSize := s.settingsManager.GetSettings().RequestLogBufferSize
+	if bufferSize <= 0 {
+		bufferSize = 5000
+	}
+	s.buffer = make(chan *models.RequestLog, bufferSize)
+
+	s.wg.Add(2)
+	go s.runWriter()
 	go s.runLoop()
 }
 
+// runWriter persists buffered logs off the proxy path, draining the buffer on stop.
+func (s *RequestLogService) runWriter() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case log := <-s.buffer:
+			s.persistLog(log)
+		case <-s.stopChan:
+			for {
+				select {
+				case log := <-s.buffer:
+					s.persistLog(log)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *RequestLogService) persistLog(log *models.RequestLog) {
+	if err := s.persist(log); err != nil {
+		logrus.Errorf("Failed to persist request log: %v", err)
+	}
+}
+
 func (s *RequestLogService) runLoop() {
 	defer s.wg.Done()
 
@@ -75,6 +213,7 @@ func (s *RequestLogService) runLoop() {
 				logrus.Debugf("Request log write interval updated to: %v", interval)
 			}
 			s.flush()
+			s.reportBackpressure()
 		case <-s.stopChan:
 			return
 		}
@@ -100,11 +239,134 @@ func (s *RequestLogService) Stop(ctx context.Context) {
 	}
 }
 
-// Record logs a request to the database and cache
+// Record queues a request log for writing. It never blocks longer than the block policy allows;
+// when the buffer is full the configured backpressure policy decides what happens to the log.
 func (s *RequestLogService) Record(log *models.RequestLog) error {
 	log.ID = uuid.NewString()
 	log.Timestamp = time.Now()
 
+	s.feed.Publish(log)
+
+	if s.buffer == nil {
+		return s.persist(log)
+	}
+
+	if s.isDetailedLoggingPaused() {
+		s.skipLog(log)
+		return nil
+	}
+
+	select {
+	case s.buffer <- log:
+		return nil
+	default:
+	}
+
+	settings := s.settingsManager.GetSettings()
+	switch settings.RequestLogBackpressurePolicy {
+	case LogBackpressureBlock:
+		timer := time.NewTimer(time.Duration(settings.RequestLogBlockTimeoutMs) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case s.buffer <- log:
+			return nil
+		case <-timer.C:
+			s.dropLog(log)
+		}
+	case LogBackpressureDisable:
+		s.pauseDetailedLogging()
+		s.skipLog(log)
+	default:
+		s.dropLog(log)
+	}
+
+	return nil
+}
+
+// RecordStatsOnly counts a request towards the key and group hourly stats without storing a
+// request log, for groups that opted out of request logging. The log is not published to the live
+// tail either, so it only needs the fields the counters read. The counts are written with the
+// periodic flush, which runs on every node, so no database write happens on the proxy path.
+func (s *RequestLogService) RecordStatsOnly(log *models.RequestLog) error {
+	log.Timestamp = time.Now()
+	s.addPendingStats(log)
+	return nil
+}
+
+// isDetailedLoggingPaused reports whether the disable policy is currently in effect.
+func (s *RequestLogService) isDetailedLoggingPaused() bool {
+	return time.Now().UnixNano() < s.disabledUntil.Load()
+}
+
+// pauseDetailedLogging turns off detailed logging for detailedLoggingPause, counting a new period once.
+func (s *RequestLogService) pauseDetailedLogging() {
+	now := time.Now()
+	current := s.disabledUntil.Load()
+	if now.UnixNano() < current {
+		return
+	}
+	if s.disabledUntil.CompareAndSwap(current, now.Add(detailedLoggingPause).UnixNano()) {
+		s.disabledPeriods.Add(1)
+		logrus.Warnf("Request log buffer is full, detailed logging disabled for %v (stat counters are kept)", detailedLoggingPause)
+	}
+}
+
+// skipLog drops the detailed log but keeps its contribution to key and group stat counters.
+func (s *RequestLogService) skipLog(log *models.RequestLog) {
+	s.skippedLogs.Add(1)
+	s.addPendingStats(log)
+}
+
+// dropLog drops a log the full buffer had no room for. Rate limits, billing and auto-pause read the
+// stat counters, so the request is still counted.
+func (s *RequestLogService) dropLog(log *models.RequestLog) {
+	s.droppedLogs.Add(1)
+	s.addPendingStats(log)
+}
+
+func (s *RequestLogService) addPendingStats(log *models.RequestLog) {
+	s.pendingStatsMu.Lock()
+	s.pendingStats.add(log)
+	s.pendingStatsMu.Unlock()
+}
+
+// GetBackpressureStats returns the effects of the backpressure policy since startup.
+func (s *RequestLogService) GetBackpressureStats() RequestLogBackpressureStats {
+	stats := RequestLogBackpressureStats{
+		Policy:          s.settingsManager.GetSettings().RequestLogBackpressurePolicy,
+		BufferSize:      cap(s.buffer),
+		BufferedLogs:    len(s.buffer),
+		DroppedLogs:     s.droppedLogs.Load(),
+		SkippedLogs:     s.skippedLogs.Load(),
+		DisabledPeriods: s.disabledPeriods.Load(),
+	}
+	if s.isDetailedLoggingPaused() {
+		disabledUntil := time.Unix(0, s.disabledUntil.Load())
+		stats.DisabledUntil = &disabledUntil
+	}
+	return stats
+}
+
+// reportBackpressure logs a warning when logs were dropped or skipped since the last report.
+func (s *RequestLogService) reportBackpressure() {
+	dropped := s.droppedLogs.Load()
+	skipped := s.skippedLogs.Load()
+	if dropped == s.reportedDropped && skipped == s.reportedSkipped {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"dropped": dropped - s.reportedDropped,
+		"skipped": skipped - s.reportedSkipped,
+		"policy":  s.settingsManager.GetSettings().RequestLogBackpressurePolicy,
+	}).Warn("Request log buffer overflowed since last report")
+
+	s.reportedDropped = dropped
+	s.reportedSkipped = skipped
+}
+
+// persist writes a log to the database directly or to the cache for the next flush
+func (s *RequestLogService) persist(log *models.RequestLog) error {
 	if s.settingsManager.GetSettings().RequestLogWriteIntervalMinutes == 0 {
 		return s.writeLogsToDB([]*models.RequestLog{log})
 	}
@@ -124,8 +386,14 @@ func (s *RequestLogService) Record(log *models.RequestLog) error {
 	return s.store.SAdd(PendingLogKeysSet, cacheKey)
 }
 
-// flush data from cache to database
+// flush writes the pending stat counters and, on the master, moves the logs cached in the store to the database
 func (s *RequestLogService) flush() {
+	s.flushPendingStats()
+
+	if !s.isMaster {
+		return
+	}
+
 	if s.settingsManager.GetSettings().RequestLogWriteIntervalMinutes == 0 {
 		logrus.Debug("Sync mode enabled, skipping scheduled log flush.")
 		return
@@ -207,92 +475,81 @@ func (s *RequestLogService) writeLogsToDB(logs []*models.RequestLog) error {
 			return fmt.Errorf("failed to batch insert request logs: %w", err)
 		}
 
-		keyStats := make(map[string]int64)
+		counters := newLogStatCounters()
 		for _, log := range logs {
-			if log.IsSuccess && log.KeyHash != "" {
-				keyStats[log.KeyHash]++
-			}
+			counters.add(log)
 		}
+		return applyLogStats(tx, counters)
+	})
+}
 
-		if len(keyStats) > 0 {
-			var caseStmt strings.Builder
-			var keyHashes []string
-			caseStmt.WriteString("CASE key_hash ")
-			for keyHash, count := range keyStats {
-				caseStmt.WriteString(fmt.Sprintf("WHEN '%s' THEN request_count + %d ", keyHash, count))
-				keyHashes = append(keyHashes, keyHash)
-			}
-			caseStmt.WriteString("END")
+// flushPendingStats writes the stat counters of logs skipped while detailed logging was disabled
+// and of requests recorded with RecordStatsOnly.
+func (s *RequestLogService) flushPendingStats() {
+	s.pendingStatsMu.Lock()
+	counters := s.pendingStats
+	s.pendingStats = newLogStatCounters()
+	s.pendingStatsMu.Unlock()
 
-			if err := tx.Model(&models.APIKey{}).Where("key_hash IN ?", keyHashes).
-				Updates(map[string]any{
-					"request_count": gorm.Expr(caseStmt.String()),
-				}).Error; err != nil {
-				return fmt.Errorf("failed to batch update api_key stats: %w", err)
-			}
-		}
-
-		// 更新统计表
-		hourlyStats := make(map[struct {
-			Time    time.Time
-			GroupID uint
-		}]struct{ Success, Failure int64 })
-		for _, log := range logs {
-			if log.RequestType == models.RequestTypeRetry {
-				continue
-			}
-			hourlyTime := log.Timestamp.Truncate(time.Hour)
-			key := struct {
-				Time    time.Time
-				GroupID uint
-			}{Time: hourlyTime, GroupID: log.GroupID}
-
-			counts := hourlyStats[key]
-			if log.IsSuccess {
-				counts.Success++
-			} else {
-				counts.Failure++
-			}
-			hourlyStats[key] = counts
+	if counters.empty() {
+		return
+	}
 
-			if log.ParentGroupID > 0 {
-				parentKey := struct {
-					Time    time.Time
-					GroupID uint
-				}{Time: hourlyTime, GroupID: log.ParentGroupID}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return applyLogStats(tx, counters)
+	}); err != nil {
+		logrus.Errorf("Failed to flush stat counters of skipped request logs: %v", err)
+	}
+}
 
-				parentCounts := hourlyStats[parentKey]
-				if log.IsSuccess {
-					parentCounts.Success++
-				} else {
-					parentCounts.Failure++
-				}
-				hourlyStats[parentKey] = parentCounts
-			}
+// applyLogStats updates api_key request counts and group hourly stats from aggregated counters
+func applyLogStats(tx *gorm.DB, counters *logStatCounters) error {
+	if len(counters.lastUsed) > 0 {
+		var caseStmt, lastUsedStmt strings.Builder
+		var keyHashes []string
+		var lastUsedArgs []any
+		caseStmt.WriteString("CASE key_hash ")
+		lastUsedStmt.WriteString("CASE key_hash ")
+		for keyHash, lastUsed := range counters.lastUsed {
+			caseStmt.WriteString(fmt.Sprintf("WHEN '%s' THEN request_count + %d ", keyHash, counters.keys[keyHash]))
+			lastUsedStmt.WriteString(fmt.Sprintf("WHEN '%s' THEN ? ", keyHash))
+			lastUsedArgs = append(lastUsedArgs, lastUsed)
+			keyHashes = append(keyHashes, keyHash)
+		}
+		caseStmt.WriteString("END")
+		lastUsedStmt.WriteString("END")
+
+		if err := tx.Model(&models.APIKey{}).Where("key_hash IN ?", keyHashes).
+			Updates(map[string]any{
+				"request_count": gorm.Expr(caseStmt.String()),
+				"last_used_at":  gorm.Expr(lastUsedStmt.String(), lastUsedArgs...),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to batch update api_key stats: %w", err)
 		}
+	}
 
-		if len(hourlyStats) > 0 {
-			for key, counts := range hourlyStats {
-				err := tx.Clauses(clause.OnConflict{
-					Columns: []clause.Column{{Name: "time"}, {Name: "group_id"}},
-					DoUpdates: clause.Assignments(map[string]any{
-						"success_count": gorm.Expr("group_hourly_stats.success_count + ?", counts.Success),
-						"failure_count": gorm.Expr("group_hourly_stats.failure_count + ?", counts.Failure),
-						"updated_at":    time.Now(),
-					}),
-				}).Create(&models.GroupHourlyStat{
-					Time:         key.Time,
-					GroupID:      key.GroupID,
-					SuccessCount: counts.Success,
-					FailureCount: counts.Failure,
-				}).Error
-
-				if err != nil {
-					return fmt.Errorf("failed to upsert group hourly stat: %w", err)
-				}
-			}
+	// 更新统计表
+	for key, counts := range counters.hourly {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "time"}, {Name: "group_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"success_count":       gorm.Expr("group_hourly_stats.success_count + ?", counts.Success),
+				"failure_count":       gorm.Expr("group_hourly_stats.failure_count + ?", counts.Failure),
+				"mid_stream_failures": gorm.Expr("group_hourly_stats.mid_stream_failures + ?", counts.MidStreamFailure),
+				"updated_at":          time.Now(),
+			}),
+		}).Create(&models.GroupHourlyStat{
+			Time:              key.Time,
+			GroupID:           key.GroupID,
+			SuccessCount:      counts.Success,
+			FailureCount:      counts.Failure,
+			MidStreamFailures: counts.MidStreamFailure,
+		}).Error
+
+		if err != nil {
+			return fmt.Errorf("failed to upsert group hourly stat: %w", err)
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
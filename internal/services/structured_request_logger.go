@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"aimanager/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// structuredRequestLogBufferSize is how many entries may wait to be written. Entries arriving while
+	// the buffer is full are dropped instead of blocking the request path.
+	structuredRequestLogBufferSize = 4096
+	// structuredRequestLogRedacted replaces the value of redacted fields and headers.
+	structuredRequestLogRedacted = "[REDACTED]"
+)
+
+// StructuredRequestLogger writes one JSON line per proxied request to stdout when
+// enable_structured_request_log is on, for log pipelines to ingest. It is independent of the request
+// logs stored in the database. Fields and request headers named in structured_log_redact_fields are
+// masked, matched case-insensitively.
+type StructuredRequestLogger struct {
+	settingsManager *config.SystemSettingsManager
+	logger          *logrus.Logger
+	entries         chan logrus.Fields
+	dropped         atomic.Int64
+	stopChan        chan struct{}
+	wg              sync.WaitGroup
+}
+
+// NewStructuredRequestLogger creates a new StructuredRequestLogger.
+func NewStructuredRequestLogger(settingsManager *config.SystemSettingsManager) *StructuredRequestLogger {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+	logger.SetFormatter(&logrus.JSONFormatter{
+		TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+	})
+
+	return &StructuredRequestLogger{
+		settingsManager: settingsManager,
+		logger:          logger,
+		entries:         make(chan logrus.Fields, structuredRequestLogBufferSize),
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Enabled reports whether structured request logging is on, so callers can skip building entries.
+func (l *StructuredRequestLogger) Enabled() bool {
+	return l.settingsManager.GetSettings().EnableStructuredRequestLog
+}
+
+// Log queues an entry for writing without blocking. Header values may be given as a map[string]string
+// under the "headers" field. When the buffer is full the entry is dropped.
+func (l *StructuredRequestLogger) Log(fields logrus.Fields) {
+	select {
+	case l.entries <- fields:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Start starts the writer.
+func (l *StructuredRequestLogger) Start() {
+	l.wg.Add(1)
+	go l.run()
+}
+
+// Stop writes the queued entries and stops the writer.
+func (l *StructuredRequestLogger) Stop(ctx context.Context) {
+	close(l.stopChan)
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("StructuredRequestLogger stopped gracefully.")
+	case <-ctx.Done():
+		logrus.Warn("StructuredRequestLogger stop timed out.")
+	}
+}
+
+func (l *StructuredRequestLogger) run() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case fields := <-l.entries:
+			l.write(fields)
+		case <-ticker.C:
+			if dropped := l.dropped.Swap(0); dropped > 0 {
+				logrus.Warnf("Dropped %d structured request logs in the last minute because the buffer was full", dropped)
+			}
+		case <-l.stopChan:
+			for {
+				select {
+				case fields := <-l.entries:
+					l.write(fields)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *StructuredRequestLogger) write(fields logrus.Fields) {
+	redact := parseRedactFields(l.settingsManager.GetSettings().StructuredLogRedactFields)
+	for name := range fields {
+		if _, ok := redact[strings.ToLower(name)]; ok {
+			fields[name] = structuredRequestLogRedacted
+		}
+	}
+	if headers, ok := fields["headers"].(map[string]string); ok {
+		for name := range headers {
+			if _, ok := redact[strings.ToLower(name)]; ok {
+				headers[name] = structuredRequestLogRedacted
+			}
+		}
+	}
+	l.logger.WithFields(fields).Info("proxy request")
+}
+
+// parseRedactFields parses the comma-separated list of field and header names to redact.
+func parseRedactFields(value string) map[string]struct{} {
+	redact := make(map[string]struct{})
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			redact[name] = struct{}{}
+		}
+	}
+	return redact
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"math"
+
+	"aimanager/internal/models"
+)
+
+// SubGroupStatus tells whether a sub-group of an aggregate group can take a request right now.
+type SubGroupStatus struct {
+	Paused      bool `json:"paused"`
+	BreakerOpen bool `json:"breaker_open"`
+	RateLimited bool `json:"rate_limited"`
+}
+
+// Accepting reports whether selection may route a request to the sub-group.
+func (s SubGroupStatus) Accepting() bool {
+	return !s.Paused && !s.BreakerOpen && !s.RateLimited
+}
+
+// SubGroupAvailability decides which sub-groups of an aggregate group may take a request. The proxy and
+// the routing weights view share it, so the reported probabilities match actual routing.
+type SubGroupAvailability struct {
+	groupManager    *GroupManager
+	subGroupManager *SubGroupManager
+	groupService    *GroupService
+	circuitBreaker  *GroupCircuitBreaker
+}
+
+// NewSubGroupAvailability creates a new SubGroupAvailability.
+func NewSubGroupAvailability(
+	groupManager *GroupManager,
+	subGroupManager *SubGroupManager,
+	groupService *GroupService,
+	circuitBreaker *GroupCircuitBreaker,
+) *SubGroupAvailability {
+	return &SubGroupAvailability{
+		groupManager:    groupManager,
+		subGroupManager: subGroupManager,
+		groupService:    groupService,
+		circuitBreaker:  circuitBreaker,
+	}
+}
+
+// Status checks a sub-group: it must not be auto-paused, its circuit breaker must not be open and it
+// must be under its rate limits for proxyKey. Sub-groups missing from the cache are only checked
+// against their rate limits.
+func (a *SubGroupAvailability) Status(ctx context.Context, subGroupID uint, proxyKey string) SubGroupStatus {
+	var status SubGroupStatus
+	if subGroup, err := a.groupManager.GetGroupByID(subGroupID); err == nil {
+		status.Paused = subGroup.AutoPausedAt != nil
+		status.BreakerOpen = a.circuitBreaker.IsOpen(subGroup)
+	}
+	if status.Paused || status.BreakerOpen {
+		return status
+	}
+	status.RateLimited = a.groupService.CheckRateLimitCached(ctx, subGroupID, proxyKey) != nil
+	return status
+}
+
+// Filter returns the selection filter for requests made with proxyKey.
+func (a *SubGroupAvailability) Filter(ctx context.Context, proxyKey string) SubGroupFilter {
+	return func(subGroupID uint) bool {
+		return a.Status(ctx, subGroupID, proxyKey).Accepting()
+	}
+}
+
+// GetRoutingWeights returns the routing weights of an aggregate group, with the circuit breaker state
+// and recent success rate of each sub-group that uses a breaker.
+func (a *SubGroupAvailability) GetRoutingWeights(ctx context.Context, group *models.Group) []SubGroupRoutingWeight {
+	weights := a.subGroupManager.GetRoutingWeights(group, func(subGroupID uint) SubGroupStatus {
+		return a.Status(ctx, subGroupID, "")
+	})
+
+	for i := range weights {
+		subGroup, err := a.groupManager.GetGroupByID(weights[i].SubGroupID)
+		if err != nil {
+			continue
+		}
+		breaker := a.circuitBreaker.GetStatus(subGroup)
+		if breaker == nil {
+			continue
+		}
+		weights[i].CircuitBreaker = breaker
+		if breaker.WindowRequests > 0 {
+			rate := math.Round(float64(breaker.WindowRequests-breaker.WindowFailures)/float64(breaker.WindowRequests)*10000) / 10000
+			weights[i].SuccessRate = &rate
+		}
+	}
+	return weights
+}
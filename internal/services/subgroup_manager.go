@@ -3,8 +3,14 @@ package services
 import (
 	"aimanager/internal/models"
 	"aimanager/internal/store"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -32,8 +38,12 @@ func NewSubGroupManager(store store.Store) *SubGroupManager {
 	}
 }
 
-// SelectSubGroup selects an appropriate sub-group for the given aggregate group
-func (m *SubGroupManager) SelectSubGroup(group *models.Group) (string, error) {
+// SubGroupFilter reports whether a sub-group can currently accept traffic (e.g. is not rate limited).
+type SubGroupFilter func(subGroupID uint) bool
+
+// SelectSubGroup selects an appropriate sub-group for the given aggregate group.
+// Sub-groups rejected by accept are skipped and selection falls through in weight order; accept may be nil.
+func (m *SubGroupManager) SelectSubGroup(group *models.Group, accept SubGroupFilter) (string, error) {
 	if group.GroupType != "aggregate" {
 		return "", nil
 	}
@@ -43,9 +53,9 @@ func (m *SubGroupManager) SelectSubGroup(group *models.Group) (string, error) {
 		return "", fmt.Errorf("no valid sub-groups available for aggregate group '%s'", group.Name)
 	}
 
-	selectedName := selector.selectNext()
+	selectedName := selector.selectNext(accept)
 	if selectedName == "" {
-		return "", fmt.Errorf("no sub-groups with active keys for aggregate group '%s'", group.Name)
+		return "", fmt.Errorf("no available sub-groups for aggregate group '%s'", group.Name)
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -56,6 +66,153 @@ func (m *SubGroupManager) SelectSubGroup(group *models.Group) (string, error) {
 	return selectedName, nil
 }
 
+const (
+	// DefaultSessionAffinityTTLSeconds is how long a session stays bound to its sub-group when the
+	// group does not set session_affinity_ttl_seconds.
+	DefaultSessionAffinityTTLSeconds = 3600
+	// MaxSessionAffinityTTLSeconds caps session_affinity_ttl_seconds at one week.
+	MaxSessionAffinityTTLSeconds = 7 * 24 * 3600
+)
+
+// SelectSubGroupForSession selects a sub-group like SelectSubGroup, but keeps requests of the same
+// session on the same sub-group. The session is bound to the sub-group it is first routed to for the
+// group's session_affinity_ttl_seconds. If the bound sub-group is unavailable or no longer part of the
+// group, normal weighted selection applies and the session is bound to the new sub-group.
+// Without a session ID this is SelectSubGroup.
+func (m *SubGroupManager) SelectSubGroupForSession(group *models.Group, sessionID string, accept SubGroupFilter) (string, error) {
+	if group.GroupType != "aggregate" || sessionID == "" {
+		return m.SelectSubGroup(group, accept)
+	}
+
+	selector := m.getSelector(group)
+	if selector == nil {
+		return "", fmt.Errorf("no valid sub-groups available for aggregate group '%s'", group.Name)
+	}
+
+	key := sessionAffinityKey(group.ID, sessionID)
+	if value, err := m.store.Get(key); err == nil {
+		if subGroupID, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			if name := selector.selectBound(uint(subGroupID), accept); name != "" {
+				return name, nil
+			}
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		logrus.WithError(err).WithField("aggregate_group", group.Name).Warn("Failed to read session affinity, using weighted selection")
+	}
+
+	selectedName := selector.selectNext(accept)
+	if selectedName == "" {
+		return "", fmt.Errorf("no available sub-groups for aggregate group '%s'", group.Name)
+	}
+
+	ttl := DefaultSessionAffinityTTLSeconds
+	if group.ParsedConfig.SessionAffinityTTLSeconds != nil && *group.ParsedConfig.SessionAffinityTTLSeconds > 0 {
+		ttl = *group.ParsedConfig.SessionAffinityTTLSeconds
+	}
+	if subGroupID, ok := selector.subGroupID(selectedName); ok {
+		if err := m.store.Set(key, []byte(strconv.FormatUint(uint64(subGroupID), 10)), time.Duration(ttl)*time.Second); err != nil {
+			logrus.WithError(err).WithField("aggregate_group", group.Name).Warn("Failed to store session affinity")
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"aggregate_group": group.Name,
+		"selected_group":  selectedName,
+	}).Debug("Bound session to sub-group")
+
+	return selectedName, nil
+}
+
+// SelectSubGroupForModel returns the sub-group the aggregate group's model_routing directs the model
+// to. It returns "" when the model is not routed, or when its sub-group is unavailable or no longer
+// part of the group, so the caller falls back to weighted selection.
+func (m *SubGroupManager) SelectSubGroupForModel(group *models.Group, model string, accept SubGroupFilter) string {
+	if group.GroupType != "aggregate" || model == "" {
+		return ""
+	}
+	subGroupID, ok := group.ParsedConfig.ModelRouting[model]
+	if !ok {
+		return ""
+	}
+
+	selector := m.getSelector(group)
+	if selector == nil {
+		return ""
+	}
+
+	selectedName := selector.selectBound(subGroupID, accept)
+	if selectedName != "" {
+		logrus.WithFields(logrus.Fields{
+			"aggregate_group": group.Name,
+			"selected_group":  selectedName,
+			"model":           model,
+		}).Debug("Routed model to sub-group")
+	}
+	return selectedName
+}
+
+// sessionAffinityKey stores only a hash of the session ID, since clients may send anything in the header.
+func sessionAffinityKey(groupID uint, sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return fmt.Sprintf("session_affinity:%d:%s", groupID, hex.EncodeToString(sum[:16]))
+}
+
+// SubGroupRoutingWeight describes how likely a sub-group is to be selected right now.
+type SubGroupRoutingWeight struct {
+	SubGroupID    uint   `json:"sub_group_id"`
+	SubGroupName  string `json:"sub_group_name"`
+	StaticWeight  int    `json:"static_weight"`
+	HasActiveKeys bool   `json:"has_active_keys"`
+	SubGroupStatus
+	// CircuitBreaker is the breaker state of the sub-group, nil when it does not use one
+	CircuitBreaker *CircuitBreakerStatus `json:"circuit_breaker,omitempty"`
+	// SuccessRate is the share of successful upstream attempts in the breaker window
+	SuccessRate     *float64 `json:"success_rate,omitempty"`
+	Accepting       bool     `json:"accepting"`
+	EffectiveWeight int      `json:"effective_weight"`
+	Probability     float64  `json:"probability"`
+}
+
+// GetRoutingWeights returns the current selection probability of each sub-group of an aggregate group.
+// Sub-groups without active keys or not accepting traffic according to status get an effective weight
+// of 0, since selection falls through them; the remaining weights are normalized into probabilities.
+func (m *SubGroupManager) GetRoutingWeights(group *models.Group, status func(subGroupID uint) SubGroupStatus) []SubGroupRoutingWeight {
+	result := make([]SubGroupRoutingWeight, 0, len(group.SubGroups))
+	if group.GroupType != "aggregate" {
+		return result
+	}
+
+	selector := m.getSelector(group)
+	if selector == nil {
+		return result
+	}
+
+	totalWeight := 0
+	for _, item := range selector.snapshotItems() {
+		entry := SubGroupRoutingWeight{
+			SubGroupID:     item.subGroupID,
+			SubGroupName:   item.name,
+			StaticWeight:   item.weight,
+			HasActiveKeys:  selector.hasActiveKeys(item.subGroupID),
+			SubGroupStatus: status(item.subGroupID),
+		}
+		entry.Accepting = entry.HasActiveKeys && entry.SubGroupStatus.Accepting()
+		if entry.Accepting {
+			entry.EffectiveWeight = item.weight
+			totalWeight += item.weight
+		}
+		result = append(result, entry)
+	}
+
+	if totalWeight > 0 {
+		for i := range result {
+			result[i].Probability = math.Round(float64(result[i].EffectiveWeight)/float64(totalWeight)*10000) / 10000
+		}
+	}
+
+	return result
+}
+
 // RebuildSelectors rebuild all selectors based on the incoming group
 func (m *SubGroupManager) RebuildSelectors(groups map[string]*models.Group) {
 	newSelectors := make(map[uint]*selector)
@@ -141,8 +298,8 @@ type selector struct {
 	mu        sync.Mutex
 }
 
-// selectNext uses weighted round-robin algorithm to select a sub-group with active keys
-func (s *selector) selectNext() string {
+// selectNext uses weighted round-robin algorithm to select an available sub-group
+func (s *selector) selectNext(accept SubGroupFilter) string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -151,13 +308,13 @@ func (s *selector) selectNext() string {
 	}
 
 	if len(s.subGroups) == 1 {
-		if s.hasActiveKeys(s.subGroups[0].subGroupID) {
+		if s.isAvailable(s.subGroups[0].subGroupID, accept) {
 			return s.subGroups[0].name
 		}
 		logrus.WithFields(logrus.Fields{
 			"group_id":   s.subGroups[0].subGroupID,
 			"group_name": s.subGroups[0].name,
-		}).Debug("Single sub-group has no active keys")
+		}).Debug("Single sub-group is unavailable")
 		return ""
 	}
 
@@ -173,12 +330,12 @@ func (s *selector) selectNext() string {
 		}
 		attempted[item.subGroupID] = true
 
-		if s.hasActiveKeys(item.subGroupID) {
+		if s.isAvailable(item.subGroupID, accept) {
 			logrus.WithFields(logrus.Fields{
 				"aggregate_group": s.groupName,
 				"selected_group":  item.name,
 				"attempts":        len(attempted),
-			}).Debug("Selected sub-group with active keys")
+			}).Debug("Selected available sub-group")
 			return item.name
 		}
 
@@ -186,17 +343,61 @@ func (s *selector) selectNext() string {
 			"group_id":   item.subGroupID,
 			"group_name": item.name,
 			"attempts":   len(attempted),
-		}).Debug("Sub-group has no active keys, trying next")
+		}).Debug("Sub-group has no active keys or is rate limited, trying next")
 	}
 
 	logrus.WithFields(logrus.Fields{
 		"aggregate_group":  s.groupName,
 		"total_sub_groups": len(s.subGroups),
-	}).Warn("No sub-groups with active keys available")
+	}).Warn("No available sub-groups (no active keys or rate limited)")
 
 	return ""
 }
 
+// selectBound returns the name of the given sub-group if it belongs to the group and is available.
+// The round-robin state is left untouched, so bound sessions and routed models do not shift weighted
+// selection.
+func (s *selector) selectBound(subGroupID uint, accept SubGroupFilter) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.subGroups {
+		if item.subGroupID != subGroupID {
+			continue
+		}
+		if s.isAvailable(item.subGroupID, accept) {
+			return item.name
+		}
+		logrus.WithFields(logrus.Fields{
+			"aggregate_group": s.groupName,
+			"group_name":      item.name,
+		}).Debug("Bound sub-group is unavailable, using weighted selection")
+		return ""
+	}
+	return ""
+}
+
+// subGroupID looks up the ID of a sub-group by name.
+func (s *selector) subGroupID(name string) (uint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.subGroups {
+		if item.name == name {
+			return item.subGroupID, true
+		}
+	}
+	return 0, false
+}
+
+// snapshotItems returns a copy of the sub-group items.
+func (s *selector) snapshotItems() []subGroupItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]subGroupItem(nil), s.subGroups...)
+}
+
 // selectByWeight implements smooth weighted round-robin algorithm
 func (s *selector) selectByWeight() *subGroupItem {
 	totalWeight := 0
@@ -220,6 +421,14 @@ func (s *selector) selectByWeight() *subGroupItem {
 	return best
 }
 
+// isAvailable checks that a sub-group has active keys and passes the caller's filter
+func (s *selector) isAvailable(groupID uint, accept SubGroupFilter) bool {
+	if !s.hasActiveKeys(groupID) {
+		return false
+	}
+	return accept == nil || accept(groupID)
+}
+
 // hasActiveKeys checks if a sub-group has available API keys
 func (s *selector) hasActiveKeys(groupID uint) bool {
 	key := fmt.Sprintf("group:%d:active_keys", groupID)
@@ -6,19 +6,29 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 const (
-	globalTaskKey = "global_task"
-	ResultTTL     = 60 * time.Minute
+	globalTaskKey       = "global_task"
+	globalTaskCancelKey = "global_task_cancel"
+	ResultTTL           = 60 * time.Minute
 )
 
 const (
 	TaskTypeKeyValidation = "KEY_VALIDATION"
 	TaskTypeKeyImport     = "KEY_IMPORT"
 	TaskTypeKeyDelete     = "KEY_DELETE"
+
+	TaskTypeGlobalKeyValidation = "GLOBAL_KEY_VALIDATION"
 )
 
+// cancellableTaskTypes lists the task types whose runners poll IsCancelRequested.
+var cancellableTaskTypes = map[string]bool{
+	TaskTypeGlobalKeyValidation: true,
+}
+
 // TaskStatus represents the full lifecycle of a long-running task.
 type TaskStatus struct {
 	TaskType        string     `json:"task_type"`
@@ -26,6 +36,7 @@ type TaskStatus struct {
 	GroupName       string     `json:"group_name,omitempty"`
 	Processed       int        `json:"processed"`
 	Total           int        `json:"total"`
+	Progress        any        `json:"progress,omitempty"`
 	Result          any        `json:"result,omitempty"`
 	Error           string     `json:"error,omitempty"`
 	StartedAt       time.Time  `json:"started_at"`
@@ -73,6 +84,10 @@ func (s *TaskService) StartTask(taskType, groupName string, total int) (*TaskSta
 		return nil, fmt.Errorf("failed to set initial task status: %w", err)
 	}
 
+	if err := s.store.Delete(globalTaskCancelKey); err != nil {
+		return nil, fmt.Errorf("failed to reset task cancellation flag: %w", err)
+	}
+
 	return status, nil
 }
 
@@ -100,6 +115,12 @@ func (s *TaskService) GetTaskStatus() (*TaskStatus, error) {
 
 // UpdateProgress updates the progress of the current task.
 func (s *TaskService) UpdateProgress(processed int) error {
+	return s.UpdateProgressWithDetail(processed, nil)
+}
+
+// UpdateProgressWithDetail updates the progress of the current task along with
+// task-specific progress details. A nil detail keeps the previous one.
+func (s *TaskService) UpdateProgressWithDetail(processed int, detail any) error {
 	status, err := s.GetTaskStatus()
 	if err != nil {
 		return err
@@ -109,6 +130,28 @@ func (s *TaskService) UpdateProgress(processed int) error {
 	}
 
 	status.Processed = processed
+	if detail != nil {
+		status.Progress = detail
+	}
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to serialize updated status: %w", err)
+	}
+
+	return s.store.Set(globalTaskKey, statusBytes, ResultTTL)
+}
+
+// SetTotal updates the total of the current task, for tasks that only learn it after starting.
+func (s *TaskService) SetTotal(total int) error {
+	status, err := s.GetTaskStatus()
+	if err != nil {
+		return err
+	}
+	if !status.IsRunning {
+		return nil
+	}
+
+	status.Total = total
 	statusBytes, err := json.Marshal(status)
 	if err != nil {
 		return fmt.Errorf("failed to serialize updated status: %w", err)
@@ -144,3 +187,30 @@ func (s *TaskService) EndTask(resultData any, taskErr error) error {
 
 	return s.store.Set(globalTaskKey, updatedTaskBytes, ResultTTL)
 }
+
+// RequestCancel asks the running task to stop. It fails for tasks that do not poll IsCancelRequested,
+// so a cancel is never reported for a task that keeps running.
+func (s *TaskService) RequestCancel() error {
+	status, err := s.GetTaskStatus()
+	if err != nil {
+		return err
+	}
+	if !status.IsRunning {
+		return errors.New("no task is running")
+	}
+	if !cancellableTaskTypes[status.TaskType] {
+		return fmt.Errorf("task of type %s cannot be cancelled", status.TaskType)
+	}
+
+	return s.store.Set(globalTaskCancelKey, []byte("1"), ResultTTL)
+}
+
+// IsCancelRequested reports whether cancellation of the running task has been requested.
+func (s *TaskService) IsCancelRequested() bool {
+	exists, err := s.store.Exists(globalTaskCancelKey)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to check task cancellation flag")
+		return false
+	}
+	return exists
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aimanager/internal/models"
+	"aimanager/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields kept per upstream in a group's upstream health hash, suffixed to the upstream URL.
+const (
+	upstreamHealthFailuresField       = "|failures"
+	upstreamHealthUnhealthyUntilField = "|unhealthy_until"
+	upstreamHealthLastFailureField    = "|last_failure_at"
+)
+
+// UpstreamHealthStatus is the current health of one configured upstream of a group.
+type UpstreamHealthStatus struct {
+	Upstream            string     `json:"upstream"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	UnhealthyUntil      *time.Time `json:"unhealthy_until,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+}
+
+// UpstreamHealth tracks consecutive upstream failures per group and upstream URL in the store, so
+// every node routes around an upstream once it crosses the group's upstream_failure_threshold.
+// An unhealthy upstream is skipped for upstream_cooldown_seconds and then receives traffic again,
+// which acts as the re-probe: a success clears its failures, another failure starts a new cooldown.
+type UpstreamHealth struct {
+	store store.Store
+	// dirty holds "groupID|upstream" pairs that may have failures recorded, so successes on healthy
+	// upstreams do not cost a store write.
+	dirty sync.Map
+}
+
+// NewUpstreamHealth creates a new UpstreamHealth.
+func NewUpstreamHealth(store store.Store) *UpstreamHealth {
+	return &UpstreamHealth{store: store}
+}
+
+// RecordUpstreamFailure counts a 5xx response or connection error from the upstream and takes it out
+// of rotation once the group's failure threshold is reached.
+func (h *UpstreamHealth) RecordUpstreamFailure(group *models.Group, upstream string) {
+	threshold := group.EffectiveConfig.UpstreamFailureThreshold
+	if threshold <= 0 {
+		return
+	}
+	upstream = normalizeUpstream(upstream)
+	key := upstreamHealthKey(group.ID)
+	h.dirty.Store(upstreamHealthDirtyKey(group.ID, upstream), struct{}{})
+
+	failures, err := h.store.HIncrBy(key, upstream+upstreamHealthFailuresField, 1)
+	if err != nil {
+		logrus.WithError(err).WithField("upstream", upstream).Warn("Failed to record upstream failure")
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]any{upstream + upstreamHealthLastFailureField: now.UnixMilli()}
+	if failures >= int64(threshold) {
+		cooldown := time.Duration(group.EffectiveConfig.UpstreamCooldownSeconds) * time.Second
+		updates[upstream+upstreamHealthUnhealthyUntilField] = now.Add(cooldown).UnixMilli()
+		if failures == int64(threshold) {
+			logrus.WithFields(logrus.Fields{
+				"group":    group.Name,
+				"upstream": upstream,
+				"failures": failures,
+				"cooldown": cooldown,
+			}).Warn("Upstream marked unhealthy after consecutive failures")
+		}
+	}
+	if err := h.store.HSet(key, updates); err != nil {
+		logrus.WithError(err).WithField("upstream", upstream).Warn("Failed to update upstream health")
+	}
+}
+
+// RecordUpstreamSuccess clears the upstream's consecutive failures. Any response below 500 counts,
+// since it shows the upstream is reachable.
+func (h *UpstreamHealth) RecordUpstreamSuccess(groupID uint, upstream string) {
+	upstream = normalizeUpstream(upstream)
+	if _, dirty := h.dirty.LoadAndDelete(upstreamHealthDirtyKey(groupID, upstream)); !dirty {
+		return
+	}
+
+	if err := h.store.HSet(upstreamHealthKey(groupID), map[string]any{
+		upstream + upstreamHealthFailuresField:       0,
+		upstream + upstreamHealthUnhealthyUntilField: 0,
+	}); err != nil {
+		logrus.WithError(err).WithField("upstream", upstream).Warn("Failed to reset upstream health")
+	}
+}
+
+// GetHealthyUpstreams returns the given upstreams of the group that are not in their cooldown, in the
+// given order. If none is healthy, or the health state cannot be read, all upstreams are returned, so
+// traffic is never refused because of health tracking alone.
+func (h *UpstreamHealth) GetHealthyUpstreams(groupID uint, upstreams []string) []string {
+	statuses, err := h.GetUpstreamHealth(groupID, upstreams)
+	if err != nil {
+		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to read upstream health, using all upstreams")
+		return upstreams
+	}
+
+	healthy := make([]string, 0, len(upstreams))
+	for i, status := range statuses {
+		if status.Healthy {
+			healthy = append(healthy, upstreams[i])
+		}
+	}
+	if len(healthy) == 0 {
+		return upstreams
+	}
+	return healthy
+}
+
+// GetUpstreamHealth returns the health of each given upstream of the group, in the given order.
+func (h *UpstreamHealth) GetUpstreamHealth(groupID uint, upstreams []string) ([]UpstreamHealthStatus, error) {
+	fields, err := h.store.HGetAll(upstreamHealthKey(groupID))
+	if err != nil {
+		return nil, err
+	}
+	return h.statusesFromFields(groupID, upstreams, fields), nil
+}
+
+// GetUpstreamHealthMulti is GetUpstreamHealth for several groups in one store round trip. upstreams
+// holds the upstreams of each group, keyed by group ID.
+func (h *UpstreamHealth) GetUpstreamHealthMulti(upstreams map[uint][]string) (map[uint][]UpstreamHealthStatus, error) {
+	groupIDs := make([]uint, 0, len(upstreams))
+	keys := make([]string, 0, len(upstreams))
+	for groupID := range upstreams {
+		groupIDs = append(groupIDs, groupID)
+		keys = append(keys, upstreamHealthKey(groupID))
+	}
+	hashes, err := h.store.HGetAllMulti(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint][]UpstreamHealthStatus, len(groupIDs))
+	for i, groupID := range groupIDs {
+		result[groupID] = h.statusesFromFields(groupID, upstreams[groupID], hashes[i])
+	}
+	return result, nil
+}
+
+// Reset forgets the health state of the group, for when the group is removed or its upstreams change.
+func (h *UpstreamHealth) Reset(groupID uint) {
+	prefix := fmt.Sprintf("%d|", groupID)
+	h.dirty.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			h.dirty.Delete(key)
+		}
+		return true
+	})
+	if err := h.store.Delete(upstreamHealthKey(groupID)); err != nil {
+		logrus.WithError(err).WithField("group_id", groupID).Warn("Failed to reset upstream health")
+	}
+}
+
+// statusesFromFields builds the health of each given upstream from the group's health hash.
+func (h *UpstreamHealth) statusesFromFields(groupID uint, upstreams []string, fields map[string]string) []UpstreamHealthStatus {
+	now := time.Now()
+	statuses := make([]UpstreamHealthStatus, len(upstreams))
+	for i, upstream := range upstreams {
+		upstream = normalizeUpstream(upstream)
+		status := UpstreamHealthStatus{Upstream: upstream, Healthy: true}
+		status.ConsecutiveFailures, _ = strconv.ParseInt(fields[upstream+upstreamHealthFailuresField], 10, 64)
+		if status.ConsecutiveFailures > 0 {
+			// Failures may have been recorded by another node, so the next success must reset them
+			h.dirty.Store(upstreamHealthDirtyKey(groupID, upstream), struct{}{})
+		}
+		if until := parseUnixMilli(fields[upstream+upstreamHealthUnhealthyUntilField]); until != nil && now.Before(*until) {
+			status.Healthy = false
+			status.UnhealthyUntil = until
+		}
+		status.LastFailureAt = parseUnixMilli(fields[upstream+upstreamHealthLastFailureField])
+		statuses[i] = status
+	}
+	return statuses
+}
+
+// parseUnixMilli parses a stored millisecond timestamp, returning nil for missing or zero values.
+func parseUnixMilli(value string) *time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return nil
+	}
+	t := time.UnixMilli(ms)
+	return &t
+}
+
+// normalizeUpstream makes upstream URLs comparable regardless of a trailing slash.
+func normalizeUpstream(upstream string) string {
+	return strings.TrimRight(strings.TrimSpace(upstream), "/")
+}
+
+func upstreamHealthKey(groupID uint) string {
+	return fmt.Sprintf("upstream_health:%d", groupID)
+}
+
+func upstreamHealthDirtyKey(groupID uint, upstream string) string {
+	return fmt.Sprintf("%d|%s", groupID, upstream)
+}
+
+// GetUpstreamHealth returns the health of the group's configured upstreams. Aggregate groups have no
+// upstreams of their own and, like groups not yet persisted or without health tracking, return nil.
+func (s *GroupService) GetUpstreamHealth(group *models.Group) []UpstreamHealthStatus {
+	upstreams := s.trackedUpstreams(group)
+	if upstreams == nil {
+		return nil
+	}
+
+	statuses, err := s.upstreamHealth.GetUpstreamHealth(group.ID, upstreams)
+	if err != nil {
+		logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to read upstream health")
+		return nil
+	}
+	return statuses
+}
+
+// GetUpstreamHealthForGroups is GetUpstreamHealth for a list of groups, keyed by group ID, read in one
+// store round trip.
+func (s *GroupService) GetUpstreamHealthForGroups(groups []models.Group) map[uint][]UpstreamHealthStatus {
+	upstreams := make(map[uint][]string)
+	for i := range groups {
+		if tracked := s.trackedUpstreams(&groups[i]); tracked != nil {
+			upstreams[groups[i].ID] = tracked
+		}
+	}
+	if len(upstreams) == 0 {
+		return nil
+	}
+
+	statuses, err := s.upstreamHealth.GetUpstreamHealthMulti(upstreams)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read upstream health")
+		return nil
+	}
+	return statuses
+}
+
+// trackedUpstreams returns the configured upstream URLs of a group whose upstream health is tracked.
+func (s *GroupService) trackedUpstreams(group *models.Group) []string {
+	if group.ID == 0 || group.GroupType == "aggregate" || len(group.Upstreams) == 0 {
+		return nil
+	}
+	if s.settingsManager.GetEffectiveConfig(group.Config).UpstreamFailureThreshold <= 0 {
+		return nil
+	}
+
+	var defs []struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(group.Upstreams, &defs); err != nil {
+		logrus.WithError(err).WithField("group_name", group.Name).Warn("Failed to parse upstreams for health status")
+		return nil
+	}
+	upstreams := make([]string, len(defs))
+	for i, def := range defs {
+		upstreams[i] = def.URL
+	}
+	return upstreams
+}
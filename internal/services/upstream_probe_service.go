@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"aimanager/internal/channel"
+	"aimanager/internal/config"
+	app_errors "aimanager/internal/errors"
+	"aimanager/internal/models"
+)
+
+// upstreamProbeBodyLimit caps how much of the probe response is searched for its first line.
+const upstreamProbeBodyLimit = 64 * 1024
+
+// UpstreamProbeParams describes an upstream to test before it is added to a group.
+type UpstreamProbeParams struct {
+	URL         string
+	ChannelType string
+	TestModel   string
+	APIKey      string
+}
+
+// UpstreamProbeResult is the outcome of sending one validation request to an upstream. OK means the
+// upstream answered with a 2xx status.
+type UpstreamProbeResult struct {
+	OK         bool    `json:"ok"`
+	StatusCode int     `json:"status_code,omitempty"`
+	LatencyMs  float64 `json:"latency_ms"`
+	FirstLine  string  `json:"first_line"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// UpstreamProbeService tests upstreams that do not belong to any group yet.
+type UpstreamProbeService struct {
+	settingsManager *config.SystemSettingsManager
+	channelFactory  *channel.Factory
+	groupService    *GroupService
+}
+
+// NewUpstreamProbeService creates a new UpstreamProbeService.
+func NewUpstreamProbeService(settingsManager *config.SystemSettingsManager, channelFactory *channel.Factory, groupService *GroupService) *UpstreamProbeService {
+	return &UpstreamProbeService{
+		settingsManager: settingsManager,
+		channelFactory:  channelFactory,
+		groupService:    groupService,
+	}
+}
+
+// Probe sends the validation request key validation would send for a group with this single upstream,
+// using the system settings for timeouts and the proxy. Invalid parameters are returned as errors,
+// while failures of the upstream itself are reported in the result.
+func (s *UpstreamProbeService) Probe(ctx context.Context, params UpstreamProbeParams) (*UpstreamProbeResult, error) {
+	channelType := strings.TrimSpace(params.ChannelType)
+	if !s.groupService.isValidChannelType(channelType) {
+		supported := strings.Join(s.groupService.channelRegistry, ", ")
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.invalid_channel_type", map[string]any{"types": supported})
+	}
+
+	testModel := strings.TrimSpace(params.TestModel)
+	if testModel == "" {
+		return nil, NewI18nError(app_errors.ErrValidation, "validation.test_model_required", nil)
+	}
+
+	upstreams, err := json.Marshal([]map[string]any{{"url": params.URL, "weight": 1}})
+	if err != nil {
+		return nil, err
+	}
+	cleanedUpstreams, err := s.groupService.validateAndCleanUpstreams(upstreams)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &models.Group{
+		Name:        "upstream-probe",
+		ChannelType: channelType,
+		TestModel:   testModel,
+		Upstreams:   cleanedUpstreams,
+	}
+	group.EffectiveConfig = s.settingsManager.GetEffectiveConfig(nil)
+
+	ch, err := s.channelFactory.NewChannel(group)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(group.EffectiveConfig.KeyValidationTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	result := &UpstreamProbeResult{}
+	start := time.Now()
+	resp, err := ch.ProbeValidation(ctx, &models.APIKey{KeyValue: strings.TrimSpace(params.APIKey)}, group, testModel)
+	if err != nil {
+		result.LatencyMs = elapsedMs(start)
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	// Latency is measured to the first line of the body, like a client waiting for the first token
+	firstLine, readErr := readFirstLine(resp.Body)
+	result.LatencyMs = elapsedMs(start)
+	result.StatusCode = resp.StatusCode
+	result.FirstLine = firstLine
+	result.OK = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if readErr != nil {
+		result.Error = readErr.Error()
+	}
+	return result, nil
+}
+
+// readFirstLine returns the first non-empty line of the body, without its line break.
+func readFirstLine(body io.Reader) (string, error) {
+	scanner := bufio.NewScanner(io.LimitReader(body, upstreamProbeBodyLimit))
+	scanner.Buffer(make([]byte, 0, 4096), upstreamProbeBodyLimit)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line, nil
+		}
+	}
+	return "", scanner.Err()
+}
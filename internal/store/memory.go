@@ -2,6 +2,7 @@ package store
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -193,6 +194,19 @@ func (s *MemoryStore) HGetAll(key string) (map[string]string, error) {
 	return result, nil
 }
 
+// HGetAllMulti retrieves several hashes, in the order of keys.
+func (s *MemoryStore) HGetAllMulti(keys ...string) ([]map[string]string, error) {
+	results := make([]map[string]string, len(keys))
+	for i, key := range keys {
+		hash, err := s.HGetAll(key)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = hash
+	}
+	return results, nil
+}
+
 func (s *MemoryStore) HIncrBy(key, field string, incr int64) (int64, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -217,6 +231,40 @@ func (s *MemoryStore) HIncrBy(key, field string, incr int64) (int64, error) {
 	return newVal, nil
 }
 
+// --- RATE LIMIT operations ---
+
+// TakeToken takes a token from a GCRA bucket. The key holds the bucket's theoretical arrival time.
+func (s *MemoryStore) TakeToken(key string, interval time.Duration, burst int64, consume bool) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	tat := now
+	if rawItem, exists := s.data[key]; exists {
+		item, ok := rawItem.(memoryStoreItem)
+		if !ok {
+			return false, 0, fmt.Errorf("type mismatch: key '%s' holds a different data type", key)
+		}
+		if item.expiresAt == 0 || now < item.expiresAt {
+			if stored, err := strconv.ParseInt(string(item.value), 10, 64); err == nil && stored > now {
+				tat = stored
+			}
+		}
+	}
+
+	newTat := tat + interval.Nanoseconds()
+	if allowAt := newTat - burst*interval.Nanoseconds(); allowAt > now {
+		return false, time.Duration(allowAt - now), nil
+	}
+	if consume {
+		s.data[key] = memoryStoreItem{
+			value:     []byte(strconv.FormatInt(newTat, 10)),
+			expiresAt: newTat,
+		}
+	}
+	return true, 0, nil
+}
+
 // --- LIST operations ---
 
 func (s *MemoryStore) LPush(key string, values ...any) error {
@@ -320,6 +368,62 @@ func (s *MemoryStore) LLen(key string) (int64, error) {
 	return int64(len(list)), nil
 }
 
+// LRange returns all elements of a list, head first.
+func (s *MemoryStore) LRange(key string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rawList, exists := s.data[key]
+	if !exists {
+		return []string{}, nil
+	}
+
+	list, ok := rawList.([]string)
+	if !ok {
+		return nil, fmt.Errorf("type mismatch: key '%s' holds a different data type", key)
+	}
+
+	return slices.Clone(list), nil
+}
+
+// LReorder moves the listed members to the tail of a list, in order, under the store lock.
+func (s *MemoryStore) LReorder(key string, order []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rawList, exists := s.data[key]
+	if !exists {
+		return nil
+	}
+
+	list, ok := rawList.([]string)
+	if !ok {
+		return fmt.Errorf("type mismatch: key '%s' holds a different data type", key)
+	}
+
+	present := make(map[string]bool, len(list))
+	for _, item := range list {
+		present[item] = true
+	}
+	moved := make(map[string]bool, len(order))
+	tail := make([]string, 0, len(order))
+	for _, item := range order {
+		if present[item] && !moved[item] {
+			moved[item] = true
+			tail = append(tail, item)
+		}
+	}
+
+	newList := make([]string, 0, len(list))
+	for _, item := range list {
+		if !moved[item] {
+			newList = append(newList, item)
+		}
+	}
+	s.data[key] = append(newList, tail...)
+	return nil
+}
+
 // --- SET operations ---
 
 // SAdd adds members to a set.
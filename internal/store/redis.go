@@ -96,6 +96,28 @@ func (s *RedisStore) HGetAll(key string) (map[string]string, error) {
 	return s.client.HGetAll(context.Background(), s.prefixKey(key)).Result()
 }
 
+// HGetAllMulti retrieves several hashes in a single pipeline.
+func (s *RedisStore) HGetAllMulti(keys ...string) ([]map[string]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	ctx := context.Background()
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(ctx, s.prefixKey(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]string, len(keys))
+	for i, cmd := range cmds {
+		results[i] = cmd.Val()
+	}
+	return results, nil
+}
+
 func (s *RedisStore) HIncrBy(key, field string, incr int64) (int64, error) {
 	return s.client.HIncrBy(context.Background(), s.prefixKey(key), field, incr).Result()
 }
@@ -127,6 +149,55 @@ func (s *RedisStore) LLen(key string) (int64, error) {
 	return s.client.LLen(context.Background(), s.prefixKey(key)).Result()
 }
 
+// LRange returns all elements of a list, head first.
+func (s *RedisStore) LRange(key string) ([]string, error) {
+	return s.client.LRange(context.Background(), s.prefixKey(key), 0, -1).Result()
+}
+
+// lReorderScript rebuilds the list in one script call, so members pushed or removed concurrently
+// are never lost or brought back. RPUSH is chunked to stay below Lua's unpack limit.
+var lReorderScript = redis.NewScript(`
+local list = redis.call('LRANGE', KEYS[1], 0, -1)
+if #list == 0 then
+	return 0
+end
+local present = {}
+for _, item in ipairs(list) do
+	present[item] = true
+end
+local moved = {}
+local tail = {}
+for _, item in ipairs(ARGV) do
+	if present[item] and not moved[item] then
+		moved[item] = true
+		table.insert(tail, item)
+	end
+end
+local result = {}
+for _, item in ipairs(list) do
+	if not moved[item] then
+		table.insert(result, item)
+	end
+end
+for _, item in ipairs(tail) do
+	table.insert(result, item)
+end
+redis.call('DEL', KEYS[1])
+for i = 1, #result, 1000 do
+	redis.call('RPUSH', KEYS[1], unpack(result, i, math.min(i + 999, #result)))
+end
+return 1
+`)
+
+// LReorder moves the listed members to the tail of a list in a single script call.
+func (s *RedisStore) LReorder(key string, order []string) error {
+	args := make([]any, len(order))
+	for i, item := range order {
+		args[i] = item
+	}
+	return lReorderScript.Run(context.Background(), s.client, []string{s.prefixKey(key)}, args...).Err()
+}
+
 // --- SET operations ---
 
 func (s *RedisStore) SAdd(key string, members ...any) error {
@@ -137,6 +208,49 @@ func (s *RedisStore) SPopN(key string, count int64) ([]string, error) {
 	return s.client.SPopN(context.Background(), s.prefixKey(key), count).Result()
 }
 
+// --- RATE LIMIT operations ---
+
+// takeTokenScript implements GCRA on the bucket's theoretical arrival time in microseconds, read from
+// the Redis clock so all nodes agree. It returns whether a token was taken and otherwise the wait.
+var takeTokenScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local newTat = tat + interval
+local allowAt = newTat - burst * interval
+if allowAt > now then
+	return {0, allowAt - now}
+end
+if ARGV[3] == '1' then
+	-- Lua would print the timestamp with 14 significant digits, format it as an integer
+	local ttl = math.max(1, math.ceil((newTat - now) / 1000))
+	redis.call('SET', KEYS[1], string.format('%.0f', newTat), 'PX', string.format('%.0f', ttl))
+end
+return {1, 0}
+`)
+
+// TakeToken takes a token from a GCRA bucket in one script call.
+func (s *RedisStore) TakeToken(key string, interval time.Duration, burst int64, consume bool) (bool, time.Duration, error) {
+	consumeArg := "0"
+	if consume {
+		consumeArg = "1"
+	}
+	result, err := takeTokenScript.Run(context.Background(), s.client, []string{s.prefixKey(key)},
+		max(interval.Microseconds(), 1), burst, consumeArg).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected token bucket result: %v", result)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Microsecond, nil
+}
+
 // --- Pipeliner implementation ---
 
 type redisPipeliner struct {
@@ -43,6 +43,8 @@ type Store interface {
 	// HASH operations
 	HSet(key string, values map[string]any) error
 	HGetAll(key string) (map[string]string, error)
+	// HGetAllMulti retrieves several hashes in one round trip, in the order of keys. Missing hashes are empty.
+	HGetAllMulti(keys ...string) ([]map[string]string, error)
 	HIncrBy(key, field string, incr int64) (int64, error)
 
 	// LIST operations
@@ -50,11 +52,22 @@ type Store interface {
 	LRem(key string, count int64, value any) error
 	Rotate(key string) (string, error)
 	LLen(key string) (int64, error)
+	LRange(key string) ([]string, error)
+	// LReorder atomically moves the members of order that are in the list to its tail, in that
+	// order. Members not named in order keep their relative order at the head, and names that are
+	// not in the list are skipped.
+	LReorder(key string, order []string) error
 
 	// SET operations
 	SAdd(key string, members ...any) error
 	SPopN(key string, count int64) ([]string, error)
 
+	// RATE LIMIT operations
+	// TakeToken takes a token from a GCRA token bucket holding up to burst tokens, one refilled every
+	// interval, in a single atomic operation. When the bucket is empty it returns false and how long
+	// until the next token. With consume false the bucket is only inspected.
+	TakeToken(key string, interval time.Duration, burst int64, consume bool) (bool, time.Duration, error)
+
 	// Close closes the store and releases any underlying resources.
 	Close() error
 
@@ -3,6 +3,7 @@ package syncer
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"aimanager/internal/store"
@@ -24,6 +25,17 @@ type CacheSyncer[T any] struct {
 	stopChan    chan struct{}
 	wg          sync.WaitGroup
 	afterReload func(newValue T)
+
+	// reloadMu serializes reloads. reloadRequests numbers every reload request and reloadedUpTo is the
+	// highest request number covered by a completed reload, so queued requests can be merged.
+	reloadMu       sync.Mutex
+	reloadRequests atomic.Uint64
+	reloadedUpTo   uint64
+
+	// invalidateMu guards invalidateTimer, the pending coalesced notification, if any.
+	invalidateMu     sync.Mutex
+	invalidateTimer  *time.Timer
+	invalidateWindow func() time.Duration
 }
 
 // NewCacheSyncer creates and initializes a new CacheSyncer.
@@ -60,19 +72,101 @@ func (s *CacheSyncer[T]) Get() T {
 	return s.cache
 }
 
+// SetInvalidateWindow sets the function returning the window within which successive Invalidate calls
+// are merged into one notification. A window of 0 or less publishes on every call.
+func (s *CacheSyncer[T]) SetInvalidateWindow(window func() time.Duration) {
+	s.invalidateMu.Lock()
+	defer s.invalidateMu.Unlock()
+	s.invalidateWindow = window
+}
+
 // Invalidate publishes a notification to all instances to reload their cache.
+// With an invalidate window set, the notification is delayed by the window and calls made while one is
+// pending are merged into it. The notification is always published after the last merged call, so the
+// reload it triggers sees every change made before those calls.
 func (s *CacheSyncer[T]) Invalidate() error {
+	s.invalidateMu.Lock()
+	var window time.Duration
+	if s.invalidateWindow != nil {
+		window = s.invalidateWindow()
+	}
+	if window <= 0 {
+		s.invalidateMu.Unlock()
+		return s.publish()
+	}
+	if s.invalidateTimer != nil {
+		s.invalidateMu.Unlock()
+		s.logger.Debug("invalidation merged into pending notification")
+		return nil
+	}
+	s.invalidateTimer = time.AfterFunc(window, s.flushInvalidate)
+	s.invalidateMu.Unlock()
+	return nil
+}
+
+// flushInvalidate publishes the pending coalesced notification. Calls arriving after the pending
+// timer is cleared schedule a new notification, so none of them is lost.
+func (s *CacheSyncer[T]) flushInvalidate() {
+	s.invalidateMu.Lock()
+	s.invalidateTimer = nil
+	s.invalidateMu.Unlock()
+
+	if err := s.publish(); err != nil {
+		s.logger.Errorf("failed to publish coalesced invalidation: %v", err)
+	}
+}
+
+func (s *CacheSyncer[T]) publish() error {
 	s.logger.Debug("publishing invalidation notification")
 	return s.store.Publish(s.channelName, []byte("reload"))
 }
 
+// Reload refreshes the local cache from the loader without notifying other instances.
+// Concurrent calls are coalesced: at most one reload runs at a time, and callers that queued behind it
+// share a single follow-up reload that started after all of their calls.
+func (s *CacheSyncer[T]) Reload() error {
+	return s.coalescedReload()
+}
+
 // Stop gracefully shuts down the syncer's background goroutine.
+// A pending coalesced notification is published right away so other instances still pick it up.
 func (s *CacheSyncer[T]) Stop() {
+	s.invalidateMu.Lock()
+	pending := s.invalidateTimer != nil && s.invalidateTimer.Stop()
+	s.invalidateTimer = nil
+	s.invalidateMu.Unlock()
+	if pending {
+		if err := s.publish(); err != nil {
+			s.logger.Errorf("failed to publish pending invalidation on stop: %v", err)
+		}
+	}
+
 	close(s.stopChan)
 	s.wg.Wait()
 	s.logger.Info("cache syncer stopped.")
 }
 
+// coalescedReload reloads the cache unless a reload that started after this request already completed.
+func (s *CacheSyncer[T]) coalescedReload() error {
+	requested := s.reloadRequests.Add(1)
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if s.reloadedUpTo >= requested {
+		s.logger.Debug("reload request merged into a reload that started after it")
+		return nil
+	}
+
+	// Every request numbered up to here was made before the loader runs, so this reload covers them all
+	covers := s.reloadRequests.Load()
+	if err := s.reload(); err != nil {
+		return err
+	}
+	s.reloadedUpTo = covers
+	return nil
+}
+
 // reload fetches the latest data using the loader function and updates the cache.
 func (s *CacheSyncer[T]) reload() error {
 	s.logger.Debug("reloading cache...")
@@ -134,9 +228,15 @@ func (s *CacheSyncer[T]) listenForUpdates() {
 					break subscriberLoop
 				}
 				s.logger.Debugf("received invalidation notification, payload: %s", string(msg.Payload))
-				if err := s.reload(); err != nil {
+				// Notifications already queued are served by the same reload
+				open := s.drainNotifications(subscription)
+				if err := s.coalescedReload(); err != nil {
 					s.logger.Errorf("failed to reload cache after notification: %v", err)
 				}
+				if !open {
+					s.logger.Warn("subscription channel closed, attempting to re-subscribe...")
+					break subscriberLoop
+				}
 			case <-s.stopChan:
 				if err := subscription.Close(); err != nil {
 					s.logger.Errorf("failed to close subscription: %v", err)
@@ -158,3 +258,18 @@ func (s *CacheSyncer[T]) listenForUpdates() {
 		}
 	}
 }
+
+// drainNotifications discards notifications already waiting on the subscription.
+// It returns false if the subscription channel was closed.
+func (s *CacheSyncer[T]) drainNotifications(subscription store.Subscription) bool {
+	for {
+		select {
+		case _, ok := <-subscription.Channel():
+			if !ok {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package syncer
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aimanager/internal/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// countingStore counts published notifications and reports when the syncer has subscribed.
+type countingStore struct {
+	*store.MemoryStore
+	publishes  atomic.Int64
+	subscribed chan struct{}
+	once       sync.Once
+}
+
+func newCountingStore() *countingStore {
+	return &countingStore{MemoryStore: store.NewMemoryStore(), subscribed: make(chan struct{})}
+}
+
+func (s *countingStore) Publish(channel string, message []byte) error {
+	s.publishes.Add(1)
+	return s.MemoryStore.Publish(channel, message)
+}
+
+func (s *countingStore) Subscribe(channel string) (store.Subscription, error) {
+	sub, err := s.MemoryStore.Subscribe(channel)
+	s.once.Do(func() { close(s.subscribed) })
+	return sub, err
+}
+
+func testLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logrus.NewEntry(logger)
+}
+
+// versionedSource stands in for the database: mutations bump the version, loads read it slowly.
+type versionedSource struct {
+	version atomic.Int64
+	loads   atomic.Int64
+}
+
+func (s *versionedSource) load() (int64, error) {
+	s.loads.Add(1)
+	version := s.version.Load()
+	time.Sleep(5 * time.Millisecond)
+	return version, nil
+}
+
+func newTestSyncer(t *testing.T, st store.Store, source *versionedSource) *CacheSyncer[int64] {
+	t.Helper()
+	s, err := NewCacheSyncer(source.load, st, "test:reload", testLogger(), nil)
+	if err != nil {
+		t.Fatalf("NewCacheSyncer: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestReloadCoalescesConcurrentCalls(t *testing.T) {
+	source := &versionedSource{}
+	s := newTestSyncer(t, newCountingStore(), source)
+	initialLoads := source.loads.Load()
+
+	const callers = 50
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source.version.Add(1)
+			if err := s.Reload(); err != nil {
+				t.Errorf("Reload: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.Get(); got != callers {
+		t.Errorf("cache holds version %d after all reloads, want %d", got, callers)
+	}
+	if loads := source.loads.Load() - initialLoads; loads >= callers {
+		t.Errorf("%d concurrent reloads ran the loader %d times, want them merged", callers, loads)
+	}
+}
+
+func TestInvalidateCoalescesNotifications(t *testing.T) {
+	st := newCountingStore()
+	source := &versionedSource{}
+	s := newTestSyncer(t, st, source)
+	s.SetInvalidateWindow(func() time.Duration { return 50 * time.Millisecond })
+
+	select {
+	case <-st.subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("syncer did not subscribe")
+	}
+
+	const callers = 50
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source.version.Add(1)
+			if err := s.Invalidate(); err != nil {
+				t.Errorf("Invalidate: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Get() != callers {
+		if time.Now().After(deadline) {
+			t.Fatalf("cache holds version %d, want %d", s.Get(), callers)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if publishes := st.publishes.Load(); publishes >= callers {
+		t.Errorf("%d invalidations published %d notifications, want them merged", callers, publishes)
+	}
+}
@@ -19,27 +19,67 @@ type ConfigManager interface {
 // SystemSettings 定义所有系统配置项
 type SystemSettings struct {
 	// 基础参数
-	AppUrl                         string `json:"app_url" default:"http://localhost:3001" name:"config.app_url" category:"config.category.basic" desc:"config.app_url_desc" validate:"required"`
-	ProxyKeys                      string `json:"proxy_keys" name:"config.proxy_keys" category:"config.category.basic" desc:"config.proxy_keys_desc" validate:"required"`
-	RequestLogRetentionDays        int    `json:"request_log_retention_days" default:"7" name:"config.log_retention_days" category:"config.category.basic" desc:"config.log_retention_days_desc" validate:"required,min=0"`
-	RequestLogWriteIntervalMinutes int    `json:"request_log_write_interval_minutes" default:"1" name:"config.log_write_interval" category:"config.category.basic" desc:"config.log_write_interval_desc" validate:"required,min=0"`
-	EnableRequestBodyLogging       bool   `json:"enable_request_body_logging" default:"false" name:"config.enable_request_body_logging" category:"config.category.basic" desc:"config.enable_request_body_logging_desc"`
+	AppUrl                           string `json:"app_url" default:"http://localhost:3001" name:"config.app_url" category:"config.category.basic" desc:"config.app_url_desc" validate:"required"`
+	ProxyKeys                        string `json:"proxy_keys" name:"config.proxy_keys" category:"config.category.basic" desc:"config.proxy_keys_desc" validate:"required"`
+	RequestLogRetentionDays          int    `json:"request_log_retention_days" default:"7" name:"config.log_retention_days" category:"config.category.basic" desc:"config.log_retention_days_desc" validate:"required,min=0"`
+	RequestLogWriteIntervalMinutes   int    `json:"request_log_write_interval_minutes" default:"1" name:"config.log_write_interval" category:"config.category.basic" desc:"config.log_write_interval_desc" validate:"required,min=0"`
+	RequestLogBufferSize             int    `json:"request_log_buffer_size" default:"5000" name:"config.log_buffer_size" category:"config.category.basic" desc:"config.log_buffer_size_desc" validate:"required,min=100"`
+	RequestLogBackpressurePolicy     string `json:"request_log_backpressure_policy" default:"drop" name:"config.log_backpressure_policy" category:"config.category.basic" desc:"config.log_backpressure_policy_desc" validate:"required,oneof=drop block disable"`
+	RequestLogBlockTimeoutMs         int    `json:"request_log_block_timeout_ms" default:"200" name:"config.log_block_timeout" category:"config.category.basic" desc:"config.log_block_timeout_desc" validate:"required,min=1"`
+	EnforceUniqueProxyKeys           bool   `json:"enforce_unique_proxy_keys" default:"false" name:"config.enforce_unique_proxy_keys" category:"config.category.basic" desc:"config.enforce_unique_proxy_keys_desc"`
+	MaskKeysInLogExport              bool   `json:"mask_keys_in_log_export" default:"true" name:"config.mask_keys_in_log_export" category:"config.category.basic" desc:"config.mask_keys_in_log_export_desc"`
+	StatsExcludeCurrentHour          bool   `json:"stats_exclude_current_hour" default:"false" name:"config.stats_exclude_current_hour" category:"config.category.basic" desc:"config.stats_exclude_current_hour_desc"`
+	StatsTimeseriesMaxHours          int    `json:"stats_timeseries_max_hours" default:"2160" name:"config.stats_timeseries_max_hours" category:"config.category.basic" desc:"config.stats_timeseries_max_hours_desc" validate:"required,min=1,max=8760"`
+	StatsHourlyRetentionDays         int    `json:"stats_hourly_retention_days" default:"0" name:"config.stats_hourly_retention_days" category:"config.category.basic" desc:"config.stats_hourly_retention_days_desc" validate:"required,min=0"`
+	EnableRequestBodyLogging         bool   `json:"enable_request_body_logging" default:"false" name:"config.enable_request_body_logging" category:"config.category.basic" desc:"config.enable_request_body_logging_desc"`
+	EnableStructuredRequestLog       bool   `json:"enable_structured_request_log" default:"false" name:"config.enable_structured_request_log" category:"config.category.basic" desc:"config.enable_structured_request_log_desc"`
+	StructuredLogRedactFields        string `json:"structured_log_redact_fields" default:"authorization,x-api-key,x-goog-api-key,cookie,proxy-authorization" name:"config.structured_log_redact_fields" category:"config.category.basic" desc:"config.structured_log_redact_fields_desc"`
+	GroupCacheRefreshIntervalSeconds int    `json:"group_cache_refresh_interval_seconds" default:"300" name:"config.group_cache_refresh_interval" category:"config.category.basic" desc:"config.group_cache_refresh_interval_desc" validate:"required,min=0"`
+	GroupCacheRefreshJitterSeconds   int    `json:"group_cache_refresh_jitter_seconds" default:"60" name:"config.group_cache_refresh_jitter" category:"config.category.basic" desc:"config.group_cache_refresh_jitter_desc" validate:"required,min=0"`
+	GroupCacheInvalidateWindowMs     int    `json:"group_cache_invalidate_window_ms" default:"200" name:"config.group_cache_invalidate_window" category:"config.category.basic" desc:"config.group_cache_invalidate_window_desc" validate:"required,min=0,max=10000"`
 
 	// 请求设置
-	RequestTimeout        int    `json:"request_timeout" default:"600" name:"config.request_timeout" category:"config.category.request" desc:"config.request_timeout_desc" validate:"required,min=1"`
-	ConnectTimeout        int    `json:"connect_timeout" default:"15" name:"config.connect_timeout" category:"config.category.request" desc:"config.connect_timeout_desc" validate:"required,min=1"`
-	IdleConnTimeout       int    `json:"idle_conn_timeout" default:"120" name:"config.idle_conn_timeout" category:"config.category.request" desc:"config.idle_conn_timeout_desc" validate:"required,min=1"`
-	ResponseHeaderTimeout int    `json:"response_header_timeout" default:"600" name:"config.response_header_timeout" category:"config.category.request" desc:"config.response_header_timeout_desc" validate:"required,min=1"`
-	MaxIdleConns          int    `json:"max_idle_conns" default:"100" name:"config.max_idle_conns" category:"config.category.request" desc:"config.max_idle_conns_desc" validate:"required,min=1"`
-	MaxIdleConnsPerHost   int    `json:"max_idle_conns_per_host" default:"50" name:"config.max_idle_conns_per_host" category:"config.category.request" desc:"config.max_idle_conns_per_host_desc" validate:"required,min=1"`
-	ProxyURL              string `json:"proxy_url" name:"config.proxy_url" category:"config.category.request" desc:"config.proxy_url_desc"`
+	RequestTimeout           int    `json:"request_timeout" default:"600" name:"config.request_timeout" category:"config.category.request" desc:"config.request_timeout_desc" validate:"required,min=1"`
+	ConnectTimeout           int    `json:"connect_timeout" default:"15" name:"config.connect_timeout" category:"config.category.request" desc:"config.connect_timeout_desc" validate:"required,min=1"`
+	IdleConnTimeout          int    `json:"idle_conn_timeout" default:"120" name:"config.idle_conn_timeout" category:"config.category.request" desc:"config.idle_conn_timeout_desc" validate:"required,min=1"`
+	ResponseHeaderTimeout    int    `json:"response_header_timeout" default:"600" name:"config.response_header_timeout" category:"config.category.request" desc:"config.response_header_timeout_desc" validate:"required,min=1"`
+	MaxIdleConns             int    `json:"max_idle_conns" default:"100" name:"config.max_idle_conns" category:"config.category.request" desc:"config.max_idle_conns_desc" validate:"required,min=1"`
+	MaxIdleConnsPerHost      int    `json:"max_idle_conns_per_host" default:"50" name:"config.max_idle_conns_per_host" category:"config.category.request" desc:"config.max_idle_conns_per_host_desc" validate:"required,min=1"`
+	ProxyURL                 string `json:"proxy_url" name:"config.proxy_url" category:"config.category.request" desc:"config.proxy_url_desc"`
+	StreamBufferSizeKB       int    `json:"stream_buffer_size_kb" default:"4" name:"config.stream_buffer_size_kb" category:"config.category.request" desc:"config.stream_buffer_size_kb_desc" validate:"required,min=1,max=1024"`
+	RequireHTTPSUpstreams    bool   `json:"require_https_upstreams" default:"false" name:"config.require_https_upstreams" category:"config.category.request" desc:"config.require_https_upstreams_desc"`
+	NormalizeProxyPath       bool   `json:"normalize_proxy_path" default:"false" name:"config.normalize_proxy_path" category:"config.category.request" desc:"config.normalize_proxy_path_desc"`
+	TrustedProxyDepth        int    `json:"trusted_proxy_depth" default:"0" name:"config.trusted_proxy_depth" category:"config.category.request" desc:"config.trusted_proxy_depth_desc" validate:"required,min=0"`
+	GlobalRateLimitRPS       int    `json:"global_rate_limit_rps" default:"0" name:"config.global_rate_limit_rps" category:"config.category.request" desc:"config.global_rate_limit_rps_desc" validate:"required,min=0"`
+	GlobalRateLimitBurst     int    `json:"global_rate_limit_burst" default:"0" name:"config.global_rate_limit_burst" category:"config.category.request" desc:"config.global_rate_limit_burst_desc" validate:"required,min=0"`
+	HedgeDelayMs             int    `json:"hedge_delay_ms" default:"0" name:"config.hedge_delay_ms" category:"config.category.request" desc:"config.hedge_delay_ms_desc" validate:"required,min=0"`
+	HedgeBudgetPercent       int    `json:"hedge_budget_percent" default:"10" name:"config.hedge_budget_percent" category:"config.category.request" desc:"config.hedge_budget_percent_desc" validate:"required,min=1,max=100"`
+	MaxResponseSizeKB        int    `json:"max_response_size_kb" default:"0" name:"config.max_response_size_kb" category:"config.category.request" desc:"config.max_response_size_kb_desc" validate:"required,min=0,max=1048576"`
+	OversizedResponseMode    string `json:"oversized_response_mode" default:"reject" name:"config.oversized_response_mode" category:"config.category.request" desc:"config.oversized_response_mode_desc" validate:"oneof=reject truncate"`
+	UpstreamFailureThreshold int    `json:"upstream_failure_threshold" default:"5" name:"config.upstream_failure_threshold" category:"config.category.request" desc:"config.upstream_failure_threshold_desc" validate:"required,min=0"`
+	UpstreamCooldownSeconds  int    `json:"upstream_cooldown_seconds" default:"60" name:"config.upstream_cooldown_seconds" category:"config.category.request" desc:"config.upstream_cooldown_seconds_desc" validate:"required,min=1"`
+	GlobalMaxConcurrent      int    `json:"global_max_concurrent_requests" default:"0" name:"config.global_max_concurrent_requests" category:"config.category.request" desc:"config.global_max_concurrent_requests_desc" validate:"required,min=0"`
+	ConcurrencyOverflow      string `json:"concurrency_overflow" default:"reject" name:"config.concurrency_overflow" category:"config.category.request" desc:"config.concurrency_overflow_desc" validate:"oneof=reject queue"`
+	ConcurrencyWaitTimeoutMs int    `json:"concurrency_wait_timeout_ms" default:"5000" name:"config.concurrency_wait_timeout_ms" category:"config.category.request" desc:"config.concurrency_wait_timeout_ms_desc" validate:"required,min=1"`
 
 	// 密钥配置
-	MaxRetries                   int `json:"max_retries" default:"3" name:"config.max_retries" category:"config.category.key" desc:"config.max_retries_desc" validate:"required,min=0"`
-	BlacklistThreshold           int `json:"blacklist_threshold" default:"3" name:"config.blacklist_threshold" category:"config.category.key" desc:"config.blacklist_threshold_desc" validate:"required,min=0"`
-	KeyValidationIntervalMinutes int `json:"key_validation_interval_minutes" default:"60" name:"config.key_validation_interval" category:"config.category.key" desc:"config.key_validation_interval_desc" validate:"required,min=1"`
-	KeyValidationConcurrency     int `json:"key_validation_concurrency" default:"10" name:"config.key_validation_concurrency" category:"config.category.key" desc:"config.key_validation_concurrency_desc" validate:"required,min=1"`
-	KeyValidationTimeoutSeconds  int `json:"key_validation_timeout_seconds" default:"20" name:"config.key_validation_timeout" category:"config.category.key" desc:"config.key_validation_timeout_desc" validate:"required,min=1"`
+	MaxRetries                   int    `json:"max_retries" default:"3" name:"config.max_retries" category:"config.category.key" desc:"config.max_retries_desc" validate:"required,min=0"`
+	BlacklistThreshold           int    `json:"blacklist_threshold" default:"3" name:"config.blacklist_threshold" category:"config.category.key" desc:"config.blacklist_threshold_desc" validate:"required,min=0"`
+	KeyValidationIntervalMinutes int    `json:"key_validation_interval_minutes" default:"60" name:"config.key_validation_interval" category:"config.category.key" desc:"config.key_validation_interval_desc" validate:"required,min=1"`
+	KeyValidationConcurrency     int    `json:"key_validation_concurrency" default:"10" name:"config.key_validation_concurrency" category:"config.category.key" desc:"config.key_validation_concurrency_desc" validate:"required,min=1"`
+	KeyValidationTimeoutSeconds  int    `json:"key_validation_timeout_seconds" default:"20" name:"config.key_validation_timeout" category:"config.category.key" desc:"config.key_validation_timeout_desc" validate:"required,min=1"`
+	KeyValidationConnectTimeout  int    `json:"key_validation_connect_timeout" default:"5" name:"config.key_validation_connect_timeout" category:"config.category.key" desc:"config.key_validation_connect_timeout_desc" validate:"required,min=1"`
+	KeyValidationReadTimeout     int    `json:"key_validation_read_timeout" default:"10" name:"config.key_validation_read_timeout" category:"config.category.key" desc:"config.key_validation_read_timeout_desc" validate:"required,min=1"`
+	KeyWarmupMinutes             int    `json:"key_warmup_minutes" default:"0" name:"config.key_warmup_minutes" category:"config.category.key" desc:"config.key_warmup_minutes_desc" validate:"required,min=0"`
+	KeySelectionMode             string `json:"key_selection_mode" default:"round_robin" name:"config.key_selection_mode" category:"config.category.key" desc:"config.key_selection_mode_desc" validate:"oneof=round_robin least_failures"`
+	RateLimitWarningPercent      int    `json:"rate_limit_warning_percent" default:"0" name:"config.rate_limit_warning_percent" category:"config.category.key" desc:"config.rate_limit_warning_percent_desc" validate:"required,min=0,max=100"`
+	JointUpstreamKeySelection    bool   `json:"joint_upstream_key_selection" default:"false" name:"config.joint_upstream_key_selection" category:"config.category.key" desc:"config.joint_upstream_key_selection_desc"`
+	KeyInvalidateAfterFailures   int    `json:"key_invalidate_after_failures" default:"0" name:"config.key_invalidate_after_failures" category:"config.category.key" desc:"config.key_invalidate_after_failures_desc" validate:"required,min=0"`
+	KeyRecoverAfterSuccesses     int    `json:"key_recover_after_successes" default:"1" name:"config.key_recover_after_successes" category:"config.category.key" desc:"config.key_recover_after_successes_desc" validate:"required,min=1"`
+	AutoPauseFailureRate         int    `json:"auto_pause_failure_rate" default:"0" name:"config.auto_pause_failure_rate" category:"config.category.key" desc:"config.auto_pause_failure_rate_desc" validate:"required,min=0,max=100"`
+	AutoPauseWindowHours         int    `json:"auto_pause_window_hours" default:"1" name:"config.auto_pause_window_hours" category:"config.category.key" desc:"config.auto_pause_window_hours_desc" validate:"required,min=1,max=24"`
+	AutoPauseMinRequests         int    `json:"auto_pause_min_requests" default:"20" name:"config.auto_pause_min_requests" category:"config.category.key" desc:"config.auto_pause_min_requests_desc" validate:"required,min=1"`
+	AutoPauseCooldownMinutes     int    `json:"auto_pause_cooldown_minutes" default:"30" name:"config.auto_pause_cooldown_minutes" category:"config.category.key" desc:"config.auto_pause_cooldown_minutes_desc" validate:"required,min=0"`
 
 	// For cache
 	ProxyKeysMap map[string]struct{} `json:"-"`
@@ -59,9 +99,11 @@ type ServerConfig struct {
 
 // AuthConfig represents authentication configuration
 type AuthConfig struct {
-	Key                 string `json:"key"`
-	MaxFailedAttempts   int    `json:"max_failed_attempts"`    // Maximum failed login attempts before lockout
-	LockoutDuration     int    `json:"lockout_duration"`        // Lockout duration in seconds
+	Key                     string `json:"key"`
+	MaxFailedAttempts       int    `json:"max_failed_attempts"`        // Maximum failed login attempts from one IP before it is locked out
+	LockoutDuration         int    `json:"lockout_duration"`           // Lockout duration in seconds
+	GlobalMaxFailedAttempts int    `json:"global_max_failed_attempts"` // Maximum failed login attempts across all IPs before all logins are locked out, 0 to disable
+	GlobalFailureWindow     int    `json:"global_failure_window"`      // Window in seconds in which failures across all IPs are counted towards the global cap
 }
 
 // CORSConfig represents CORS configuration
@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"aimanager/internal/models"
+)
+
+const (
+	// MaxBodyRules is the maximum number of body rules per group.
+	MaxBodyRules = 32
+	// MaxBodyRuleConditions is the maximum number of conditions per body rule.
+	MaxBodyRuleConditions = 8
+	// BodyRuleCostBudget bounds the evaluation cost of all body rules of a group. Every condition and
+	// action costs one step per path segment it walks, so a request never does more work than this.
+	BodyRuleCostBudget = 256
+
+	maxBodyRulePathDepth     = 8
+	maxBodyRuleOperandLength = 256
+	maxBodyRuleInValues      = 32
+	maxBodyRuleValueBytes    = 4096
+)
+
+var bodyRuleHeaderNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,100}$`)
+
+// BodyRuleContext holds the request attributes body rule conditions can refer to.
+type BodyRuleContext struct {
+	Model  string
+	Header http.Header
+}
+
+// ValidateBodyRules checks body rules when a group is saved, including their total evaluation cost.
+func ValidateBodyRules(rules []models.BodyRule) error {
+	if len(rules) > MaxBodyRules {
+		return fmt.Errorf("at most %d rules are allowed", MaxBodyRules)
+	}
+
+	cost := 0
+	for i, rule := range rules {
+		if len(rule.When) > MaxBodyRuleConditions {
+			return fmt.Errorf("rule %d: at most %d conditions are allowed", i+1, MaxBodyRuleConditions)
+		}
+		for j, cond := range rule.When {
+			condCost, err := validateBodyRuleCondition(cond)
+			if err != nil {
+				return fmt.Errorf("rule %d, condition %d: %w", i+1, j+1, err)
+			}
+			cost += condCost
+		}
+
+		path, err := parseBodyRulePath(rule.Field)
+		if err != nil {
+			return fmt.Errorf("rule %d: field %w", i+1, err)
+		}
+		cost += len(path)
+
+		switch rule.Action {
+		case models.BodyRuleActionSet:
+			if data, err := json.Marshal(rule.Value); err != nil || len(data) > maxBodyRuleValueBytes {
+				return fmt.Errorf("rule %d: value must be JSON of at most %d bytes", i+1, maxBodyRuleValueBytes)
+			}
+		case models.BodyRuleActionRemove:
+		case models.BodyRuleActionClamp:
+			if rule.Min == nil && rule.Max == nil {
+				return fmt.Errorf("rule %d: clamp requires min and/or max", i+1)
+			}
+			if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+				return fmt.Errorf("rule %d: min must not be greater than max", i+1)
+			}
+		default:
+			return fmt.Errorf("rule %d: action must be one of: %s, %s, %s", i+1, models.BodyRuleActionSet, models.BodyRuleActionRemove, models.BodyRuleActionClamp)
+		}
+	}
+
+	if cost > BodyRuleCostBudget {
+		return fmt.Errorf("rules are too expensive to evaluate (cost %d, budget %d), use fewer rules, conditions or shorter paths", cost, BodyRuleCostBudget)
+	}
+	return nil
+}
+
+// validateBodyRuleCondition checks one condition and returns its evaluation cost.
+func validateBodyRuleCondition(cond models.BodyRuleCondition) (int, error) {
+	cost := 1
+	switch cond.Source {
+	case models.BodyRuleSourceModel:
+	case models.BodyRuleSourceHeader:
+		if !bodyRuleHeaderNamePattern.MatchString(cond.Name) {
+			return 0, fmt.Errorf("name must be a valid header name")
+		}
+	case models.BodyRuleSourceField:
+		path, err := parseBodyRulePath(cond.Name)
+		if err != nil {
+			return 0, fmt.Errorf("name %w", err)
+		}
+		cost = len(path)
+	default:
+		return 0, fmt.Errorf("source must be one of: %s, %s, %s", models.BodyRuleSourceModel, models.BodyRuleSourceHeader, models.BodyRuleSourceField)
+	}
+
+	switch cond.Op {
+	case "exists", "missing":
+	case "eq", "ne", "prefix", "suffix", "contains":
+		operand, ok := cond.Value.(string)
+		if cond.Source == models.BodyRuleSourceField && (cond.Op == "eq" || cond.Op == "ne") {
+			ok = isBodyRuleScalar(cond.Value)
+		}
+		if !ok {
+			return 0, fmt.Errorf("op %s requires a string value", cond.Op)
+		}
+		if len(operand) > maxBodyRuleOperandLength {
+			return 0, fmt.Errorf("value must be at most %d characters", maxBodyRuleOperandLength)
+		}
+	case "in":
+		list, ok := cond.Value.([]any)
+		if !ok || len(list) == 0 || len(list) > maxBodyRuleInValues {
+			return 0, fmt.Errorf("op in requires an array of 1 to %d values", maxBodyRuleInValues)
+		}
+		for _, item := range list {
+			if !isBodyRuleScalar(item) {
+				return 0, fmt.Errorf("op in only supports string, number and boolean values")
+			}
+		}
+	case "gt", "gte", "lt", "lte":
+		if _, ok := cond.Value.(float64); !ok {
+			return 0, fmt.Errorf("op %s requires a number value", cond.Op)
+		}
+	default:
+		return 0, fmt.Errorf("op must be one of: eq, ne, in, prefix, suffix, contains, exists, missing, gt, gte, lt, lte")
+	}
+	return cost, nil
+}
+
+// parseBodyRulePath splits a dot-separated field path into its segments.
+func parseBodyRulePath(path string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("must be a non-empty dot-separated path")
+	}
+	segments := strings.Split(path, ".")
+	if len(segments) > maxBodyRulePathDepth {
+		return nil, fmt.Errorf("must be at most %d levels deep", maxBodyRulePathDepth)
+	}
+	for _, segment := range segments {
+		if strings.TrimSpace(segment) == "" {
+			return nil, fmt.Errorf("must not contain empty segments")
+		}
+	}
+	return segments, nil
+}
+
+func isBodyRuleScalar(value any) bool {
+	switch value.(type) {
+	case string, float64, bool:
+		return true
+	}
+	return false
+}
+
+// ApplyBodyRules applies the rules in order to a decoded JSON request body and reports whether it changed.
+// Rules are expected to have passed ValidateBodyRules; anything malformed is skipped.
+func ApplyBodyRules(body map[string]any, rules []models.BodyRule, ctx BodyRuleContext) bool {
+	changed := false
+	for _, rule := range rules {
+		if !bodyRuleMatches(body, rule.When, ctx) {
+			continue
+		}
+		path, err := parseBodyRulePath(rule.Field)
+		if err != nil {
+			continue
+		}
+		if applyBodyRuleAction(body, path, rule) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func bodyRuleMatches(body map[string]any, conditions []models.BodyRuleCondition, ctx BodyRuleContext) bool {
+	for _, cond := range conditions {
+		var actual any
+		found := false
+		switch cond.Source {
+		case models.BodyRuleSourceModel:
+			actual, found = ctx.Model, ctx.Model != ""
+		case models.BodyRuleSourceHeader:
+			if values := ctx.Header.Values(cond.Name); len(values) > 0 {
+				actual, found = values[0], true
+			}
+		case models.BodyRuleSourceField:
+			path, err := parseBodyRulePath(cond.Name)
+			if err != nil {
+				return false
+			}
+			actual, found = lookupBodyField(body, path)
+		default:
+			return false
+		}
+		if !evaluateBodyRuleCondition(cond, actual, found) {
+			return false
+		}
+	}
+	return true
+}
+
+func evaluateBodyRuleCondition(cond models.BodyRuleCondition, actual any, found bool) bool {
+	switch cond.Op {
+	case "exists":
+		return found
+	case "missing":
+		return !found
+	}
+	if !found {
+		// A missing attribute only satisfies "ne"
+		return cond.Op == "ne"
+	}
+
+	switch cond.Op {
+	case "eq":
+		return reflect.DeepEqual(actual, cond.Value)
+	case "ne":
+		return !reflect.DeepEqual(actual, cond.Value)
+	case "in":
+		list, _ := cond.Value.([]any)
+		for _, item := range list {
+			if reflect.DeepEqual(actual, item) {
+				return true
+			}
+		}
+		return false
+	case "prefix", "suffix", "contains":
+		actualStr, ok1 := actual.(string)
+		operand, ok2 := cond.Value.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch cond.Op {
+		case "prefix":
+			return strings.HasPrefix(actualStr, operand)
+		case "suffix":
+			return strings.HasSuffix(actualStr, operand)
+		default:
+			return strings.Contains(actualStr, operand)
+		}
+	case "gt", "gte", "lt", "lte":
+		actualNum, ok1 := actual.(float64)
+		operand, ok2 := cond.Value.(float64)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch cond.Op {
+		case "gt":
+			return actualNum > operand
+		case "gte":
+			return actualNum >= operand
+		case "lt":
+			return actualNum < operand
+		default:
+			return actualNum <= operand
+		}
+	}
+	return false
+}
+
+// lookupBodyField walks the path through nested objects.
+func lookupBodyField(body map[string]any, path []string) (any, bool) {
+	current := body
+	for i, segment := range path {
+		value, ok := current[segment]
+		if !ok {
+			return nil, false
+		}
+		if i == len(path)-1 {
+			return value, true
+		}
+		if current, ok = value.(map[string]any); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func applyBodyRuleAction(body map[string]any, path []string, rule models.BodyRule) bool {
+	parent := body
+	last := path[len(path)-1]
+	for _, segment := range path[:len(path)-1] {
+		next, ok := parent[segment].(map[string]any)
+		if !ok {
+			if _, exists := parent[segment]; exists || rule.Action != models.BodyRuleActionSet {
+				// Never replace a non-object value, and only "set" creates missing objects
+				return false
+			}
+			next = make(map[string]any)
+			parent[segment] = next
+		}
+		parent = next
+	}
+
+	switch rule.Action {
+	case models.BodyRuleActionSet:
+		parent[last] = copyBodyRuleValue(rule.Value)
+		return true
+	case models.BodyRuleActionRemove:
+		if _, exists := parent[last]; !exists {
+			return false
+		}
+		delete(parent, last)
+		return true
+	case models.BodyRuleActionClamp:
+		value, ok := parent[last].(float64)
+		if !ok {
+			return false
+		}
+		clamped := value
+		if rule.Min != nil && clamped < *rule.Min {
+			clamped = *rule.Min
+		}
+		if rule.Max != nil && clamped > *rule.Max {
+			clamped = *rule.Max
+		}
+		if clamped == value {
+			return false
+		}
+		parent[last] = clamped
+		return true
+	}
+	return false
+}
+
+// copyBodyRuleValue deep-copies a configured value so later rules editing the body never modify the group config.
+func copyBodyRuleValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = copyBodyRuleValue(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = copyBodyRuleValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
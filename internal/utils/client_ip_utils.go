@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP of the client that sent the request. trustedProxyDepth is the number of
+// reverse proxies in front of this server that append to X-Forwarded-For: with 0 the header is ignored
+// and the peer address is used, with N the Nth entry from the right is used. Entries left of it are
+// written by the client and cannot be trusted. If the header has fewer entries, the leftmost one is used.
+func ClientIP(r *http.Request, trustedProxyDepth int) net.IP {
+	if trustedProxyDepth > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				if hop = strings.TrimSpace(hop); hop != "" {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		if len(hops) > 0 {
+			return net.ParseIP(hops[max(len(hops)-trustedProxyDepth, 0)])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// ParseIPNetwork parses a CIDR such as "10.0.0.0/8", or a single IP address as a network of one host.
+func ParseIPNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: value}
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	return network, err
+}
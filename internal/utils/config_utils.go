@@ -187,3 +187,18 @@ func GetValidationEndpoint(group *models.Group) string {
 		return ""
 	}
 }
+
+// DefaultTestModel returns the preset test model of a channel type, matching the one the group form
+// suggests. Unknown channel types have no preset.
+func DefaultTestModel(channelType string) string {
+	switch channelType {
+	case "openai":
+		return "gpt-4.1-nano"
+	case "gemini":
+		return "gemini-2.0-flash-lite"
+	case "anthropic":
+		return "claude-3-haiku-20240307"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Failure reasons a group can return a custom error body for.
+const (
+	ErrorReasonExpired         = "expired"
+	ErrorReasonHourlyLimit     = "hourly_limit"
+	ErrorReasonMonthlyLimit    = "monthly_limit"
+	ErrorReasonTokenBucket     = "token_bucket_limit"
+	ErrorReasonRateLimited     = "rate_limited" // fallback for hourly_limit, monthly_limit and token_bucket_limit
+	ErrorReasonNoKeys          = "no_keys"
+	ErrorReasonPaused          = "paused"
+	ErrorReasonModelNotAllowed = "model_not_allowed"
+	ErrorReasonConcurrency     = "concurrency_limited"
+	ErrorReasonCircuitOpen     = "circuit_open"
+)
+
+// ErrorResponseReasons lists every supported custom error reason.
+var ErrorResponseReasons = []string{
+	ErrorReasonExpired,
+	ErrorReasonHourlyLimit,
+	ErrorReasonMonthlyLimit,
+	ErrorReasonTokenBucket,
+	ErrorReasonRateLimited,
+	ErrorReasonNoKeys,
+	ErrorReasonPaused,
+	ErrorReasonModelNotAllowed,
+	ErrorReasonConcurrency,
+	ErrorReasonCircuitOpen,
+}
+
+// Variables available in custom error bodies
+var errorTemplateVariables = []string{
+	"${REASON}",
+	"${MESSAGE}",
+	"${STATUS}",
+	"${GROUP_NAME}",
+	"${RESET_AT}",
+	"${RESET_AFTER_S}",
+	"${LIMIT}",
+	"${USED}",
+}
+
+var errorTemplateVariablePattern = regexp.MustCompile(`\$\{[A-Z0-9_]+\}`)
+
+// ValidateErrorResponseTemplate checks that a custom error body is a JSON object and only
+// uses known ${...} variables in its string values.
+func ValidateErrorResponseTemplate(body any) error {
+	if _, ok := body.(map[string]any); !ok {
+		return fmt.Errorf("custom error body must be a JSON object")
+	}
+	return validateErrorTemplateValue(body)
+}
+
+func validateErrorTemplateValue(value any) error {
+	switch v := value.(type) {
+	case string:
+		for _, variable := range errorTemplateVariablePattern.FindAllString(v, -1) {
+			if !slices.Contains(errorTemplateVariables, variable) {
+				return fmt.Errorf("unknown variable %s, supported: %s", variable, strings.Join(errorTemplateVariables, ", "))
+			}
+		}
+	case map[string]any:
+		for _, item := range v {
+			if err := validateErrorTemplateValue(item); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := validateErrorTemplateValue(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderErrorResponseTemplate returns a copy of the body with variables in string values
+// replaced. The body itself is not modified.
+func RenderErrorResponseTemplate(body any, variables map[string]string) any {
+	switch v := body.(type) {
+	case string:
+		return errorTemplateVariablePattern.ReplaceAllStringFunc(v, func(variable string) string {
+			if value, ok := variables[variable]; ok {
+				return value
+			}
+			return variable
+		})
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for key, item := range v {
+			rendered[key] = RenderErrorResponseTemplate(item, variables)
+		}
+		return rendered
+	case []any:
+		rendered := make([]any, len(v))
+		for i, item := range v {
+			rendered[i] = RenderErrorResponseTemplate(item, variables)
+		}
+		return rendered
+	default:
+		return v
+	}
+}
@@ -54,3 +54,30 @@ func StringToSet(s string, sep string) map[string]struct{} {
 	}
 	return set
 }
+
+// MatchWildcard reports whether s matches pattern, where '*' matches any run of characters, including
+// '/', and '?' matches a single character. Every other character matches itself.
+func MatchWildcard(pattern, s string) bool {
+	p, i := 0, 0
+	star, starMatch := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, starMatch = p, i
+			p++
+		case star >= 0:
+			// Let the last '*' swallow one more character and retry from there
+			starMatch++
+			p, i = star+1, starMatch
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -6,7 +6,7 @@ export interface ApiResponse<T> {
 }
 
 // 密钥状态
-export type KeyStatus = "active" | "invalid" | undefined;
+export type KeyStatus = "active" | "invalid" | "disabled" | undefined;
 
 // 分组类型
 export type GroupType = "standard" | "aggregate";
@@ -21,6 +21,7 @@ export interface APIKey {
   key_value: string;
   notes?: string;
   status: KeyStatus;
+  tier: number;
   request_count: number;
   failure_count: number;
   last_used_at?: string;
@@ -52,6 +53,7 @@ export interface SubGroupInfo {
   total_keys: number;
   active_keys: number;
   invalid_keys: number;
+  disabled_keys: number;
 }
 
 // 父聚合分组信息（展示时使用）
@@ -62,6 +64,15 @@ export interface ParentAggregateGroup {
   weight: number;
 }
 
+// 上游健康状态
+export interface UpstreamHealthStatus {
+  upstream: string;
+  healthy: boolean;
+  consecutive_failures: number;
+  unhealthy_until?: string;
+  last_failure_at?: string;
+}
+
 export interface Group {
   id?: number;
   name: string;
@@ -89,6 +100,8 @@ export interface Group {
   stats_24_hour?: RequestStats;
   stats_7_day?: RequestStats;
   stats_30_day?: RequestStats;
+  // 上游健康状态（只读，仅标准分组）
+  upstream_health?: UpstreamHealthStatus[];
   // 限流和有效期字段（存储在 config 中）
   expires_at?: string;              // ISO8601 格式
   max_requests_per_hour?: number;   // 0 表示不限制
@@ -115,6 +128,7 @@ export interface KeyStats {
   total_keys: number;
   active_keys: number;
   invalid_keys: number;
+  disabled_keys: number;
 }
 
 // RequestStats defines the statistics for requests over a period.
@@ -135,6 +149,8 @@ export interface KeyValidationResult {
 export interface KeyImportResult {
   added_count: number;
   ignored_count: number;
+  skipped_duplicates?: number;
+  duplicate_groups?: Record<string, number>;
 }
 
 export interface KeyDeleteResult {
@@ -172,6 +188,8 @@ export interface RequestLog {
   parent_group_name?: string;
   key_value?: string;
   model: string;
+  model_variant?: "control" | "canary" | "";
+  request_id?: string;
   upstream_addr: string;
   is_stream: boolean;
   request_body?: string;
@@ -189,9 +207,17 @@ export interface LogsResponse {
   pagination: Pagination;
 }
 
+// 游标分页的日志响应，next_cursor 为空表示已到最后一页
+export interface LogsCursorResponse {
+  items: RequestLog[];
+  page_size: number;
+  next_cursor: string;
+}
+
 export interface LogFilter {
   page?: number;
   page_size?: number;
+  cursor?: string;
   group_name?: string;
   parent_group_name?: string;
   key_value?: string;
@@ -264,9 +290,20 @@ export interface GroupUsageData {
   monthly_usage: number; // 当前月已使用次数
   monthly_limit: number; // 每月限额（0=不限制）
   last_updated: string; // ISO8601格式时间戳
+  current_rps: number; // 上一秒的请求数
+  avg_rps_1m: number; // 最近一分钟的平均每秒请求数
+}
+
+// 分组熔断器状态
+export interface CircuitBreakerStatus {
+  state: "closed" | "open" | "half_open";
+  open_until?: string; // 冷却结束时间，ISO8601格式
+  cooldown_remaining_seconds: number; // 剩余冷却时间（秒）
+  window_requests: number; // 时间窗口内的请求数
+  window_failures: number; // 时间窗口内的失败数
 }
 
 // 分组监控列表响应
 export interface GroupMonitorResponse {
-  groups: Array<Group & { usage_data?: GroupUsageData }>;
+  groups: Array<Group & { usage_data?: GroupUsageData; circuit_breaker?: CircuitBreakerStatus }>;
 }